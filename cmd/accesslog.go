@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+
+	"github.com/ompatil-15/coconut/internal/factory"
+	"github.com/spf13/cobra"
+)
+
+func NewAccessLogCmd(f *factory.Factory) *cobra.Command {
+	var lines int
+
+	cmd := &cobra.Command{
+		Use:   "access-log",
+		Short: "Show the log of secret access events",
+		Long: `Show when secrets were read, revealed (singly or all at once), copied,
+cloned, updated or deleted.
+
+Entries identify secrets by index, name and ID only; passwords are never
+recorded. Disable recording with 'coconut config set access-log off'.`,
+		Example: `  coconut access-log
+  coconut access-log -n 20`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out := f.IO.Out
+			path := f.Logger.AccessLogPath()
+
+			file, err := os.Open(path)
+			if err != nil {
+				if os.IsNotExist(err) {
+					fmt.Fprintln(out, "No access events recorded yet.")
+					return nil
+				}
+				return fmt.Errorf("failed to open access log: %w", err)
+			}
+			defer file.Close()
+
+			var entries []string
+			scanner := bufio.NewScanner(file)
+			for scanner.Scan() {
+				entries = append(entries, scanner.Text())
+			}
+			if err := scanner.Err(); err != nil {
+				return fmt.Errorf("failed to read access log: %w", err)
+			}
+
+			if len(entries) == 0 {
+				fmt.Fprintln(out, "No access events recorded yet.")
+			}
+
+			if lines > 0 && len(entries) > lines {
+				entries = entries[len(entries)-lines:]
+			}
+			for _, entry := range entries {
+				fmt.Fprintln(out, entry)
+			}
+
+			if !f.Config.AccessLog {
+				fmt.Fprintln(f.IO.ErrOut, "Note: access logging is currently disabled.")
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVarP(&lines, "lines", "n", 0, "Show only the last N entries")
+
+	return cmd
+}
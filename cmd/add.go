@@ -8,32 +8,70 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/ompatil-15/coconut/internal/clipboard"
 	"github.com/ompatil-15/coconut/internal/db/model"
 	"github.com/ompatil-15/coconut/internal/factory"
+	"github.com/ompatil-15/coconut/internal/journal"
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
 )
 
 func NewAddCmd(f *factory.Factory) *cobra.Command {
 	var (
+		name        string
 		username    string
 		password    string
 		url         string
 		description string
+		expires     string
+		tags        []string
+		fields      []string
+		fromClip    bool
+		noNormalize bool
 	)
 
 	cmd := &cobra.Command{
 		Use:     "add",
 		Aliases: []string{"insert"},
 		Short:   "Add a new secret to the vault",
-		Long:    `Adds a new secret (username, password, URL, etc.) to your encrypted vault.`,
+		Long: `Adds a new secret (username, password, URL, etc.) to your encrypted vault.
+
+With --from-clipboard the password is read from the system clipboard, so
+it never appears on screen or in your shell history; give the other
+fields with flags. Trailing whitespace is trimmed. If a clipboard timeout
+is set, the clipboard is cleared after it unless something else was
+copied in the meantime.
+
+Use --field name=value, repeated, for anything the fixed fields do not
+cover, such as security question answers or account numbers. Fields are
+encrypted with the rest of the secret and shown by 'coconut get' in the
+order given; a name given twice keeps the last value.
+
+A URL without a scheme is stored with https:// in front, so "github.com"
+becomes "https://github.com". Use --no-normalize to store it as typed.`,
+		Example: `  coconut add -n GitHub -u me@example.com -l https://github.com --from-clipboard
+  coconut add -n Bank -u me -p secret --field "account=12345678" --field "memorable word=coconut"`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if fromClip && password != "" {
+				return fmt.Errorf("--from-clipboard cannot be combined with --password")
+			}
+			customFields, err := parseFieldArgs(fields)
+			if err != nil {
+				return err
+			}
+
 			if err := EnsureVaultUnlocked(f); err != nil {
 				return err
 			}
 
-			if username == "" && password == "" && url == "" && description == "" {
-				if err := readAddInteractive(f, &username, &password, &url, &description); err != nil {
+			if fromClip {
+				if password, err = readClipboardPassword(); err != nil {
+					return err
+				}
+			}
+
+			if name == "" && username == "" && password == "" && url == "" && description == "" {
+				if err := readAddInteractive(f, &name, &username, &password, &url, &description); err != nil {
 					return err
 				}
 			}
@@ -44,19 +82,42 @@ func NewAddCmd(f *factory.Factory) *cobra.Command {
 			if password == "" {
 				return fmt.Errorf("password is required")
 			}
+			if !noNormalize {
+				if url, err = normalizeURL(url); err != nil {
+					return err
+				}
+			}
+			if err := checkPasswordPolicy(f, password); err != nil {
+				return err
+			}
 
 			now := time.Now()
+			expiresAt, err := parseExpiry(expires, now)
+			if err != nil {
+				return err
+			}
+
 			secret := model.Secret{
 				ID:          uuid.New().String(),
+				Name:        name,
 				Username:    username,
 				Password:    password,
 				URL:         url,
 				Description: description,
+				Tags:        model.NormalizeTags(tags),
 				CreatedAt:   now,
 				UpdatedAt:   now,
+				ExpiresAt:   expiresAt,
+			}
+			for _, field := range customFields {
+				secret.SetField(field.Name, field.Value)
 			}
 
-			if _, err := f.Secrets.Add(secret); err != nil {
+			err = journaled(f, journal.Entry{Op: journal.OpAdd, Secret: &secret}, func() error {
+				_, err := f.Secrets.Add(secret)
+				return err
+			})
+			if err != nil {
 				f.Logger.Error("failed to add secret: %v", err)
 				return fmt.Errorf("failed to add secret: %w", err)
 			}
@@ -64,22 +125,39 @@ func NewAddCmd(f *factory.Factory) *cobra.Command {
 			f.Logger.Info("Secret added successfully")
 			fmt.Printf("Secret for '%s' saved successfully!\n", username)
 
+			if fromClip {
+				// Paste read the system clipboard, so clear that one.
+				cfg := *f.Config
+				cfg.ClipboardCmd = ""
+				scheduleClipboardClear(password, &cfg, false)
+			}
+
 			return nil
 		},
 	}
 
+	cmd.Flags().StringVarP(&name, "name", "n", "", "Display name for the secret (e.g. GitHub)")
 	cmd.Flags().StringVarP(&username, "username", "u", "", "Username for the secret")
 	cmd.Flags().StringVarP(&password, "password", "p", "", "Password for the secret")
 	cmd.Flags().StringVarP(&url, "url", "l", "", "URL for the secret")
 	cmd.Flags().StringVarP(&description, "description", "d", "", "Description for the secret")
+	cmd.Flags().StringSliceVarP(&tags, "tag", "t", nil, "Tag to group the secret under (repeatable)")
+	cmd.Flags().StringArrayVar(&fields, "field", nil, "Custom field as name=value (repeatable)")
+	cmd.Flags().StringVar(&expires, "expires", "", "Expiry as a date (YYYY-MM-DD) or duration from now (e.g. 90d)")
+	cmd.Flags().BoolVar(&fromClip, "from-clipboard", false, "Read the password from the clipboard instead of prompting")
+	cmd.Flags().BoolVar(&noNormalize, "no-normalize", false, "Store the URL as typed instead of adding https:// and lowercasing the host")
 
 	return cmd
 }
 
-func readAddInteractive(f *factory.Factory, username, password, url, description *string) error {
+func readAddInteractive(f *factory.Factory, name, username, password, url, description *string) error {
 	reader := bufio.NewReader(f.IO.In)
 	out := f.IO.Out
 
+	fmt.Fprint(out, "Name (optional): ")
+	n, _ := reader.ReadString('\n')
+	*name = strings.TrimSpace(n)
+
 	fmt.Fprint(out, "Username: ")
 	u, _ := reader.ReadString('\n')
 	*username = strings.TrimSpace(u)
@@ -102,3 +180,17 @@ func readAddInteractive(f *factory.Factory, username, password, url, description
 
 	return nil
 }
+
+// readClipboardPassword returns the clipboard contents without trailing
+// whitespace, such as the newline some sites copy along.
+func readClipboardPassword() (string, error) {
+	value, err := clipboard.Paste()
+	if err != nil {
+		return "", fmt.Errorf("failed to read the clipboard: %w", err)
+	}
+	value = strings.TrimRight(value, " \t\r\n")
+	if value == "" {
+		return "", fmt.Errorf("the clipboard is empty")
+	}
+	return value, nil
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/ompatil-15/coconut/internal/factory"
+	"github.com/spf13/cobra"
+)
+
+func NewArchiveCmd(f *factory.Factory) *cobra.Command {
+	return &cobra.Command{
+		Use:   "archive <index|name>",
+		Short: "Hide a secret from list and search without deleting it",
+		Long: `Archive a secret you no longer use but want to keep. Archived secrets
+are left out of 'coconut list' and 'coconut search' unless --archived or
+--all is given. Nothing else changes: the secret stays encrypted, keeps
+its index and can still be read with 'coconut get'.`,
+		Example: `  coconut archive 3
+  coconut list --archived
+  coconut unarchive 3`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeSecretNames(f),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return setArchived(f, args[0], true)
+		},
+	}
+}
+
+func NewUnarchiveCmd(f *factory.Factory) *cobra.Command {
+	return &cobra.Command{
+		Use:               "unarchive <index|name>",
+		Short:             "Show an archived secret in list and search again",
+		Example:           `  coconut unarchive 3`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeSecretNames(f),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return setArchived(f, args[0], false)
+		},
+	}
+}
+
+// setArchived sets the archived flag of the secret an <index|name>
+// argument refers to.
+func setArchived(f *factory.Factory, arg string, archived bool) error {
+	index, secret, err := loadSecretArg(f, arg)
+	if err != nil {
+		return err
+	}
+
+	if secret.IsArchived == archived {
+		if archived {
+			fmt.Fprintf(f.IO.Out, "Secret %d is already archived.\n", index)
+		} else {
+			fmt.Fprintf(f.IO.Out, "Secret %d is not archived.\n", index)
+		}
+		return nil
+	}
+
+	secret.IsArchived = archived
+	if err := f.Secrets.Update(*secret); err != nil {
+		f.Logger.Error("failed to update archived flag: %v", err)
+		return fmt.Errorf("failed to update secret: %w", err)
+	}
+
+	if archived {
+		f.Logger.Info("Secret %d archived", index)
+		fmt.Fprintf(f.IO.Out, "Secret %d (%s) archived. Show it with 'coconut list --archived'.\n", index, mergeLabel(*secret))
+	} else {
+		f.Logger.Info("Secret %d unarchived", index)
+		fmt.Fprintf(f.IO.Out, "Secret %d (%s) unarchived.\n", index, mergeLabel(*secret))
+	}
+	return nil
+}
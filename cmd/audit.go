@@ -0,0 +1,229 @@
+package cmd
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ompatil-15/coconut/internal/db/model"
+	"github.com/ompatil-15/coconut/internal/factory"
+	"github.com/ompatil-15/coconut/internal/strength"
+	"github.com/spf13/cobra"
+)
+
+func NewAuditCmd(f *factory.Factory) *cobra.Command {
+	var (
+		expired   bool
+		reused    bool
+		sequences bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Check your vault for secrets that need attention",
+		Long: `Inspect the secrets in your vault for hygiene problems.
+
+Available checks:
+  --expired    Secrets whose expiry date has passed
+  --reused     Secrets that share a password with another secret, grouped
+               by password with its estimated strength. Groups are ordered
+               by accounts affected times how weak the password is, so
+               reused-and-weak passwords come first.
+  --sequences  Secrets whose password contains a run such as "abc", "321"
+               or "aaa", which guessers try early. Only the kind and
+               length of the run are shown.
+
+If no check is selected, all checks are run. Passwords are never printed.`,
+		Example: `  coconut audit
+  coconut audit --expired
+  coconut audit --reused
+  coconut audit --sequences`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := EnsureVaultUnlocked(f); err != nil {
+				return err
+			}
+
+			all := !expired && !reused && !sequences
+
+			secrets, err := f.Secrets.List()
+			if err != nil {
+				f.Logger.Error("failed to fetch secrets: %v", err)
+				return fmt.Errorf("failed to fetch secrets: %w", err)
+			}
+
+			out := f.IO.Out
+			if all || expired {
+				auditExpired(out, secrets, time.Now())
+			}
+			if all || reused {
+				if all {
+					fmt.Fprintln(out)
+				}
+				auditReused(out, secrets)
+			}
+			if all || sequences {
+				if all {
+					fmt.Fprintln(out)
+				}
+				auditSequences(out, secrets)
+			}
+
+			f.Logger.Info("Audit completed over %d secrets", len(secrets))
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&expired, "expired", false, "Report secrets past their expiry date")
+	cmd.Flags().BoolVar(&reused, "reused", false, "Report secrets that share a password")
+	cmd.Flags().BoolVar(&sequences, "sequences", false, "Report passwords containing runs like abc, 321 or aaa")
+
+	return cmd
+}
+
+// auditExpired prints every secret whose expiry is at or before now.
+// Indices match those shown by the list command.
+func auditExpired(out io.Writer, secrets []model.Secret, now time.Time) {
+	var rows []string
+	for i, secret := range secrets {
+		if !secret.IsExpired(now) {
+			continue
+		}
+		days := int(now.Sub(secret.ExpiresAt).Hours() / 24)
+		rows = append(rows, fmt.Sprintf("%-10d %-30s %-30s %s (%d days ago)",
+			i+1,
+			truncate(secret.Username, 20),
+			truncate(secret.URL, 40),
+			secret.ExpiresAt.Format("2006-01-02"),
+			days,
+		))
+	}
+
+	if len(rows) == 0 {
+		fmt.Fprintln(out, "No expired secrets found.")
+		return
+	}
+
+	fmt.Fprintf(out, "Expired secrets (%d):\n", len(rows))
+	fmt.Fprintf(out, "%-10s %-30s %-30s %s\n", "ID", "USERNAME", "URL", "EXPIRED")
+	fmt.Fprintln(out, strings.Repeat("-", 100))
+	for _, row := range rows {
+		fmt.Fprintln(out, row)
+	}
+}
+
+// auditSequences prints every secret whose password contains a run
+// strength.FindSequence reports, describing the run but not its
+// characters.
+func auditSequences(out io.Writer, secrets []model.Secret) {
+	var rows []string
+	for i, secret := range secrets {
+		seq, found := strength.FindSequence(secret.Password)
+		if !found {
+			continue
+		}
+		rows = append(rows, fmt.Sprintf("%-10d %-30s %-30s %s",
+			i+1,
+			truncate(secret.Username, 20),
+			truncate(secret.URL, 40),
+			seq,
+		))
+	}
+
+	if len(rows) == 0 {
+		fmt.Fprintln(out, "No passwords with sequences found.")
+		return
+	}
+
+	fmt.Fprintf(out, "Passwords with sequences (%d):\n", len(rows))
+	fmt.Fprintf(out, "%-10s %-30s %-30s %s\n", "ID", "USERNAME", "URL", "SEQUENCE")
+	fmt.Fprintln(out, strings.Repeat("-", 100))
+	for _, row := range rows {
+		fmt.Fprintln(out, row)
+	}
+}
+
+// reusedPasswords groups the positions of secrets that share a password.
+// Only groups of two or more are returned, ordered by their first member.
+func reusedPasswords(secrets []model.Secret) [][]int {
+	byPassword := make(map[string][]int)
+	var order []string
+	for i, secret := range secrets {
+		if secret.Password == "" {
+			continue
+		}
+		if _, ok := byPassword[secret.Password]; !ok {
+			order = append(order, secret.Password)
+		}
+		byPassword[secret.Password] = append(byPassword[secret.Password], i)
+	}
+
+	var groups [][]int
+	for _, password := range order {
+		if group := byPassword[password]; len(group) > 1 {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+// reusedGroup is a set of secrets sharing one password, with that
+// password's estimated strength.
+type reusedGroup struct {
+	members  []int
+	estimate strength.Estimate
+}
+
+// priority ranks groups for fixing: more accounts and a weaker password
+// both make a group more urgent.
+func (g reusedGroup) priority() float64 {
+	return float64(len(g.members)) * g.estimate.Weakness()
+}
+
+// rankReused estimates the strength of each reused password and orders the
+// groups most urgent first. Ties keep the order of reusedPasswords.
+func rankReused(secrets []model.Secret) []reusedGroup {
+	var groups []reusedGroup
+	for _, members := range reusedPasswords(secrets) {
+		groups = append(groups, reusedGroup{
+			members:  members,
+			estimate: strength.Of(secrets[members[0]].Password),
+		})
+	}
+	slices.SortStableFunc(groups, func(a, b reusedGroup) int {
+		return cmp.Compare(b.priority(), a.priority())
+	})
+	return groups
+}
+
+// auditReused prints each group of secrets sharing a password, most
+// urgent first, with the number of accounts affected and the estimated
+// strength of the shared password. The password itself is never shown.
+func auditReused(out io.Writer, secrets []model.Secret) {
+	groups := rankReused(secrets)
+	if len(groups) == 0 {
+		fmt.Fprintln(out, "No reused passwords found.")
+		return
+	}
+
+	fmt.Fprintf(out, "Reused passwords (%d groups), most urgent first:\n", len(groups))
+	fmt.Fprintf(out, "%-7s %-10s %-22s %s\n", "GROUP", "ACCOUNTS", "STRENGTH", "PRIORITY")
+	fmt.Fprintln(out, strings.Repeat("-", 50))
+	for n, g := range groups {
+		fmt.Fprintf(out, "%-7d %-10d %-22s %.0f\n", n+1, len(g.members), strengthLabel(g.estimate), g.priority())
+	}
+
+	for n, g := range groups {
+		fmt.Fprintf(out, "\nGroup %d (%d accounts, %s):\n", n+1, len(g.members), g.estimate.Rating)
+		for _, i := range g.members {
+			row := fmt.Sprintf("  %-8d %-30s %s", i+1, truncate(secrets[i].Username, 30), truncate(secrets[i].URL, 40))
+			fmt.Fprintln(out, strings.TrimRight(row, " "))
+		}
+	}
+}
+
+func strengthLabel(e strength.Estimate) string {
+	return fmt.Sprintf("%s (%.0f bits)", e.Rating, e.Bits)
+}
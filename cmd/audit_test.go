@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/ompatil-15/coconut/internal/db/model"
+)
+
+func TestRankReused_WeakAndWidelyReusedFirst(t *testing.T) {
+	secrets := []model.Secret{
+		{Username: "a", Password: "k#9Lw!q2Vz@7rXp$"},
+		{Username: "b", Password: "k#9Lw!q2Vz@7rXp$"},
+		{Username: "c", Password: "k#9Lw!q2Vz@7rXp$"},
+		{Username: "d", Password: "sunflower"},
+		{Username: "e", Password: "sunflower"},
+		{Username: "f", Password: "123456"},
+		{Username: "g", Password: "123456"},
+		{Username: "h", Password: "123456"},
+		{Username: "i", Password: "unique"},
+	}
+
+	groups := rankReused(secrets)
+	if len(groups) != 3 {
+		t.Fatalf("Expected 3 groups, got %d", len(groups))
+	}
+
+	// The first member of each group identifies its password.
+	var order []string
+	for _, g := range groups {
+		order = append(order, secrets[g.members[0]].Username)
+	}
+	want := []string{"f", "d", "a"}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("Expected groups led by %v, got %v", want, order)
+		}
+	}
+}
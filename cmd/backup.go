@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/ompatil-15/coconut/internal/backup"
+	"github.com/ompatil-15/coconut/internal/factory"
+	"github.com/spf13/cobra"
+)
+
+func NewBackupCmd(f *factory.Factory) *cobra.Command {
+	var list bool
+
+	cmd := &cobra.Command{
+		Use:   "backup",
+		Short: "Snapshot the vault database",
+		Long: `Write a copy of the vault database to the backups directory next to it
+(~/.coconut/backups by default). Snapshots stay encrypted, so the vault
+does not need to be unlocked.
+
+Destructive bulk operations take a snapshot automatically before they
+run. Only the newest snapshots are kept; change how many with
+'coconut config set backup-keep <n>'.
+
+To restore, copy a snapshot over the vault file while coconut is not running.`,
+		Example: `  coconut backup
+  coconut backup --list`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out := f.IO.Out
+
+			if list {
+				paths, err := backup.List(f.Config.DBPath)
+				if err != nil {
+					return fmt.Errorf("failed to list backups: %w", err)
+				}
+				if len(paths) == 0 {
+					fmt.Fprintln(out, "No backups found.")
+					return nil
+				}
+				for _, path := range paths {
+					fmt.Fprintln(out, path)
+				}
+				return nil
+			}
+
+			path, err := backupDBFile(f)
+			if err != nil {
+				f.Logger.Error("backup failed: %v", err)
+				return err
+			}
+
+			fmt.Fprintf(out, "Vault backed up to %s\n", path)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&list, "list", false, "List existing backups, oldest first")
+
+	return cmd
+}
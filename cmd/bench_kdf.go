@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/ompatil-15/coconut/internal/crypto"
+	"github.com/spf13/cobra"
+)
+
+// maxBenchRuns bounds --runs, as a single run can take seconds.
+const maxBenchRuns = 20
+
+// maxBenchMemoryMiB bounds --memory so a typo cannot exhaust the machine.
+const maxBenchMemoryMiB = 16 * 1024
+
+// newBenchKDFCmd is the hidden, experimental command for timing key
+// derivation with chosen parameters. Like clipboard-clear it never opens
+// the vault.
+func newBenchKDFCmd() *cobra.Command {
+	var (
+		kdf       string
+		memoryMiB uint32
+		timeCost  uint32
+		threads   uint8
+		n, r, p   uint32
+		runs      int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "bench-kdf",
+		Short: "Time key derivation with given parameters (experimental)",
+		Long: `Derive a key from a throwaway password a few times and report the average
+time and memory per derivation, i.e. how long each unlock would take with
+these parameters on this machine. The vault is not opened.
+
+Parameters not given keep the defaults that 'init' and 'passwd --rehash'
+use, so running it without flags times the current defaults.`,
+		Example: `  coconut bench-kdf
+  coconut bench-kdf --memory 128 --time 4 --threads 4
+  coconut bench-kdf --kdf scrypt --n 131072`,
+		Hidden: true,
+		Args:   cobra.NoArgs,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			params, err := crypto.DefaultKDFParamsFor(kdf)
+			if err != nil {
+				return err
+			}
+
+			flags := cmd.Flags()
+			if params.Algorithm == crypto.KDFArgon2id {
+				if flags.Changed("n") || flags.Changed("r") || flags.Changed("p") {
+					return fmt.Errorf("--n, --r and --p only apply to --kdf scrypt")
+				}
+				if flags.Changed("memory") {
+					if memoryMiB > maxBenchMemoryMiB {
+						return fmt.Errorf("--memory must be at most %d MiB", maxBenchMemoryMiB)
+					}
+					params.MemoryKiB = memoryMiB * 1024
+				}
+				if flags.Changed("time") {
+					params.Time = timeCost
+				}
+				if flags.Changed("threads") {
+					params.Threads = threads
+				}
+			} else {
+				if flags.Changed("memory") || flags.Changed("time") || flags.Changed("threads") {
+					return fmt.Errorf("--memory, --time and --threads only apply to --kdf argon2id")
+				}
+				if flags.Changed("n") {
+					params.N = n
+				}
+				if flags.Changed("r") {
+					params.R = r
+				}
+				if flags.Changed("p") {
+					params.P = p
+				}
+			}
+			if err := params.Validate(); err != nil {
+				return err
+			}
+			if runs < 1 || runs > maxBenchRuns {
+				return fmt.Errorf("--runs must be between 1 and %d", maxBenchRuns)
+			}
+
+			out := cmd.OutOrStdout()
+			fmt.Fprintf(out, "Benchmarking %s (%s), %d run(s)...\n", params.Algorithm, describeKDFParams(params), runs)
+
+			salt := crypto.GenerateRandomSalt(16)
+			var total time.Duration
+			var before, after runtime.MemStats
+			runtime.ReadMemStats(&before)
+			for i := 1; i <= runs; i++ {
+				start := time.Now()
+				if _, err := crypto.DeriveKeyWithParams("coconut-bench-kdf", salt, params); err != nil {
+					return err
+				}
+				elapsed := time.Since(start)
+				total += elapsed
+				fmt.Fprintf(out, "  run %d: %s\n", i, elapsed.Round(time.Millisecond))
+			}
+			runtime.ReadMemStats(&after)
+
+			allocated := (after.TotalAlloc - before.TotalAlloc) / uint64(runs)
+			fmt.Fprintf(out, "Average: %s per unlock\n", (total / time.Duration(runs)).Round(time.Millisecond))
+			fmt.Fprintf(out, "Memory:  %s per derivation (%s allocated)\n", formatMiB(params.MemoryBytes()), formatMiB(allocated))
+			return nil
+		},
+	}
+
+	def := crypto.DefaultKDFParams()
+	sdef, _ := crypto.DefaultKDFParamsFor(crypto.KDFScrypt)
+	flags := cmd.Flags()
+	flags.StringVar(&kdf, "kdf", crypto.KDFArgon2id, "Key derivation algorithm ("+strings.Join(crypto.KDFs(), ", ")+")")
+	flags.Uint32Var(&memoryMiB, "memory", def.MemoryKiB/1024, "Argon2id memory in MiB")
+	flags.Uint32Var(&timeCost, "time", def.Time, "Argon2id passes over memory")
+	flags.Uint8Var(&threads, "threads", def.Threads, "Argon2id parallelism")
+	flags.Uint32Var(&n, "n", sdef.N, "scrypt cost N (a power of two)")
+	flags.Uint32Var(&r, "r", sdef.R, "scrypt block size r")
+	flags.Uint32Var(&p, "p", sdef.P, "scrypt parallelism p")
+	flags.IntVar(&runs, "runs", 3, "Number of derivations to average")
+
+	return cmd
+}
+
+// describeKDFParams formats the parameters that apply to the algorithm.
+func describeKDFParams(p crypto.KDFParams) string {
+	if p.Algorithm == crypto.KDFScrypt {
+		return fmt.Sprintf("N=%d, r=%d, p=%d", p.N, p.R, p.P)
+	}
+	return fmt.Sprintf("time=%d, memory=%s, threads=%d", p.Time, formatMiB(p.MemoryBytes()), p.Threads)
+}
+
+func formatMiB(bytes uint64) string {
+	return fmt.Sprintf("%.1f MiB", float64(bytes)/(1<<20))
+}
@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ompatil-15/coconut/internal/browse"
+	"github.com/ompatil-15/coconut/internal/factory"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+const (
+	enterAltScreen = "\x1b[?1049h\x1b[?25l"
+	// Clear the screen and scrollback before leaving the alternate screen
+	// so no secret stays visible once browse exits.
+	leaveAltScreen = "\x1b[H\x1b[2J\x1b[3J\x1b[?25h\x1b[?1049l"
+)
+
+func NewBrowseCmd(f *factory.Factory) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "browse",
+		Short: "Browse secrets interactively in a full-screen view",
+		Long: `Browse secrets interactively in a full-screen terminal view.
+
+Keys:
+  Up/Down, j/k     move through the list (PgUp/PgDn jump by 10)
+  Enter            open the selected secret
+  s                show or hide the password of the open secret
+  /                search; accepts the same syntax as 'coconut search'
+  c                copy the password to the clipboard
+  Esc              close the secret, or clear the search
+  q, Ctrl-C        quit
+
+Passwords are only decrypted when a secret is opened or copied and are
+never written to disk. The screen is cleared on exit. Other coconut
+commands cannot open the vault while browse is running.
+
+If browse is ended by a signal, such as SIGHUP from a dropped SSH
+connection or a closed terminal, the vault is locked and its session
+cleared on the way out.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			inFd, outFd := int(os.Stdin.Fd()), int(os.Stdout.Fd())
+			if !term.IsTerminal(inFd) || !term.IsTerminal(outFd) {
+				return fmt.Errorf("browse needs an interactive terminal")
+			}
+			if f.PasswordStdin {
+				return fmt.Errorf("browse reads keys from stdin and cannot be combined with --password-stdin")
+			}
+
+			if err := EnsureVaultUnlocked(f); err != nil {
+				return err
+			}
+
+			secrets, err := f.Secrets.ListMetadata()
+			if err != nil {
+				f.Logger.Error("failed to fetch secrets: %v", err)
+				return fmt.Errorf("failed to fetch secrets: %w", err)
+			}
+
+			state, err := term.MakeRaw(inFd)
+			if err != nil {
+				return fmt.Errorf("failed to set up terminal: %w", err)
+			}
+			fmt.Fprint(f.IO.Out, enterAltScreen)
+			restore := func() {
+				fmt.Fprint(f.IO.Out, leaveAltScreen)
+				_ = term.Restore(inFd, state)
+			}
+			defer restore()
+
+			stop := lockOnSignal(f, restore)
+			defer stop()
+
+			m := browse.New(secrets)
+			m.Mask = func(password string) string { return maskPassword(f.Config, password) }
+			return runBrowser(f, m, bufio.NewReader(os.Stdin), outFd)
+		},
+	}
+
+	return cmd
+}
+
+// runBrowser draws the browser and handles keys until the user quits.
+func runBrowser(f *factory.Factory, m *browse.Model, keys *bufio.Reader, outFd int) error {
+	for {
+		width, height, err := term.GetSize(outFd)
+		if err != nil || width <= 0 || height <= 0 {
+			width, height = 80, 24
+		}
+		m.Render(f.IO.Out, width, height)
+
+		key, err := browse.ReadKey(keys)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read key: %w", err)
+		}
+
+		switch m.Handle(key) {
+		case browse.ActionQuit:
+			return nil
+
+		case browse.ActionOpen:
+			listed, index, _ := m.Selected()
+			secret, err := f.Secrets.Get(listed.ID)
+			if err != nil {
+				f.Logger.Error("failed to load secret: %v", err)
+				m.Status = "Failed to load secret: " + err.Error()
+				continue
+			}
+			f.Logger.Access("read", accessTarget(index, secret))
+			m.Show(secret)
+
+		case browse.ActionShowPassword:
+			listed, index, _ := m.Selected()
+			f.Logger.Access("reveal", accessTarget(index, listed))
+
+		case browse.ActionCopy:
+			listed, index, _ := m.Selected()
+			secret, err := f.Secrets.Get(listed.ID)
+			if err != nil {
+				f.Logger.Error("failed to load secret: %v", err)
+				m.Status = "Failed to load secret: " + err.Error()
+				continue
+			}
+			if secret.IsProtected() {
+				m.Status = "This secret is protected by a PIN; use 'coconut get -c' to copy it."
+				continue
+			}
+			if err := copyToClipboard(secret.Password, f.Config); err != nil {
+				f.Logger.Error("failed to copy password: %v", err)
+				m.Status = "Failed to copy password to clipboard"
+				continue
+			}
+			f.Logger.Access("copy", accessTarget(index, secret))
+			markUsed(f, secret)
+			m.Status = "Password copied to clipboard."
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/ompatil-15/coconut/internal/backup"
+	"github.com/ompatil-15/coconut/internal/crypto"
+	"github.com/ompatil-15/coconut/internal/db"
+	"github.com/ompatil-15/coconut/internal/factory"
+	"github.com/ompatil-15/coconut/internal/vault"
+	"github.com/spf13/cobra"
+)
+
+// Check outcomes, as printed.
+const (
+	checkOK    = "ok"
+	checkWarn  = "warn"
+	checkFail  = "FAIL"
+	checkFixed = "fixed"
+)
+
+type checkResult struct {
+	name   string
+	status string
+	detail string
+
+	// fix, when set, repairs what the check found and describes the
+	// change. Only safe repairs get one: modes, directories and empty
+	// buckets, never secrets.
+	fix func() (string, error)
+}
+
+// bucketChecker is implemented by stores that can tell a missing bucket
+// from an empty one.
+type bucketChecker interface {
+	HasBucket(bucket string) (bool, error)
+}
+
+func NewCheckCmd(f *factory.Factory) *cobra.Command {
+	var fix bool
+
+	cmd := &cobra.Command{
+		Use:     "check",
+		Aliases: []string{"doctor"},
+		Short:   "Check that this build and the vault setup work correctly",
+		Long: `Run diagnostics without unlocking the vault.
+
+The crypto self-test decrypts published AES-GCM test vectors, round-trips
+a value with fixed 128- and 256-bit keys, checks that tampered data is
+rejected and compares derived keys against known answers. If it fails,
+this build or one of its dependencies is broken: stop using it, since it
+could write data that a correct build cannot read. 'coconut init' runs the
+same self-test before creating a vault.
+
+The other checks look for a vault, for database, log, data and backup
+directory permissions that let other users read them, for a missing log
+directory, and for missing database buckets.
+
+With --fix the safe repairs are made and reported as "fixed": modes are
+tightened to 0600 for files and 0700 for directories, missing
+directories are created, and missing buckets are created empty. Nothing
+that holds secrets is changed, and a failed crypto self-test cannot be
+fixed.
+
+Exits with an error if any check fails; warnings do not.`,
+		Example: `  coconut check
+  coconut doctor --fix`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			results := runChecks(f)
+			if fix {
+				applyFixes(f, results)
+			}
+
+			failed := 0
+			for _, r := range results {
+				fmt.Fprintf(f.IO.Out, "%-5s %-20s %s\n", r.status, r.name, r.detail)
+				if r.status == checkFail {
+					failed++
+					f.Logger.Error("check %s failed: %s", r.name, r.detail)
+				}
+			}
+
+			if failed > 0 {
+				return fmt.Errorf("%d check(s) failed", failed)
+			}
+			f.Logger.Info("All checks passed")
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&fix, "fix", false, "Repair permissions, missing directories and missing buckets")
+
+	return cmd
+}
+
+// applyFixes runs the fix of every result that has one and records the
+// outcome in place of the original finding.
+func applyFixes(f *factory.Factory, results []checkResult) {
+	for i := range results {
+		r := &results[i]
+		if r.fix == nil {
+			continue
+		}
+		changed, err := r.fix()
+		if err != nil {
+			r.status, r.detail = checkFail, fmt.Sprintf("could not fix: %v", err)
+			continue
+		}
+		r.status, r.detail = checkFixed, changed
+		f.Logger.Info("check --fix: %s: %s", r.name, changed)
+	}
+}
+
+func runChecks(f *factory.Factory) []checkResult {
+	var results []checkResult
+
+	if err := crypto.SelfTest(f.Crypto); err != nil {
+		results = append(results, checkResult{name: "crypto self-test", status: checkFail, detail: err.Error()})
+	} else {
+		results = append(results, checkResult{name: "crypto self-test", status: checkOK, detail: "AES-GCM and key derivation give the expected results"})
+	}
+
+	if vault.CheckVaultExists(f.System) {
+		results = append(results, checkResult{name: "vault", status: checkOK, detail: "initialized"})
+	} else {
+		results = append(results, checkResult{name: "vault", status: checkWarn, detail: "not initialized; run 'coconut init'"})
+	}
+
+	results = append(results, checkMode("database file", f.Config.DBPath, 0600, false))
+	results = append(results, checkMode("data directory", filepath.Dir(f.Config.DBPath), 0700, true))
+	if dir := backup.Dir(f.Config.DBPath); exists(dir) {
+		results = append(results, checkMode("backup directory", dir, 0700, true))
+	}
+	if f.Logger != nil {
+		logDir := filepath.Dir(f.Logger.AccessLogPath())
+		results = append(results, checkMode("log directory", logDir, 0700, true))
+		for _, name := range []string{"coconut.log", "audit.log"} {
+			if path := filepath.Join(logDir, name); exists(path) {
+				results = append(results, checkMode(name, path, 0600, false))
+			}
+		}
+	}
+
+	if store, ok := f.DB.(bucketChecker); ok {
+		results = append(results, checkBuckets(f.DB, store, vaultBuckets(f)))
+	}
+
+	return results
+}
+
+// vaultBuckets lists the buckets an opened vault should have.
+func vaultBuckets(f *factory.Factory) []string {
+	buckets := []string{f.Config.SystemBucket}
+	buckets = append(buckets, db.ShardBuckets(f.Config.SecretsBucket, f.Config.SecretShards)...)
+	return append(buckets, f.Config.IndexBucket, f.Config.JournalBucket)
+}
+
+// checkBuckets warns about missing buckets. Writes create them on demand,
+// so this is only a warning, and the fix creates them empty.
+func checkBuckets(store db.DB, checker bucketChecker, buckets []string) checkResult {
+	const name = "database buckets"
+
+	var missing []string
+	for _, bucket := range buckets {
+		found, err := checker.HasBucket(bucket)
+		if err != nil {
+			return checkResult{name: name, status: checkFail, detail: err.Error()}
+		}
+		if !found {
+			missing = append(missing, bucket)
+		}
+	}
+	if len(missing) == 0 {
+		return checkResult{name: name, status: checkOK, detail: fmt.Sprintf("all %d present", len(buckets))}
+	}
+
+	return checkResult{
+		name:   name,
+		status: checkWarn,
+		detail: fmt.Sprintf("missing: %s", strings.Join(missing, ", ")),
+		fix: func() (string, error) {
+			for _, bucket := range missing {
+				if err := store.CreateBucket(bucket); err != nil {
+					return "", err
+				}
+			}
+			return fmt.Sprintf("created %s", strings.Join(missing, ", ")), nil
+		},
+	}
+}
+
+// checkMode warns when path grants group or other users any access beyond
+// want, or when a directory is missing. Windows has no Unix modes, so the
+// check is skipped there.
+func checkMode(name, path string, want os.FileMode, dir bool) checkResult {
+	if runtime.GOOS == "windows" {
+		return checkResult{name: name, status: checkOK, detail: "permissions not checked on Windows"}
+	}
+
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		r := checkResult{name: name, status: checkWarn, detail: path + " does not exist"}
+		if dir {
+			r.fix = func() (string, error) {
+				if err := os.MkdirAll(path, want); err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("created %s (mode %04o)", path, want), nil
+			}
+		}
+		return r
+	}
+	if err != nil {
+		return checkResult{name: name, status: checkFail, detail: err.Error()}
+	}
+
+	mode := info.Mode().Perm()
+	if mode&^want != 0 {
+		return checkResult{
+			name:   name,
+			status: checkWarn,
+			detail: fmt.Sprintf("%s has mode %04o; other users may read it (run: chmod %o %s, or check --fix)", path, mode, want, path),
+			fix: func() (string, error) {
+				if err := os.Chmod(path, mode&want); err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("%s mode %04o -> %04o", path, mode, mode&want), nil
+			},
+		}
+	}
+	return checkResult{name: name, status: checkOK, detail: fmt.Sprintf("%s (mode %04o)", path, mode)}
+}
+
+// exists reports whether path exists.
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
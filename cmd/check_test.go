@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestCheckMode_Fix(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no Unix modes on Windows")
+	}
+	dir := t.TempDir()
+
+	file := filepath.Join(dir, "vault.db")
+	if err := os.WriteFile(file, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(file, 0644); err != nil {
+		t.Fatal(err)
+	}
+	r := checkMode("database file", file, 0600, false)
+	if r.status != checkWarn || r.fix == nil {
+		t.Fatalf("Expected a fixable warning for mode 0644, got %+v", r)
+	}
+	if _, err := r.fix(); err != nil {
+		t.Fatalf("fix failed: %v", err)
+	}
+	if info, _ := os.Stat(file); info.Mode().Perm() != 0600 {
+		t.Errorf("Expected mode 0600 after fix, got %04o", info.Mode().Perm())
+	}
+	if r := checkMode("database file", file, 0600, false); r.status != checkOK {
+		t.Errorf("Expected ok after fix, got %+v", r)
+	}
+
+	if r := checkMode("database file", filepath.Join(dir, "missing.db"), 0600, false); r.fix != nil {
+		t.Error("A missing file must not get a fix")
+	}
+
+	logs := filepath.Join(dir, "logs")
+	r = checkMode("log directory", logs, 0700, true)
+	if r.status != checkWarn || r.fix == nil {
+		t.Fatalf("Expected a fixable warning for a missing directory, got %+v", r)
+	}
+	if _, err := r.fix(); err != nil {
+		t.Fatalf("fix failed: %v", err)
+	}
+	if info, err := os.Stat(logs); err != nil || !info.IsDir() || info.Mode().Perm() != 0700 {
+		t.Errorf("Expected a 0700 directory, got %v, %v", info, err)
+	}
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ompatil-15/coconut/internal/clipboard"
+	"github.com/spf13/cobra"
+)
+
+// newClipboardClearCmd is the hidden helper that copyToClipboard starts in
+// the background to clear the clipboard after the configured timeout. It
+// reads the fingerprint of the copied value from stdin and never opens the
+// vault, so it does not hold the database lock while it waits.
+func newClipboardClearCmd() *cobra.Command {
+	var (
+		after   int
+		command string
+		osc52   bool
+	)
+
+	cmd := &cobra.Command{
+		Use:    "clipboard-clear",
+		Short:  "Clear the clipboard after a delay (used internally)",
+		Hidden: true,
+		Args:   cobra.NoArgs,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fingerprint, err := readLine(os.Stdin)
+			if err != nil {
+				return fmt.Errorf("failed to read fingerprint: %w", err)
+			}
+
+			time.Sleep(time.Duration(after) * time.Second)
+			return clipboard.ClearIfUnchanged(fingerprint, command, osc52)
+		},
+	}
+
+	cmd.Flags().IntVar(&after, "after", 0, "Seconds to wait before clearing")
+	cmd.Flags().StringVar(&command, "cmd", "", "Clipboard command to clear through")
+	cmd.Flags().BoolVar(&osc52, "osc52", false, "Clear through the terminal with OSC 52")
+
+	return cmd
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/ompatil-15/coconut/internal/db"
+	"github.com/ompatil-15/coconut/internal/db/model"
+	"github.com/ompatil-15/coconut/internal/factory"
+	"github.com/spf13/cobra"
+)
+
+// completeSecretNames offers secret names for an <index|name> argument.
+// Like completeSecretIndexes it reads only the plaintext metadata index,
+// so completing never prompts for the master password or touches the key.
+// With the plaintext index turned off there is nothing to offer.
+func completeSecretNames(f *factory.Factory) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		var names []cobra.Completion
+		seen := map[string]bool{}
+		for _, s := range indexedSecrets(f) {
+			if s.Name == "" || seen[s.Name] || !strings.HasPrefix(strings.ToLower(s.Name), strings.ToLower(toComplete)) {
+				continue
+			}
+			seen[s.Name] = true
+			names = append(names, s.Name)
+		}
+		return names, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeSecretIndexes offers indexes, described by name, for commands
+// that take an <index>.
+func completeSecretIndexes(f *factory.Factory) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		var indexes []cobra.Completion
+		for i, s := range indexedSecrets(f) {
+			index := strconv.Itoa(i + 1)
+			if !strings.HasPrefix(index, toComplete) {
+				continue
+			}
+			indexes = append(indexes, cobra.CompletionWithDesc(index, mergeLabel(s)))
+		}
+		return indexes, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// indexedSecrets returns the metadata of every secret from the index, in
+// list order, without the vault key. The entries are not verified, which
+// is fine for suggestions. It returns nothing unless the index holds
+// exactly the stored secrets, as otherwise indexes would not line up.
+func indexedSecrets(f *factory.Factory) []model.Secret {
+	if f.Repo == nil || !f.Config.PlaintextIndex {
+		return nil
+	}
+
+	keys, err := f.Repo.NewRecordsRepository(f.Config.SecretsBucket).ListKeys()
+	if err != nil || len(keys) == 0 {
+		return nil
+	}
+
+	index := db.NewSecretIndex(f.Repo.NewBaseRepository(f.Config.IndexBucket), nil)
+	secrets, err := index.Unverified()
+	if err != nil || len(secrets) != len(keys) {
+		return nil
+	}
+	for i, s := range secrets {
+		if s.ID != keys[i] {
+			return nil
+		}
+	}
+	return secrets
+}
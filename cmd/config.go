@@ -3,17 +3,69 @@ package cmd
 import (
 	"fmt"
 	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
+	"github.com/ompatil-15/coconut/internal/clipboard"
 	"github.com/ompatil-15/coconut/internal/config"
+	"github.com/ompatil-15/coconut/internal/db"
 	"github.com/ompatil-15/coconut/internal/factory"
+	"github.com/ompatil-15/coconut/internal/logger"
+	"github.com/ompatil-15/coconut/internal/timeutil"
 	"github.com/spf13/cobra"
 )
 
+// maxClipboardClearSecs caps clipboard-timeout at one hour.
+const maxClipboardClearSecs = 3600
+
+// maxAutoLockSecs caps autolock at 24 hours.
+const maxAutoLockSecs = 86400
+
+// maxMaskLength caps mask-length so a mask fits on a line.
+const maxMaskLength = 64
+
+// maxGenerateLength and maxGenerateWords cap the generate-length and
+// generate-words defaults; --length and --words can still go higher.
+const (
+	maxGenerateLength = 128
+	maxGenerateWords  = 32
+)
+
+// durationUnitAliases maps spelled-out units to the ones
+// time.ParseDuration knows, so "10min" or "1 hour" parse too.
+var durationUnitAliases = strings.NewReplacer(
+	"hours", "h", "hour", "h", "hrs", "h", "hr", "h",
+	"minutes", "m", "minute", "m", "mins", "m", "min", "m",
+	"seconds", "s", "second", "s", "secs", "s", "sec", "s",
+	" ", "",
+)
+
 func NewConfigCmd(f *factory.Factory) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "config",
 		Short: "Configure coconut settings",
-		Long:  `View and modify coconut configuration settings.`,
+		Long: `View and modify coconut configuration settings.
+
+Settings are stored in the vault. They can also be set in a config file
+in the data directory (usually ~/.coconut), named config.yaml, config.yml
+or config.toml, with one setting per line:
+
+  # ~/.coconut/config.yaml        # ~/.coconut/config.toml
+  autolock: 15m                   autolock = "15m"
+  clipboard-timeout: 30           clipboard-timeout = 30
+  generate-length: 24             generate-length = 24
+  db: ~/vaults/work.db            db = "~/vaults/work.db"
+
+Keys are the setting names below, and db picks the vault database.
+secret-shards and plaintext-index describe what is stored in the vault,
+so they can only be set with 'config set'.
+
+Command line flags such as --db come first, then the config file, then
+the settings stored in the vault. 'config set' on a setting the file
+sets updates the file, as a value stored in the vault would have no
+effect. A file or line that cannot be read is reported and ignored.`,
 	}
 
 	cmd.AddCommand(newConfigGetCmd(f))
@@ -29,20 +81,99 @@ func newConfigGetCmd(f *factory.Factory) *cobra.Command {
 		Long: `Get the current value of a configuration setting.
 
 Available settings:
-  autolock    Inactivity timeout in seconds before autolocking (default: 300)`,
-		Example: `coconut config get autolock`,
-		Args:    cobra.ExactArgs(1),
+  autolock    Inactivity timeout before autolocking (default: 300 seconds, 5m)
+  access-log  Whether secret access events are recorded (default: on)
+  backup-keep Number of automatic backups to keep (default: 10)
+  policy      Password composition policy for add/update (default: none)
+  clipboard-cmd  Command used to copy to the clipboard (default: system clipboard)
+  clipboard-osc52  Whether copies go to the terminal via OSC 52 (default: off)
+  clipboard-timeout  Seconds until a copied password is cleared (default: 0, never)
+  log-format  Format of the log file, text or json (default: text)
+  log-level   Least severe messages written to the log file (default: info)
+  generate-length  Length of generated passwords (default: 16)
+  generate-words   Words in generated passphrases (default: 6)
+  plaintext-index  Whether names, URLs etc. are indexed unencrypted (default: off)
+  secret-shards  Number of buckets secrets are spread over (default: 1)
+  mask-char   Character a hidden password is shown as (default: *)
+  mask-length Characters in a hidden password, or actual (default: 8)`,
+		Example: `coconut config get autolock
+coconut config get policy`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			setting := args[0]
 
+			if f.Config.Overridden(setting) {
+				defer fmt.Printf("Set in %s, which overrides the vault's setting.\n", f.Config.FilePath)
+			}
+
 			switch setting {
 			case "autolock":
 				timeout := getAutoLockTimeout(f)
-				minutes := float64(timeout) / 60.0
-				fmt.Printf("Autolock timeout: %d seconds (%.2f minutes)\n", timeout, minutes)
+				if timeout == 0 {
+					fmt.Println("Autolock timeout: 0 seconds (disabled)")
+				} else {
+					fmt.Printf("Autolock timeout: %d seconds (%s)\n", timeout, formatSeconds(timeout))
+				}
+				return nil
+			case "access-log":
+				fmt.Printf("Access log: %s\n", onOff(f.Config.AccessLog))
+				return nil
+			case "backup-keep":
+				fmt.Printf("Backups kept: %d\n", f.Config.BackupKeep)
+				return nil
+			case "policy", "policy-min-length", "policy-require", "policy-enforce":
+				fmt.Printf("Password policy: %s\n", f.Config.Policy)
+				if !f.Config.Policy.IsEmpty() {
+					fmt.Printf("Enforcement: %s\n", policyMode(f.Config.Policy.Enforce))
+				}
+				return nil
+			case "clipboard-cmd":
+				if f.Config.ClipboardCmd == "" {
+					fmt.Println("Clipboard command: none (system clipboard)")
+				} else {
+					fmt.Printf("Clipboard command: %s\n", f.Config.ClipboardCmd)
+				}
+				return nil
+			case "clipboard-osc52":
+				fmt.Printf("OSC 52 clipboard: %s\n", onOff(f.Config.ClipboardOSC52))
+				return nil
+			case "clipboard-timeout":
+				if f.Config.ClipboardClearSecs == 0 {
+					fmt.Println("Clipboard timeout: off (copied passwords are not cleared)")
+				} else {
+					fmt.Printf("Clipboard timeout: %d seconds\n", f.Config.ClipboardClearSecs)
+				}
+				return nil
+			case "log-format":
+				fmt.Printf("Log format: %s\n", f.Config.LogFormat)
+				return nil
+			case "log-level":
+				fmt.Printf("Log level: %s\n", f.Config.LogLevel)
+				return nil
+			case "generate-length":
+				fmt.Printf("Generated password length: %d\n", f.Config.GenerateLength)
+				return nil
+			case "generate-words":
+				fmt.Printf("Generated passphrase words: %d\n", f.Config.GenerateWords)
+				return nil
+			case "plaintext-index":
+				fmt.Printf("Plaintext index: %s\n", onOff(f.Config.PlaintextIndex))
+				return nil
+			case "secret-shards":
+				fmt.Printf("Secret shards: %d\n", max(f.Config.SecretShards, 1))
+				return nil
+			case "mask-char":
+				fmt.Printf("Mask character: %s\n", f.Config.MaskChar)
+				return nil
+			case "mask-length":
+				if f.Config.MaskLength == 0 {
+					fmt.Println("Mask length: actual (the password's own length)")
+				} else {
+					fmt.Printf("Mask length: %d\n", f.Config.MaskLength)
+				}
 				return nil
 			default:
-				return fmt.Errorf("unknown setting: %s\nAvailable settings: autolock", setting)
+				return fmt.Errorf("unknown setting: %s\nAvailable settings: autolock, access-log, backup-keep, policy, policy-min-length, policy-require, policy-enforce, clipboard-cmd, clipboard-osc52, clipboard-timeout, log-format, log-level, generate-length, generate-words, plaintext-index, secret-shards, mask-char, mask-length", setting)
 			}
 		},
 	}
@@ -55,43 +186,132 @@ func newConfigSetCmd(f *factory.Factory) *cobra.Command {
 		Long: `Set the value of a configuration setting.
 
 Available settings:
-  autolock    Inactivity timeout in seconds before autolocking
-              The vault locks after this many seconds of no command activity.
-              Each command execution resets the inactivity timer.
+  autolock    Inactivity timeout before autolocking, as a number of
+              seconds or a duration such as 15m, 10min, 1h or 1h30m (at
+              most 24h). The vault locks after this long with no command
+              activity. Each command execution resets the inactivity timer.
 
 Examples:
-  0    = autolock disabled
-  300  = 5 minutes of inactivity (default)
-  600  = 10 minutes of inactivity
-  900  = 15 minutes of inactivity
-  1800 = 30 minutes of inactivity
-  3600 = 1 hour of inactivity`,
-		Example: `coconut config set autolock 600`,
-		Args:    cobra.ExactArgs(2),
+  0     = autolock disabled
+  300   = 5 minutes of inactivity (default)
+  10m   = 10 minutes of inactivity
+  15min = 15 minutes of inactivity
+  1800  = 30 minutes of inactivity
+  1h    = 1 hour of inactivity
+
+  access-log  Record which secrets are read, copied, updated or deleted
+              in ~/.coconut/logs/audit.log (on|off). Passwords are never
+              written to this log.
+
+  backup-keep Number of automatic vault backups to keep in
+              ~/.coconut/backups (minimum 1). Older ones are pruned.
+
+  policy-min-length  Minimum length of passwords entered on add/update
+                     (0 = no minimum).
+
+  policy-require     Character classes passwords must contain, as a
+                     comma-separated list of upper, lower, digit, symbol,
+                     or none.
+
+  policy-enforce     on = reject passwords that break the policy,
+                     off = store them with a warning (default).
+
+  clipboard-cmd      Command that copies to the clipboard, e.g. wl-copy or
+                     "xclip -selection clipboard". The value is piped to
+                     its stdin; arguments are split on spaces, without a
+                     shell. Use "default" to go back to the system
+                     clipboard.
+
+  clipboard-osc52    Copy by asking the terminal to set its clipboard with
+                     an OSC 52 escape sequence (on|off). Works over SSH if
+                     your terminal supports it. Takes precedence over
+                     clipboard-cmd.
+
+  clipboard-timeout  Seconds after which a copied password is cleared from
+                     the clipboard (0-3600, 0 = never). With the system
+                     clipboard it is only cleared if it still holds that
+                     password.
+
+  log-format         Format of ~/.coconut/logs/coconut.log: text, or json
+                     for one {"ts","level","msg"} object per line to ship
+                     to a log aggregator. --verbose output stays text.
+
+  log-level          Least severe messages written to the log file: info
+                     (default), warn or error. --verbose still shows all.
+
+  generate-length    Length of passwords from generate and duplicate
+                     --generate when --length is not given (4-128,
+                     default 16).
+
+  generate-words     Words in passphrases from generate --words or
+                     --wordlist when no count is given (1-32, default 6).
+
+  plaintext-index    Keep an unencrypted index of every secret's name,
+                     username, URL, description, tags and dates (on|off,
+                     default off). With it, list, search and browse skip
+                     decrypting each secret, which is much faster for
+                     large vaults, and shell completion can suggest names
+                     without unlocking. The cost is privacy: anyone who
+                     can read the database file sees those fields, though
+                     never the passwords. Turning it off deletes the
+                     index; turning it on builds it on the next list.
+
+  secret-shards      Number of buckets the encrypted secrets are spread
+                     over (1-256, default 1), by a hash of each secret's
+                     ID. Only worth raising for vaults of tens of
+                     thousands of secrets, where one large bucket makes
+                     every write slower. Existing secrets are moved to
+                     the new layout in a single transaction, after a
+                     backup; commands work the same either way.
+
+  mask-char          Character a hidden password is shown as in get and
+                     browse, e.g. • (default *).
+
+  mask-length        How many mask characters a hidden password is shown
+                     as (1-64, default 8), or actual for one per character
+                     of the password. A fixed length does not reveal how
+                     long the password is; actual does.`,
+		Example: `coconut config set autolock 600
+coconut config set autolock 15m
+coconut config set access-log off
+coconut config set backup-keep 5
+coconut config set policy-min-length 14
+coconut config set policy-require upper,lower,digit
+coconut config set policy-enforce on
+coconut config set clipboard-cmd "xclip -selection clipboard"
+coconut config set clipboard-osc52 on
+coconut config set clipboard-timeout 30
+coconut config set log-format json
+coconut config set log-level warn
+coconut config set generate-length 24
+coconut config set plaintext-index on
+coconut config set secret-shards 16
+coconut config set mask-char "•"
+coconut config set mask-length actual`,
+		Args: cobra.ExactArgs(2),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			setting := args[0]
 			value := args[1]
 
+			if f.Config.Overridden(setting) {
+				return setInConfigFile(f, setting, value)
+			}
+
 			switch setting {
 			case "autolock":
-				seconds, err := strconv.Atoi(value)
+				seconds, err := parseAutoLock(value)
 				if err != nil {
-					return fmt.Errorf("invalid value: must be a number (seconds)")
-				}
-
-				if seconds > 86400 {
-					return fmt.Errorf("autolock timeout must be at most 86400 seconds (24 hours)")
+					return err
 				}
 
 				if err := setAutoLockTimeout(f, seconds); err != nil {
 					return fmt.Errorf("failed to set autolock timeout: %w", err)
 				}
 
-				minutes := float64(seconds) / 60.0
 				if seconds == 0 {
 					fmt.Println("Autolock disabled: Vault will remain unlocked until manually locked.")
 				} else {
-					fmt.Printf("Autolock set to %d seconds (%.2f minutes) of inactivity\n", seconds, minutes)
+					fmt.Printf("Autolock set to %d seconds (%s) of inactivity\n", seconds, formatSeconds(seconds))
 				}
 				fmt.Println("")
 				fmt.Println("Note: This will take effect on your next unlock.")
@@ -100,8 +320,259 @@ Examples:
 				f.Logger.Info("Autolock timeout changed to %d seconds", seconds)
 				return nil
 
+			case "access-log":
+				enabled, err := parseOnOff(value)
+				if err != nil {
+					return err
+				}
+
+				f.Config.AccessLog = enabled
+				if err := config.Save(f.System, f.Config); err != nil {
+					return fmt.Errorf("failed to set access log: %w", err)
+				}
+
+				fmt.Printf("Access log turned %s.\n", onOff(enabled))
+				f.Logger.Info("Access log turned %s", onOff(enabled))
+				return nil
+
+			case "backup-keep":
+				keep, err := strconv.Atoi(value)
+				if err != nil || keep < 1 {
+					return fmt.Errorf("invalid value: must be a positive number of backups")
+				}
+
+				f.Config.BackupKeep = keep
+				if err := config.Save(f.System, f.Config); err != nil {
+					return fmt.Errorf("failed to set backup count: %w", err)
+				}
+
+				fmt.Printf("Keeping the newest %d backups.\n", keep)
+				f.Logger.Info("Backup count changed to %d", keep)
+				return nil
+
+			case "policy-min-length":
+				length, err := strconv.Atoi(value)
+				if err != nil || length < 0 {
+					return fmt.Errorf("invalid value: must be a non-negative number of characters")
+				}
+				f.Config.Policy.MinLength = length
+				return savePolicy(f)
+
+			case "policy-require":
+				if err := f.Config.Policy.SetRequired(strings.Split(value, ",")); err != nil {
+					return err
+				}
+				return savePolicy(f)
+
+			case "policy-enforce":
+				enforce, err := parseOnOff(value)
+				if err != nil {
+					return err
+				}
+				f.Config.Policy.Enforce = enforce
+				return savePolicy(f)
+
+			case "clipboard-cmd":
+				if strings.EqualFold(strings.TrimSpace(value), "default") {
+					value = ""
+				} else if _, err := clipboard.ParseCommand(value); err != nil {
+					return fmt.Errorf("invalid value: %w", err)
+				}
+
+				f.Config.ClipboardCmd = strings.TrimSpace(value)
+				if err := config.Save(f.System, f.Config); err != nil {
+					return fmt.Errorf("failed to set clipboard command: %w", err)
+				}
+
+				if f.Config.ClipboardCmd == "" {
+					fmt.Println("Copying to the system clipboard.")
+				} else {
+					fmt.Printf("Copying with: %s\n", f.Config.ClipboardCmd)
+				}
+				f.Logger.Info("Clipboard command changed to %q", f.Config.ClipboardCmd)
+				return nil
+
+			case "clipboard-osc52":
+				enabled, err := parseOnOff(value)
+				if err != nil {
+					return err
+				}
+
+				f.Config.ClipboardOSC52 = enabled
+				if err := config.Save(f.System, f.Config); err != nil {
+					return fmt.Errorf("failed to set OSC 52 clipboard: %w", err)
+				}
+
+				fmt.Printf("OSC 52 clipboard turned %s.\n", onOff(enabled))
+				f.Logger.Info("OSC 52 clipboard turned %s", onOff(enabled))
+				return nil
+
+			case "clipboard-timeout":
+				seconds, err := strconv.Atoi(value)
+				if err != nil || seconds < 0 || seconds > maxClipboardClearSecs {
+					return fmt.Errorf("invalid value: must be between 0 and %d seconds", maxClipboardClearSecs)
+				}
+
+				f.Config.ClipboardClearSecs = seconds
+				if err := config.Save(f.System, f.Config); err != nil {
+					return fmt.Errorf("failed to set clipboard timeout: %w", err)
+				}
+
+				if seconds == 0 {
+					fmt.Println("Copied passwords will stay on the clipboard.")
+				} else {
+					fmt.Printf("Copied passwords will be cleared after %d seconds.\n", seconds)
+				}
+				f.Logger.Info("Clipboard timeout changed to %d seconds", seconds)
+				return nil
+
+			case "log-format":
+				format := strings.ToLower(value)
+				if format != logger.FormatText && format != logger.FormatJSON {
+					return fmt.Errorf("invalid value: must be %s or %s", logger.FormatText, logger.FormatJSON)
+				}
+
+				f.Config.LogFormat = format
+				if err := config.Save(f.System, f.Config); err != nil {
+					return fmt.Errorf("failed to set log format: %w", err)
+				}
+				f.Logger.SetFormat(format)
+
+				fmt.Printf("Log format set to %s.\n", format)
+				f.Logger.Info("Log format changed to %s", format)
+				return nil
+
+			case "log-level":
+				level, err := parseLogLevel(value)
+				if err != nil {
+					return err
+				}
+
+				f.Config.LogLevel = level
+				if err := config.Save(f.System, f.Config); err != nil {
+					return fmt.Errorf("failed to set log level: %w", err)
+				}
+				f.Logger.Info("Log level changed to %s", level)
+				setLogLevel(f)
+
+				fmt.Printf("Log level set to %s.\n", level)
+				return nil
+
+			case "generate-length":
+				length, err := parseGenerateLength(value)
+				if err != nil {
+					return err
+				}
+
+				f.Config.GenerateLength = length
+				if err := config.Save(f.System, f.Config); err != nil {
+					return fmt.Errorf("failed to set generated password length: %w", err)
+				}
+
+				fmt.Printf("Generated passwords are now %d characters long.\n", length)
+				f.Logger.Info("Generated password length changed to %d", length)
+				return nil
+
+			case "generate-words":
+				words, err := parseGenerateWords(value)
+				if err != nil {
+					return err
+				}
+
+				f.Config.GenerateWords = words
+				if err := config.Save(f.System, f.Config); err != nil {
+					return fmt.Errorf("failed to set generated passphrase words: %w", err)
+				}
+
+				fmt.Printf("Generated passphrases now have %d words.\n", words)
+				f.Logger.Info("Generated passphrase words changed to %d", words)
+				return nil
+
+			case "plaintext-index":
+				enabled, err := parseOnOff(value)
+				if err != nil {
+					return err
+				}
+
+				f.Config.PlaintextIndex = enabled
+				if err := config.Save(f.System, f.Config); err != nil {
+					return fmt.Errorf("failed to set plaintext index: %w", err)
+				}
+
+				if enabled {
+					fmt.Println("Plaintext index turned on. It is built the next time secrets are listed,")
+					fmt.Println("or now with 'coconut reindex'. Names, usernames, URLs, descriptions and")
+					fmt.Println("tags are then readable by anyone with the database file.")
+				} else {
+					if err := dropPlaintextIndex(f); err != nil {
+						return fmt.Errorf("failed to remove the plaintext index: %w", err)
+					}
+					fmt.Println("Plaintext index turned off and removed. Listing now decrypts every secret.")
+				}
+				f.Logger.Info("Plaintext index turned %s", onOff(enabled))
+				return nil
+
+			case "secret-shards":
+				shards, err := strconv.Atoi(value)
+				if err != nil || shards < 1 || shards > db.MaxShards {
+					return fmt.Errorf("invalid value: must be between 1 and %d", db.MaxShards)
+				}
+				if shards == max(f.Config.SecretShards, 1) {
+					fmt.Printf("Secrets are already spread over %d bucket(s).\n", shards)
+					return nil
+				}
+
+				if _, err := backupDBFile(f); err != nil {
+					f.Logger.Error("backup before resharding failed: %v", err)
+					return fmt.Errorf("aborting, backup failed: %w", err)
+				}
+
+				moved, err := reshardSecrets(f, shards)
+				if err != nil {
+					f.Logger.Error("resharding failed, nothing was moved: %v", err)
+					return fmt.Errorf("failed to set secret shards: %w", err)
+				}
+
+				fmt.Printf("Moved %d secret(s) into %d bucket(s).\n", moved, shards)
+				f.Logger.Info("Secret shards changed to %d (%d secrets moved)", shards, moved)
+				return nil
+
+			case "mask-char":
+				char, err := parseMaskChar(value)
+				if err != nil {
+					return err
+				}
+
+				f.Config.MaskChar = char
+				if err := config.Save(f.System, f.Config); err != nil {
+					return fmt.Errorf("failed to set mask character: %w", err)
+				}
+
+				fmt.Printf("Hidden passwords are shown as %s.\n", maskPassword(f.Config, "password"))
+				f.Logger.Info("Mask character changed to %q", char)
+				return nil
+
+			case "mask-length":
+				length, err := parseMaskLength(value)
+				if err != nil {
+					return err
+				}
+
+				f.Config.MaskLength = length
+				if err := config.Save(f.System, f.Config); err != nil {
+					return fmt.Errorf("failed to set mask length: %w", err)
+				}
+
+				if length == 0 {
+					fmt.Println("Hidden passwords are masked at their real length, which reveals how long they are.")
+				} else {
+					fmt.Printf("Hidden passwords are shown as %s.\n", maskPassword(f.Config, "password"))
+				}
+				f.Logger.Info("Mask length changed to %d", length)
+				return nil
+
 			default:
-				return fmt.Errorf("unknown setting: %s\nAvailable settings: autolock", setting)
+				return fmt.Errorf("unknown setting: %s\nAvailable settings: autolock, access-log, backup-keep, policy, policy-min-length, policy-require, policy-enforce, clipboard-cmd, clipboard-osc52, clipboard-timeout, log-format, log-level, generate-length, generate-words, plaintext-index, secret-shards, mask-char, mask-length", setting)
 			}
 		},
 	}
@@ -115,3 +586,142 @@ func setAutoLockTimeout(f *factory.Factory, seconds int) error {
 	f.Config.AutoLockSecs = seconds
 	return config.Save(f.System, f.Config)
 }
+
+// parseAutoLock reads an autolock timeout: a bare number of seconds, as
+// before, or a duration such as 15m, 10min or 1h30m. It returns whole
+// seconds between 0 and maxAutoLockSecs.
+func parseAutoLock(value string) (int, error) {
+	value = strings.TrimSpace(value)
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return checkAutoLock(seconds)
+	}
+
+	d, err := timeutil.ParseDuration(durationUnitAliases.Replace(strings.ToLower(value)))
+	if err != nil {
+		return 0, fmt.Errorf("invalid value: must be a number of seconds or a duration such as 15m or 1h")
+	}
+	if d%time.Second != 0 {
+		return 0, fmt.Errorf("invalid value: autolock timeout must be a whole number of seconds")
+	}
+	return checkAutoLock(int(min(d/time.Second, maxAutoLockSecs+1)))
+}
+
+func checkAutoLock(seconds int) (int, error) {
+	if seconds < 0 {
+		return 0, fmt.Errorf("invalid value: autolock timeout cannot be negative")
+	}
+	if seconds > maxAutoLockSecs {
+		return 0, fmt.Errorf("autolock timeout must be at most %d seconds (24 hours)", maxAutoLockSecs)
+	}
+	return seconds, nil
+}
+
+// parseMaskChar checks that value is a single visible character.
+func parseMaskChar(value string) (string, error) {
+	r, size := utf8.DecodeRuneInString(value)
+	if size == 0 || size != len(value) || r == utf8.RuneError || !unicode.IsGraphic(r) || unicode.IsSpace(r) {
+		return "", fmt.Errorf("invalid mask character %q: must be a single visible character", value)
+	}
+	return value, nil
+}
+
+// parseMaskLength reads a mask length of 1 to maxMaskLength, or "actual"
+// for the password's real length, returned as 0.
+func parseMaskLength(value string) (int, error) {
+	if strings.EqualFold(value, "actual") {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 || n > maxMaskLength {
+		return 0, fmt.Errorf("invalid mask length %q: must be between 1 and %d, or actual", value, maxMaskLength)
+	}
+	return n, nil
+}
+
+// parseLogLevel reads a log level, info, warn or error, in any case.
+func parseLogLevel(value string) (string, error) {
+	if _, err := logger.ParseLevel(value); err != nil {
+		return "", fmt.Errorf("invalid log level %q: must be info, warn or error", value)
+	}
+	return strings.ToLower(value), nil
+}
+
+// setLogLevel applies f.Config.LogLevel to the logger.
+func setLogLevel(f *factory.Factory) {
+	if level, err := logger.ParseLevel(f.Config.LogLevel); err == nil {
+		f.Logger.SetLevel(level)
+	}
+}
+
+// parseGenerateLength reads a default password length of 4 to
+// maxGenerateLength.
+func parseGenerateLength(value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 4 || n > maxGenerateLength {
+		return 0, fmt.Errorf("invalid length %q: must be between 4 and %d", value, maxGenerateLength)
+	}
+	return n, nil
+}
+
+// parseGenerateWords reads a default passphrase word count of 1 to
+// maxGenerateWords.
+func parseGenerateWords(value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 || n > maxGenerateWords {
+		return 0, fmt.Errorf("invalid word count %q: must be between 1 and %d", value, maxGenerateWords)
+	}
+	return n, nil
+}
+
+// formatSeconds writes seconds as a compact duration such as 5m, 1h30m or
+// 45s.
+func formatSeconds(seconds int) string {
+	s := (time.Duration(seconds) * time.Second).String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// savePolicy stores the password policy and prints what is now in force.
+func savePolicy(f *factory.Factory) error {
+	if err := config.Save(f.System, f.Config); err != nil {
+		return fmt.Errorf("failed to set password policy: %w", err)
+	}
+
+	if f.Config.Policy.IsEmpty() {
+		fmt.Println("Password policy: none")
+	} else {
+		fmt.Printf("Password policy: %s (%s)\n", f.Config.Policy, policyMode(f.Config.Policy.Enforce))
+	}
+	f.Logger.Info("Password policy changed to %s (%s)", f.Config.Policy, policyMode(f.Config.Policy.Enforce))
+	return nil
+}
+
+func policyMode(enforce bool) string {
+	if enforce {
+		return "enforced"
+	}
+	return "warn only"
+}
+
+func parseOnOff(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "on", "true", "yes", "1":
+		return true, nil
+	case "off", "false", "no", "0":
+		return false, nil
+	default:
+		return false, fmt.Errorf("invalid value: must be on or off")
+	}
+}
+
+func onOff(enabled bool) string {
+	if enabled {
+		return "on"
+	}
+	return "off"
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/ompatil-15/coconut/internal/config"
+)
+
+func TestParseAutoLock(t *testing.T) {
+	tests := map[string]int{
+		"0":       0,
+		"600":     600,
+		"15m":     900,
+		"10min":   600,
+		"10 mins": 600,
+		"1h":      3600,
+		"1 hour":  3600,
+		"1h30m":   5400,
+		"90s":     90,
+		"24h":     86400,
+	}
+	for input, want := range tests {
+		got, err := parseAutoLock(input)
+		if err != nil || got != want {
+			t.Errorf("parseAutoLock(%q) = %d, %v; want %d", input, got, err, want)
+		}
+	}
+
+	for _, bad := range []string{"", "-5", "-5m", "25h", "86401", "1.5s", "soon", "2d"} {
+		if got, err := parseAutoLock(bad); err == nil {
+			t.Errorf("parseAutoLock(%q) = %d, want an error", bad, got)
+		}
+	}
+}
+
+func TestFormatSeconds(t *testing.T) {
+	for seconds, want := range map[int]string{45: "45s", 300: "5m", 600: "10m", 3600: "1h", 5400: "1h30m", 3605: "1h0m5s"} {
+		if got := formatSeconds(seconds); got != want {
+			t.Errorf("formatSeconds(%d) = %q, want %q", seconds, got, want)
+		}
+	}
+}
+
+func TestMaskPassword(t *testing.T) {
+	cfg := config.Default()
+	if got := maskPassword(cfg, "hunter2"); got != "********" {
+		t.Errorf("Default mask = %q, want 8 asterisks", got)
+	}
+	if got := maskPassword(cfg, ""); got != "-" {
+		t.Errorf("Empty password mask = %q, want -", got)
+	}
+
+	cfg.MaskChar, cfg.MaskLength = "•", 0
+	if got := maskPassword(cfg, "hünter2"); got != "•••••••" {
+		t.Errorf("Actual-length mask = %q, want one • per character", got)
+	}
+
+	for _, bad := range []string{"", "**", " ", "\t"} {
+		if _, err := parseMaskChar(bad); err == nil {
+			t.Errorf("parseMaskChar(%q) should fail", bad)
+		}
+	}
+	for _, bad := range []string{"0", "65", "-1", "long"} {
+		if _, err := parseMaskLength(bad); err == nil {
+			t.Errorf("parseMaskLength(%q) should fail", bad)
+		}
+	}
+	if n, err := parseMaskLength("Actual"); err != nil || n != 0 {
+		t.Errorf("parseMaskLength(Actual) = %d, %v; want 0", n, err)
+	}
+}
+
+func TestApplyFileSetting(t *testing.T) {
+	cfg := config.Default()
+	for key, value := range map[string]string{
+		"autolock":       "15m",
+		"policy-require": "upper,digit",
+		"clipboard-cmd":  "wl-copy",
+		"mask-length":    "actual",
+		"log-level":      "WARN",
+		"generate-words": "8",
+	} {
+		if err := applyFileSetting(cfg, key, value); err != nil {
+			t.Errorf("applyFileSetting(%s, %s) failed: %v", key, value, err)
+		}
+	}
+	if cfg.AutoLockSecs != 900 || !cfg.Policy.RequireUpper || !cfg.Policy.RequireDigit || cfg.ClipboardCmd != "wl-copy" || cfg.MaskLength != 0 ||
+		cfg.LogLevel != "warn" || cfg.GenerateWords != 8 {
+		t.Errorf("Unexpected config %+v", cfg)
+	}
+
+	for key, value := range map[string]string{
+		"autolock":          "2d",
+		"clipboard-timeout": "99999",
+		"log-format":        "xml",
+		"log-level":         "debug",
+		"generate-length":   "3",
+		"secret-shards":     "4",
+	} {
+		if err := applyFileSetting(cfg, key, value); err == nil {
+			t.Errorf("Expected %s = %s to be rejected", key, value)
+		}
+	}
+}
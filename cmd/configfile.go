@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ompatil-15/coconut/internal/clipboard"
+	"github.com/ompatil-15/coconut/internal/config"
+	"github.com/ompatil-15/coconut/internal/factory"
+	"github.com/ompatil-15/coconut/internal/logger"
+	"github.com/ompatil-15/coconut/internal/paths"
+)
+
+// configFile is a parsed config file from the data directory.
+type configFile struct {
+	path     string
+	settings []config.FileSetting
+}
+
+// loadConfigFile reads the config file in the data directory, if there
+// is one. A file that cannot be read or parsed is reported on w and
+// ignored, so a typo never locks anyone out of their vault.
+func loadConfigFile(w io.Writer) *configFile {
+	dir, err := paths.DataDir()
+	if err != nil {
+		return nil
+	}
+	path := config.FindFile(dir)
+	if path == "" {
+		return nil
+	}
+
+	settings, err := config.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(w, "Warning: ignoring %s: %v\n", path, err)
+		return nil
+	}
+	return &configFile{path: path, settings: settings}
+}
+
+// dbPath returns the vault database the file names, or "". A relative
+// path is taken from the file's directory.
+func (c *configFile) dbPath() string {
+	if c == nil {
+		return ""
+	}
+	var path string
+	for _, s := range c.settings {
+		if s.Key == config.FileDBKey {
+			path = s.Value
+		}
+	}
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, rest)
+		}
+	}
+	if path != "" && !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(c.path), path)
+	}
+	return path
+}
+
+// apply overlays the file's settings on f.Config. A setting that is
+// unknown or has an invalid value is reported on w and skipped.
+func (c *configFile) apply(f *factory.Factory, w io.Writer) {
+	if c == nil {
+		return
+	}
+	for _, s := range c.settings {
+		if s.Key == config.FileDBKey {
+			continue
+		}
+		// Checked on a copy first, so a bad value leaves no trace.
+		probe := *f.Config
+		err := applyFileSetting(&probe, s.Key, s.Value)
+		if err == nil {
+			err = f.Config.Override(s.Key, c.path)
+		}
+		if err == nil {
+			err = applyFileSetting(f.Config, s.Key, s.Value)
+		}
+		if err != nil {
+			fmt.Fprintf(w, "Warning: %s line %d: %v; ignored\n", filepath.Base(c.path), s.Line, err)
+		}
+	}
+	f.Logger.SetAccessLogEnabled(f.Config.AccessLog)
+	f.Logger.SetFormat(f.Config.LogFormat)
+	setLogLevel(f)
+}
+
+// applyFileSetting validates value as 'config set' would and sets it on
+// cfg, without saving anything.
+func applyFileSetting(cfg *config.Config, key, value string) error {
+	var err error
+	switch key {
+	case "autolock":
+		cfg.AutoLockSecs, err = parseAutoLock(value)
+	case "access-log":
+		cfg.AccessLog, err = parseOnOff(value)
+	case "backup-keep":
+		keep, convErr := strconv.Atoi(value)
+		if convErr != nil || keep < 1 {
+			return fmt.Errorf("invalid value for backup-keep: must be a positive number of backups")
+		}
+		cfg.BackupKeep = keep
+	case "policy-min-length":
+		length, convErr := strconv.Atoi(value)
+		if convErr != nil || length < 0 {
+			return fmt.Errorf("invalid value for policy-min-length: must be a non-negative number of characters")
+		}
+		cfg.Policy.MinLength = length
+	case "policy-require":
+		err = cfg.Policy.SetRequired(strings.Split(value, ","))
+	case "policy-enforce":
+		cfg.Policy.Enforce, err = parseOnOff(value)
+	case "clipboard-cmd":
+		if strings.EqualFold(strings.TrimSpace(value), "default") {
+			value = ""
+		} else if _, err := clipboard.ParseCommand(value); err != nil {
+			return fmt.Errorf("invalid value for clipboard-cmd: %w", err)
+		}
+		cfg.ClipboardCmd = strings.TrimSpace(value)
+	case "clipboard-osc52":
+		cfg.ClipboardOSC52, err = parseOnOff(value)
+	case "clipboard-timeout":
+		seconds, convErr := strconv.Atoi(value)
+		if convErr != nil || seconds < 0 || seconds > maxClipboardClearSecs {
+			return fmt.Errorf("invalid value for clipboard-timeout: must be between 0 and %d seconds", maxClipboardClearSecs)
+		}
+		cfg.ClipboardClearSecs = seconds
+	case "log-format":
+		format := strings.ToLower(value)
+		if format != logger.FormatText && format != logger.FormatJSON {
+			return fmt.Errorf("invalid value for log-format: must be %s or %s", logger.FormatText, logger.FormatJSON)
+		}
+		cfg.LogFormat = format
+	case "log-level":
+		cfg.LogLevel, err = parseLogLevel(value)
+	case "generate-length":
+		cfg.GenerateLength, err = parseGenerateLength(value)
+	case "generate-words":
+		cfg.GenerateWords, err = parseGenerateWords(value)
+	case "mask-char":
+		cfg.MaskChar, err = parseMaskChar(value)
+	case "mask-length":
+		cfg.MaskLength, err = parseMaskLength(value)
+	default:
+		return fmt.Errorf("unknown setting %q (a config file may set: %s, %s)", key, config.FileDBKey, strings.Join(config.FileKeys(), ", "))
+	}
+	return err
+}
+
+// setInConfigFile handles 'config set' for a setting the config file
+// overrides: the file is updated, since a value stored in the vault would
+// have no effect.
+func setInConfigFile(f *factory.Factory, setting, value string) error {
+	if err := applyFileSetting(f.Config, setting, value); err != nil {
+		return err
+	}
+	if err := config.SetFileValue(f.Config.FilePath, setting, value); err != nil {
+		return fmt.Errorf("failed to update %s: %w", f.Config.FilePath, err)
+	}
+
+	fmt.Printf("Set %s to %s in %s.\n", setting, value, f.Config.FilePath)
+	f.Logger.Info("Setting %s changed in %s", setting, f.Config.FilePath)
+	return nil
+}
@@ -6,19 +6,52 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/ompatil-15/coconut/internal/db"
+	"github.com/ompatil-15/coconut/internal/db/model"
 	"github.com/ompatil-15/coconut/internal/factory"
+	"github.com/ompatil-15/coconut/internal/journal"
+	"github.com/ompatil-15/coconut/internal/undo"
 	"github.com/spf13/cobra"
 )
 
 func NewDeleteCmd(f *factory.Factory) *cobra.Command {
+	var (
+		all  bool
+		tags []string
+		yes  bool
+	)
+
 	cmd := &cobra.Command{
 		Use:     "delete <index>",
 		Aliases: []string{"del", "rm"},
 		Short:   "Delete a saved secret from the vault",
-		Long:    `Safely deletes a specific secret from your encrypted vault using its index.`,
-		Args:    cobra.ExactArgs(1),
+		Long: `Safely deletes a specific secret from your encrypted vault using its index.
 
+Use --all --tag <tag> instead of an index to delete every secret with any
+of the given tags. The matching secrets are listed and you confirm once;
+--yes skips the question and is required when stdin is not a terminal.
+The vault is backed up first and the secrets are deleted in a single
+transaction. 'coconut undo' cannot restore a bulk delete; restore the
+backup instead.`,
+		Example: `  coconut delete 3
+  coconut delete --all --tag work
+  coconut delete --all --tag old --tag temp --yes`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if all || len(tags) > 0 {
+				return cobra.NoArgs(cmd, args)
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
+
+		ValidArgsFunction: completeSecretIndexes(f),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if all || len(tags) > 0 {
+				if !all || len(tags) == 0 {
+					return fmt.Errorf("bulk delete needs both --all and at least one --tag")
+				}
+				return deleteByTags(f, model.NormalizeTags(tags), yes)
+			}
+
 			if err := EnsureVaultUnlocked(f); err != nil {
 				return err
 			}
@@ -58,17 +91,102 @@ func NewDeleteCmd(f *factory.Factory) *cobra.Command {
 				return nil
 			}
 
-			if err := f.Secrets.Delete(secret.ID); err != nil {
+			if err := f.Undo.Record(undo.OpDelete, secret); err != nil {
+				logger.Warn("Failed to record undo state: %v", err)
+			}
+
+			err = journaled(f, journal.Entry{Op: journal.OpDelete, Previous: &secret}, func() error {
+				return f.Secrets.Delete(secret.ID)
+			})
+			if err != nil {
 				logger.Error("Failed to delete secret %d: %v", index, err)
 				fmt.Fprintln(errOut, "Error: failed to delete secret. Check log for details.")
 				return err
 			}
 
-			fmt.Fprintf(out, "Secret %d deleted successfully.\n", index)
+			logger.Access("delete", accessTarget(index, &secret))
+			fmt.Fprintf(out, "Secret %d deleted successfully. Run 'coconut undo' to restore it.\n", index)
 			logger.Info("Secret %d deleted successfully", index)
 			return nil
 		},
 	}
 
+	cmd.Flags().BoolVar(&all, "all", false, "Delete every secret matching --tag")
+	cmd.Flags().StringSliceVarP(&tags, "tag", "t", nil, "With --all, delete secrets with this tag (repeatable)")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "With --all, do not ask for confirmation")
+
 	return cmd
 }
+
+// deleteByTags deletes every secret with any of tags after one
+// confirmation. The targets come from a single List so the indexes shown
+// are the ones deleted.
+func deleteByTags(f *factory.Factory, tags []string, yes bool) error {
+	out := f.IO.Out
+
+	if !yes && !stdinIsTerminal(f) {
+		return fmt.Errorf("refusing to delete several secrets without --yes when stdin is not a terminal")
+	}
+
+	if err := EnsureVaultUnlocked(f); err != nil {
+		return err
+	}
+
+	secrets, err := f.Secrets.List()
+	if err != nil {
+		f.Logger.Error("Failed to list secrets: %v", err)
+		return fmt.Errorf("failed to fetch secrets: %w", err)
+	}
+
+	var targets []listEntry
+	for _, e := range indexEntries(secrets) {
+		if hasAnyTag(&e.secret, tags) {
+			targets = append(targets, e)
+		}
+	}
+	if len(targets) == 0 {
+		fmt.Fprintf(out, "No secrets are tagged %s.\n", strings.Join(tags, " or "))
+		return nil
+	}
+
+	fmt.Fprintf(out, "%d secret(s) tagged %s:\n", len(targets), strings.Join(tags, " or "))
+	for _, e := range targets {
+		fmt.Fprintf(out, "  %d  %s (%s)\n", e.index, e.secret.Name, e.secret.Username)
+	}
+
+	if !yes {
+		fmt.Fprintf(out, "Delete these %d secret(s)? (y/N): ", len(targets))
+		confirm, _ := bufio.NewReader(f.IO.In).ReadString('\n')
+		if strings.ToLower(strings.TrimSpace(confirm)) != "y" {
+			fmt.Fprintln(out, "Delete cancelled.")
+			f.Logger.Info("Bulk delete cancelled")
+			return nil
+		}
+	}
+
+	path, err := backupDBFile(f)
+	if err != nil {
+		f.Logger.Error("backup before bulk delete failed: %v", err)
+		return fmt.Errorf("failed to back up vault: %w", err)
+	}
+
+	err = f.Secrets.Batch(func(repo db.SecretRepository) error {
+		for _, e := range targets {
+			if err := repo.Delete(e.secret.ID); err != nil {
+				return fmt.Errorf("delete secret %d: %w", e.index, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		f.Logger.Error("Bulk delete failed, nothing deleted: %v", err)
+		return fmt.Errorf("failed to delete secrets (nothing was deleted): %w", err)
+	}
+
+	for _, e := range targets {
+		f.Logger.Access("delete", accessTarget(e.index, &e.secret))
+	}
+	f.Logger.Info("Deleted %d secret(s) tagged %s", len(targets), strings.Join(tags, ","))
+	fmt.Fprintf(out, "Deleted %d secret(s). A backup was saved to %s.\n", len(targets), path)
+	return nil
+}
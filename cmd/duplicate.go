@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ompatil-15/coconut/internal/db/model"
+	"github.com/ompatil-15/coconut/internal/factory"
+	"github.com/ompatil-15/coconut/internal/journal"
+	"github.com/spf13/cobra"
+)
+
+// copySuffix is appended to the name of a duplicated secret.
+const copySuffix = " (copy)"
+
+func NewDuplicateCmd(f *factory.Factory) *cobra.Command {
+	var (
+		generate bool
+		length   int
+	)
+
+	cmd := &cobra.Command{
+		Use:     "duplicate <index|name>",
+		Aliases: []string{"dup", "clone"},
+		Short:   "Create a copy of an existing secret",
+		Long: `Copy a secret into a new entry, e.g. to add a second account on the
+same site. The copy keeps the username, URL, description, tags and expiry,
+gets a new ID and fresh timestamps (it has never been used), and has
+" (copy)" appended to its name.
+
+Use --generate to give the copy a new random password instead of reusing
+the original one.`,
+		Example: `  coconut duplicate 3
+  coconut duplicate github --generate
+  coconut duplicate 3 --generate --length 24`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeSecretNames(f),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := EnsureVaultUnlocked(f); err != nil {
+				return err
+			}
+
+			secrets, err := f.Secrets.List()
+			if err != nil {
+				f.Logger.Error("failed to fetch secrets: %v", err)
+				return fmt.Errorf("failed to fetch secrets: %w", err)
+			}
+
+			index, err := resolveSecretArg(f, secrets, args[0])
+			if err != nil {
+				return err
+			}
+
+			if !cmd.Flags().Changed("length") {
+				length = f.Config.GenerateLength
+			}
+			if generate && length < 4 {
+				return fmt.Errorf("password length must be at least 4")
+			}
+
+			original := secrets[index-1]
+			clone := duplicateSecret(original, time.Now())
+
+			// A v4 UUID collision is practically impossible, but an existing
+			// ID would be silently overwritten by Add, so rule it out.
+			for {
+				if _, err := f.Secrets.Get(clone.ID); err != nil {
+					break
+				}
+				clone.ID = uuid.New().String()
+			}
+
+			if generate {
+				clone.Password, err = generatePassword(length)
+				if err != nil {
+					return fmt.Errorf("failed to generate password: %w", err)
+				}
+			}
+
+			err = journaled(f, journal.Entry{Op: journal.OpAdd, Secret: &clone}, func() error {
+				_, err := f.Secrets.Add(clone)
+				return err
+			})
+			if err != nil {
+				f.Logger.Error("failed to add duplicated secret: %v", err)
+				return fmt.Errorf("failed to add secret: %w", err)
+			}
+
+			f.Logger.Access("clone", accessTarget(index, &original))
+			f.Logger.Info("Secret %d duplicated", index)
+
+			fmt.Fprintf(f.IO.Out, "Secret %d duplicated as '%s'.\n", index, clone.Name)
+			if generate {
+				fmt.Fprintln(f.IO.Out, "A new password was generated for the copy. Use 'coconut get' to view it.")
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVarP(&generate, "generate", "g", false, "Generate a new password for the copy")
+	cmd.Flags().IntVarP(&length, "length", "l", 16, "Length of the generated password; the generate-length setting changes the default")
+
+	return cmd
+}
+
+// duplicateSecret returns a copy of s with a new ID, a "(copy)" name,
+// timestamps reset to now and no last use. Tags are copied so the two
+// never share a slice.
+func duplicateSecret(s model.Secret, now time.Time) model.Secret {
+	clone := s
+	clone.ID = uuid.New().String()
+	clone.Tags = slices.Clone(s.Tags)
+	clone.CreatedAt = now
+	clone.UpdatedAt = now
+	clone.LastUsedAt = time.Time{}
+
+	base := s.Name
+	if base == "" {
+		base = s.Username
+	}
+	clone.Name = base + copySuffix
+
+	return clone
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ompatil-15/coconut/internal/db/model"
+)
+
+func TestDuplicateSecret(t *testing.T) {
+	then := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	now := then.Add(48 * time.Hour)
+	original := model.Secret{
+		ID:         "orig",
+		Name:       "GitHub",
+		Username:   "alice",
+		Password:   "pw",
+		Tags:       []string{"work"},
+		CreatedAt:  then,
+		UpdatedAt:  then,
+		LastUsedAt: then.Add(time.Hour),
+	}
+
+	clone := duplicateSecret(original, now)
+	if clone.ID == "" || clone.ID == original.ID {
+		t.Errorf("Expected a new ID, got %q", clone.ID)
+	}
+	if clone.Name != "GitHub (copy)" || clone.Username != "alice" || clone.Password != "pw" {
+		t.Errorf("Unexpected copy: %+v", clone)
+	}
+	if !clone.CreatedAt.Equal(now) || !clone.UpdatedAt.Equal(now) {
+		t.Errorf("Expected fresh timestamps, got created %v, updated %v", clone.CreatedAt, clone.UpdatedAt)
+	}
+	if !clone.LastUsedAt.IsZero() {
+		t.Errorf("Expected a copy that was never used, got last used %v", clone.LastUsedAt)
+	}
+
+	clone.Tags[0] = "personal"
+	if original.Tags[0] != "work" {
+		t.Error("Expected the copy's tags not to share the original's slice")
+	}
+}
@@ -0,0 +1,308 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ompatil-15/coconut/internal/db/model"
+	"github.com/ompatil-15/coconut/internal/exporter"
+	"github.com/ompatil-15/coconut/internal/factory"
+	"github.com/ompatil-15/coconut/internal/sealed"
+	"github.com/spf13/cobra"
+)
+
+func NewExportCmd(f *factory.Factory) *cobra.Command {
+	var (
+		format string
+		schema string
+		tags   []string
+		yes    bool
+		out    string
+		force  bool
+
+		encrypted bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export secrets for use elsewhere, in plaintext or encrypted",
+		Long: `Export secrets in plaintext to stdout, or to a file with --out.
+
+Supported formats:
+  env   KEY='password' lines for a .env file. The key is the secret's
+        name, uppercased, with other characters turned into underscores
+        (e.g. "GitHub (work)" becomes GITHUB_WORK). Values are
+        single-quoted, so a shell sourcing the file reads them as is.
+        Notes, unnamed secrets and names that map to a key already used
+        are skipped.
+  json  One JSON document with every field, passwords included. With
+        --schema bitwarden it is a file Bitwarden's "Bitwarden (json)"
+        import accepts; otherwise it is an array of coconut objects.
+
+Use --tag to export only secrets with any of the given tags. Secrets
+protected with 'coconut protect' are never exported.
+
+--out writes the export to a file readable only by you (mode 0600). It is
+written to a temporary file next to it first and renamed into place once
+complete, so an interrupted export never leaves a partial file. An
+existing file is only replaced with --force.
+
+The output contains your passwords in plaintext. Because of this the
+command refuses to run without --yes.
+
+With --encrypted the export is a coconut JSON export sealed under a
+passphrase you choose, asked for once the vault is unlocked. The key is
+derived from the passphrase alone with Argon2id, so the file does not
+depend on your master password. No plaintext is written anywhere, so
+--yes is not needed. Read the file back with 'coconut import --encrypted'.
+--format can be left out; only json with the coconut schema can be
+encrypted. With --password-stdin the passphrase is read from the line
+after the master password.`,
+		Example: `  coconut export --format env --tag myapp --yes > .env
+  coconut export --format env --tag staging --tag shared --yes
+  coconut export --format json --schema bitwarden --yes > bitwarden.json
+  coconut export --format json --yes --out coconut.json
+  coconut export --encrypted --out coconut.cocobak`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			errOut := f.IO.ErrOut
+
+			if encrypted {
+				if format == "" {
+					format = "json"
+				}
+				if !strings.EqualFold(format, "json") || !strings.EqualFold(schema, exporter.SchemaCoconut) {
+					return fmt.Errorf("--encrypted only supports --format json with the %s schema", exporter.SchemaCoconut)
+				}
+			} else if format == "" {
+				return fmt.Errorf(`required flag(s) "format" not set`)
+			}
+
+			if !yes && !encrypted {
+				fmt.Fprintln(errOut, "WARNING: export writes your passwords in PLAINTEXT.")
+				fmt.Fprintln(errOut, "Anyone who can read the output can read the passwords.")
+				return fmt.Errorf("refusing to export without --yes")
+			}
+
+			if !slices.Contains(exporter.Formats(), strings.ToLower(format)) {
+				return fmt.Errorf("unknown export format %q (supported: %s)", format, strings.Join(exporter.Formats(), ", "))
+			}
+			isJSON := strings.EqualFold(format, "json")
+			if cmd.Flags().Changed("schema") && !isJSON {
+				return fmt.Errorf("--schema only applies to --format json")
+			}
+			if !slices.Contains(exporter.Schemas(), strings.ToLower(schema)) {
+				return fmt.Errorf("unknown schema %q (supported: %s)", schema, strings.Join(exporter.Schemas(), ", "))
+			}
+
+			if out != "" && !force {
+				if _, err := os.Lstat(out); err == nil {
+					return fmt.Errorf("%s already exists; use --force to overwrite it", out)
+				}
+			} else if force && out == "" {
+				return fmt.Errorf("--force only applies to --out")
+			}
+
+			if err := EnsureVaultUnlocked(f); err != nil {
+				return err
+			}
+
+			secrets, err := f.Secrets.List()
+			if err != nil {
+				f.Logger.Error("failed to fetch secrets: %v", err)
+				return fmt.Errorf("failed to fetch secrets: %w", err)
+			}
+			secrets = filterByTags(secrets, model.NormalizeTags(tags))
+
+			var passphrase string
+			if encrypted {
+				if passphrase, err = readExportPassphrase(f); err != nil {
+					return err
+				}
+			} else {
+				fmt.Fprintln(errOut, "WARNING: writing passwords in PLAINTEXT. Keep the output private and delete it when done.")
+			}
+
+			var protected []exporter.Skip
+			secrets = slices.DeleteFunc(secrets, func(s model.Secret) bool {
+				if s.IsProtected() {
+					protected = append(protected, exporter.Skip{Name: s.Name, Reason: "protected by a PIN"})
+					return true
+				}
+				return false
+			})
+
+			var skipped []exporter.Skip
+			write := func(w io.Writer) (err error) {
+				if encrypted {
+					return writeSealedExport(f, w, secrets, passphrase)
+				}
+				if isJSON {
+					skipped, err = exporter.WriteJSON(w, secrets, schema)
+				} else {
+					skipped, err = exporter.Write(format, w, secrets)
+				}
+				return err
+			}
+			if out != "" {
+				err = writeExportFile(out, force, write)
+			} else {
+				err = write(f.IO.Out)
+			}
+			if err != nil {
+				return fmt.Errorf("export failed: %w", err)
+			}
+
+			exported := len(secrets) - len(skipped)
+			skipped = append(protected, skipped...)
+			logged := strings.ToLower(format)
+			if encrypted {
+				logged = "encrypted"
+			}
+			f.Logger.Access("export", fmt.Sprintf("format=%s count=%d", logged, exported))
+			if out != "" {
+				fmt.Fprintf(errOut, "Exported %d secret(s) to %s.\n", exported, out)
+			} else {
+				fmt.Fprintf(errOut, "Exported %d secret(s).\n", exported)
+			}
+			if len(skipped) > 0 {
+				fmt.Fprintf(errOut, "Skipped %d secret(s):\n", len(skipped))
+				for _, s := range skipped {
+					fmt.Fprintf(errOut, "  %s: %s\n", s.Name, s.Reason)
+				}
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "", "Export format ("+strings.Join(exporter.Formats(), ", ")+")")
+	cmd.Flags().StringVar(&schema, "schema", exporter.SchemaCoconut, "JSON schema for --format json ("+strings.Join(exporter.Schemas(), ", ")+")")
+	cmd.Flags().StringSliceVarP(&tags, "tag", "t", nil, "Only export secrets with this tag (repeatable)")
+	cmd.Flags().BoolVar(&yes, "yes", false, "Confirm writing passwords in plaintext")
+	cmd.Flags().StringVarP(&out, "out", "o", "", "Write the export to this file (mode 0600) instead of stdout")
+	cmd.Flags().BoolVar(&force, "force", false, "With --out, replace an existing file")
+	cmd.Flags().BoolVar(&encrypted, "encrypted", false, "Seal the export under a passphrase instead of writing plaintext")
+
+	return cmd
+}
+
+// readExportPassphrase asks for the passphrase of an encrypted export
+// twice, or reads it once from the next line with --password-stdin.
+func readExportPassphrase(f *factory.Factory) (string, error) {
+	passphrase, err := readExtraPassword(f, "Enter a passphrase for the export: ")
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	if passphrase == "" {
+		return "", errors.New("export passphrase cannot be empty")
+	}
+	if f.PasswordStdin {
+		return passphrase, nil
+	}
+
+	confirm, err := readExtraPassword(f, "Confirm the passphrase: ")
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	if confirm != passphrase {
+		return "", errors.New("passphrases do not match")
+	}
+	return passphrase, nil
+}
+
+// writeSealedExport writes secrets to w as a coconut JSON export sealed
+// under passphrase. The plaintext only ever exists in memory.
+func writeSealedExport(f *factory.Factory, w io.Writer, secrets []model.Secret, passphrase string) error {
+	var export bytes.Buffer
+	if _, err := exporter.WriteJSON(&export, secrets, exporter.SchemaCoconut); err != nil {
+		return err
+	}
+
+	f.IO.StartProgressIndicator("Encrypting...")
+	data, err := sealed.Seal(export.Bytes(), len(secrets), passphrase, time.Now())
+	f.IO.StopProgressIndicator()
+	clear(export.Bytes())
+	if err != nil {
+		return fmt.Errorf("failed to encrypt export: %w", err)
+	}
+
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// writeExportFile runs write against a temporary file with mode 0600 in
+// path's directory and renames it over path once it is complete and
+// synced, so path holds either the whole export or nothing new. Without
+// force an existing path is left alone.
+func writeExportFile(path string, force bool, write func(w io.Writer) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	done := false
+	defer func() {
+		if !done {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	// CreateTemp already uses 0600; keep it that way regardless of umask
+	// or platform.
+	if err := tmp.Chmod(0600); err != nil {
+		return fmt.Errorf("failed to restrict output file permissions: %w", err)
+	}
+	if err := write(tmp); err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+
+	if !force {
+		// A hard link fails if path has appeared since the check before
+		// unlocking, where a rename would replace it. Filesystems without
+		// hard links fall back to the rename.
+		err := os.Link(tmp.Name(), path)
+		if errors.Is(err, fs.ErrExist) {
+			return fmt.Errorf("%s already exists; use --force to overwrite it", path)
+		}
+		if err == nil {
+			done = true
+			os.Remove(tmp.Name())
+			return nil
+		}
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to move output file into place: %w", err)
+	}
+	done = true
+	return nil
+}
+
+// filterByTags keeps the secrets that have any of tags. No tags keeps all.
+func filterByTags(secrets []model.Secret, tags []string) []model.Secret {
+	if len(tags) == 0 {
+		return secrets
+	}
+
+	var kept []model.Secret
+	for _, s := range secrets {
+		if hasAnyTag(&s, tags) {
+			kept = append(kept, s)
+		}
+	}
+	return kept
+}
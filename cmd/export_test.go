@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteExportFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "export.json")
+	writeString := func(s string) func(io.Writer) error {
+		return func(w io.Writer) error {
+			_, err := io.WriteString(w, s)
+			return err
+		}
+	}
+
+	if err := writeExportFile(path, false, writeString("first")); err != nil {
+		t.Fatalf("writeExportFile failed: %v", err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Expected a 0600 file, got %v, %v", info, err)
+	}
+
+	if err := writeExportFile(path, false, writeString("second")); err == nil {
+		t.Error("Expected an existing file to be refused without force")
+	}
+	failing := func(w io.Writer) error {
+		io.WriteString(w, "partial")
+		return errors.New("interrupted")
+	}
+	if err := writeExportFile(path, true, failing); err == nil {
+		t.Error("Expected the write error to be returned")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "first" {
+		t.Errorf("A refused or failed export must leave the file alone, got %q", data)
+	}
+
+	if err := writeExportFile(path, true, writeString("second")); err != nil {
+		t.Fatalf("writeExportFile with force failed: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "second" {
+		t.Errorf("Expected the file to be replaced, got %q", data)
+	}
+
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("Expected no temporary files left behind, got %v", entries)
+	}
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/ompatil-15/coconut/internal/factory"
+	"github.com/spf13/cobra"
+)
+
+func NewFavCmd(f *factory.Factory) *cobra.Command {
+	return &cobra.Command{
+		Use:     "fav <index>",
+		Aliases: []string{"pin"},
+		Short:   "Mark a secret as a favorite",
+		Long: `Mark a secret as a favorite. Favorites are starred in 'coconut list' and
+can be shown on their own with 'coconut list --favorites'.`,
+		Example: `  coconut fav 3
+  coconut list --favorites`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return setFavorite(f, args[0], true)
+		},
+	}
+}
+
+func NewUnfavCmd(f *factory.Factory) *cobra.Command {
+	return &cobra.Command{
+		Use:     "unfav <index>",
+		Aliases: []string{"unpin"},
+		Short:   "Remove a secret from favorites",
+		Example: `  coconut unfav 3`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return setFavorite(f, args[0], false)
+		},
+	}
+}
+
+// setFavorite sets the favorite flag of the secret at a 1-based list index.
+func setFavorite(f *factory.Factory, arg string, favorite bool) error {
+	if err := EnsureVaultUnlocked(f); err != nil {
+		return err
+	}
+
+	index, err := strconv.Atoi(arg)
+	if err != nil {
+		return fmt.Errorf("please provide a valid index number (e.g. 1, 2, 3)")
+	}
+
+	secrets, err := f.Secrets.List()
+	if err != nil {
+		f.Logger.Error("failed to fetch secrets: %v", err)
+		return fmt.Errorf("failed to fetch secrets: %w", err)
+	}
+
+	if index < 1 || index > len(secrets) {
+		return fmt.Errorf("invalid index: %d (valid range: 1–%d)", index, len(secrets))
+	}
+
+	secret := secrets[index-1]
+	if secret.IsFavorite == favorite {
+		if favorite {
+			fmt.Fprintf(f.IO.Out, "Secret %d is already a favorite.\n", index)
+		} else {
+			fmt.Fprintf(f.IO.Out, "Secret %d is not a favorite.\n", index)
+		}
+		return nil
+	}
+
+	secret.IsFavorite = favorite
+	if err := f.Secrets.Update(secret); err != nil {
+		f.Logger.Error("failed to update favorite: %v", err)
+		return fmt.Errorf("failed to update secret: %w", err)
+	}
+
+	if favorite {
+		f.Logger.Info("Secret %d marked as favorite", index)
+		fmt.Fprintf(f.IO.Out, "Secret %d added to favorites.\n", index)
+	} else {
+		f.Logger.Info("Secret %d removed from favorites", index)
+		fmt.Fprintf(f.IO.Out, "Secret %d removed from favorites.\n", index)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/ompatil-15/coconut/internal/db/model"
+)
+
+// maxFieldNameLen bounds a custom field name so it fits the label column
+// of 'coconut get'.
+const maxFieldNameLen = 30
+
+// parseFieldArgs parses repeated --field name=value flags. Only the first
+// '=' separates name and value, so values may contain '='.
+func parseFieldArgs(args []string) ([]model.Field, error) {
+	var fields []model.Field
+	for _, arg := range args {
+		name, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			return nil, fmt.Errorf("field %q is not in name=value form", arg)
+		}
+		name = strings.TrimSpace(name)
+		if err := validateFieldName(name); err != nil {
+			return nil, err
+		}
+		fields = append(fields, model.Field{Name: name, Value: value})
+	}
+	return fields, nil
+}
+
+// validateFieldName rejects names that could not be shown or looked up
+// again with 'get --field'.
+func validateFieldName(name string) error {
+	switch {
+	case name == "":
+		return fmt.Errorf("field name is blank")
+	case strings.ContainsFunc(name, unicode.IsControl):
+		return fmt.Errorf("field name %q contains a control character", name)
+	case len(name) > maxFieldNameLen:
+		return fmt.Errorf("field name %q is longer than %d characters", name, maxFieldNameLen)
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/ompatil-15/coconut/internal/db/model"
+)
+
+func TestParseFieldArgs(t *testing.T) {
+	fields, err := parseFieldArgs([]string{"Account = 1234", "query=a=b", "empty="})
+	if err != nil {
+		t.Fatalf("parseFieldArgs failed: %v", err)
+	}
+	want := []model.Field{{Name: "Account", Value: " 1234"}, {Name: "query", Value: "a=b"}, {Name: "empty", Value: ""}}
+	if !slices.Equal(fields, want) {
+		t.Errorf("Expected %v, got %v", want, fields)
+	}
+
+	for _, bad := range []string{"novalue", "=value", " =value", "tab\there=x", "a-field-name-that-is-far-too-long=x"} {
+		if _, err := parseFieldArgs([]string{"ok=1", bad}); err == nil {
+			t.Errorf("Expected %q to be rejected", bad)
+		}
+	}
+}
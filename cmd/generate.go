@@ -2,11 +2,18 @@ package cmd
 
 import (
 	"crypto/rand"
+	"errors"
 	"fmt"
+	"io/fs"
+	"math"
 	"math/big"
+	"os"
+	"slices"
+	"strings"
 
-	"github.com/atotto/clipboard"
 	"github.com/ompatil-15/coconut/internal/factory"
+	"github.com/ompatil-15/coconut/internal/strength"
+	"github.com/ompatil-15/coconut/internal/wordlist"
 	"github.com/spf13/cobra"
 )
 
@@ -15,36 +22,262 @@ const (
 	uppercase = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
 	digits    = "0123456789"
 	special   = "!@#$%^&*()_+-=[]{}|;:,.<>?"
+
+	// Letters for --pronounceable. Consonants that are awkward to read
+	// aloud or easily confused (c, q, w, x, y) are left out.
+	consonants = "bdfghjklmnprstvz"
+	vowels     = "aeiou"
 )
 
+// patternClasses maps each --pattern token to the charset it expands to.
+var patternClasses = map[rune]string{
+	'L': lowercase + uppercase,
+	'd': digits,
+	's': special,
+	'a': lowercase + uppercase + digits + special,
+}
+
 func NewGenerateCmd(f *factory.Factory) *cobra.Command {
 	var (
-		length int
-		copy   bool
+		length    int
+		copy      bool
+		pattern   string
+		words     int
+		listPath  string
+		separator string
+		pronounce bool
+		numDigits int
+		count     int
+		output    string
+		force     bool
+		osc52     bool
+		similarTo string
+		minBits   float64
+		avoid     string
+		noSeq     bool
 	)
 
 	cmd := &cobra.Command{
 		Use:     "generate",
 		Aliases: []string{"gen"},
 		Short:   "Generate a random strong password",
-		Long:    `Generate a random strong password with letters, numbers, and special characters.`,
+		Long: `Generate a random strong password with letters, numbers, and special characters.
+
+Use --pattern to control the exact shape of the password. Each token
+expands to one random character of its class:
+  L    letter (a-z, A-Z)
+  d    digit (0-9)
+  s    symbol
+  a    any of the above
+
+For example, 'LLLLdds' produces 4 letters, 2 digits and 1 symbol.
+
+Use --words to generate a diceware-style passphrase instead. Words are
+drawn from the built-in EFF large wordlist (7776 words), or from your own
+file with --wordlist (one word per line; blank lines and lines starting
+with '#' are ignored). The estimated entropy is printed alongside.
+
+Use --pronounceable for a password that is easy to read out and type,
+built from alternating consonants and vowels with --digits digits mixed
+in at random positions (e.g. "bokuti3pa"). These are weaker than fully
+random passwords of the same length, so the entropy is printed too;
+raise --length to compensate.
+
+Use --count to generate several at once, printed one per line. Use
+--output to write them to a file instead, so they never appear in the
+terminal or shell history. The file holds only the passwords, one per
+line, and is created readable by you alone. An existing file is not
+overwritten unless --force is given.
+
+Use --similar-to <index|name> for a fresh password with the same shape as
+an existing secret's: the same length and the same character classes
+(lowercase, uppercase, digits, symbols), each used at least once. This is
+for sites that only accept passwords of a particular form. The original
+password is never shown or logged, only its shape.
+
+Use --min-entropy <bits> to regenerate until the strength estimate (the
+one 'audit' uses) reaches the threshold, for provisioning where nobody
+checks the result. It gives up with an error after ` + fmt.Sprint(maxEntropyAttempts) + ` tries, which
+means the length or mode cannot reach it.
+
+Use --avoid <chars> to leave out characters a site rejects, such as
+quotes or '<>&'. It applies to random and --pattern passwords. A class
+with nothing left, e.g. all digits avoided, is no longer required in a
+random password; a --pattern token whose class is left empty is an
+error.
+
+Use --exclude-sequences to regenerate until the result has no run of ` + fmt.Sprint(strength.MinSequence) + `
+or more characters that repeat ("aaa") or step through letters or digits
+("abc", "321"), which some policies reject. It works in every mode, can
+be combined with --min-entropy, and gives up after ` + fmt.Sprint(maxSequenceAttempts) + ` tries. 'audit
+--sequences' finds such runs in stored passwords.`,
 		Example: `  coconut generate
   coconut generate --length 16
-  coconut generate -l 20 --copy`,
+  coconut generate -l 20 --copy
+  coconut generate --pattern LLLLdds
+  coconut generate --words 6
+  coconut generate --words 5 --wordlist ~/words.txt --separator .
+  coconut generate --pronounceable --length 12 --digits 2
+  coconut generate --count 5 --output ~/new-accounts.txt
+  coconut generate --similar-to 3 --copy
+  coconut generate --length 12 --min-entropy 70
+  coconut generate --avoid '<>&"'
+  coconut generate --exclude-sequences --min-entropy 90`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if length < 4 {
-				return fmt.Errorf("password length must be at least 4")
+			var err error
+
+			if !cmd.Flags().Changed("length") {
+				length = f.Config.GenerateLength
+			}
+			if !cmd.Flags().Changed("words") {
+				words = f.Config.GenerateWords
 			}
 
-			password, err := generatePassword(length)
-			if err != nil {
-				return fmt.Errorf("failed to generate password: %w", err)
+			passphrase := cmd.Flags().Changed("words") || cmd.Flags().Changed("wordlist")
+			if passphrase && cmd.Flags().Changed("pattern") {
+				return fmt.Errorf("--pattern cannot be combined with --words or --wordlist")
+			}
+			if pronounce && (passphrase || cmd.Flags().Changed("pattern")) {
+				return fmt.Errorf("--pronounceable cannot be combined with --pattern, --words or --wordlist")
+			}
+			if similarTo != "" && (passphrase || pronounce || cmd.Flags().Changed("pattern") || cmd.Flags().Changed("length")) {
+				return fmt.Errorf("--similar-to cannot be combined with --length, --pattern, --pronounceable, --words or --wordlist")
+			}
+			if avoid != "" && (passphrase || pronounce || similarTo != "") {
+				return fmt.Errorf("--avoid cannot be combined with --pronounceable, --words, --wordlist or --similar-to")
+			}
+			if cmd.Flags().Changed("digits") && !pronounce {
+				return fmt.Errorf("--digits only applies to --pronounceable")
+			}
+			if count < 1 {
+				return fmt.Errorf("--count must be at least 1")
+			}
+			if minBits < 0 {
+				return fmt.Errorf("--min-entropy must not be negative")
+			}
+			if copy && count > 1 {
+				return fmt.Errorf("--copy cannot be combined with --count")
+			}
+			if force && output == "" {
+				return fmt.Errorf("--force only applies to --output")
+			}
+			if osc52 {
+				if !copy {
+					return fmt.Errorf("--osc52 only applies to --copy")
+				}
+				f.Config.ClipboardOSC52 = true
+			}
+
+			// Each mode sets up a generator and, where it is not the
+			// full-strength default, a line describing its entropy.
+			var (
+				next    func() (string, error)
+				kind    = "password"
+				entropy string
+			)
+
+			if pronounce {
+				if length < 4 {
+					return fmt.Errorf("password length must be at least 4")
+				}
+				if numDigits < 0 || numDigits > length/2 {
+					return fmt.Errorf("--digits must be between 0 and half the length (%d)", length/2)
+				}
+
+				next = func() (string, error) { return generatePronounceable(length, numDigits), nil }
+				entropy = fmt.Sprintf("Entropy: %.1f bits (a random password of this length has %.1f)",
+					pronounceableEntropy(length, numDigits), randomEntropy(length))
+			} else if passphrase {
+				if words < 1 {
+					return fmt.Errorf("word count must be at least 1")
+				}
+
+				list := wordlist.Default()
+				if listPath != "" {
+					list, err = wordlist.Load(listPath)
+					if err != nil {
+						return err
+					}
+					if len(list) < wordlist.RecommendedMinWords {
+						fmt.Fprintf(f.IO.ErrOut, "Warning: wordlist has only %d words; at least %d are recommended\n", len(list), wordlist.RecommendedMinWords)
+					}
+				}
+
+				next = func() (string, error) { return generatePassphrase(list, words, separator), nil }
+				kind = "passphrase"
+				entropy = fmt.Sprintf("Entropy: %.1f bits (%d words from a list of %d)", wordlist.Entropy(len(list), words), words, len(list))
+			} else if similarTo != "" {
+				shape, err := similarShape(f, similarTo)
+				if err != nil {
+					return err
+				}
+
+				fmt.Fprintf(f.IO.ErrOut, "Matching the shape of secret %s: %s\n", similarTo, shape)
+				next = func() (string, error) { return generateWithShape(shape), nil }
+				entropy = fmt.Sprintf("Entropy: %.1f bits", shape.entropy())
+			} else if cmd.Flags().Changed("pattern") {
+				next = func() (string, error) { return generateFromPattern(pattern, avoid) }
+			} else if avoid != "" {
+				if length < 4 {
+					return fmt.Errorf("password length must be at least 4")
+				}
+
+				shape, err := avoidShape(length, avoid)
+				if err != nil {
+					return err
+				}
+
+				next = func() (string, error) { return generateWithShape(shape), nil }
+				entropy = fmt.Sprintf("Entropy: %.1f bits (a random password of this length has %.1f)",
+					shape.entropy(), randomEntropy(length))
+			} else {
+				if length < 4 {
+					return fmt.Errorf("password length must be at least 4")
+				}
+
+				next = func() (string, error) {
+					password, err := generatePassword(length)
+					if err != nil {
+						return "", fmt.Errorf("failed to generate password: %w", err)
+					}
+					return password, nil
+				}
+			}
+
+			if noSeq {
+				next = withoutSequences(next)
+			}
+			if minBits > 0 {
+				next = withMinEntropy(next, minBits)
+			}
+
+			passwords := make([]string, count)
+			for i := range passwords {
+				if passwords[i], err = next(); err != nil {
+					return err
+				}
+			}
+
+			switch {
+			case output != "":
+				if err := writeSecretFile(output, passwords, force); err != nil {
+					return err
+				}
+				fmt.Printf("Wrote %d %s(s) to %s\n", count, kind, output)
+			case count == 1:
+				fmt.Printf("Generated %s: %s\n", kind, passwords[0])
+			default:
+				for _, password := range passwords {
+					fmt.Println(password)
+				}
 			}
 
-			fmt.Printf("Generated password: %s\n", password)
+			if entropy != "" {
+				fmt.Println(entropy)
+			}
 
 			if copy {
-				if err := clipboard.WriteAll(password); err != nil {
+				if err := copyToClipboard(passwords[0], f.Config); err != nil {
 					fmt.Println("Warning: Failed to copy to clipboard")
 				} else {
 					fmt.Println("Password copied to clipboard!")
@@ -55,12 +288,69 @@ func NewGenerateCmd(f *factory.Factory) *cobra.Command {
 		},
 	}
 
-	cmd.Flags().IntVarP(&length, "length", "l", 16, "Password length")
+	cmd.Flags().IntVarP(&length, "length", "l", 16, "Password length; the generate-length setting changes the default")
 	cmd.Flags().BoolVarP(&copy, "copy", "c", false, "Copy password to clipboard")
+	cmd.Flags().StringVar(&pattern, "pattern", "", "Generate from a template of L (letter), d (digit), s (symbol), a (any)")
+	cmd.Flags().IntVarP(&words, "words", "w", 6, "Generate a passphrase of this many words; the generate-words setting changes the default")
+	cmd.Flags().StringVar(&listPath, "wordlist", "", "Wordlist file for passphrases (one word per line)")
+	cmd.Flags().StringVar(&separator, "separator", "-", "Separator between passphrase words")
+	cmd.Flags().BoolVar(&pronounce, "pronounceable", false, "Generate a pronounceable password of alternating consonants and vowels")
+	cmd.Flags().IntVar(&numDigits, "digits", 1, "Number of digits in a pronounceable password")
+	cmd.Flags().BoolVar(&osc52, "osc52", false, "With --copy, set the clipboard through the terminal (OSC 52), e.g. over SSH")
+	cmd.Flags().IntVarP(&count, "count", "n", 1, "Number of passwords to generate")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Write the password(s) to this file (mode 0600) instead of printing them")
+	cmd.Flags().BoolVar(&force, "force", false, "Overwrite the --output file if it exists")
+	cmd.Flags().StringVar(&similarTo, "similar-to", "", "Match the length and character classes of this secret's password (index or name)")
+	cmd.Flags().Float64Var(&minBits, "min-entropy", 0, "Regenerate until the estimated strength is at least this many bits")
+	cmd.Flags().StringVar(&avoid, "avoid", "", "Characters never to use in random and --pattern passwords")
+	cmd.Flags().BoolVar(&noSeq, "exclude-sequences", false, "Regenerate until there are no runs like abc, 321 or aaa")
 
 	return cmd
 }
 
+// maxEntropyAttempts bounds the retries of --min-entropy.
+const maxEntropyAttempts = 100
+
+// withMinEntropy wraps next so it regenerates until the strength estimate
+// of the result is at least minBits, failing after maxEntropyAttempts.
+func withMinEntropy(next func() (string, error), minBits float64) func() (string, error) {
+	return func() (string, error) {
+		best := 0.0
+		for range maxEntropyAttempts {
+			password, err := next()
+			if err != nil {
+				return "", err
+			}
+			bits := strength.Of(password).Bits
+			if bits >= minBits {
+				return password, nil
+			}
+			best = math.Max(best, bits)
+		}
+		return "", fmt.Errorf("no result reached %.1f bits in %d tries (best %.1f); raise --length or lower --min-entropy", minBits, maxEntropyAttempts, best)
+	}
+}
+
+// maxSequenceAttempts bounds the retries of --exclude-sequences.
+const maxSequenceAttempts = 100
+
+// withoutSequences wraps next so it regenerates until the result contains
+// no run strength.FindSequence reports, failing after maxSequenceAttempts.
+func withoutSequences(next func() (string, error)) func() (string, error) {
+	return func() (string, error) {
+		for range maxSequenceAttempts {
+			password, err := next()
+			if err != nil {
+				return "", err
+			}
+			if _, found := strength.FindSequence(password); !found {
+				return password, nil
+			}
+		}
+		return "", fmt.Errorf("every result in %d tries had a sequence; the mode or pattern cannot avoid one", maxSequenceAttempts)
+	}
+}
+
 func generatePassword(length int) (string, error) {
 	charset := lowercase + uppercase + digits + special
 	password := make([]byte, length)
@@ -83,6 +373,257 @@ func generatePassword(length int) (string, error) {
 	return string(password), nil
 }
 
+// generateFromPattern expands each token of pattern into a random character
+// from its class, less the characters in avoid. Unknown tokens, tokens
+// whose class avoid empties and empty patterns are rejected.
+func generateFromPattern(pattern, avoid string) (string, error) {
+	if pattern == "" {
+		return "", fmt.Errorf("pattern must not be empty")
+	}
+
+	password := make([]byte, 0, len(pattern))
+	for i, token := range []rune(pattern) {
+		charset, ok := patternClasses[token]
+		if !ok {
+			return "", fmt.Errorf("unknown pattern token %q at position %d (valid tokens: L, d, s, a)", token, i+1)
+		}
+		if charset = withoutChars(charset, avoid); charset == "" {
+			return "", fmt.Errorf("--avoid leaves no characters for pattern token %q", token)
+		}
+		password = append(password, charset[mustRandomInt(len(charset))])
+	}
+
+	return string(password), nil
+}
+
+// passwordShape is the length and character classes of a password: all
+// that --similar-to keeps of the original.
+type passwordShape struct {
+	length  int
+	classes []string // charsets in use, in the order of shapeClasses
+}
+
+// shapeClasses are the classes a shape is made of. Characters outside
+// the first three, including non-ASCII ones, count as symbols.
+var shapeClasses = []struct {
+	name    string
+	charset string
+}{
+	{"lowercase", lowercase},
+	{"uppercase", uppercase},
+	{"digits", digits},
+	{"symbols", special},
+}
+
+// shapeOf returns the shape of password.
+func shapeOf(password string) passwordShape {
+	used := make([]bool, len(shapeClasses))
+	length := 0
+	for _, r := range password {
+		length++
+		switch {
+		case strings.ContainsRune(lowercase, r):
+			used[0] = true
+		case strings.ContainsRune(uppercase, r):
+			used[1] = true
+		case strings.ContainsRune(digits, r):
+			used[2] = true
+		default:
+			used[3] = true
+		}
+	}
+
+	shape := passwordShape{length: length}
+	for i, c := range shapeClasses {
+		if used[i] {
+			shape.classes = append(shape.classes, c.charset)
+		}
+	}
+	return shape
+}
+
+func (s passwordShape) String() string {
+	var names []string
+	for _, c := range shapeClasses {
+		if slices.Contains(s.classes, c.charset) {
+			names = append(names, c.name)
+		}
+	}
+	return fmt.Sprintf("%d characters of %s", s.length, strings.Join(names, ", "))
+}
+
+// entropy approximates the entropy of generateWithShape's output.
+func (s passwordShape) entropy() float64 {
+	return float64(s.length) * math.Log2(float64(len(strings.Join(s.classes, ""))))
+}
+
+// generateWithShape returns a random password of the given shape with at
+// least one character of each of its classes.
+func generateWithShape(shape passwordShape) string {
+	charset := strings.Join(shape.classes, "")
+	password := make([]byte, shape.length)
+	for i := range password {
+		if i < len(shape.classes) {
+			password[i] = shape.classes[i][mustRandomInt(len(shape.classes[i]))]
+		} else {
+			password[i] = charset[mustRandomInt(len(charset))]
+		}
+	}
+
+	for i := len(password) - 1; i > 0; i-- {
+		j := mustRandomInt(i + 1)
+		password[i], password[j] = password[j], password[i]
+	}
+	return string(password)
+}
+
+// avoidShape is the shape of a random password of length characters
+// without the characters in avoid. Classes left empty are dropped, so
+// they are neither used nor required.
+func avoidShape(length int, avoid string) (passwordShape, error) {
+	shape := passwordShape{length: length}
+	for _, c := range shapeClasses {
+		if charset := withoutChars(c.charset, avoid); charset != "" {
+			shape.classes = append(shape.classes, charset)
+		}
+	}
+	if len(shape.classes) == 0 {
+		return passwordShape{}, fmt.Errorf("--avoid excludes every character")
+	}
+	return shape, nil
+}
+
+// withoutChars returns charset with the characters in avoid removed.
+func withoutChars(charset, avoid string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(avoid, r) {
+			return -1
+		}
+		return r
+	}, charset)
+}
+
+// similarShape loads the secret arg refers to and returns the shape of its
+// password. Only the shape is logged.
+func similarShape(f *factory.Factory, arg string) (passwordShape, error) {
+	if err := EnsureVaultUnlocked(f); err != nil {
+		return passwordShape{}, err
+	}
+
+	secrets, err := f.Secrets.List()
+	if err != nil {
+		f.Logger.Error("failed to fetch secrets: %v", err)
+		return passwordShape{}, fmt.Errorf("failed to fetch secrets: %w", err)
+	}
+
+	index, err := resolveSecretArg(f, secrets, arg)
+	if err != nil {
+		return passwordShape{}, err
+	}
+	if secrets[index-1].Password == "" {
+		return passwordShape{}, fmt.Errorf("secret %d has no password to match", index)
+	}
+
+	shape := shapeOf(secrets[index-1].Password)
+	f.Logger.Info("Generating a password similar to secret %d (%s)", index, shape)
+	return shape, nil
+}
+
+// writeSecretFile writes one password per line to path with mode 0600.
+// An existing file is replaced only when force is set, and is then
+// narrowed to 0600 as well.
+func writeSecretFile(path string, passwords []string, force bool) error {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if force {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+
+	file, err := os.OpenFile(path, flags, 0600)
+	if errors.Is(err, fs.ErrExist) {
+		return fmt.Errorf("%s already exists; use --force to overwrite it", path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+
+	if err := file.Chmod(0600); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to restrict output file permissions: %w", err)
+	}
+
+	if _, err := file.WriteString(strings.Join(passwords, "\n") + "\n"); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+
+	return file.Close()
+}
+
+// generatePassphrase joins count words picked uniformly at random from list.
+func generatePassphrase(list []string, count int, separator string) string {
+	picked := make([]string, count)
+	for i := range picked {
+		picked[i] = list[mustRandomInt(len(list))]
+	}
+	return strings.Join(picked, separator)
+}
+
+// generatePronounceable fills length characters with alternating consonants
+// and vowels, starting with a consonant, and numDigits digits placed at
+// random positions. The letter pattern skips over the digits, so "bo3ku"
+// still reads as two syllables.
+func generatePronounceable(length, numDigits int) string {
+	isDigit := make([]bool, length)
+	for placed := 0; placed < numDigits; {
+		pos := mustRandomInt(length)
+		if !isDigit[pos] {
+			isDigit[pos] = true
+			placed++
+		}
+	}
+
+	password := make([]byte, length)
+	letter := 0
+	for i := range password {
+		switch {
+		case isDigit[i]:
+			password[i] = digits[mustRandomInt(len(digits))]
+		case letter%2 == 0:
+			password[i] = consonants[mustRandomInt(len(consonants))]
+			letter++
+		default:
+			password[i] = vowels[mustRandomInt(len(vowels))]
+			letter++
+		}
+	}
+
+	return string(password)
+}
+
+// pronounceableEntropy is the entropy in bits of generatePronounceable's
+// output: the letter choices, the digit values and the digit positions.
+func pronounceableEntropy(length, numDigits int) float64 {
+	letters := length - numDigits
+	consonantCount := (letters + 1) / 2
+	vowelCount := letters / 2
+
+	bits := float64(consonantCount)*math.Log2(float64(len(consonants))) +
+		float64(vowelCount)*math.Log2(float64(len(vowels))) +
+		float64(numDigits)*math.Log2(float64(len(digits)))
+
+	// log2 of (length choose numDigits) for the digit positions
+	for i := 0; i < numDigits; i++ {
+		bits += math.Log2(float64(length-i)) - math.Log2(float64(i+1))
+	}
+
+	return bits
+}
+
+// randomEntropy approximates the entropy of generatePassword's output.
+func randomEntropy(length int) float64 {
+	return float64(length) * math.Log2(float64(len(lowercase+uppercase+digits+special)))
+}
+
 func mustRandomInt(max int) int {
 	n, err := rand.Int(rand.Reader, big.NewInt(int64(max)))
 	if err != nil {
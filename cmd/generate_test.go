@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ompatil-15/coconut/internal/strength"
+)
+
+func TestShapeOf(t *testing.T) {
+	tests := []struct {
+		password string
+		want     string
+	}{
+		{"abcdef", "6 characters of lowercase"},
+		{"Abc123", "6 characters of lowercase, uppercase, digits"},
+		{"PIN-4821", "8 characters of uppercase, digits, symbols"},
+		{"héllo", "5 characters of lowercase, symbols"},
+	}
+
+	for _, tt := range tests {
+		if got := shapeOf(tt.password).String(); got != tt.want {
+			t.Errorf("shapeOf(%q) = %q, want %q", tt.password, got, tt.want)
+		}
+	}
+}
+
+func TestGenerateWithShape_MatchesShape(t *testing.T) {
+	for _, original := range []string{"abc", "Abc123", "PIN-4821", "x9!Q"} {
+		shape := shapeOf(original)
+		for range 50 {
+			password := generateWithShape(shape)
+			if got := shapeOf(password); got.String() != shape.String() {
+				t.Fatalf("generated %q has shape %q, want %q", password, got, shape)
+			}
+		}
+	}
+}
+
+func TestWithMinEntropy(t *testing.T) {
+	results := []string{"aaaa", "abcd", "x9!Qm2#Lp7$Rv4"}
+	calls := 0
+	next := func() (string, error) {
+		calls++
+		return results[min(calls-1, len(results)-1)], nil
+	}
+
+	got, err := withMinEntropy(next, 60)()
+	if err != nil {
+		t.Fatalf("withMinEntropy failed: %v", err)
+	}
+	if got != results[2] || calls != 3 {
+		t.Errorf("Expected the first strong enough result after 3 tries, got %q after %d", got, calls)
+	}
+	if bits := strength.Of(got).Bits; bits < 60 {
+		t.Errorf("Result has only %.1f bits", bits)
+	}
+
+	weak := func() (string, error) { return "aaaa", nil }
+	if _, err := withMinEntropy(weak, 60)(); err == nil {
+		t.Error("Expected an error when the threshold is never reached")
+	}
+}
+
+func TestWithoutSequences(t *testing.T) {
+	results := []string{"Xk4abc!q", "Q7pp7p999", "x9!Qm2#Lp7$Rv4"}
+	calls := 0
+	next := func() (string, error) {
+		calls++
+		return results[min(calls-1, len(results)-1)], nil
+	}
+
+	got, err := withoutSequences(next)()
+	if err != nil {
+		t.Fatalf("withoutSequences failed: %v", err)
+	}
+	if got != results[2] || calls != 3 {
+		t.Errorf("Expected the first result without a run after 3 tries, got %q after %d", got, calls)
+	}
+
+	runs := func() (string, error) { return "xyz", nil }
+	if _, err := withoutSequences(runs)(); err == nil {
+		t.Error("Expected an error when every result has a run")
+	}
+}
+
+func TestAvoidShape(t *testing.T) {
+	shape, err := avoidShape(20, digits+`"'<>&`)
+	if err != nil {
+		t.Fatalf("avoidShape: %v", err)
+	}
+	if len(shape.classes) != 3 {
+		t.Fatalf("got %d classes, want 3 (digits dropped)", len(shape.classes))
+	}
+	for range 50 {
+		password := generateWithShape(shape)
+		if strings.ContainsAny(password, digits+`"'<>&`) {
+			t.Fatalf("generated %q contains an avoided character", password)
+		}
+	}
+
+	if _, err := avoidShape(8, lowercase+uppercase+digits+special); err == nil {
+		t.Error("avoiding every character should fail")
+	}
+}
+
+func TestGenerateFromPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		avoid   string
+		wantErr bool
+	}{
+		{pattern: "LLLLdds"},
+		{pattern: "aaaa"},
+		{pattern: "sdL", avoid: "123456789"},
+		{pattern: "", wantErr: true},
+		{pattern: "LLx", wantErr: true},
+		{pattern: "Ld", avoid: digits, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		for range 20 {
+			got, err := generateFromPattern(tt.pattern, tt.avoid)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("generateFromPattern(%q, %q) = %q, expected an error", tt.pattern, tt.avoid, got)
+				}
+				break
+			}
+			if err != nil {
+				t.Fatalf("generateFromPattern(%q, %q): %v", tt.pattern, tt.avoid, err)
+			}
+			if len(got) != len(tt.pattern) {
+				t.Fatalf("generateFromPattern(%q) = %q, want %d characters", tt.pattern, got, len(tt.pattern))
+			}
+			for i, token := range tt.pattern {
+				if !strings.ContainsRune(patternClasses[token], rune(got[i])) {
+					t.Fatalf("generateFromPattern(%q) = %q: character %d is not of class %q", tt.pattern, got, i+1, token)
+				}
+				if strings.ContainsRune(tt.avoid, rune(got[i])) {
+					t.Fatalf("generateFromPattern(%q) = %q contains an avoided character", tt.pattern, got)
+				}
+			}
+		}
+	}
+}
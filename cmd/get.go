@@ -1,99 +1,790 @@
 package cmd
 
 import (
-	"errors"
+	"encoding/json"
 	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"os/signal"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
-	"github.com/atotto/clipboard"
+	"github.com/ompatil-15/coconut/internal/clipboard"
+	"github.com/ompatil-15/coconut/internal/config"
 	"github.com/ompatil-15/coconut/internal/db/model"
+	"github.com/ompatil-15/coconut/internal/exporter"
 	"github.com/ompatil-15/coconut/internal/factory"
+	"github.com/ompatil-15/coconut/internal/logger"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
+// getFields are the values 'get --field' can print.
+var getFields = map[string]func(s *model.Secret) string{
+	"name":        func(s *model.Secret) string { return s.Name },
+	"username":    func(s *model.Secret) string { return s.Username },
+	"password":    func(s *model.Secret) string { return s.Password },
+	"url":         func(s *model.Secret) string { return s.URL },
+	"description": func(s *model.Secret) string { return s.Description },
+}
+
+// maxRevealSecs bounds --reveal-timeout.
+const maxRevealSecs = 300
+
+// defaultCopyClearSecs is how long --copy-then-clear waits when no
+// clipboard timeout is configured.
+const defaultCopyClearSecs = 30
+
+// getFieldNames is the order used in help and error messages.
+var getFieldNames = []string{"name", "username", "password", "url", "description"}
+
 func NewGetCmd(f *factory.Factory) *cobra.Command {
 	var (
 		showPassword bool
 		copyToClip   bool
+		copyClear    bool
+		copyLogin    bool
+		waitClip     bool
+		allPasswords bool
+		field        string
+		quiet        bool
+		osc52        bool
+		notes        bool
+		revealSecs   int
+		asJSON       bool
+		schema       string
+		history      bool
+		age          bool
+		usernameOnly bool
+		passwordOnly bool
+		maskChar     string
+		maskLen      string
 	)
 
 	cmd := &cobra.Command{
-		Use:   "get <index>",
+		Use:   "get <index|name>",
 		Short: "Retrieve a specific secret from the vault",
 		Long: `Fetch details of a single secret from the encrypted vault using its index 
-(as shown in the list command). By default, the password is hidden. 
+(as shown in the list command) or its name. If no secret has exactly that
+name, the closest matches are offered to choose from. By default, the
+password is hidden. 
 
 Use:
   - '--show-password' or '-s' to reveal the password in terminal
-  - '--copy' or '-c' to copy the password to clipboard silently.`,
+  - '--copy' or '-c' to copy the password to clipboard silently.
+    Add '--osc52' to copy through the terminal instead, e.g. over SSH.
+    With a clipboard timeout set, the clear runs in a small background
+    process that outlives this command. Add '--wait-clip' to wait for
+    it in the foreground instead, e.g. where background processes are
+    killed with the terminal; Ctrl-C clears the clipboard at once.
+  - '--copy-then-clear' to copy the password and wait: pressing Enter
+    clears the clipboard at once, otherwise it is cleared after the
+    clipboard timeout (30 seconds if none is set). When stdin is not a
+    terminal the clear is scheduled in the background instead.
+  - '--copy-user-then-pass' to log in with one command: the username is
+    copied first; paste it, press Enter, and the password is copied and
+    cleared as with '--copy-then-clear'. Prompts go to stderr. When
+    stdin is not a terminal only the password is copied, with the clear
+    scheduled in the background.
+  - '--all-passwords' to print every secret with its password, e.g. to
+    move to another password manager. The master password is asked for
+    again first, even during an active session.
+  - '--reveal-timeout <seconds>' to show the password for that long and
+    then overwrite it with asterisks, so it does not stay on screen or
+    in the scrollback. When output is not a terminal it is just printed.
+  - '--notes' to read the description or secure note with its line
+    breaks intact, through $PAGER when it is set.
+  - '--field <name>' to print just that field's raw value, for scripts.
+    Fields: ` + strings.Join(getFieldNames, ", ") + `, or the name of
+    one of the secret's custom fields.
+  - '--username-only' or '-U' to print just the username, and
+    '--password-only' or '-P' to print just the password, with no
+    labels. The password stays masked unless '-s' is also given. With
+    '--json' the field is printed as a one-key object.
+  - '--mask-char <c>' and '--mask-len <n|actual>' to change how a hidden
+    password is shown: by default as 8 asterisks whatever its length.
+    'actual' shows one character per character of the password, which
+    gives its length away. Set defaults with 'coconut config set
+    mask-char' and 'mask-length'.
+  - '--json' to print the secret as a JSON object, in coconut's schema
+    or with '--schema bitwarden' as an item Bitwarden can import. The
+    password is only included with '--show-password'.
+  - '--age' to show the dates as how long ago they were, e.g.
+    "updated 10 months ago", instead of timestamps.
+  - '--history' to show the secret's timeline: when it was created and
+    last changed, and every access recorded in the access log (views,
+    copies, updates, PIN changes). Earlier passwords are not kept, so
+    they cannot be shown.
+  - '--quiet' or '-q' to skip warnings and never ask which secret was
+    meant; a name must then match exactly one secret.
+
+A secret protected with 'coconut protect' asks for its PIN before the
+password is shown, copied or printed, and '--all-passwords' leaves its
+password out.
+
+For unattended jobs, add the global --require-session flag so a locked
+vault fails immediately (exit code 3) instead of waiting for a password.`,
 		Example: `coconut get <index>
+coconut get github
 coconut get <index> -c
-coconut get <index> -s`,
-		Args: cobra.ExactArgs(1),
+coconut get <index> -c --wait-clip
+coconut get <index> --copy-then-clear
+coconut get github --copy-user-then-pass
+coconut get <index> -s
+coconut get <index> -P -s
+coconut get github -U
+coconut get <index> --reveal-timeout 5
+coconut get <index> --json --show-password --schema bitwarden
+coconut get <index> --history
+coconut get --all-passwords
+coconut --require-session get github --field password --quiet`,
+		ValidArgsFunction: completeSecretNames(f),
+		Args: func(cmd *cobra.Command, args []string) error {
+			if allPasswords {
+				return cobra.NoArgs(cmd, args)
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
 
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if allPasswords {
+				if showPassword || copyToClip || copyClear || field != "" {
+					return fmt.Errorf("--all-passwords cannot be combined with --show-password, --copy or --field")
+				}
+				return revealAllPasswords(f)
+			}
+
+			var (
+				fieldValue  func(s *model.Secret) string
+				customField bool
+			)
+			if field != "" {
+				var ok bool
+				if fieldValue, ok = getFields[strings.ToLower(field)]; !ok {
+					// Looked up among the secret's custom fields once it is loaded.
+					customField = true
+					fieldValue = func(s *model.Secret) string {
+						value, _ := s.FieldValue(field)
+						return value
+					}
+				}
+				if copyToClip || showPassword {
+					return fmt.Errorf("--field cannot be combined with --show-password or --copy")
+				}
+			}
+			if usernameOnly && passwordOnly {
+				return fmt.Errorf("--username-only and --password-only cannot be combined")
+			}
+			if usernameOnly || passwordOnly {
+				if copyToClip || copyClear || field != "" || notes || revealSecs > 0 || history || age {
+					return fmt.Errorf("--username-only and --password-only cannot be combined with --copy, --copy-then-clear, --field, --notes, --reveal-timeout, --history or --age")
+				}
+				if cmd.Flags().Changed("schema") {
+					return fmt.Errorf("--schema does not apply to --username-only or --password-only")
+				}
+				if usernameOnly && showPassword {
+					return fmt.Errorf("--show-password cannot be combined with --username-only")
+				}
+			}
+			if notes && (copyToClip || showPassword || field != "") {
+				return fmt.Errorf("--notes cannot be combined with --show-password, --copy or --field")
+			}
+			if revealSecs < 0 || revealSecs > maxRevealSecs {
+				return fmt.Errorf("--reveal-timeout must be between 1 and %d seconds", maxRevealSecs)
+			}
+			if revealSecs > 0 && (copyToClip || showPassword || field != "" || notes) {
+				return fmt.Errorf("--reveal-timeout cannot be combined with --show-password, --copy, --field or --notes")
+			}
+			if copyClear && (copyToClip || showPassword || field != "" || notes || revealSecs > 0) {
+				return fmt.Errorf("--copy-then-clear cannot be combined with --copy, --show-password, --field, --notes or --reveal-timeout")
+			}
+			if copyLogin && (copyToClip || copyClear || showPassword || field != "" || notes || revealSecs > 0 || asJSON || history || age || usernameOnly || passwordOnly) {
+				return fmt.Errorf("--copy-user-then-pass cannot be combined with other output flags")
+			}
+			if asJSON && (copyToClip || copyClear || field != "" || notes || revealSecs > 0) {
+				return fmt.Errorf("--json cannot be combined with --copy, --copy-then-clear, --field, --notes or --reveal-timeout")
+			}
+			if age && (copyToClip || copyClear || field != "" || notes || revealSecs > 0 || asJSON || history) {
+				return fmt.Errorf("--age only applies to the default view")
+			}
+			if history && (showPassword || copyToClip || copyClear || field != "" || notes || revealSecs > 0 || asJSON) {
+				return fmt.Errorf("--history cannot be combined with other output flags; earlier passwords are not kept")
+			}
+			if cmd.Flags().Changed("schema") && !asJSON {
+				return fmt.Errorf("--schema only applies to --json")
+			}
+			if !slices.Contains(exporter.Schemas(), strings.ToLower(schema)) {
+				return fmt.Errorf("unknown schema %q (supported: %s)", schema, strings.Join(exporter.Schemas(), ", "))
+			}
+			if maskChar != "" {
+				var err error
+				if f.Config.MaskChar, err = parseMaskChar(maskChar); err != nil {
+					return err
+				}
+			}
+			if maskLen != "" {
+				var err error
+				if f.Config.MaskLength, err = parseMaskLength(maskLen); err != nil {
+					return err
+				}
+			}
+			if waitClip && !copyToClip {
+				return fmt.Errorf("--wait-clip only applies to --copy")
+			}
+			if osc52 {
+				if !copyToClip && !copyClear && !copyLogin {
+					return fmt.Errorf("--osc52 only applies to --copy, --copy-then-clear and --copy-user-then-pass")
+				}
+				f.Config.ClipboardOSC52 = true
+			}
+
 			// Ensure vault is unlocked
 			if err := EnsureVaultUnlocked(f); err != nil {
 				return err
 			}
 
-			index, err := strconv.Atoi(args[0])
-			if err != nil {
-				return errors.New("please provide a valid index number (e.g. 1, 2, 3)")
-			}
-
 			secrets, err := f.Secrets.List()
 			if err != nil {
 				f.Logger.Error("failed to fetch secrets: %v", err)
 				return fmt.Errorf("failed to fetch secrets: %w", err)
 			}
 
-			if index < 1 || index > len(secrets) {
-				return fmt.Errorf("invalid index: %d (valid range: 1–%d)", index, len(secrets))
+			var index int
+			if _, numErr := strconv.Atoi(args[0]); numErr != nil && quiet {
+				matches := exactNameMatches(secrets, args[0])
+				if len(matches) == 0 {
+					return fmt.Errorf("no secret is named %q", args[0])
+				}
+				if len(matches) > 1 {
+					return fmt.Errorf("%d secrets are named %q; use an index", len(matches), args[0])
+				}
+				index = matches[0].index
+			} else if index, err = resolveSecretArg(f, secrets, args[0]); err != nil {
+				return err
 			}
 
 			secret := secrets[index-1]
 
+			if customField {
+				if _, ok := secret.FieldValue(field); !ok {
+					return fmt.Errorf("secret %d has no field %q (built-in fields: %s)", index, field, strings.Join(getFieldNames, ", "))
+				}
+			}
+
+			if secret.IsExpired(time.Now()) && !quiet {
+				fmt.Fprintf(f.IO.ErrOut, "Warning: this secret expired on %s. Consider rotating it.\n", secret.ExpiresAt.Format("2006-01-02"))
+			}
+
+			revealsPassword := copyToClip || copyClear || copyLogin || showPassword || revealSecs > 0 || strings.EqualFold(field, "password")
+			if revealsPassword {
+				if err := checkPIN(f, index, &secret); err != nil {
+					return err
+				}
+			}
+
+			if copyToClip && waitClip {
+				usedOSC52, err := writeClipboard(secret.Password, f.Config)
+				if err != nil {
+					f.Logger.Error("failed to copy password: %v", err)
+					return fmt.Errorf("failed to copy password to clipboard: %w", err)
+				}
+				f.Logger.Access("copy", accessTarget(index, &secret))
+				markUsed(f, &secret)
+				return waitClipboardClear(f, secret.Password, usedOSC52)
+			}
+
 			if copyToClip {
-				if err := clipboard.WriteAll(secret.Password); err != nil {
+				if err := copyToClipboard(secret.Password, f.Config); err != nil {
 					f.Logger.Error("failed to copy password: %v", err)
 					return fmt.Errorf("failed to copy password to clipboard: %w", err)
 				}
+				f.Logger.Access("copy", accessTarget(index, &secret))
+				markUsed(f, &secret)
 				fmt.Println("Password copied to clipboard securely.")
 				return nil
 			}
 
-			displaySecret(&secret, showPassword)
+			if copyClear {
+				usedOSC52, err := writeClipboard(secret.Password, f.Config)
+				if err != nil {
+					f.Logger.Error("failed to copy password: %v", err)
+					return fmt.Errorf("failed to copy password to clipboard: %w", err)
+				}
+				f.Logger.Access("copy", accessTarget(index, &secret))
+				markUsed(f, &secret)
+				return clearClipboardOnEnter(f, secret.Password, usedOSC52)
+			}
+
+			if copyLogin {
+				return copyUserThenPassword(f, index, &secret)
+			}
+
+			if history {
+				if err := showHistory(f, index, &secret); err != nil {
+					return err
+				}
+				f.Logger.Access("read", accessTarget(index, &secret))
+				return nil
+			}
+
+			if notes {
+				f.Logger.Access("read", accessTarget(index, &secret))
+				if err := showNotes(f, secret.Description); err != nil {
+					return err
+				}
+				markUsed(f, &secret)
+				return nil
+			}
+
+			if revealSecs > 0 {
+				f.Logger.Access("reveal", accessTarget(index, &secret))
+				revealTimed(f, secret.Password, time.Duration(revealSecs)*time.Second)
+				markUsed(f, &secret)
+				return nil
+			}
+
+			if fieldValue != nil {
+				op := "read"
+				if strings.EqualFold(field, "password") {
+					op = "reveal"
+				}
+				f.Logger.Access(op, accessTarget(index, &secret))
+				fmt.Fprintln(f.IO.Out, fieldValue(&secret))
+				markUsed(f, &secret)
+				return nil
+			}
+
+			if showPassword {
+				f.Logger.Access("reveal", accessTarget(index, &secret))
+			} else {
+				f.Logger.Access("read", accessTarget(index, &secret))
+			}
+
+			if usernameOnly || passwordOnly {
+				if err := printSingleField(f, &secret, passwordOnly, showPassword, asJSON); err != nil {
+					return err
+				}
+				markUsed(f, &secret)
+				return nil
+			}
+
+			if asJSON {
+				obj, err := exporter.Object(schema, secret, showPassword)
+				if err != nil {
+					return err
+				}
+				enc := json.NewEncoder(f.IO.Out)
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(obj); err != nil {
+					return err
+				}
+				markUsed(f, &secret)
+				return nil
+			}
+
+			// Shows the previous use, so mark only after displaying.
+			displaySecret(&secret, f.Config, showPassword, age)
+			markUsed(f, &secret)
 			return nil
 		},
 	}
 
 	cmd.Flags().BoolVarP(&showPassword, "show-password", "s", false, "Show the password value explicitly")
 	cmd.Flags().BoolVarP(&copyToClip, "copy", "c", false, "Copy the password to clipboard without showing it")
+	cmd.Flags().BoolVar(&copyClear, "copy-then-clear", false, "Copy the password, then clear the clipboard on Enter or after the clipboard timeout")
+	cmd.Flags().BoolVar(&copyLogin, "copy-user-then-pass", false, "Copy the username, then the password on Enter, clearing it like --copy-then-clear")
+	cmd.Flags().BoolVar(&waitClip, "wait-clip", false, "With --copy, stay in the foreground until the clipboard is cleared (Ctrl-C clears it now)")
+	cmd.Flags().BoolVar(&osc52, "osc52", false, "With --copy, set the clipboard through the terminal (OSC 52), e.g. over SSH")
+	cmd.Flags().IntVar(&revealSecs, "reveal-timeout", 0, "Show the password for this many seconds, then mask it")
+	cmd.Flags().BoolVar(&notes, "notes", false, "Show only the notes (description) with line breaks, via $PAGER if set")
+	cmd.Flags().StringVar(&field, "field", "", "Print only this field's value (e.g. password, or a custom field's name)")
+	cmd.Flags().StringVar(&maskChar, "mask-char", "", "Character a hidden password is shown as (default from config, *)")
+	cmd.Flags().StringVar(&maskLen, "mask-len", "", "Length of a hidden password's mask, or 'actual' for its real length (default from config, 8)")
+	cmd.Flags().BoolVarP(&usernameOnly, "username-only", "U", false, "Print only the username, with no label")
+	cmd.Flags().BoolVarP(&passwordOnly, "password-only", "P", false, "Print only the password, with no label (masked unless --show-password)")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Print the secret as a JSON object (password only with --show-password)")
+	cmd.Flags().StringVar(&schema, "schema", exporter.SchemaCoconut, "JSON schema for --json ("+strings.Join(exporter.Schemas(), ", ")+")")
+	cmd.Flags().BoolVar(&age, "age", false, "Show dates relative to now (e.g. 3 days ago)")
+	cmd.Flags().BoolVar(&history, "history", false, "Show when the secret was created, changed and accessed")
+	cmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "No warnings or prompts to pick between matches")
+	cmd.Flags().BoolVar(&allPasswords, "all-passwords", false, "Show every secret with its password (asks for the master password again)")
 
 	return cmd
 }
 
-func displaySecret(secret *model.Secret, reveal bool) {
+// printSingleField prints the username, or the password if password is
+// set, with no label: as a bare line or as a one-key JSON object. The
+// password is masked unless reveal is set.
+func printSingleField(f *factory.Factory, secret *model.Secret, password, reveal, asJSON bool) error {
+	name, value := "username", secret.Username
+	if password {
+		name, value = "password", secret.Password
+		if !reveal {
+			value = maskPassword(f.Config, value)
+		}
+	}
+
+	if !asJSON {
+		fmt.Fprintln(f.IO.Out, value)
+		return nil
+	}
+	enc := json.NewEncoder(f.IO.Out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(map[string]string{name: value})
+}
+
+// revealAllPasswords prints every secret with its password after a fresh
+// master password check.
+func revealAllPasswords(f *factory.Factory) error {
+	if err := EnsureVaultUnlocked(f); err != nil {
+		return err
+	}
+	if err := reauthenticate(f); err != nil {
+		return err
+	}
+
+	secrets, err := f.Secrets.List()
+	if err != nil {
+		f.Logger.Error("failed to fetch secrets: %v", err)
+		return fmt.Errorf("failed to fetch secrets: %w", err)
+	}
+
+	f.Logger.Access("reveal-all", fmt.Sprintf("count=%d", len(secrets)))
+
+	if len(secrets) == 0 {
+		fmt.Fprintln(f.IO.Out, "No secrets found in the vault.")
+		return nil
+	}
+
+	columns := []listColumn{
+		listColumns["name"],
+		listColumns["username"],
+		{"PASSWORD", math.MaxInt, func(s *model.Secret) string {
+			if s.IsProtected() {
+				return "(protected by a PIN)"
+			}
+			return s.Password
+		}, nil},
+		listColumns["url"],
+	}
+	renderList(f.IO.Out, indexEntries(secrets), columns, time.Now())
+
+	fmt.Fprintln(f.IO.ErrOut, "Warning: all passwords are shown in plaintext. Clear your terminal scrollback when done.")
+	return nil
+}
+
+// displaySecret prints the secret's fields, with dates relative to now
+// when age is set.
+func displaySecret(secret *model.Secret, cfg *config.Config, reveal, age bool) {
+	now := time.Now()
+	stamp := func(t time.Time) string {
+		if age {
+			return formatAge(t, now)
+		}
+		return t.Format("2006-01-02 15:04")
+	}
+
 	// fmt.Printf("%-15s: %s\n", "ID", secret.ID)
+	fmt.Printf("%-15s: %s\n", "Name", secret.Name)
 	fmt.Printf("%-15s: %s\n", "Username", secret.Username)
 
 	if reveal {
 		fmt.Printf("%-15s: %s\n", "Password", secret.Password)
 	} else {
-		fmt.Printf("%-15s: %s\n", "Password", maskPassword(secret.Password))
+		fmt.Printf("%-15s: %s\n", "Password", maskPassword(cfg, secret.Password))
 	}
 
 	fmt.Printf("%-15s: %s\n", "URL", secret.URL)
-	fmt.Printf("%-15s: %s\n", "Description", secret.Description)
-	fmt.Printf("%-15s: %s\n", "Created At", secret.CreatedAt.Format("2006-01-02 15:04"))
-	fmt.Printf("%-15s: %s\n", "Updated At", secret.UpdatedAt.Format("2006-01-02 15:04"))
+	// Continuation lines of multi-line notes line up under the first.
+	fmt.Printf("%-15s: %s\n", "Description", strings.ReplaceAll(secret.Description, "\n", "\n"+strings.Repeat(" ", 17)))
+	if secret.Type == model.SecretTypeNote {
+		fmt.Printf("%-15s: %s\n", "Type", "secure note")
+	}
+	if len(secret.Tags) > 0 {
+		fmt.Printf("%-15s: %s\n", "Tags", strings.Join(secret.Tags, ", "))
+	}
+	for _, field := range secret.Fields {
+		fmt.Printf("%-15s: %s\n", field.Name, field.Value)
+	}
+	fmt.Printf("%-15s: %s\n", "Created At", stamp(secret.CreatedAt))
+	fmt.Printf("%-15s: %s\n", "Updated At", stamp(secret.UpdatedAt))
+	if !secret.ExpiresAt.IsZero() {
+		fmt.Printf("%-15s: %s\n", "Expires At", stamp(secret.ExpiresAt))
+	}
+	if secret.LastUsedAt.IsZero() {
+		fmt.Printf("%-15s: %s\n", "Last Used", "never")
+	} else {
+		fmt.Printf("%-15s: %s\n", "Last Used", stamp(secret.LastUsedAt))
+	}
+}
+
+// historyOps describes access log operations in a secret's timeline.
+var historyOps = map[string]string{
+	"read":       "viewed",
+	"reveal":     "password shown",
+	"copy":       "password copied",
+	"update":     "updated",
+	"clone":      "duplicated",
+	"share":      "shared",
+	"protect":    "PIN set",
+	"unprotect":  "PIN removed",
+	"pin-denied": "wrong PIN entered",
+}
+
+// historyWrites are the logged operations that change the secret.
+var historyWrites = map[string]bool{"update": true, "protect": true, "unprotect": true}
+
+// historyEvent is one line of a secret's timeline.
+type historyEvent struct {
+	at   time.Time
+	what string
+}
+
+// showHistory prints the secret's timeline, oldest first: its creation,
+// the access log entries that name its ID and its last change if the log
+// does not already show it.
+func showHistory(f *factory.Factory, index int, secret *model.Secret) error {
+	events := []historyEvent{{secret.CreatedAt, "created"}}
+
+	logged, err := logger.ReadAccessEvents(f.Logger.AccessLogPath())
+	if err != nil {
+		f.Logger.Error("failed to read access log: %v", err)
+		return fmt.Errorf("failed to read access log: %w", err)
+	}
+	updateLogged := false
+	for _, e := range logged {
+		if !strings.HasSuffix(e.Target, " id="+secret.ID) {
+			continue
+		}
+		what, ok := historyOps[e.Op]
+		if !ok {
+			what = e.Op
+		}
+		events = append(events, historyEvent{e.Time, what})
+		// Log timestamps are truncated to the second.
+		if historyWrites[e.Op] && !e.Time.Before(secret.UpdatedAt.Truncate(time.Second)) {
+			updateLogged = true
+		}
+	}
+	if secret.UpdatedAt.After(secret.CreatedAt) && !updateLogged {
+		events = append(events, historyEvent{secret.UpdatedAt, "changed"})
+	}
+
+	slices.SortStableFunc(events, func(a, b historyEvent) int {
+		return a.at.Truncate(time.Second).Compare(b.at.Truncate(time.Second))
+	})
+
+	out := f.IO.Out
+	fmt.Fprintf(out, "History of secret %d (%s):\n", index, mergeLabel(*secret))
+	for _, e := range events {
+		fmt.Fprintf(out, "  %s  %s\n", e.at.Local().Format("2006-01-02 15:04:05"), e.what)
+	}
+	if !f.Config.AccessLog {
+		fmt.Fprintln(f.IO.ErrOut, "Note: access logging is disabled, so recent accesses are missing.")
+	}
+	return nil
+}
+
+// showNotes prints notes with their line breaks. When $PAGER is set and
+// stdout is a terminal the notes are piped to the pager instead; they are
+// never written to a file.
+func showNotes(f *factory.Factory, notes string) error {
+	if notes == "" {
+		fmt.Fprintln(f.IO.ErrOut, "This secret has no notes.")
+		return nil
+	}
+	if !strings.HasSuffix(notes, "\n") {
+		notes += "\n"
+	}
+
+	pager := strings.Fields(os.Getenv("PAGER"))
+	if len(pager) == 0 || !term.IsTerminal(int(os.Stdout.Fd())) {
+		fmt.Fprint(f.IO.Out, notes)
+		return nil
+	}
+
+	cmd := exec.Command(pager[0], pager[1:]...)
+	cmd.Stdin = strings.NewReader(notes)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		f.Logger.Warn("pager %q failed: %v", pager[0], err)
+		fmt.Fprintf(f.IO.ErrOut, "Warning: pager %q failed (%v); printing instead\n", pager[0], err)
+		fmt.Fprint(f.IO.Out, notes)
+	}
+	return nil
+}
+
+// revealTimed shows password on a line of its own for d, then moves back
+// over every terminal row the line took and clears them before printing
+// the masked form. Ctrl-C during the wait clears the password too. When
+// stdout is not a terminal the password is printed as is.
+func revealTimed(f *factory.Factory, password string, d time.Duration) {
+	line := fmt.Sprintf("%-15s: %s", "Password", password)
+	fd := int(os.Stdout.Fd())
+	if !term.IsTerminal(fd) {
+		fmt.Fprintln(f.IO.Out, line)
+		return
+	}
+
+	interrupted := make(chan os.Signal, 1)
+	signal.Notify(interrupted, os.Interrupt)
+	defer signal.Stop(interrupted)
+
+	fmt.Fprint(f.IO.Out, line)
+	select {
+	case <-time.After(d):
+	case <-interrupted:
+	}
+
+	// A long password wraps, and "\r" only reaches the start of the last
+	// row, so step up to the first row before clearing to the end.
+	rows := 1
+	if width, _, err := term.GetSize(fd); err == nil && width > 0 {
+		rows = max(1, (utf8.RuneCountInString(line)+width-1)/width)
+	}
+	clear := "\r"
+	if rows > 1 {
+		clear += fmt.Sprintf("\x1b[%dA", rows-1)
+	}
+	fmt.Fprintf(f.IO.Out, "%s\x1b[J%-15s: %s\n", clear, "Password", maskPassword(f.Config, password))
+}
+
+// clearClipboardOnEnter waits for Enter, Ctrl-C or the clipboard timeout,
+// whichever comes first, and then clears value from the clipboard. When
+// stdin is not a terminal nobody can press Enter, so the clear is
+// scheduled in the background like a plain --copy.
+func clearClipboardOnEnter(f *factory.Factory, value string, osc52 bool) error {
+	secs := f.Config.ClipboardClearSecs
+	if secs <= 0 {
+		secs = defaultCopyClearSecs
+	}
+
+	if !stdinIsTerminal(f) {
+		fmt.Fprintln(f.IO.Out, "Password copied to clipboard securely.")
+		cfg := *f.Config
+		cfg.ClipboardClearSecs = secs
+		scheduleClipboardClear(value, &cfg, osc52)
+		return nil
+	}
+
+	interrupted := make(chan os.Signal, 1)
+	signal.Notify(interrupted, os.Interrupt)
+	defer signal.Stop(interrupted)
+
+	// Left blocked on the terminal if the timer wins; the process exits
+	// right after.
+	pressed := make(chan struct{})
+	go func() {
+		_, _ = readLine(f.IO.In)
+		close(pressed)
+	}()
+
+	fmt.Fprintf(f.IO.Out, "Password copied; press Enter to clear it now or wait %ds.\n", secs)
+	select {
+	case <-pressed:
+	case <-interrupted:
+	case <-time.After(time.Duration(secs) * time.Second):
+	}
+
+	return clearCopiedValue(f, value, osc52)
 }
 
-func maskPassword(pw string) string {
+// copyUserThenPassword copies the username and, once Enter is pressed,
+// the password, which is then cleared by clearClipboardOnEnter. Without a
+// terminal on stdin nobody can press Enter between the two, so only the
+// password is copied.
+func copyUserThenPassword(f *factory.Factory, index int, secret *model.Secret) error {
+	if stdinIsTerminal(f) && secret.Username != "" {
+		if _, err := writeClipboard(secret.Username, f.Config); err != nil {
+			f.Logger.Error("failed to copy username: %v", err)
+			return fmt.Errorf("failed to copy username to clipboard: %w", err)
+		}
+		fmt.Fprintln(f.IO.ErrOut, "Username copied. Paste it, then press Enter to copy the password.")
+		if _, err := readLine(f.IO.In); err != nil {
+			return fmt.Errorf("failed to read input: %w", err)
+		}
+	} else if secret.Username == "" {
+		fmt.Fprintln(f.IO.ErrOut, "This secret has no username; copying the password.")
+	}
+
+	usedOSC52, err := writeClipboard(secret.Password, f.Config)
+	if err != nil {
+		f.Logger.Error("failed to copy password: %v", err)
+		return fmt.Errorf("failed to copy password to clipboard: %w", err)
+	}
+	f.Logger.Access("copy", accessTarget(index, secret))
+	markUsed(f, secret)
+	return clearClipboardOnEnter(f, secret.Password, usedOSC52)
+}
+
+// waitClipboardClear keeps the command in the foreground until the
+// clipboard timeout (30 seconds if none is set) and then clears value
+// from the clipboard, so the clear does not depend on a background
+// process surviving. Ctrl-C, SIGTERM or SIGHUP clear it at once. The
+// database is released first so other commands can run meanwhile.
+func waitClipboardClear(f *factory.Factory, value string, osc52 bool) error {
+	secs := f.Config.ClipboardClearSecs
+	if secs <= 0 {
+		secs = defaultCopyClearSecs
+	}
+
+	if f.DB != nil {
+		if err := f.DB.Close(); err != nil {
+			f.Logger.Warn("failed to release vault database: %v", err)
+		}
+		f.DB = nil
+	}
+
+	interrupted := make(chan os.Signal, 1)
+	signal.Notify(interrupted, lockSignals...)
+	defer signal.Stop(interrupted)
+
+	fmt.Fprintf(f.IO.Out, "Password copied; clearing the clipboard in %ds (Ctrl-C to clear now).\n", secs)
+	select {
+	case <-interrupted:
+	case <-time.After(time.Duration(secs) * time.Second):
+	}
+
+	return clearCopiedValue(f, value, osc52)
+}
+
+// clearCopiedValue empties the clipboard if it still holds value.
+func clearCopiedValue(f *factory.Factory, value string, osc52 bool) error {
+	if err := clipboard.ClearIfUnchanged(clipboard.Fingerprint(value), f.Config.ClipboardCmd, osc52); err != nil {
+		f.Logger.Error("failed to clear clipboard: %v", err)
+		return fmt.Errorf("failed to clear clipboard: %w", err)
+	}
+	fmt.Fprintln(f.IO.Out, "Clipboard cleared.")
+	return nil
+}
+
+// markUsed records the access time on the secret. Failing to do so must
+// not fail the command that already delivered the secret.
+func markUsed(f *factory.Factory, secret *model.Secret) {
+	if err := f.Secrets.MarkUsed(secret.ID, time.Now()); err != nil {
+		f.Logger.Warn("failed to record last use: %v", err)
+	}
+}
+
+// maskPassword hides pw behind cfg.MaskLength copies of cfg.MaskChar. A
+// fixed length, the default of 8, does not give away how long pw is;
+// MaskLength 0 shows one mask character per character of pw instead.
+func maskPassword(cfg *config.Config, pw string) string {
 	if len(pw) == 0 {
 		return "-"
 	}
-	return strings.Repeat("*", 8)
+	length := cfg.MaskLength
+	if length <= 0 {
+		length = utf8.RuneCountInString(pw)
+	}
+	char := cfg.MaskChar
+	if char == "" {
+		char = "*"
+	}
+	return strings.Repeat(char, length)
 }
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/ompatil-15/coconut/internal/factory"
+	"github.com/ompatil-15/coconut/internal/session"
+	"github.com/spf13/cobra"
+)
+
+// lockSignals end a command that holds the vault unlocked in the
+// foreground. SIGHUP is what a dropped SSH connection or closed terminal
+// sends.
+var lockSignals = []os.Signal{os.Interrupt, syscall.SIGTERM, syscall.SIGHUP}
+
+// lockVault zeroes the key in memory and clears the cached session, so
+// the next command asks for the master password. The database is
+// reopened briefly if the command has already released it.
+func lockVault(f *factory.Factory) error {
+	if f.Vault != nil && f.Vault.IsUnlocked() {
+		f.Vault.Lock()
+	}
+	if f.DB == nil || f.Session == nil {
+		return withSession(f, func(m *session.Manager) error { return m.Clear() })
+	}
+	return f.Session.Clear()
+}
+
+// executeCommand runs cmd with relockOnFail deferred, so every command
+// ends with the vault relocked if its key failed to decrypt anything.
+func executeCommand(f *factory.Factory, cmd *cobra.Command) error {
+	defer relockOnFail(f)
+	return cmd.Execute()
+}
+
+// relockOnFail locks the vault and clears the session when f.Vault failed
+// to decrypt something during the command, wherever that happened, so a
+// key that does not fit the vault is neither left in memory nor reused
+// from the session by the next command. Other vaults a command opens,
+// such as merge's, are not f.Vault and leave it alone.
+func relockOnFail(f *factory.Factory) {
+	if f.Vault.DecryptFailed() {
+		relock(f)
+	}
+}
+
+// relock locks the vault after an authentication or decryption failure.
+// With --no-session the session is left alone, as the key did not come
+// from it.
+func relock(f *factory.Factory) {
+	if f.NoSession {
+		if f.Vault != nil {
+			f.Vault.Lock()
+		}
+		return
+	}
+	if err := lockVault(f); err != nil {
+		f.Logger.Warn("failed to clear the session after an authentication failure: %v", err)
+	}
+}
+
+// lockOnSignal locks the vault when one of lockSignals arrives, for
+// foreground commands that block on input and so cannot watch a context.
+// restore runs first to put the terminal back; then the process exits
+// with 128 plus the signal number, as a shell reports it. Call the
+// returned function once the command is done to remove the handler.
+func lockOnSignal(f *factory.Factory, restore func()) (stop func()) {
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(signals, lockSignals...)
+
+	go func() {
+		select {
+		case sig := <-signals:
+			if restore != nil {
+				restore()
+			}
+			if err := lockVault(f); err != nil {
+				f.Logger.Error("Failed to clear session on %v: %v", sig, err)
+			} else {
+				f.Logger.Info("Vault locked on %v", sig)
+			}
+			code := 1
+			if n, ok := sig.(syscall.Signal); ok {
+				code = 128 + int(n)
+			}
+			os.Exit(code)
+		case <-done:
+		}
+	}()
+
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
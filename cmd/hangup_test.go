@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ompatil-15/coconut/internal/crypto"
+	"github.com/ompatil-15/coconut/internal/db/model"
+	"github.com/ompatil-15/coconut/internal/factory"
+	"github.com/ompatil-15/coconut/internal/vault"
+)
+
+// hasSession reports whether f's system bucket holds either session entry.
+func hasSession(t *testing.T, f *factory.Factory) bool {
+	t.Helper()
+	keys, err := f.System.ListKeys()
+	if err != nil {
+		t.Fatalf("ListKeys failed: %v", err)
+	}
+	for _, k := range keys {
+		if k == "session:data" || k == "session:key" {
+			return true
+		}
+	}
+	return false
+}
+
+// startSession runs list so that f holds an unlocked vault and a session.
+func startSession(t *testing.T, f *factory.Factory) {
+	t.Helper()
+	if err := executeCommand(f, NewListCmd(f)); err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if !f.Vault.IsUnlocked() || !hasSession(t, f) {
+		t.Fatal("Expected list to leave the vault unlocked with a session")
+	}
+}
+
+func TestExecuteCommand_DecryptFailureRelocks(t *testing.T) {
+	f := newTestFactory(t, testPassword+"\n")
+	startSession(t, f)
+
+	// A record written under another key stands in for a corrupt one.
+	f.Repo.SetVault(vault.UnlockWithKey(crypto.NewAESGCM(), nil, bytes.Repeat([]byte{1}, 32)))
+	corrupt := f.Repo.NewEncryptedRepository(f.Config.SecretsBucket)
+	if _, err := corrupt.Add(model.Secret{ID: "corrupt", Name: "corrupt", Password: "pw"}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	f.Repo.SetVault(f.Vault)
+
+	if err := executeCommand(f, NewListCmd(f)); err == nil {
+		t.Fatal("Expected list to fail on the corrupt record")
+	}
+	if f.Vault.IsUnlocked() {
+		t.Error("Expected the vault locked after a decrypt failure")
+	}
+	if hasSession(t, f) {
+		t.Error("Expected session:data and session:key removed after a decrypt failure")
+	}
+}
+
+func TestExecuteCommand_MergeWrongPasswordKeepsSession(t *testing.T) {
+	f := newTestFactory(t, testPassword+"\nnot-the-other-password\n")
+	startSession(t, f)
+
+	other := filepath.Join(t.TempDir(), "other.db")
+	newTestVault(t, other)
+
+	merge := NewMergeCmd(f)
+	merge.SetArgs([]string{other})
+	merge.SilenceErrors, merge.SilenceUsage = true, true
+	err := executeCommand(f, merge)
+	if err == nil || !strings.Contains(err.Error(), "authentication failed for") {
+		t.Fatalf("Expected the other vault's password to be rejected, got %v", err)
+	}
+	if !f.Vault.IsUnlocked() {
+		t.Error("Expected this vault to stay unlocked")
+	}
+	if !hasSession(t, f) {
+		t.Error("Expected this vault's session to survive a wrong password for another vault")
+	}
+}
@@ -1,11 +1,28 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/ompatil-15/coconut/internal/backup"
+	"github.com/ompatil-15/coconut/internal/clipboard"
+	"github.com/ompatil-15/coconut/internal/config"
 	"github.com/ompatil-15/coconut/internal/crypto"
+	"github.com/ompatil-15/coconut/internal/db"
+	"github.com/ompatil-15/coconut/internal/db/model"
 	"github.com/ompatil-15/coconut/internal/factory"
+	"github.com/ompatil-15/coconut/internal/journal"
+	"github.com/ompatil-15/coconut/internal/session"
+	"github.com/ompatil-15/coconut/internal/timeutil"
+	"github.com/ompatil-15/coconut/internal/undo"
 	"github.com/ompatil-15/coconut/internal/vault"
 	"golang.org/x/term"
 )
@@ -36,26 +53,37 @@ func EnsureVaultUnlocked(f *factory.Factory) error {
 		return fmt.Errorf("failed to retrieve vault salt: %w", err)
 	}
 
+	// Key derivation parameters (including key size) chosen at init
+	params, err := vault.LoadKDFParams(f.System)
+	if err != nil {
+		return err
+	}
+
 	var vaultKey []byte
 	var createSession bool
 
-	// Try to get cached key from valid session
-	if cachedKey, err := f.Session.GetCachedKey(); err == nil {
+	// Try to get cached key from valid session (skipped entirely with --no-session).
+	// A cached key of the wrong size cannot belong to this vault, so ignore it.
+	if cachedKey, err := getCachedKey(f); err == nil && len(cachedKey) == int(params.KeyLen) {
 		// Session is valid - use cached key
 		vaultKey = cachedKey
 		createSession = false
 
 		// Update session activity timestamp
 		f.Session.UpdateActivity()
+	} else if f.RequireSession {
+		return fmt.Errorf("%w: run 'coconut unlock' first", session.ErrSessionExpired)
 	} else {
-		// No valid session - prompt for password and derive key
-		promptedKey, err := promptForPasswordAndDeriveKey(salt)
+		// No valid session - obtain password and derive key
+		promptedKey, err := promptForPasswordAndDeriveKey(f, salt, params)
 		if err != nil {
-			f.Session.Clear()
+			if !f.NoSession {
+				f.Session.Clear()
+			}
 			return err
 		}
 		vaultKey = promptedKey
-		createSession = true
+		createSession = !f.NoSession
 	}
 
 	// Unlock vault using the key (vault package responsibility)
@@ -64,14 +92,14 @@ func EnsureVaultUnlocked(f *factory.Factory) error {
 	// Verify the password is correct (vault package responsibility)
 	if err := vault.VerifyVaultPassword(f.System, v); err != nil {
 		v.Lock()
-		f.Session.Clear()
+		if !f.NoSession {
+			f.Session.Clear()
+		}
 		return fmt.Errorf("authentication failed: %w", err)
 	}
 
 	// Update factory state (command layer responsibility)
-	f.Vault = v
-	f.Repo.SetVault(v)
-	f.Secrets = f.Repo.NewEncryptedRepository(f.Config.SecretsBucket)
+	useVault(f, v)
 
 	// Create new session if we prompted for password
 	if createSession {
@@ -83,15 +111,212 @@ func EnsureVaultUnlocked(f *factory.Factory) error {
 	return nil
 }
 
-// promptForPasswordAndDeriveKey prompts the user for password and derives the vault key
-func promptForPasswordAndDeriveKey(salt []byte) ([]byte, error) {
-	password, err := promptForPassword()
+// useVault points the factory's stores at the unlocked vault v.
+func useVault(f *factory.Factory, v *vault.Vault) {
+	f.Vault = v
+	f.Repo.SetVault(v)
+	f.Secrets = f.Repo.NewVerifiedRepository(f.Config.SecretsBucket, f.Config.MetadataIndexBucket(), f.Config.SystemBucket)
+	f.Undo = undo.NewStore(f.System, v)
+	f.Journal = journal.NewStore(f.Repo.NewBaseRepository(f.Config.JournalBucket), v)
+
+	if !f.Config.PlaintextIndex {
+		if err := dropPlaintextIndex(f); err != nil {
+			f.Logger.Warn("failed to remove the plaintext index: %v", err)
+		}
+	}
+}
+
+// dropPlaintextIndex deletes any metadata index entries, e.g. left from
+// when the plaintext index was turned on, so no names or URLs stay
+// readable without the key. It writes nothing when the index is empty.
+func dropPlaintextIndex(f *factory.Factory) error {
+	keys, err := f.Repo.NewBaseRepository(f.Config.IndexBucket).ListKeys()
+	if err != nil || len(keys) == 0 {
+		return err
+	}
+
+	err = f.DB.Batch(func(tx db.Tx) error {
+		index := db.NewTxRepository(tx, f.Config.IndexBucket)
+		for _, k := range keys {
+			if err := index.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err == nil {
+		f.Logger.Info("Removed %d plaintext index entries", len(keys))
+	}
+	return err
+}
+
+// reshardSecrets moves the secret records into shards buckets and saves
+// the new count, in one transaction so the stored layout always matches
+// the config. Records are moved still encrypted; no unlock is needed.
+func reshardSecrets(f *factory.Factory, shards int) (int, error) {
+	var moved int
+	err := f.DB.Batch(func(tx db.Tx) error {
+		var err error
+		moved, err = db.Reshard(tx, f.Config.SecretsBucket, f.Config.SecretShards, shards)
+		if err != nil {
+			return err
+		}
+
+		cfg := *f.Config
+		cfg.SecretShards = shards
+		return config.Save(db.NewTxRepository(tx, f.Config.SystemBucket), &cfg)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	f.Config.SecretShards = shards
+	f.Repo.SetSecretShards(shards)
+
+	// Shards no record hashed to yet are created empty, so check does not
+	// report them missing.
+	for _, bucket := range db.ShardBuckets(f.Config.SecretsBucket, shards) {
+		if err := f.DB.CreateBucket(bucket); err != nil {
+			f.Logger.Warn("failed to create bucket %s: %v", bucket, err)
+		}
+	}
+	return moved, nil
+}
+
+// reauthenticate asks for the master password again and checks it against
+// the vault's verification token, even when a session is active. The
+// session and the unlocked vault are left untouched. $COCONUT_MASTER_PASSWORD
+// is not accepted here: a value sitting in the environment proves nothing.
+// With --password-stdin the line unlocking read, if any, is checked again.
+func reauthenticate(f *factory.Factory) error {
+	salt, err := f.System.Get("salt")
+	if err != nil {
+		return fmt.Errorf("failed to retrieve vault salt: %w", err)
+	}
+
+	params, err := vault.LoadKDFParams(f.System)
+	if err != nil {
+		return err
+	}
+
+	var password string
+	if f.PasswordStdin {
+		password, err = readStdinPassword(f)
+	} else {
+		fmt.Fprintln(f.IO.ErrOut, "This action requires your master password again.")
+		password, err = promptForPassword()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read password: %w", err)
+	}
+
+	f.IO.StartProgressIndicator("Verifying password...")
+	key, err := crypto.DeriveKeyWithParams(password, salt, params)
+	f.IO.StopProgressIndicator()
+	if err != nil {
+		return fmt.Errorf("failed to derive key: %w", err)
+	}
+
+	check := vault.UnlockWithKey(f.Crypto, salt, key)
+	defer check.Lock()
+
+	if err := vault.VerifyVaultPassword(f.System, check); err != nil {
+		f.Logger.Warn("Re-authentication failed")
+		relock(f)
+		return fmt.Errorf("re-authentication failed: %w", err)
+	}
+
+	f.Logger.Info("Re-authentication succeeded")
+	return nil
+}
+
+// masterPasswordEnv names the environment variable consulted for the master
+// password when no --password-stdin is given, for headless use.
+const masterPasswordEnv = "COCONUT_MASTER_PASSWORD"
+
+// getCachedKey returns the vault key from a valid session, or an error when
+// there is none or sessions are disabled with --no-session.
+func getCachedKey(f *factory.Factory) ([]byte, error) {
+	if f.NoSession {
+		return nil, fmt.Errorf("sessions disabled")
+	}
+	return f.Session.GetCachedKey()
+}
+
+// promptForPasswordAndDeriveKey obtains the master password and derives the vault key
+func promptForPasswordAndDeriveKey(f *factory.Factory, salt []byte, params crypto.KDFParams) ([]byte, error) {
+	password, err := readMasterPassword(f)
 	if err != nil {
 		return nil, err
 	}
 
-	key := crypto.DeriveKey(password, salt)
-	return key, nil
+	// Key derivation is deliberately slow; show it is working, not frozen.
+	f.IO.StartProgressIndicator("Unlocking vault...")
+	key, err := crypto.DeriveKeyWithParams(password, salt, params)
+	f.IO.StopProgressIndicator()
+
+	return key, err
+}
+
+// readMasterPassword resolves the master password in order of precedence:
+// --password-stdin, then $COCONUT_MASTER_PASSWORD, then an interactive prompt.
+func readMasterPassword(f *factory.Factory) (string, error) {
+	if f.PasswordStdin {
+		password, err := readStdinPassword(f)
+		if err != nil {
+			return "", fmt.Errorf("failed to read password from stdin: %w", err)
+		}
+		return password, nil
+	}
+
+	if password, ok := os.LookupEnv(masterPasswordEnv); ok && password != "" {
+		fmt.Fprintf(f.IO.ErrOut, "Warning: using master password from $%s, which is less secure than the interactive prompt.\n", masterPasswordEnv)
+		f.Logger.Warn("Master password read from environment variable")
+		return password, nil
+	}
+
+	return promptForPassword()
+}
+
+// readStdinPassword returns the master password from the first line of
+// stdin. It is read once per command: unlocking and re-authenticating
+// both use that line, so scripts give the password once.
+func readStdinPassword(f *factory.Factory) (string, error) {
+	if f.StdinPassword != "" {
+		return f.StdinPassword, nil
+	}
+	password, err := readLine(f.IO.In)
+	if err != nil {
+		return "", err
+	}
+	f.StdinPassword = password
+	return password, nil
+}
+
+// readLine reads a single line from r without buffering past the newline,
+// so later readers of the same stream see the remaining input intact.
+func readLine(r io.Reader) (string, error) {
+	var line []byte
+	buf := make([]byte, 1)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if buf[0] == '\n' {
+				break
+			}
+			line = append(line, buf[0])
+		}
+		if err == io.EOF {
+			if len(line) == 0 {
+				return "", err
+			}
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return strings.TrimRight(string(line), "\r"), nil
 }
 
 // promptForPassword prompts for password with hidden input
@@ -104,3 +329,208 @@ func promptForPassword() (string, error) {
 	fmt.Println()
 	return string(pwd), nil
 }
+
+// parseExpiry converts an --expires value into an absolute expiry time.
+// Empty input and "never" both yield the zero time (no expiry).
+func parseExpiry(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || strings.EqualFold(value, "never") {
+		return time.Time{}, nil
+	}
+
+	expiresAt, err := timeutil.Parse(value, now, true)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --expires value: %w", err)
+	}
+	return expiresAt, nil
+}
+
+// normalizeURL returns raw with "https://" prepended if it has no scheme,
+// so "github.com" is stored as "https://github.com". The scheme and host
+// are lowercased. Empty input stays empty; anything that still does not
+// parse as a URL with a host is an error.
+func normalizeURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", nil
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL %q (use --no-normalize to store it as typed)", raw)
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("invalid URL %q: no host (use --no-normalize to store it as typed)", raw)
+	}
+	u.Host = strings.ToLower(u.Host)
+	return u.String(), nil
+}
+
+// checkPasswordPolicy validates a password the user typed against the
+// configured policy. Unmet rules are an error when the policy is enforced
+// and a warning otherwise.
+func checkPasswordPolicy(f *factory.Factory, password string) error {
+	unmet := f.Config.Policy.Validate(password)
+	if len(unmet) == 0 {
+		return nil
+	}
+
+	if f.Config.Policy.Enforce {
+		return fmt.Errorf("password does not meet the policy; it needs %s", strings.Join(unmet, ", "))
+	}
+	fmt.Fprintf(f.IO.ErrOut, "Warning: password does not meet the policy; it needs %s\n", strings.Join(unmet, ", "))
+	return nil
+}
+
+// copyToClipboard copies value through the terminal with OSC 52 when
+// enabled, otherwise with the configured clipboard command, or the system
+// clipboard when none is set. If the terminal clearly cannot do OSC 52 it
+// warns and falls back to the other methods. With a clipboard timeout
+// configured it also schedules the clear. Every command that copies must
+// go through here or writeClipboard.
+func copyToClipboard(value string, cfg *config.Config) error {
+	osc52, err := writeClipboard(value, cfg)
+	if err != nil {
+		return err
+	}
+	scheduleClipboardClear(value, cfg, osc52)
+	return nil
+}
+
+// writeClipboard copies value like copyToClipboard but leaves clearing to
+// the caller. It reports whether OSC 52 was used, which the clear must use
+// too.
+func writeClipboard(value string, cfg *config.Config) (osc52 bool, err error) {
+	if cfg.ClipboardOSC52 {
+		err := clipboard.CopyOSC52(value)
+		if err == nil {
+			return true, nil
+		}
+		if !errors.Is(err, clipboard.ErrOSC52Unsupported) {
+			return false, err
+		}
+		fmt.Fprintf(os.Stderr, "Warning: %v; using the regular clipboard instead\n", err)
+	}
+
+	return false, clipboard.Copy(value, cfg.ClipboardCmd)
+}
+
+// scheduleClipboardClear starts a background 'coconut clipboard-clear'
+// that empties the clipboard after the configured timeout. Only a
+// fingerprint of value is passed to it, on stdin. Failing to schedule is
+// reported but does not undo the copy.
+func scheduleClipboardClear(value string, cfg *config.Config, osc52 bool) {
+	if cfg.ClipboardClearSecs <= 0 {
+		return
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not schedule clipboard clear: %v\n", err)
+		return
+	}
+
+	args := []string{"clipboard-clear", "--after", strconv.Itoa(cfg.ClipboardClearSecs)}
+	if osc52 {
+		args = append(args, "--osc52")
+	} else if cfg.ClipboardCmd != "" {
+		args = append(args, "--cmd", cfg.ClipboardCmd)
+	}
+
+	// Hand over stdin as a pipe that is already written and closed: this
+	// process may exit before a copying goroutine would have run.
+	r, w, err := os.Pipe()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not schedule clipboard clear: %v\n", err)
+		return
+	}
+	defer r.Close()
+	_, err = w.WriteString(clipboard.Fingerprint(value) + "\n")
+	w.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not schedule clipboard clear: %v\n", err)
+		return
+	}
+
+	child := exec.Command(exe, args...)
+	child.Stdin = r
+	if err := child.Start(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not schedule clipboard clear: %v\n", err)
+		return
+	}
+	// Not waited for: it outlives this command by design.
+	_ = child.Process.Release()
+
+	fmt.Fprintf(os.Stderr, "Clipboard will be cleared in %d seconds.\n", cfg.ClipboardClearSecs)
+}
+
+// journaled applies one secret change with a journal entry written
+// first, so a change cut short by a crash can be completed or rolled back
+// with 'coconut recover'. A change that fails with an error is rolled back
+// straight away.
+func journaled(f *factory.Factory, entry journal.Entry, apply func() error) error {
+	// Only one entry is kept; writing another would lose the interrupted one.
+	if f.Journal.HasPending() {
+		return fmt.Errorf("an interrupted change is pending; run 'coconut recover' first")
+	}
+	if err := f.Journal.Begin(entry); err != nil {
+		f.Logger.Error("failed to write journal entry: %v", err)
+		return fmt.Errorf("failed to write journal entry: %w", err)
+	}
+
+	if err := apply(); err != nil {
+		if rbErr := entry.Rollback(f.Secrets); rbErr != nil {
+			f.Logger.Error("rollback of failed %s left a journal entry: %v", entry.Op, rbErr)
+			return fmt.Errorf("%w (run 'coconut recover' to repair)", err)
+		}
+		_ = f.Journal.Commit()
+		return err
+	}
+
+	if err := f.Journal.Commit(); err != nil {
+		f.Logger.Warn("failed to clear journal entry: %v", err)
+	}
+	return nil
+}
+
+// stdinIsTerminal reports whether someone can answer prompts on f.IO.In.
+func stdinIsTerminal(f *factory.Factory) bool {
+	in, ok := f.IO.In.(*os.File)
+	return ok && term.IsTerminal(int(in.Fd()))
+}
+
+// accessTarget describes a secret for the access log by index, name and ID.
+// It deliberately has no access to any secret value.
+func accessTarget(index int, secret *model.Secret) string {
+	return fmt.Sprintf("index=%d name=%q id=%s", index, secret.Name, secret.ID)
+}
+
+// backupDBFile snapshots the open vault database into the backups directory
+// and prunes old snapshots beyond the configured count. Destructive bulk
+// commands call it before touching any data. The snapshot is as encrypted
+// as the vault itself.
+func backupDBFile(f *factory.Factory) (string, error) {
+	path := backup.Path(f.Config.DBPath, time.Now())
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", fmt.Errorf("create backup dir: %w", err)
+	}
+
+	if err := f.DB.Backup(path); err != nil {
+		return "", fmt.Errorf("backup vault: %w", err)
+	}
+	f.Logger.Info("Vault backed up to %s", path)
+
+	if f.Config.BackupKeep > 0 {
+		removed, err := backup.Prune(f.Config.DBPath, f.Config.BackupKeep)
+		if err != nil {
+			f.Logger.Warn("failed to prune old backups: %v", err)
+		} else if len(removed) > 0 {
+			f.Logger.Info("Pruned %d old backup(s)", len(removed))
+		}
+	}
+
+	return path, nil
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ompatil-15/coconut/internal/crypto"
+	"github.com/ompatil-15/coconut/internal/db/boltdb"
+	"github.com/ompatil-15/coconut/internal/factory"
+	"github.com/ompatil-15/coconut/internal/iostreams"
+)
+
+// testPassword is the master password of vaults made by newTestVault.
+const testPassword = "correct-horse"
+
+// testKDFParams keep key derivation fast in tests.
+var testKDFParams = crypto.KDFParams{Algorithm: crypto.KDFArgon2id, Time: 1, MemoryKiB: 8 * 1024, Threads: 1, KeyLen: 32}
+
+// newTestVault creates a vault with testPassword at path.
+func newTestVault(t *testing.T, path string) {
+	t.Helper()
+	store, err := boltdb.NewBoltStore(path, boltdb.WithAutoCreateBuckets())
+	if err != nil {
+		t.Fatalf("NewBoltStore failed: %v", err)
+	}
+	f, err := factory.New(path, factory.WithDB(store), factory.WithIOStreams(&iostreams.IOStreams{}))
+	if err != nil {
+		t.Fatalf("factory.New failed: %v", err)
+	}
+	defer f.Close()
+	if err := createVault(f.System, testKDFParams, testPassword, f.Config); err != nil {
+		t.Fatalf("createVault failed: %v", err)
+	}
+}
+
+// newTestFactory opens a factory, as PersistentPreRunE would, on a new
+// vault in a temporary directory. The master password is read from
+// stdin, which holds in.
+func newTestFactory(t *testing.T, in string) *factory.Factory {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_DATA_HOME", filepath.Join(dir, "data"))
+
+	path := filepath.Join(dir, "vault.db")
+	newTestVault(t, path)
+
+	store, err := boltdb.NewBoltStore(path, boltdb.WithAutoCreateBuckets())
+	if err != nil {
+		t.Fatalf("NewBoltStore failed: %v", err)
+	}
+	var out bytes.Buffer
+	f, err := factory.New(path, factory.WithDB(store), factory.WithIOStreams(&iostreams.IOStreams{In: strings.NewReader(in), Out: &out, ErrOut: &out}))
+	if err != nil {
+		t.Fatalf("factory.New failed: %v", err)
+	}
+	t.Cleanup(f.Close)
+	f.PasswordStdin = true
+	return f
+}
+
+func TestNormalizeURL(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"", ""},
+		{"github.com", "https://github.com"},
+		{"  GitHub.com/Login ", "https://github.com/Login"},
+		{"localhost:8080", "https://localhost:8080"},
+		{"http://intranet.local", "http://intranet.local"},
+		{"HTTPS://Example.COM/a?b=c", "https://example.com/a?b=c"},
+		{"ftp://files.example.com", "ftp://files.example.com"},
+	}
+	for _, tt := range tests {
+		got, err := normalizeURL(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("normalizeURL(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"https://", "exa mple.com", "https://%zz"} {
+		if got, err := normalizeURL(bad); err == nil {
+			t.Errorf("normalizeURL(%q) = %q, expected an error", bad, got)
+		}
+	}
+}
+
+func TestReadStdinPassword_ReadsOnce(t *testing.T) {
+	f := &factory.Factory{
+		IO:            &iostreams.IOStreams{In: strings.NewReader("hunter2\nnext line\n")},
+		PasswordStdin: true,
+	}
+
+	for range 2 {
+		if got, err := readStdinPassword(f); err != nil || got != "hunter2" {
+			t.Fatalf("readStdinPassword = %q, %v; want the first line both times", got, err)
+		}
+	}
+	if rest, _ := readLine(f.IO.In); rest != "next line" {
+		t.Errorf("Expected later input left for other prompts, got %q", rest)
+	}
+}
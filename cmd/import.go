@@ -0,0 +1,247 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ompatil-15/coconut/internal/db"
+	"github.com/ompatil-15/coconut/internal/factory"
+	"github.com/ompatil-15/coconut/internal/importer"
+	"github.com/ompatil-15/coconut/internal/sealed"
+	"github.com/spf13/cobra"
+)
+
+func NewImportCmd(f *factory.Factory) *cobra.Command {
+	var (
+		format string
+		dryRun bool
+		asJSON bool
+
+		encrypted bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "import <file|->",
+		Short: "Import secrets from another password manager",
+		Long: `Import secrets from an export file produced by another password manager.
+Use '-' to read the export from stdin.
+
+Supported formats:
+  chrome     Chrome, Edge or other Chromium browser password CSV (a
+             blank name is taken from the site, duplicate rows are
+             skipped)
+  json       coconut's own 'export --format json' file (custom fields,
+             tags, notes and favorites are preserved)
+  lastpass   LastPass CSV export (folders become tags, secure notes are
+             kept as notes, favorites are preserved)
+
+The whole file is parsed before anything is written, and the secrets are
+stored in a single transaction: if any of them fails, none are imported.
+Rows that cannot be imported are listed with the reason at the end. Use
+--dry-run to see what would be imported without unlocking the vault. A
+backup of the vault is taken before the import runs.
+
+With --json the outcome is printed as one object for scripts instead:
+
+  {"imported": 2, "skipped": [{"row": 3, "reason": "..."}], "merged": 0}
+
+With --dry-run, "imported" is what would be imported and "dryRun" is
+true. Import only adds new secrets, so "merged" is always 0; use
+'coconut merge' to fold entries into existing ones.
+
+With --encrypted the file is one written by 'coconut export --encrypted'.
+You are asked for its passphrase, after the master password unless
+--dry-run is given; with --password-stdin it is read from the next line.
+--format can be left out. Nothing is decrypted to disk.
+
+Other export files contain your passwords in plaintext; delete them once
+the import is done.`,
+		Example: `  coconut import --format lastpass lastpass_export.csv
+  coconut import --format lastpass --dry-run lastpass_export.csv
+  coconut import --format json coconut_export.json
+  coconut import --format chrome --dry-run "Chrome Passwords.csv"
+  coconut import --format lastpass --json lastpass_export.csv
+  coconut import --encrypted coconut.cocobak`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out := f.IO.Out
+
+			var in io.Reader = f.IO.In
+			if args[0] == "-" && f.PasswordStdin && (!dryRun || encrypted) {
+				return fmt.Errorf("cannot read both the import file and --password-stdin from stdin")
+			}
+			if args[0] != "-" {
+				file, err := os.Open(args[0])
+				if err != nil {
+					return fmt.Errorf("failed to open import file: %w", err)
+				}
+				defer file.Close()
+				in = file
+			}
+
+			if encrypted {
+				if format != "" && !strings.EqualFold(format, "json") {
+					return fmt.Errorf("--encrypted files hold a json export; leave out --format or use json")
+				}
+				format = "json"
+			} else if format == "" {
+				return fmt.Errorf(`required flag(s) "format" not set`)
+			}
+
+			// The master password comes first on stdin, as for receive.
+			unlocked := false
+			if encrypted {
+				if !dryRun {
+					if err := EnsureVaultUnlocked(f); err != nil {
+						return err
+					}
+					unlocked = true
+				}
+				export, err := openSealedExport(f, in)
+				if err != nil {
+					return err
+				}
+				in = bytes.NewReader(export)
+			}
+
+			plan, err := importer.Parse(format, in)
+			if err != nil {
+				return err
+			}
+
+			if dryRun && asJSON {
+				return printImportReport(out, len(plan.Records), plan.Skipped, true)
+			}
+			if dryRun {
+				fmt.Fprintf(out, "Would import %d secret(s) from %s:\n", len(plan.Records), plan.Format)
+				for _, rec := range plan.Records {
+					label := rec.Secret.Name
+					if label == "" {
+						label = rec.Secret.Username
+					}
+					fmt.Fprintf(out, "  row %d: %s\n", rec.Row, label)
+				}
+				printImportSkipped(out, plan.Skipped)
+				return nil
+			}
+
+			if len(plan.Records) == 0 {
+				if asJSON {
+					return printImportReport(out, 0, plan.Skipped, false)
+				}
+				fmt.Fprintln(out, "Nothing to import.")
+				printImportSkipped(out, plan.Skipped)
+				return nil
+			}
+
+			if !unlocked {
+				if err := EnsureVaultUnlocked(f); err != nil {
+					return err
+				}
+			}
+
+			if _, err := backupDBFile(f); err != nil {
+				f.Logger.Error("backup before import failed: %v", err)
+				return fmt.Errorf("aborting import, backup failed: %w", err)
+			}
+
+			// All or nothing: a failure part way leaves the vault untouched.
+			var added int
+			err = f.Secrets.Batch(func(repo db.SecretRepository) error {
+				added, err = plan.Apply(repo, time.Now())
+				return err
+			})
+			if err != nil {
+				f.Logger.Error("import failed, nothing was imported: %v", err)
+				return fmt.Errorf("import failed, nothing was imported: %w", err)
+			}
+
+			f.Logger.Info("Imported %d secret(s) from %s", added, plan.Format)
+			if asJSON {
+				err = printImportReport(out, added, plan.Skipped, false)
+			} else {
+				fmt.Fprintf(out, "Imported %d secret(s), skipped %d.\n", added, len(plan.Skipped))
+				printImportSkipped(out, plan.Skipped)
+			}
+
+			if args[0] != "-" && !encrypted {
+				fmt.Fprintf(f.IO.ErrOut, "Remember to delete %s; it contains your passwords in plaintext.\n", args[0])
+			}
+
+			return err
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "", "Export format ("+strings.Join(importer.Formats(), ", ")+")")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be imported without writing anything")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Print the outcome as a JSON report")
+	cmd.Flags().BoolVar(&encrypted, "encrypted", false, "Read a file written by 'export --encrypted', asking for its passphrase")
+
+	return cmd
+}
+
+// openSealedExport asks for the passphrase of the encrypted export in r
+// and returns the JSON export it holds.
+func openSealedExport(f *factory.Factory, r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read import file: %w", err)
+	}
+
+	passphrase, err := readExtraPassword(f, "Enter the export passphrase: ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read passphrase: %w", err)
+	}
+
+	f.IO.StartProgressIndicator("Decrypting...")
+	export, file, err := sealed.Open(data, passphrase)
+	f.IO.StopProgressIndicator()
+	if errors.Is(err, sealed.ErrWrongPassphrase) {
+		f.Logger.Warn("encrypted import failed: %v", err)
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	f.Logger.Info("Opened encrypted export of %d secret(s) from %s", file.Count, file.ExportedAt.Format(time.RFC3339))
+	return export, nil
+}
+
+// importReport is the --json output of import.
+type importReport struct {
+	Imported int             `json:"imported"`
+	Skipped  []importer.Skip `json:"skipped"`
+	Merged   int             `json:"merged"`
+	DryRun   bool            `json:"dryRun,omitempty"`
+}
+
+func printImportReport(out io.Writer, imported int, skipped []importer.Skip, dryRun bool) error {
+	report := importReport{
+		Imported: imported,
+		Skipped:  skipped,
+		DryRun:   dryRun,
+	}
+	if report.Skipped == nil {
+		report.Skipped = []importer.Skip{}
+	}
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+func printImportSkipped(out io.Writer, skipped []importer.Skip) {
+	if len(skipped) == 0 {
+		return
+	}
+	fmt.Fprintf(out, "Skipped %d row(s):\n", len(skipped))
+	for _, s := range skipped {
+		fmt.Fprintf(out, "  row %d: %s\n", s.Row, s.Reason)
+	}
+}
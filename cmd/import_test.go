@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/ompatil-15/coconut/internal/importer"
+)
+
+func TestPrintImportReport(t *testing.T) {
+	var buf bytes.Buffer
+	if err := printImportReport(&buf, 3, nil, false); err != nil {
+		t.Fatalf("printImportReport failed: %v", err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("Report is not JSON: %v\n%s", err, buf.String())
+	}
+	if got["imported"] != float64(3) || got["merged"] != float64(0) {
+		t.Errorf("Unexpected counts in %v", got)
+	}
+	if skipped, ok := got["skipped"].([]any); !ok || len(skipped) != 0 {
+		t.Errorf("Expected an empty skipped list, got %v", got["skipped"])
+	}
+	if _, ok := got["dryRun"]; ok {
+		t.Errorf("Expected no dryRun outside a dry run, got %v", got)
+	}
+
+	buf.Reset()
+	skips := []importer.Skip{{Row: 4, Reason: "missing password"}}
+	if err := printImportReport(&buf, 1, skips, true); err != nil {
+		t.Fatalf("printImportReport failed: %v", err)
+	}
+	var report importReport
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("Report is not JSON: %v", err)
+	}
+	if !report.DryRun || len(report.Skipped) != 1 || report.Skipped[0] != skips[0] {
+		t.Errorf("Unexpected report %+v", report)
+	}
+}
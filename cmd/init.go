@@ -4,7 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strings"
 
+	"github.com/ompatil-15/coconut/internal/backup"
 	"github.com/ompatil-15/coconut/internal/config"
 	"github.com/ompatil-15/coconut/internal/crypto"
 	"github.com/ompatil-15/coconut/internal/db"
@@ -16,24 +18,135 @@ import (
 )
 
 func NewInitCmd(f *factory.Factory) *cobra.Command {
+	var (
+		keyBits int
+		kdf     string
+		force   bool
+	)
+
 	cmd := &cobra.Command{
 		Use:     "init",
 		Aliases: []string{"initialize"},
 		Short:   "Initialize a new vault (one-time setup)",
 		Long: `Initialize a new vault for storing secrets. This is a one-time operation.
 
-If you already have a vault, use 'coconut unlock' to unlock it.`,
+If you already have a vault, use 'coconut unlock' to unlock it.
+
+Use --key-bits 128 to encrypt with AES-128 instead of the default AES-256,
+for environments whose policy requires it. The key size is recorded in the
+vault and cannot be changed afterwards.
+
+The master password is turned into the key with Argon2id by default. Use
+--kdf scrypt to use scrypt instead (N=65536, r=8, p=1). The algorithm and
+its parameters are recorded in the vault so unlock uses the same ones.
+
+An existing vault is never overwritten unless you pass --force. You are
+then asked to type DELETE, the master password of the old vault and the
+new master password. Only then are all of the old vault's secrets and
+settings wiped as by 'coconut purge', in the same transaction that
+creates the new vault, so if anything fails the old vault is left as it
+was. Existing backups are left in place and still open with the old
+master password.`,
+		Example: `  coconut init
+  coconut init --key-bits 128
+  coconut init --kdf scrypt
+  coconut init --force`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return InitializeVault(f.System, f.Logger)
+			keyLen, err := crypto.KeyLenForBits(keyBits)
+			if err != nil {
+				return err
+			}
+
+			params, err := crypto.DefaultKDFParamsFor(kdf)
+			if err != nil {
+				return err
+			}
+			params.KeyLen = keyLen
+
+			// A vault written by a broken build might never open again.
+			if err := crypto.SelfTest(f.Crypto); err != nil {
+				f.Logger.Error("%v", err)
+				return fmt.Errorf("%w; refusing to create a vault with this build", err)
+			}
+
+			if force && vault.CheckVaultExists(f.System) {
+				return reinitVault(f, params)
+			}
+
+			return InitializeVaultWithParams(f.System, f.Logger, params)
 		},
 	}
 
+	cmd.Flags().IntVar(&keyBits, "key-bits", 256, "Encryption key size: 128 or 256 (AES-128 or AES-256)")
+	cmd.Flags().StringVar(&kdf, "kdf", crypto.KDFArgon2id, "Key derivation algorithm ("+strings.Join(crypto.KDFs(), ", ")+")")
+	cmd.Flags().BoolVar(&force, "force", false, "Wipe an existing vault and create a new one (asks for DELETE and the old master password)")
+
 	return cmd
 }
 
+// reinitVault replaces the existing vault with a new one whose key is
+// derived with params. Everything is asked for before anything is
+// written, and the old vault is wiped in the same batch that creates the
+// new one, so backing out or failing leaves it untouched.
+func reinitVault(f *factory.Factory, params crypto.KDFParams) error {
+	out := f.IO.Out
+	dbPath := f.Config.DBPath
+
+	fmt.Fprintf(out, "A vault already exists in %s. Reinitializing permanently destroys\n", dbPath)
+	fmt.Fprintln(out, "every secret and setting in it.")
+	if !confirmPurge(f) {
+		fmt.Fprintln(out, "Init cancelled; the existing vault is untouched.")
+		f.Logger.Info("Reinitialization cancelled")
+		return nil
+	}
+	if err := reauthenticate(f); err != nil {
+		return err
+	}
+
+	password, err := promptNewMasterPassword()
+	if err != nil {
+		return err
+	}
+
+	f.Logger.Warn("Reinitializing vault %s", dbPath)
+	f.Logger.Access("reinit", fmt.Sprintf("db=%s", dbPath))
+
+	var wiped int
+	err = f.DB.Batch(func(tx db.Tx) error {
+		var err error
+		if wiped, err = db.WipeTx(tx, wipeBuckets(f)); err != nil {
+			return fmt.Errorf("failed to wipe vault: %w", err)
+		}
+		return createVault(db.NewTxRepository(tx, f.Config.SystemBucket), params, password, config.Default())
+	})
+	if err != nil {
+		f.Logger.Error("reinitialization failed: %v", err)
+		return fmt.Errorf("reinitialization failed, the existing vault is untouched: %w", err)
+	}
+	// The wipe already removed the session entries. Clearing only after
+	// the commit leaves a failed reinit's session as it was.
+	if err := f.Session.Clear(); err != nil {
+		f.Logger.Error("failed to clear the old session: %v", err)
+		return fmt.Errorf("vault reinitialized, but the old session could not be cleared (run 'coconut lock'): %w", err)
+	}
+	fmt.Fprintf(out, "Wiped %d entries from the old vault.\n", wiped)
+
+	if backups, _ := backup.List(dbPath); len(backups) > 0 {
+		fmt.Fprintf(out, "Note: %d backup(s) of the old vault remain in %s.\n", len(backups), backup.Dir(dbPath))
+	}
+	reportInitialized(f.Logger, params)
+	return nil
+}
+
 // InitializeVault creates a new vault (one-time operation)
 // Returns error if vault already exists
 func InitializeVault(systemRepo db.Repository, log *logger.Logger) error {
+	return InitializeVaultWithParams(systemRepo, log, crypto.DefaultKDFParams())
+}
+
+// InitializeVaultWithParams creates a new vault whose key is derived with
+// params, recording them so unlock derives the same key.
+func InitializeVaultWithParams(systemRepo db.Repository, log *logger.Logger, params crypto.KDFParams) error {
 	const saltKey = "salt"
 
 	// Check if vault already exists
@@ -46,10 +159,27 @@ func InitializeVault(systemRepo db.Repository, log *logger.Logger) error {
 		fmt.Println("")
 		fmt.Println("To lock your vault, use:")
 		fmt.Println("  coconut lock")
+		fmt.Println("")
+		fmt.Println("To wipe it and start over, use:")
+		fmt.Println("  coconut init --force")
 		return fmt.Errorf("vault already initialized")
 	}
 
-	// Create new vault
+	password, err := promptNewMasterPassword()
+	if err != nil {
+		return err
+	}
+
+	if err := createVault(systemRepo, params, password, config.Default()); err != nil {
+		return err
+	}
+
+	reportInitialized(log, params)
+	return nil
+}
+
+// promptNewMasterPassword asks for the new vault's master password twice.
+func promptNewMasterPassword() (string, error) {
 	fmt.Println("Creating a new vault...")
 	fmt.Println("")
 	fmt.Println("Please create a strong master password:")
@@ -58,14 +188,34 @@ func InitializeVault(systemRepo db.Repository, log *logger.Logger) error {
 	fmt.Println("  • Don't reuse passwords from other services")
 	fmt.Println("")
 
-	password, err := promptPasswordTwice()
-	if err != nil {
-		return err
-	}
+	return promptPasswordTwice()
+}
 
+// reportInitialized logs the new vault and prints the next steps.
+func reportInitialized(log *logger.Logger, params crypto.KDFParams) {
+	log.Info("Vault initialized successfully (AES-%d, %s)", params.KeyBits(), params.Algorithm)
+	fmt.Println("")
+	fmt.Println("Vault created successfully!")
+	fmt.Println("")
+	fmt.Println("Next steps:")
+	fmt.Println("  - Add a secret:       coconut add -u username -p password")
+	fmt.Println("  - List secrets:       coconut list")
+	fmt.Println("  - Get a secret:       coconut get <index>")
+	fmt.Println("")
+	fmt.Println("Note: You'll be prompted for your master password when needed.")
+	fmt.Println("")
+}
+
+// createVault derives the key from password and writes what unlocks the
+// vault, its salt, verification token and key derivation params, followed
+// by cfg.
+func createVault(systemRepo db.Repository, params crypto.KDFParams, password string, cfg *config.Config) error {
 	// Generate salt and derive key
 	salt := crypto.GenerateRandomSalt(16)
-	key := crypto.DeriveKey(password, salt)
+	key, err := crypto.DeriveKeyWithParams(password, salt, params)
+	if err != nil {
+		return err
+	}
 
 	// Create and unlock vault temporarily
 	v := vault.NewVault(crypto.NewAESGCM(), salt)
@@ -78,7 +228,7 @@ func InitializeVault(systemRepo db.Repository, log *logger.Logger) error {
 	}
 
 	// Store salt and verification token in database
-	if err := systemRepo.Put(saltKey, salt); err != nil {
+	if err := systemRepo.Put("salt", salt); err != nil {
 		return fmt.Errorf("failed to save salt: %w", err)
 	}
 
@@ -86,21 +236,13 @@ func InitializeVault(systemRepo db.Repository, log *logger.Logger) error {
 		return fmt.Errorf("failed to save verification token: %w", err)
 	}
 
-	if err := config.Save(systemRepo, config.Default()); err != nil {
-		return fmt.Errorf("failed to save default configuration: %w", err)
+	if err := vault.SaveKDFParams(systemRepo, params); err != nil {
+		return fmt.Errorf("failed to save key derivation params: %w", err)
 	}
 
-	log.Info("Vault initialized successfully")
-	fmt.Println("")
-	fmt.Println("Vault created successfully!")
-	fmt.Println("")
-	fmt.Println("Next steps:")
-	fmt.Println("  - Add a secret:       coconut add -u username -p password")
-	fmt.Println("  - List secrets:       coconut list")
-	fmt.Println("  - Get a secret:       coconut get <index>")
-	fmt.Println("")
-	fmt.Println("Note: You'll be prompted for your master password when needed.")
-	fmt.Println("")
+	if err := config.Save(systemRepo, cfg); err != nil {
+		return fmt.Errorf("failed to save configuration: %w", err)
+	}
 
 	return nil
 }
@@ -49,7 +49,10 @@ func setupTestEnvironment(t *testing.T) (*factory.Factory, string, func()) {
 		t.Fatalf("Failed to create database: %v", err)
 	}
 
-	repoFactory := db.NewRepositoryFactory(bdb, nil, cfg.SystemBucket, cfg.SecretsBucket)
+	repoFactory, err := db.NewRepositoryFactory(bdb, nil, cfg.SystemBucket, cfg.SecretsBucket)
+	if err != nil {
+		t.Fatalf("Failed to create buckets: %v", err)
+	}
 	systemRepo := repoFactory.NewBaseRepository(cfg.SystemBucket)
 
 	f := &factory.Factory{
@@ -2,22 +2,167 @@ package cmd
 
 import (
 	"fmt"
+	"io"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/ompatil-15/coconut/internal/db/model"
 	"github.com/ompatil-15/coconut/internal/factory"
+	"github.com/ompatil-15/coconut/internal/timeutil"
 	"github.com/spf13/cobra"
 )
 
-var verbose bool
+// listColumn describes a selectable column of the list table.
+type listColumn struct {
+	header string
+	limit  int // values longer than this are truncated
+	value  func(s *model.Secret) string
+	date   func(s *model.Secret) time.Time // set for date columns, which --age shows relative to now
+}
+
+var listColumns = map[string]listColumn{
+	"name":        {"NAME", 30, func(s *model.Secret) string { return s.Name }, nil},
+	"username":    {"USERNAME", 20, func(s *model.Secret) string { return s.Username }, nil},
+	"url":         {"URL", 40, func(s *model.Secret) string { return s.URL }, nil},
+	"description": {"DESCRIPTION", 50, func(s *model.Secret) string { return s.Description }, nil},
+	"tags":        {"TAGS", 30, func(s *model.Secret) string { return strings.Join(s.Tags, ",") }, nil},
+	"created":     dateColumn("CREATED", func(s *model.Secret) time.Time { return s.CreatedAt }),
+	"updated":     dateColumn("UPDATED", func(s *model.Secret) time.Time { return s.UpdatedAt }),
+	"expires":     dateColumn("EXPIRES", func(s *model.Secret) time.Time { return s.ExpiresAt }),
+	"used":        dateColumn("LAST USED", func(s *model.Secret) time.Time { return s.LastUsedAt }),
+}
+
+// dateColumn is a column showing a date as YYYY-MM-DD.
+func dateColumn(header string, date func(s *model.Secret) time.Time) listColumn {
+	return listColumn{
+		header: header,
+		limit:  16,
+		value:  func(s *model.Secret) string { return formatListDate(date(s)) },
+		date:   date,
+	}
+}
+
+// relativeDates returns columns with every date column showing how long
+// ago (or, for expiries, how far ahead) the date is from now.
+func relativeDates(columns []listColumn, now time.Time) []listColumn {
+	out := slices.Clone(columns)
+	for i, column := range out {
+		if column.date == nil {
+			continue
+		}
+		date := column.date
+		out[i].value = func(s *model.Secret) string { return formatAge(date(s), now) }
+	}
+	return out
+}
+
+// listFieldNames is the canonical order used in help and error messages.
+var listFieldNames = []string{"name", "username", "url", "description", "tags", "created", "updated", "expires", "used"}
+
+// listSortOrders are the accepted --sort values. Index order is the vault
+// order; last-used puts the most recently used first and never-used last.
+var listSortOrders = []string{"index", "last-used"}
+
+const (
+	defaultListFields  = "name,username,url,description"
+	detailedListFields = "name,username,url,created,description"
+)
 
 func NewListCmd(f *factory.Factory) *cobra.Command {
+	var (
+		fields         string
+		porcelain      bool
+		favoritesOnly  bool
+		favoritesFirst bool
+		archivedOnly   bool
+		showAll        bool
+		sortBy         string
+		detailed       bool
+		age            bool
+		tags           []string
+		dates          listDateFlags
+	)
+
 	listCmd := &cobra.Command{
 		Use:     "list",
 		Aliases: []string{"ls"},
 		Short:   "View all your saved secrets securely",
 		Long: `Retrieves and displays all secret entries from the encrypted vault. 
-By default, only essential metadata is shown. Use --verbose for detailed view.`,
+By default, only essential metadata is shown. Use --detailed for a detailed view.
+
+Use --fields to choose which columns appear and in what order. The index
+column is always shown first. Available fields:
+  ` + strings.Join(listFieldNames, ", ") + `
+
+Use --porcelain for scripts. It prints one secret per line as
+tab-separated "id<TAB>name<TAB>username<TAB>url" with no header and no
+truncation; tabs and newlines inside values are replaced by spaces. This
+format is a stable interface and will not change when the table does.
+
+Favorites (see 'coconut fav') are marked with * in the index column. Use
+--favorites to show only favorites, or --favorites-first to list them
+ahead of the rest. Indexes are unchanged by either flag.
+
+Archived secrets (see 'coconut archive') are hidden. Use --archived to
+list only them, or --all to include them, marked with ~.
+
+Use --age to show dates as how long ago they were, e.g. "312 days ago"
+becomes "10 months ago", and expiries as how far ahead they are.
+
+Use --sort last-used to show the secrets you used most recently first.
+A secret counts as used when 'coconut get' shows or copies it.
+
+Use --created-after, --created-before, --updated-after and
+--updated-before to filter by date. Each takes a date (YYYY-MM-DD) or a
+duration into the past (e.g. 180d, 4w, 1y). "After" includes the given
+time, "before" excludes it. Use --tag to keep secrets with any of the
+given tags. All filters combine with each other and with --favorites.`,
+		Example: `  coconut list
+  coconut list --detailed
+  coconut list --fields name,username,updated
+  coconut list --favorites
+  coconut list --archived
+  coconut list --sort last-used --fields name,username,used
+  coconut list --updated-before 180d --fields name,username,updated
+  coconut list --fields name,created,updated --age
+  coconut list --tag work --created-after 2024-01-01
+  coconut list --porcelain | grep github | cut -f1`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			spec := defaultListFields
+			if detailed {
+				spec = detailedListFields
+			}
+			if cmd.Flags().Changed("fields") {
+				spec = fields
+			}
+
+			if porcelain && cmd.Flags().Changed("fields") {
+				return fmt.Errorf("--porcelain cannot be combined with --fields")
+			}
+			if archivedOnly && showAll {
+				return fmt.Errorf("--archived cannot be combined with --all")
+			}
+
+			columns, err := parseListFields(spec)
+			if err != nil {
+				return err
+			}
+			if age {
+				columns = relativeDates(columns, time.Now())
+			}
+
+			if !slices.Contains(listSortOrders, sortBy) {
+				return fmt.Errorf("unknown sort order %q (available: %s)", sortBy, strings.Join(listSortOrders, ", "))
+			}
+
+			dateFilter, err := dates.parse(time.Now())
+			if err != nil {
+				return err
+			}
+			tags = model.NormalizeTags(tags)
+
 			if err := EnsureVaultUnlocked(f); err != nil {
 				return err
 			}
@@ -26,58 +171,62 @@ By default, only essential metadata is shown. Use --verbose for detailed view.`,
 			errOut := f.IO.ErrOut
 			logger := f.Logger
 
-			logger.Info("Executing 'list' command (verbose=%v)", verbose)
+			logger.Info("Executing 'list' command (fields=%s)", spec)
 
-			secrets, err := f.Secrets.List()
+			secrets, err := f.Secrets.ListMetadata()
 			if err != nil {
 				logger.Error("Failed to fetch secrets: %v", err)
 				fmt.Fprintf(errOut, "Error: failed to fetch secrets: %v\n", err)
 				return fmt.Errorf("failed to fetch secrets: %w", err)
 			}
 
+			entries := indexEntries(secrets)
+			filtered := len(tags) > 0 || !dateFilter.isEmpty()
+			if filtered {
+				entries = slices.DeleteFunc(entries, func(e listEntry) bool {
+					return !dateFilter.match(&e.secret) || !hasAnyTag(&e.secret, tags)
+				})
+			}
+			entries = selectArchived(entries, archivedOnly, showAll)
+			if sortBy == "last-used" {
+				sortByLastUsed(entries)
+			}
+			entries = selectFavorites(entries, favoritesOnly, favoritesFirst)
+
+			if porcelain {
+				renderPorcelain(out, entries)
+				return nil
+			}
+
 			if len(secrets) == 0 {
 				fmt.Fprintln(out, "No secrets found in the vault.")
 				logger.Info("No secrets found in vault")
 				return nil
 			}
+			if len(entries) == 0 && filtered {
+				fmt.Fprintln(out, "No secrets match the filters.")
+				return nil
+			}
+			if len(entries) == 0 && archivedOnly {
+				fmt.Fprintln(out, "No archived secrets.")
+				return nil
+			}
+			if len(entries) == 0 && favoritesOnly {
+				fmt.Fprintln(out, "No favorites yet. Pin one with 'coconut fav <index>'.")
+				return nil
+			}
+			if len(entries) == 0 {
+				fmt.Fprintln(out, "Every secret is archived. Show them with 'coconut list --archived'.")
+				return nil
+			}
 
 			logger.Info("Fetched %d secrets from vault", len(secrets))
 
-			var headerFmt, rowFmt, divider string
-			if verbose {
-				headerFmt = "%-10s %-30s %-30s %-15s %s\n"
-				rowFmt = "%-10d %-30s %-30s %-15s %s\n"
-				divider = strings.Repeat("-", 120)
-			} else {
-				headerFmt = "%-10s %-30s %-30s %s\n"
-				rowFmt = "%-10d %-30s %-30s %s\n"
-				divider = strings.Repeat("-", 100)
-			}
-
-			if verbose {
-				fmt.Fprintf(out, headerFmt, "ID", "USERNAME", "URL", "CREATED", "DESCRIPTION")
-			} else {
-				fmt.Fprintf(out, headerFmt, "ID", "USERNAME", "URL", "DESCRIPTION")
-			}
-			fmt.Fprintln(out, divider)
-
-			for i, secret := range secrets {
-				if verbose {
-					fmt.Fprintf(out, rowFmt,
-						i+1,
-						truncate(secret.Username, 20),
-						truncate(secret.URL, 40),
-						secret.CreatedAt.Format("2006-01-02"),
-						truncate(secret.Description, 50),
-					)
-				} else {
-					fmt.Fprintf(out, rowFmt,
-						i+1,
-						truncate(secret.Username, 20),
-						truncate(secret.URL, 40),
-						truncate(secret.Description, 50),
-					)
-				}
+			expired := renderList(out, entries, columns, time.Now())
+
+			if expired > 0 {
+				fmt.Fprintln(out)
+				fmt.Fprintf(out, "%d secret(s) marked with ! have expired. Run 'coconut audit --expired' for details.\n", expired)
 			}
 
 			logger.Info("Successfully listed all secrets")
@@ -85,10 +234,270 @@ By default, only essential metadata is shown. Use --verbose for detailed view.`,
 		},
 	}
 
-	listCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Show detailed information")
+	listCmd.Flags().BoolVar(&detailed, "detailed", false, "Show detailed information")
+	listCmd.Flags().BoolVar(&age, "age", false, "Show dates relative to now (e.g. 3 days ago)")
+	listCmd.Flags().BoolVar(&porcelain, "porcelain", false, "Stable tab-separated output for scripts")
+	listCmd.Flags().BoolVar(&favoritesOnly, "favorites", false, "Show only favorite secrets")
+	listCmd.Flags().BoolVar(&favoritesFirst, "favorites-first", false, "List favorite secrets before the rest")
+	listCmd.Flags().BoolVar(&archivedOnly, "archived", false, "Show only archived secrets")
+	listCmd.Flags().BoolVar(&showAll, "all", false, "Include archived secrets")
+	listCmd.Flags().StringVar(&sortBy, "sort", "index", "Sort order ("+strings.Join(listSortOrders, ", ")+")")
+	listCmd.Flags().StringVar(&fields, "fields", "", "Comma-separated columns to show (e.g. name,username,updated)")
+	listCmd.Flags().StringSliceVarP(&tags, "tag", "t", nil, "Only list secrets with this tag (repeatable)")
+	listCmd.Flags().StringVar(&dates.createdAfter, "created-after", "", "Only secrets created at or after this date or duration ago (e.g. 2024-01-01, 30d)")
+	listCmd.Flags().StringVar(&dates.createdBefore, "created-before", "", "Only secrets created before this date or duration ago")
+	listCmd.Flags().StringVar(&dates.updatedAfter, "updated-after", "", "Only secrets updated at or after this date or duration ago")
+	listCmd.Flags().StringVar(&dates.updatedBefore, "updated-before", "", "Only secrets updated before this date or duration ago (e.g. 180d)")
 	return listCmd
 }
 
+// parseListFields validates a comma-separated field list against the
+// known columns, preserving the requested order.
+func parseListFields(spec string) ([]listColumn, error) {
+	var columns []listColumn
+	seen := make(map[string]bool)
+
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		column, ok := listColumns[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown field %q (available: %s)", name, strings.Join(listFieldNames, ", "))
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("field %q listed more than once", name)
+		}
+
+		seen[name] = true
+		columns = append(columns, column)
+	}
+
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("at least one field is required (available: %s)", strings.Join(listFieldNames, ", "))
+	}
+
+	return columns, nil
+}
+
+// listDateFlags holds the raw values of the list date filter flags.
+type listDateFlags struct {
+	createdAfter, createdBefore string
+	updatedAfter, updatedBefore string
+}
+
+// listDateFilter bounds the creation and update times of listed secrets.
+// A zero bound is not applied.
+type listDateFilter struct {
+	createdAfter, createdBefore time.Time
+	updatedAfter, updatedBefore time.Time
+}
+
+// parse reads each flag as a date or as a duration before now, the same
+// way --expires reads its value but looking back instead of ahead.
+func (d listDateFlags) parse(now time.Time) (listDateFilter, error) {
+	var filter listDateFilter
+	for _, flag := range []struct {
+		name  string
+		value string
+		dest  *time.Time
+	}{
+		{"--created-after", d.createdAfter, &filter.createdAfter},
+		{"--created-before", d.createdBefore, &filter.createdBefore},
+		{"--updated-after", d.updatedAfter, &filter.updatedAfter},
+		{"--updated-before", d.updatedBefore, &filter.updatedBefore},
+	} {
+		if flag.value == "" {
+			continue
+		}
+		t, err := timeutil.Parse(flag.value, now, false)
+		if err != nil {
+			return listDateFilter{}, fmt.Errorf("invalid %s value: %w", flag.name, err)
+		}
+		*flag.dest = t
+	}
+	return filter, nil
+}
+
+func (f listDateFilter) isEmpty() bool {
+	return f == listDateFilter{}
+}
+
+// match reports whether s falls within every bound that is set.
+func (f listDateFilter) match(s *model.Secret) bool {
+	inRange := func(t, after, before time.Time) bool {
+		return (after.IsZero() || !t.Before(after)) && (before.IsZero() || t.Before(before))
+	}
+	return inRange(s.CreatedAt, f.createdAfter, f.createdBefore) &&
+		inRange(s.UpdatedAt, f.updatedAfter, f.updatedBefore)
+}
+
+// hasAnyTag reports whether s has one of tags, which must be normalized.
+// No tags matches every secret.
+func hasAnyTag(s *model.Secret, tags []string) bool {
+	if len(tags) == 0 {
+		return true
+	}
+	for _, tag := range model.NormalizeTags(s.Tags) {
+		if slices.Contains(tags, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// listEntry pairs a secret with its 1-based index as shown by list, so
+// filtered views still print the index that get/update/delete expect.
+type listEntry struct {
+	index  int
+	secret model.Secret
+}
+
+// indexEntries numbers secrets in vault order.
+func indexEntries(secrets []model.Secret) []listEntry {
+	entries := make([]listEntry, len(secrets))
+	for i, secret := range secrets {
+		entries[i] = listEntry{index: i + 1, secret: secret}
+	}
+	return entries
+}
+
+// sortByLastUsed orders entries most recently used first. Never-used
+// entries keep their index order at the end.
+func sortByLastUsed(entries []listEntry) {
+	slices.SortStableFunc(entries, func(a, b listEntry) int {
+		return b.secret.LastUsedAt.Compare(a.secret.LastUsedAt)
+	})
+}
+
+// selectFavorites drops non-favorites when only is set, or moves favorites
+// ahead of the rest when first is set. Relative order is kept either way.
+func selectFavorites(entries []listEntry, only, first bool) []listEntry {
+	if !only && !first {
+		return entries
+	}
+
+	var favorites, rest []listEntry
+	for _, e := range entries {
+		if e.secret.IsFavorite {
+			favorites = append(favorites, e)
+		} else {
+			rest = append(rest, e)
+		}
+	}
+
+	if only {
+		return favorites
+	}
+	return append(favorites, rest...)
+}
+
+// selectArchived drops archived entries unless all is set, or keeps only
+// them when only is set.
+func selectArchived(entries []listEntry, only, all bool) []listEntry {
+	if all {
+		return entries
+	}
+	return slices.DeleteFunc(entries, func(e listEntry) bool {
+		return e.secret.IsArchived != only
+	})
+}
+
+// renderList prints the entries as a table of the given columns, sizing each
+// column to its widest value. It returns the number of expired secrets.
+func renderList(out io.Writer, entries []listEntry, columns []listColumn, now time.Time) int {
+	ids := make([]string, len(entries))
+	cells := make([][]string, len(entries))
+	widths := make([]int, len(columns))
+	idWidth := len("ID")
+	expired := 0
+
+	for i, column := range columns {
+		widths[i] = len(column.header)
+	}
+
+	for i := range entries {
+		secret := &entries[i].secret
+
+		ids[i] = strconv.Itoa(entries[i].index)
+		marks := ""
+		if secret.IsFavorite {
+			marks += "*"
+		}
+		if secret.IsArchived {
+			marks += "~"
+		}
+		if secret.IsExpired(now) {
+			marks += "!"
+			expired++
+		}
+		if marks != "" {
+			ids[i] += " " + marks
+		}
+		idWidth = max(idWidth, len(ids[i]))
+
+		cells[i] = make([]string, len(columns))
+		for j, column := range columns {
+			cells[i][j] = truncate(column.value(secret), column.limit)
+			widths[j] = max(widths[j], len(cells[i][j]))
+		}
+	}
+
+	total := idWidth
+	header := []string{fmt.Sprintf("%-*s", idWidth, "ID")}
+	for i, column := range columns {
+		header = append(header, fmt.Sprintf("%-*s", widths[i], column.header))
+		total += widths[i] + 2
+	}
+
+	fmt.Fprintln(out, strings.TrimRight(strings.Join(header, "  "), " "))
+	fmt.Fprintln(out, strings.Repeat("-", total))
+
+	for i := range entries {
+		row := []string{fmt.Sprintf("%-*s", idWidth, ids[i])}
+		for j := range columns {
+			row = append(row, fmt.Sprintf("%-*s", widths[j], cells[i][j]))
+		}
+		fmt.Fprintln(out, strings.TrimRight(strings.Join(row, "  "), " "))
+	}
+
+	return expired
+}
+
+// porcelainEscaper keeps every porcelain record on one line with exactly
+// four fields.
+var porcelainEscaper = strings.NewReplacer("\t", " ", "\r", " ", "\n", " ")
+
+// renderPorcelain prints the stable script-facing list format:
+// id, name, username and url separated by tabs, one secret per line.
+func renderPorcelain(out io.Writer, entries []listEntry) {
+	for _, e := range entries {
+		fmt.Fprintf(out, "%d\t%s\t%s\t%s\n",
+			e.index,
+			porcelainEscaper.Replace(e.secret.Name),
+			porcelainEscaper.Replace(e.secret.Username),
+			porcelainEscaper.Replace(e.secret.URL),
+		)
+	}
+}
+
+func formatListDate(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format("2006-01-02")
+}
+
+// formatAge shows t relative to now, e.g. "3 days ago", or "-" when unset.
+func formatAge(t, now time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return timeutil.Humanize(now.Sub(t))
+}
+
 func truncate(s string, max int) string {
 	if len(s) <= max {
 		return s
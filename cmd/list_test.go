@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ompatil-15/coconut/internal/db/model"
+	"github.com/ompatil-15/coconut/internal/factory"
+)
+
+func TestNewListCmd_NoSharedFlagState(t *testing.T) {
+	first := NewListCmd(&factory.Factory{})
+	second := NewListCmd(&factory.Factory{})
+
+	if err := first.Flags().Set("detailed", "true"); err != nil {
+		t.Fatalf("Failed to set --detailed: %v", err)
+	}
+
+	if got := second.Flags().Lookup("detailed").Value.String(); got != "false" {
+		t.Errorf("Setting --detailed on one list command changed another to %s", got)
+	}
+}
+
+func TestNewListCmd_LeavesVerboseToRoot(t *testing.T) {
+	root := NewRootCmd(&factory.Factory{})
+
+	list, _, err := root.Find([]string{"list"})
+	if err != nil {
+		t.Fatalf("Failed to find list command: %v", err)
+	}
+	if list.LocalNonPersistentFlags().Lookup("verbose") != nil {
+		t.Error("list must not define its own --verbose; it would shadow the global flag")
+	}
+	if list.LocalNonPersistentFlags().ShorthandLookup("v") != nil {
+		t.Error("list must not define its own -v")
+	}
+}
+
+func TestListDateFilter(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.Local)
+	old := model.Secret{CreatedAt: now.AddDate(-2, 0, 0), UpdatedAt: now.AddDate(-1, 0, 0)}
+	recent := model.Secret{CreatedAt: now.AddDate(0, -1, 0), UpdatedAt: now.AddDate(0, 0, -1)}
+
+	tests := []struct {
+		name      string
+		flags     listDateFlags
+		wantOld   bool
+		wantFresh bool
+	}{
+		{"no filters", listDateFlags{}, true, true},
+		{"updated before 180d", listDateFlags{updatedBefore: "180d"}, true, false},
+		{"updated after 180d", listDateFlags{updatedAfter: "180d"}, false, true},
+		{"created after date", listDateFlags{createdAfter: "2025-01-01"}, false, true},
+		{"created range", listDateFlags{createdAfter: "3y", createdBefore: "1y"}, true, false},
+		{"empty range", listDateFlags{createdAfter: "2025-01-01", updatedBefore: "7d"}, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := tt.flags.parse(now)
+			if err != nil {
+				t.Fatalf("parse failed: %v", err)
+			}
+			if got := filter.match(&old); got != tt.wantOld {
+				t.Errorf("old secret: match = %v, want %v", got, tt.wantOld)
+			}
+			if got := filter.match(&recent); got != tt.wantFresh {
+				t.Errorf("recent secret: match = %v, want %v", got, tt.wantFresh)
+			}
+		})
+	}
+
+	if _, err := (listDateFlags{updatedBefore: "soon"}).parse(now); err == nil {
+		t.Error("Expected an invalid value to be rejected")
+	}
+}
+
+func TestSelectArchived(t *testing.T) {
+	entries := func() []listEntry {
+		return indexEntries([]model.Secret{{Name: "a"}, {Name: "b", IsArchived: true}, {Name: "c"}})
+	}
+	indexes := func(entries []listEntry) []int {
+		var out []int
+		for _, e := range entries {
+			out = append(out, e.index)
+		}
+		return out
+	}
+
+	if got := indexes(selectArchived(entries(), false, false)); !slices.Equal(got, []int{1, 3}) {
+		t.Errorf("Expected archived secrets hidden by default, got indexes %v", got)
+	}
+	if got := indexes(selectArchived(entries(), true, false)); !slices.Equal(got, []int{2}) {
+		t.Errorf("Expected only the archived secret with --archived, got indexes %v", got)
+	}
+	if got := indexes(selectArchived(entries(), false, true)); !slices.Equal(got, []int{1, 2, 3}) {
+		t.Errorf("Expected every secret with --all, got indexes %v", got)
+	}
+}
+
+func TestRelativeDates(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.Local)
+	secret := model.Secret{Name: "a", CreatedAt: now.AddDate(0, 0, -3), ExpiresAt: now.AddDate(0, 0, 10)}
+
+	columns, err := parseListFields("name,created,expires,used")
+	if err != nil {
+		t.Fatalf("parseListFields failed: %v", err)
+	}
+	var got []string
+	for _, column := range relativeDates(columns, now) {
+		got = append(got, column.value(&secret))
+	}
+
+	want := []string{"a", "3 days ago", "in 10 days", "-"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	if columns[1].value(&secret) != "2025-05-29" {
+		t.Errorf("Expected the original columns to keep absolute dates, got %q", columns[1].value(&secret))
+	}
+}
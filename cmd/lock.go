@@ -2,21 +2,75 @@ package cmd
 
 import (
 	"fmt"
+	"time"
 
+	"github.com/ompatil-15/coconut/internal/clipboard"
 	"github.com/ompatil-15/coconut/internal/factory"
+	"github.com/ompatil-15/coconut/internal/timeutil"
 	"github.com/spf13/cobra"
 )
 
 func NewLockCmd(f *factory.Factory) *cobra.Command {
+	var (
+		timeout        string
+		clearClipboard bool
+	)
+
 	cmd := &cobra.Command{
 		Use:   "lock",
 		Short: "Lock the vault",
 		Long: `Lock your vault to secure your secrets.
 
 After locking, you'll need to run 'coconut unlock' and enter your 
-master password again to access your secrets.`,
+master password again to access your secrets.
+
+Use --timeout to schedule the lock instead: the current session stays
+usable and ends after the given duration, whatever your activity.
+
+Use --clear-clipboard to also empty the clipboard, in case a password
+is still on it. It goes through the configured clipboard command or
+OSC 52 like copying does. If no clipboard tool is available, or clearing
+fails, a warning is printed and the vault is locked anyway.`,
+		Example: `  coconut lock
+  coconut lock --clear-clipboard
+  coconut lock --timeout 30m`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			// Clear the session (removes cached key)
+			if clearClipboard && timeout != "" {
+				return fmt.Errorf("--clear-clipboard cannot be combined with --timeout")
+			}
+
+			if timeout != "" {
+				d, err := timeutil.ParseDuration(timeout)
+				if err != nil || d <= 0 {
+					return fmt.Errorf("invalid --timeout %q: use a positive duration like 30m or 2h", timeout)
+				}
+
+				if !f.Session.IsValid() {
+					fmt.Println("No active session. Run 'coconut unlock --expire-in <duration>' to start one.")
+					return nil
+				}
+
+				at := time.Now().Add(d)
+				if err := f.Session.SetExpiry(at); err != nil {
+					f.Logger.Error("Failed to schedule lock: %v", err)
+					return fmt.Errorf("failed to schedule lock: %w", err)
+				}
+
+				f.Logger.Info("Vault lock scheduled in %s", d)
+				fmt.Printf("Vault will lock at %s (in %s).\n", at.Format("15:04"), d)
+				return nil
+			}
+
+			if clearClipboard {
+				// After the lock messages, whichever way the lock goes.
+				defer clearClipboardNow(f)
+			}
+
+			// Check before clearing so the output says what actually happened
+			wasActive := f.Session.IsValid()
+
+			// Clear the session (removes cached key). Done even when it is
+			// not valid, to remove an expired session left on disk.
 			if err := f.Session.Clear(); err != nil {
 				f.Logger.Error("Failed to clear session: %v", err)
 			}
@@ -26,6 +80,12 @@ master password again to access your secrets.`,
 				f.Vault.Lock()
 			}
 
+			if !wasActive {
+				f.Logger.Info("Lock requested with no active session")
+				fmt.Println("Vault is already locked: there was no active session.")
+				return nil
+			}
+
 			f.Logger.Info("Vault locked and session cleared")
 
 			fmt.Println("Vault locked successfully!")
@@ -38,5 +98,24 @@ master password again to access your secrets.`,
 		},
 	}
 
+	cmd.Flags().StringVar(&timeout, "timeout", "", "Lock after this long instead of now (e.g. 30m, 2h)")
+	cmd.Flags().BoolVar(&clearClipboard, "clear-clipboard", false, "Also empty the clipboard")
+
 	return cmd
 }
+
+// clearClipboardNow empties the clipboard through the configured backend.
+// It only warns on failure: the lock itself has already happened.
+func clearClipboardNow(f *factory.Factory) {
+	if !f.Config.ClipboardOSC52 && !clipboard.Available(f.Config.ClipboardCmd) {
+		fmt.Fprintln(f.IO.ErrOut, "Warning: no clipboard tool found; the clipboard was not cleared")
+		return
+	}
+	if _, err := writeClipboard("", f.Config); err != nil {
+		f.Logger.Warn("Failed to clear clipboard on lock: %v", err)
+		fmt.Fprintf(f.IO.ErrOut, "Warning: failed to clear the clipboard: %v\n", err)
+		return
+	}
+	f.Logger.Info("Clipboard cleared on lock")
+	fmt.Println("Clipboard cleared.")
+}
@@ -0,0 +1,223 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ompatil-15/coconut/internal/config"
+	"github.com/ompatil-15/coconut/internal/crypto"
+	"github.com/ompatil-15/coconut/internal/db"
+	"github.com/ompatil-15/coconut/internal/db/boltdb"
+	"github.com/ompatil-15/coconut/internal/db/model"
+	"github.com/ompatil-15/coconut/internal/factory"
+	"github.com/ompatil-15/coconut/internal/merge"
+	"github.com/ompatil-15/coconut/internal/vault"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+func NewMergeCmd(f *factory.Factory) *cobra.Command {
+	var (
+		strategy string
+		dryRun   bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "merge <otherdb>",
+		Short: "Merge the secrets of another vault file into this one",
+		Long: `Merge the secrets of another vault file into this one, e.g. to reconcile
+vaults kept on two machines.
+
+The other vault is opened read-only and unlocked with its own master
+password; it is never modified. Secrets with the same username and URL
+(ignoring case) are treated as the same login, and notes without either
+are matched by name. Identical secrets are skipped. When the two versions
+differ, --strategy decides:
+  newest       keep whichever was updated last (default)
+  keep-both    add their version alongside yours
+  keep-mine    leave yours unchanged
+  keep-theirs  replace yours with theirs
+
+A backup of this vault is taken first, and the merge is written in a
+single transaction. Use --dry-run to see the counts without writing.`,
+		Example: `  coconut merge ~/laptop-coconut.db
+  coconut merge --strategy keep-both /mnt/usb/coconut.db
+  coconut merge --dry-run other.db`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out := f.IO.Out
+
+			strat, err := merge.ParseStrategy(strategy)
+			if err != nil {
+				return err
+			}
+
+			otherPath := args[0]
+			if sameFile(otherPath, f.Config.DBPath) {
+				return fmt.Errorf("cannot merge a vault into itself")
+			}
+			if _, err := os.Stat(otherPath); err != nil {
+				return fmt.Errorf("cannot open vault to merge: %w", err)
+			}
+
+			if err := EnsureVaultUnlocked(f); err != nil {
+				return err
+			}
+
+			theirs, err := readOtherVault(f, otherPath)
+			if err != nil {
+				return err
+			}
+
+			mine, err := f.Secrets.List()
+			if err != nil {
+				return fmt.Errorf("failed to load secrets: %w", err)
+			}
+
+			plan := merge.Build(mine, theirs, strat)
+
+			if dryRun {
+				fmt.Fprintf(out, "Would add %d, update %d, skip %d secret(s) from %s.\n",
+					len(plan.Added), len(plan.Updated), plan.Skipped, otherPath)
+				printMergePlan(out, plan)
+				return nil
+			}
+
+			if len(plan.Added) == 0 && len(plan.Updated) == 0 {
+				fmt.Fprintf(out, "Nothing to merge; skipped %d secret(s).\n", plan.Skipped)
+				return nil
+			}
+
+			if _, err := backupDBFile(f); err != nil {
+				f.Logger.Error("backup before merge failed: %v", err)
+				return fmt.Errorf("aborting merge, backup failed: %w", err)
+			}
+
+			// All or nothing: a failure part way leaves the vault untouched.
+			err = f.Secrets.Batch(func(repo db.SecretRepository) error {
+				return plan.Apply(repo)
+			})
+			if err != nil {
+				f.Logger.Error("merge failed, nothing was changed: %v", err)
+				return fmt.Errorf("merge failed, nothing was changed: %w", err)
+			}
+
+			f.Logger.Info("Merged %s (%s): added %d, updated %d, skipped %d",
+				otherPath, strat, len(plan.Added), len(plan.Updated), plan.Skipped)
+			fmt.Fprintf(out, "Added %d, updated %d, skipped %d secret(s).\n",
+				len(plan.Added), len(plan.Updated), plan.Skipped)
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&strategy, "strategy", string(merge.Newest), "Conflict resolution ("+strings.Join(merge.Strategies(), ", ")+")")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would change without writing anything")
+
+	return cmd
+}
+
+// readOtherVault opens the vault file at path read-only, asks for its
+// master password and returns all of its secrets decrypted.
+func readOtherVault(f *factory.Factory, path string) ([]model.Secret, error) {
+	store, err := boltdb.NewBoltStore(path, boltdb.WithReadOnly())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer store.Close()
+
+	system := db.NewBaseRepository(store, config.Default().SystemBucket)
+	if !vault.CheckVaultExists(system) {
+		return nil, fmt.Errorf("%s is not a coconut vault", path)
+	}
+
+	cfg, err := config.Load(system)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config of %s: %w", path, err)
+	}
+
+	salt, err := system.Get("salt")
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve vault salt of %s: %w", path, err)
+	}
+
+	params, err := vault.LoadKDFParams(system)
+	if err != nil {
+		return nil, err
+	}
+
+	password, err := readExtraPassword(f, "Enter master password for "+path+": ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read password: %w", err)
+	}
+
+	f.IO.StartProgressIndicator("Unlocking " + path + "...")
+	key, err := crypto.DeriveKeyWithParams(password, salt, params)
+	f.IO.StopProgressIndicator()
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+
+	other := vault.UnlockWithKey(f.Crypto, salt, key)
+	defer other.Lock()
+
+	if err := vault.VerifyVaultPassword(system, other); err != nil {
+		return nil, fmt.Errorf("authentication failed for %s: %v", path, err)
+	}
+
+	repo := db.NewEncryptedRepository(db.NewShardedRepository(store, cfg.SecretsBucket, cfg.SecretShards), other, cfg.SecretsBucket)
+	secrets, err := repo.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secrets from %s: %v", path, err)
+	}
+	return secrets, nil
+}
+
+// readExtraPassword reads a password other than this vault's, such as
+// another vault's master password: the next line of stdin with
+// --password-stdin, otherwise a hidden prompt. The environment variable is
+// not used, as it holds this vault's password.
+func readExtraPassword(f *factory.Factory, prompt string) (string, error) {
+	if f.PasswordStdin {
+		return readLine(f.IO.In)
+	}
+
+	fmt.Fprint(f.IO.ErrOut, prompt)
+	pwd, err := term.ReadPassword(int(os.Stdin.Fd()))
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintln(f.IO.ErrOut)
+	return string(pwd), nil
+}
+
+// sameFile reports whether a and b name the same file on disk.
+func sameFile(a, b string) bool {
+	ai, errA := os.Stat(a)
+	bi, errB := os.Stat(b)
+	if errA == nil && errB == nil {
+		return os.SameFile(ai, bi)
+	}
+	absA, _ := filepath.Abs(a)
+	absB, _ := filepath.Abs(b)
+	return absA == absB
+}
+
+func printMergePlan(out io.Writer, plan *merge.Plan) {
+	for _, s := range plan.Added {
+		fmt.Fprintf(out, "  add:    %s\n", mergeLabel(s))
+	}
+	for _, s := range plan.Updated {
+		fmt.Fprintf(out, "  update: %s\n", mergeLabel(s))
+	}
+}
+
+func mergeLabel(s model.Secret) string {
+	if s.Name != "" {
+		return s.Name
+	}
+	return s.Username
+}
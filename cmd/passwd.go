@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ompatil-15/coconut/internal/crypto"
+	"github.com/ompatil-15/coconut/internal/db"
+	"github.com/ompatil-15/coconut/internal/factory"
+	"github.com/ompatil-15/coconut/internal/undo"
+	"github.com/ompatil-15/coconut/internal/vault"
+	"github.com/spf13/cobra"
+)
+
+func NewPasswdCmd(f *factory.Factory) *cobra.Command {
+	var rehash bool
+
+	cmd := &cobra.Command{
+		Use:   "passwd",
+		Short: "Change the master password",
+		Long: `Change the master password and re-encrypt the vault under the new key.
+
+Your current password is always asked for first, even with an active
+session. The new key is derived with a fresh salt and the current default
+parameters of the vault's key derivation algorithm, so changing the
+password also picks up stronger defaults from newer releases.
+
+Use --rehash to keep your password and only upgrade the key derivation
+parameters and salt, e.g. after a release raises the defaults.
+
+Every secret, the undo entry and the verification token are re-encrypted
+in a single transaction, so the vault is never left half under the old
+key. A backup is taken first. The current session is replaced by one for
+the new key. With --password-stdin the current password is read from the
+first line and the new one from the second.`,
+		Example: `  coconut passwd
+  coconut passwd --rehash`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !vault.CheckVaultExists(f.System) {
+				return fmt.Errorf("%w: run 'coconut init' first", vault.ErrVaultNotFound)
+			}
+			if f.Journal.HasPending() {
+				return fmt.Errorf("an interrupted change is pending; run 'coconut recover' first")
+			}
+
+			params, err := vault.LoadKDFParams(f.System)
+			if err != nil {
+				return err
+			}
+
+			current, err := readExtraPassword(f, "Enter current master password: ")
+			if err != nil {
+				return fmt.Errorf("failed to read password: %w", err)
+			}
+			old, err := unlockWithPassword(f, current, params)
+			if err != nil {
+				f.Logger.Warn("Master password change refused: %v", err)
+				return err
+			}
+			defer old.Lock()
+
+			password := current
+			if !rehash {
+				password, err = readNewMasterPassword(f)
+				if err != nil {
+					return err
+				}
+			}
+
+			next, err := crypto.DefaultKDFParamsFor(params.Algorithm)
+			if err != nil {
+				return err
+			}
+			next.KeyLen = params.KeyLen
+
+			path, err := backupDBFile(f)
+			if err != nil {
+				f.Logger.Error("backup before passwd failed: %v", err)
+				return fmt.Errorf("failed to back up vault: %w", err)
+			}
+
+			salt := crypto.GenerateRandomSalt(16)
+			f.IO.StartProgressIndicator("Deriving new key...")
+			key, err := crypto.DeriveKeyWithParams(password, salt, next)
+			f.IO.StopProgressIndicator()
+			if err != nil {
+				return fmt.Errorf("failed to derive key: %w", err)
+			}
+			// The session keeps its own copy; the vault zeroes this one on lock.
+			sessionKey := append([]byte(nil), key...)
+			nv := vault.UnlockWithKey(f.Crypto, salt, key)
+
+			n, err := rekeyVault(f, old, nv, next)
+			if err != nil {
+				nv.Lock()
+				f.Logger.Error("re-encryption failed, vault unchanged: %v", err)
+				return fmt.Errorf("failed to re-encrypt vault (nothing was changed): %w", err)
+			}
+
+			useVault(f, nv)
+			if f.Config.PlaintextIndex {
+				if report, err := f.Secrets.Reindex(); err != nil {
+					f.Logger.Warn("reindex after passwd failed: %v", err)
+				} else if len(report.Failed) > 0 {
+					f.Logger.Warn("reindex after passwd could not decrypt %d secret(s)", len(report.Failed))
+				}
+			}
+
+			if !f.NoSession {
+				f.Session.Clear()
+				if err := f.Session.CreateSession(sessionKey); err != nil {
+					f.Logger.Error("Failed to create session: %v", err)
+				}
+			}
+
+			if rehash {
+				f.Logger.Info("Key derivation parameters upgraded (%s); %d secret(s) re-encrypted (backup %s)", next.Algorithm, n, path)
+				fmt.Fprintf(f.IO.Out, "Key derivation parameters upgraded; %d secret(s) re-encrypted.\n", n)
+			} else {
+				f.Logger.Info("Master password changed; %d secret(s) re-encrypted (backup %s)", n, path)
+				fmt.Fprintf(f.IO.Out, "Master password changed; %d secret(s) re-encrypted.\n", n)
+			}
+			fmt.Fprintf(f.IO.Out, "The previous vault was backed up to %s.\n", path)
+			fmt.Fprintln(f.IO.Out, "It still opens with the old password; delete it once you no longer need it.")
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&rehash, "rehash", false, "Keep the password; only upgrade the key derivation parameters and salt")
+
+	return cmd
+}
+
+// unlockWithPassword derives the vault key from password and checks it
+// against the verification token, ignoring any session.
+func unlockWithPassword(f *factory.Factory, password string, params crypto.KDFParams) (*vault.Vault, error) {
+	salt, err := f.System.Get("salt")
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve vault salt: %w", err)
+	}
+
+	f.IO.StartProgressIndicator("Verifying password...")
+	key, err := crypto.DeriveKeyWithParams(password, salt, params)
+	f.IO.StopProgressIndicator()
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+
+	v := vault.UnlockWithKey(f.Crypto, salt, key)
+	if err := vault.VerifyVaultPassword(f.System, v); err != nil {
+		v.Lock()
+		relock(f)
+		return nil, fmt.Errorf("authentication failed: %w", err)
+	}
+	return v, nil
+}
+
+// readNewMasterPassword asks for the new master password twice, or reads
+// it once from the next line with --password-stdin.
+func readNewMasterPassword(f *factory.Factory) (string, error) {
+	password, err := readExtraPassword(f, "Enter new master password: ")
+	if err != nil {
+		return "", fmt.Errorf("failed to read new password: %w", err)
+	}
+	if password == "" {
+		return "", errors.New("new master password cannot be empty")
+	}
+	if f.PasswordStdin {
+		return password, nil
+	}
+
+	confirm, err := readExtraPassword(f, "Confirm new master password: ")
+	if err != nil {
+		return "", fmt.Errorf("failed to read new password: %w", err)
+	}
+	if confirm != password {
+		return "", errors.New("passwords do not match")
+	}
+	return password, nil
+}
+
+// rekeyVault re-encrypts everything stored under from so that to opens
+// it, and records to's integrity tag, salt, verification token and params,
+// all in one transaction. It returns the number of secrets re-encrypted.
+func rekeyVault(f *factory.Factory, from, to *vault.Vault, params crypto.KDFParams) (int, error) {
+	var n int
+	err := f.DB.Batch(func(tx db.Tx) error {
+		records := db.NewShardedRepository(tx, f.Config.SecretsBucket, f.Config.SecretShards)
+		var err error
+		n, err = db.RekeyRecords(records, from, to)
+		if err != nil {
+			return err
+		}
+
+		system := db.NewTxRepository(tx, f.Config.SystemBucket)
+		if err := undo.Rekey(system, from, to); err != nil {
+			return err
+		}
+		if err := db.NewIntegrityTag(system, to).Update(records); err != nil {
+			return err
+		}
+		return vault.SaveKey(system, to, params)
+	})
+	return n, err
+}
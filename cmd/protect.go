@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ompatil-15/coconut/internal/db/model"
+	"github.com/ompatil-15/coconut/internal/factory"
+	"github.com/ompatil-15/coconut/internal/pin"
+	"github.com/spf13/cobra"
+)
+
+func NewProtectCmd(f *factory.Factory) *cobra.Command {
+	return &cobra.Command{
+		Use:   "protect <index|name>",
+		Short: "Require a PIN to reveal a secret's password",
+		Long: `Set an access PIN on a secret. While it is set, 'get' asks for the PIN
+before it shows, copies or prints the password, even in an unlocked vault,
+and 'share' asks for it before writing the share file. 'browse' does not
+reveal or copy it at all. It is left out of 'export'.
+
+Only an Argon2id hash of the PIN is stored, inside the encrypted secret.
+Use 'coconut unprotect' to remove the PIN; it asks for the PIN too. With
+--password-stdin the PIN is read from the next line of stdin, after the
+master password if the vault is locked.`,
+		Example: `  coconut protect 3
+  coconut protect "Bank account"`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeSecretNames(f),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			index, secret, err := loadSecretArg(f, args[0])
+			if err != nil {
+				return err
+			}
+			if secret.IsProtected() {
+				return fmt.Errorf("secret %d already has a PIN; run 'coconut unprotect %d' first to change it", index, index)
+			}
+
+			code, err := readExtraPassword(f, "Enter new PIN: ")
+			if err != nil {
+				return fmt.Errorf("failed to read PIN: %w", err)
+			}
+			if !f.PasswordStdin {
+				confirm, err := readExtraPassword(f, "Confirm PIN: ")
+				if err != nil {
+					return fmt.Errorf("failed to read PIN: %w", err)
+				}
+				if confirm != code {
+					return errors.New("PINs do not match")
+				}
+			}
+
+			f.IO.StartProgressIndicator("Hashing PIN...")
+			secret.PinHash, err = pin.Hash(code)
+			f.IO.StopProgressIndicator()
+			if err != nil {
+				return err
+			}
+
+			if err := f.Secrets.Update(*secret); err != nil {
+				f.Logger.Error("failed to protect secret: %v", err)
+				return fmt.Errorf("failed to update secret: %w", err)
+			}
+
+			f.Logger.Access("protect", accessTarget(index, secret))
+			fmt.Fprintf(f.IO.Out, "Secret %d (%s) now needs its PIN to reveal the password.\n", index, mergeLabel(*secret))
+			return nil
+		},
+	}
+}
+
+func NewUnprotectCmd(f *factory.Factory) *cobra.Command {
+	return &cobra.Command{
+		Use:               "unprotect <index|name>",
+		Short:             "Remove the PIN from a secret",
+		Long:              `Remove the access PIN set with 'coconut protect'. The current PIN is asked for first.`,
+		Example:           `  coconut unprotect 3`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeSecretNames(f),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			index, secret, err := loadSecretArg(f, args[0])
+			if err != nil {
+				return err
+			}
+			if !secret.IsProtected() {
+				fmt.Fprintf(f.IO.Out, "Secret %d has no PIN.\n", index)
+				return nil
+			}
+
+			if err := checkPIN(f, index, secret); err != nil {
+				return err
+			}
+
+			secret.PinHash = ""
+			if err := f.Secrets.Update(*secret); err != nil {
+				f.Logger.Error("failed to unprotect secret: %v", err)
+				return fmt.Errorf("failed to update secret: %w", err)
+			}
+
+			f.Logger.Access("unprotect", accessTarget(index, secret))
+			fmt.Fprintf(f.IO.Out, "PIN removed from secret %d (%s).\n", index, mergeLabel(*secret))
+			return nil
+		},
+	}
+}
+
+// loadSecretArg unlocks the vault and returns the secret an <index|name>
+// argument refers to.
+func loadSecretArg(f *factory.Factory, arg string) (int, *model.Secret, error) {
+	if err := EnsureVaultUnlocked(f); err != nil {
+		return 0, nil, err
+	}
+
+	secrets, err := f.Secrets.List()
+	if err != nil {
+		f.Logger.Error("failed to fetch secrets: %v", err)
+		return 0, nil, fmt.Errorf("failed to fetch secrets: %w", err)
+	}
+
+	index, err := resolveSecretArg(f, secrets, arg)
+	if err != nil {
+		return 0, nil, err
+	}
+	return index, &secrets[index-1], nil
+}
+
+// checkPIN asks for the PIN of a protected secret and verifies it. Call it
+// before anything that reveals the password; it does nothing for secrets
+// without a PIN.
+func checkPIN(f *factory.Factory, index int, secret *model.Secret) error {
+	if !secret.IsProtected() {
+		return nil
+	}
+
+	code, err := readExtraPassword(f, fmt.Sprintf("Enter PIN for %s: ", mergeLabel(*secret)))
+	if err != nil {
+		return fmt.Errorf("failed to read PIN: %w", err)
+	}
+
+	f.IO.StartProgressIndicator("Checking PIN...")
+	err = pin.Verify(secret.PinHash, code)
+	f.IO.StopProgressIndicator()
+	if errors.Is(err, pin.ErrWrongPIN) {
+		f.Logger.Access("pin-denied", accessTarget(index, secret))
+		return err
+	}
+	if err != nil {
+		f.Logger.Error("cannot check PIN of secret %d: %v", index, err)
+		return err
+	}
+	return nil
+}
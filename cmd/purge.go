@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ompatil-15/coconut/internal/backup"
+	"github.com/ompatil-15/coconut/internal/db"
+	"github.com/ompatil-15/coconut/internal/factory"
+	"github.com/ompatil-15/coconut/internal/vault"
+	"github.com/spf13/cobra"
+)
+
+// purgeConfirmation must be typed exactly to run purge.
+const purgeConfirmation = "DELETE"
+
+func NewPurgeCmd(f *factory.Factory) *cobra.Command {
+	var (
+		removeFile    bool
+		removeBackups bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "purge",
+		Short: "Irreversibly wipe the entire vault",
+		Long: `Wipe every secret, the salt and verification token, the session, undo
+and recovery data and all settings from the vault, e.g. before handing a
+machine on. There is no undo and no automatic backup.
+
+You are asked to type DELETE and then your master password, even during
+an active session. With --password-stdin, stdin holds DELETE on the first
+line and the master password on the second.
+
+Values are overwritten with zeros before they are deleted. The database
+format keeps old pages around until they are reused, so also pass
+--remove-file to overwrite the whole file and delete it, and --backups
+to do the same to the snapshots in the backups directory. Log files are
+left in place; they never contain passwords.`,
+		Example: `  coconut purge
+  coconut purge --remove-file --backups`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out := f.IO.Out
+			dbPath := f.Config.DBPath
+
+			if !vault.CheckVaultExists(f.System) {
+				return vault.ErrVaultNotFound
+			}
+			backups, err := backup.List(dbPath)
+			if err != nil {
+				return fmt.Errorf("failed to list backups: %w", err)
+			}
+
+			fmt.Fprintf(out, "This permanently destroys the vault in %s.\n", dbPath)
+			if removeFile {
+				fmt.Fprintln(out, "The database file is overwritten and deleted.")
+			}
+			if removeBackups && len(backups) > 0 {
+				fmt.Fprintf(out, "%d backup(s) in %s are overwritten and deleted.\n", len(backups), backup.Dir(dbPath))
+			}
+			if !confirmPurge(f) {
+				fmt.Fprintln(out, "Purge cancelled.")
+				f.Logger.Info("Purge cancelled")
+				return nil
+			}
+
+			if err := reauthenticate(f); err != nil {
+				return err
+			}
+
+			// Logged first: afterwards there is no vault left to name.
+			f.Logger.Warn("Purging vault %s (remove-file=%v, backups=%v)", dbPath, removeFile, removeBackups)
+			f.Logger.Access("purge", fmt.Sprintf("db=%s", dbPath))
+
+			wiped, err := wipeVault(f)
+			if err != nil {
+				f.Logger.Error("purge failed: %v", err)
+				return fmt.Errorf("failed to wipe vault: %w", err)
+			}
+			fmt.Fprintf(out, "Wiped %d entries from the vault.\n", wiped)
+
+			if removeFile {
+				_ = f.DB.Close()
+				f.DB = nil
+				if err := destroyFile(dbPath); err != nil {
+					f.Logger.Error("failed to remove vault file: %v", err)
+					return fmt.Errorf("failed to remove %s: %w", dbPath, err)
+				}
+				fmt.Fprintf(out, "Removed %s.\n", dbPath)
+			}
+
+			if removeBackups {
+				for _, path := range backups {
+					if err := destroyFile(path); err != nil {
+						f.Logger.Error("failed to remove backup %s: %v", path, err)
+						return fmt.Errorf("failed to remove backup %s: %w", path, err)
+					}
+				}
+				fmt.Fprintf(out, "Removed %d backup(s).\n", len(backups))
+			} else if len(backups) > 0 {
+				fmt.Fprintf(out, "Note: %d encrypted backup(s) remain in %s; run with --backups to remove them.\n", len(backups), backup.Dir(dbPath))
+			}
+
+			f.Logger.Warn("Vault purged")
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&removeFile, "remove-file", false, "Also overwrite and delete the database file")
+	cmd.Flags().BoolVar(&removeBackups, "backups", false, "Also overwrite and delete the vault's backups")
+
+	return cmd
+}
+
+// confirmPurge asks the user to type purgeConfirmation and reports
+// whether they did.
+func confirmPurge(f *factory.Factory) bool {
+	fmt.Fprintf(f.IO.Out, "Type %s to continue: ", purgeConfirmation)
+	answer, err := readLine(f.IO.In)
+	return err == nil && strings.TrimSpace(answer) == purgeConfirmation
+}
+
+// wipeVault clears the session and overwrites and deletes every entry in
+// the vault's buckets, returning how many were wiped.
+func wipeVault(f *factory.Factory) (int, error) {
+	_ = f.Session.Clear()
+	return db.Wipe(f.DB, wipeBuckets(f))
+}
+
+// wipeBuckets lists every bucket purge and init --force empty.
+func wipeBuckets(f *factory.Factory) []string {
+	return append(db.ShardBuckets(f.Config.SecretsBucket, f.Config.SecretShards), f.Config.IndexBucket, f.Config.JournalBucket, f.Config.QuarantineBucket, f.Config.SystemBucket)
+}
+
+// destroyFile overwrites path with zeros, syncs it to disk and removes it.
+// On SSDs and copy-on-write filesystems the old blocks may still survive.
+func destroyFile(path string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	zeros := make([]byte, 64*1024)
+	for left := info.Size(); left > 0; {
+		n := int64(len(zeros))
+		if left < n {
+			n = left
+		}
+		if _, err := file.Write(zeros[:n]); err != nil {
+			file.Close()
+			return err
+		}
+		left -= n
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ompatil-15/coconut/internal/db"
+	"github.com/ompatil-15/coconut/internal/factory"
+	"github.com/ompatil-15/coconut/internal/journal"
+	"github.com/spf13/cobra"
+)
+
+func NewRecoverCmd(f *factory.Factory) *cobra.Command {
+	var (
+		complete bool
+		rollback bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "recover",
+		Short: "Complete or roll back an add, update or delete that was interrupted",
+		Long: `Repair the vault after an add, update or delete was cut short, e.g. by a
+crash or power loss.
+
+Each of these changes is recorded in a journal before it is applied and
+the record is cleared once it succeeds. If a record is left behind, every
+command warns about it and further changes are refused until it is dealt
+with. This command shows the interrupted change and either completes it
+(--complete) or restores the secret to how it was before (--rollback).
+Without either flag you are asked which to do.`,
+		Example: `  coconut recover
+  coconut recover --rollback`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out := f.IO.Out
+
+			if complete && rollback {
+				return fmt.Errorf("--complete cannot be combined with --rollback")
+			}
+
+			if err := EnsureVaultUnlocked(f); err != nil {
+				return err
+			}
+
+			entry, err := f.Journal.Pending()
+			if errors.Is(err, journal.ErrNoPending) {
+				fmt.Fprintln(out, "Nothing to recover.")
+				return nil
+			}
+			if err != nil {
+				f.Logger.Error("failed to load journal entry: %v", err)
+				return fmt.Errorf("failed to load journal entry: %w", err)
+			}
+
+			target := entry.Target()
+			fmt.Fprintf(out, "Interrupted %s of '%s' (started %s).\n",
+				entry.Op, mergeLabel(*target), entry.StartedAt.Format("2006-01-02 15:04"))
+
+			if !complete && !rollback {
+				fmt.Fprint(out, "Complete it (c), roll it back (r), or cancel? [c/r/N]: ")
+				choice, _ := readLine(f.IO.In)
+				switch strings.ToLower(strings.TrimSpace(choice)) {
+				case "c":
+					complete = true
+				case "r":
+					rollback = true
+				default:
+					fmt.Fprintln(out, "Recovery cancelled.")
+					return nil
+				}
+			}
+
+			action, apply := "Completed", entry.Complete
+			if rollback {
+				action, apply = "Rolled back", entry.Rollback
+			}
+
+			err = f.Secrets.Batch(func(repo db.SecretRepository) error {
+				return apply(repo)
+			})
+			if err != nil {
+				f.Logger.Error("recovery of %s failed: %v", entry.Op, err)
+				return fmt.Errorf("recovery failed: %w", err)
+			}
+
+			if err := f.Journal.Commit(); err != nil {
+				return fmt.Errorf("failed to clear journal entry: %w", err)
+			}
+
+			f.Logger.Info("%s interrupted %s of secret %s", action, entry.Op, target.ID)
+			fmt.Fprintf(out, "%s the %s.\n", action, entry.Op)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&complete, "complete", false, "Apply the interrupted change")
+	cmd.Flags().BoolVar(&rollback, "rollback", false, "Undo the interrupted change")
+
+	return cmd
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/ompatil-15/coconut/internal/factory"
+	"github.com/spf13/cobra"
+)
+
+func NewReindexCmd(f *factory.Factory) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "reindex",
+		Short: "Rebuild the metadata index from the encrypted secrets",
+		Long: `Rebuild the metadata index that 'list' and 'search' read from.
+
+The index is only kept when the plaintext-index setting is on (see
+'coconut config set plaintext-index'); otherwise this has nothing to do.
+
+Every encrypted secret is decrypted and its index entry rewritten, and
+entries left behind by deleted secrets are removed. The encrypted records
+are never modified. Secrets that cannot be decrypted are listed by ID.
+
+The index repairs itself entry by entry during normal use, so this is
+only needed if listing looks wrong or after restoring from a backup.`,
+		Example: `  coconut reindex`,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := EnsureVaultUnlocked(f); err != nil {
+				return err
+			}
+
+			out := f.IO.Out
+
+			if !f.Config.PlaintextIndex {
+				fmt.Fprintln(out, "The plaintext index is off, so there is nothing to rebuild.")
+				fmt.Fprintln(out, "Turn it on with 'coconut config set plaintext-index on'.")
+				return nil
+			}
+
+			report, err := f.Secrets.Reindex()
+			if err != nil {
+				f.Logger.Error("reindex failed: %v", err)
+				return fmt.Errorf("reindex failed: %w", err)
+			}
+
+			f.Logger.Info("Reindexed %d secret(s), removed %d stale entries, %d failed", report.Indexed, report.Removed, len(report.Failed))
+			fmt.Fprintf(out, "Reindexed %d secret(s).\n", report.Indexed)
+			if report.Removed > 0 {
+				fmt.Fprintf(out, "Removed %d stale index entries.\n", report.Removed)
+			}
+			if len(report.Failed) > 0 {
+				fmt.Fprintf(out, "%d secret(s) could not be decrypted and were left out of the index:\n", len(report.Failed))
+				for _, id := range report.Failed {
+					fmt.Fprintf(out, "  %s\n", id)
+				}
+			}
+
+			return nil
+		},
+	}
+
+	return cmd
+}
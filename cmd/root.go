@@ -1,15 +1,28 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"runtime/debug"
 
 	"github.com/ompatil-15/coconut/internal/factory"
+	"github.com/ompatil-15/coconut/internal/iostreams"
+	"github.com/ompatil-15/coconut/internal/session"
+	"github.com/ompatil-15/coconut/internal/vault"
 	"github.com/spf13/cobra"
 )
 
 func NewRootCmd(f *factory.Factory) *cobra.Command {
+	var (
+		dbPath         string
+		passwordStdin  bool
+		noSession      bool
+		requireSession bool
+		verbose        bool
+	)
+
 	cmd := &cobra.Command{
 		Use:   "coconut",
 		Short: "A CLI password manager with Zero Knowledge Architecture",
@@ -18,25 +31,96 @@ func NewRootCmd(f *factory.Factory) *cobra.Command {
 Store all your passwords effortlessly while having to only remember a
 single master password. With the Zero Knowledge Architecture, your 
 passwords are safe even after full device compromise.`,
+		// The factory is opened here rather than in Execute so that global
+		// flags such as --db are parsed before the database is touched.
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			errOut := io.Writer(os.Stderr)
+			if f.IO != nil {
+				errOut = f.IO.ErrOut
+			}
+			// Flags win over the config file, which wins over the vault.
+			file := loadConfigFile(errOut)
+			path := dbPath
+			if path == "" {
+				path = file.dbPath()
+			}
+
+			opened, err := factory.New(path)
+			if err != nil {
+				return fmt.Errorf("failed to initialize factory: %w", err)
+			}
+			*f = *opened
+			file.apply(f, f.IO.ErrOut)
+			if verbose {
+				f.Logger.SetMirror(f.IO.ErrOut)
+			}
+			f.PasswordStdin = passwordStdin
+			f.NoSession = noSession
+			f.RequireSession = requireSession
+			if noSession && requireSession {
+				return fmt.Errorf("--require-session cannot be combined with --no-session")
+			}
+			if f.Journal.HasPending() && cmd.Name() != "recover" && cmd.Name() != cobra.ShellCompRequestCmd {
+				fmt.Fprintln(f.IO.ErrOut, "Warning: an add, update or delete was interrupted. Run 'coconut recover' to complete or roll it back.")
+			}
+			return nil
+		},
 	}
 
+	cmd.PersistentFlags().StringVar(&dbPath, "db", "", "Path to the vault database file (default coconut.db in the data directory, usually ~/.coconut)")
+	cmd.PersistentFlags().BoolVar(&passwordStdin, "password-stdin", false, "Read the master password from stdin instead of prompting")
+	cmd.PersistentFlags().BoolVar(&noSession, "no-session", false, "Do not read or create a cached session; the key is discarded after the command")
+	cmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Also print log messages to stderr as they happen")
+	cmd.PersistentFlags().BoolVar(&requireSession, "require-session", false, fmt.Sprintf("Fail with exit code %d instead of prompting when the vault is locked", exitSessionExpired))
+
 	// Vault management commands
 	cmd.AddCommand(NewInitCmd(f))
+	cmd.AddCommand(NewSetupCmd(f))
 	cmd.AddCommand(NewUnlockCmd(f))
 	cmd.AddCommand(NewLockCmd(f))
+	cmd.AddCommand(NewSessionCmd(f))
+	cmd.AddCommand(NewPasswdCmd(f))
+	cmd.AddCommand(NewWatchCmd(f))
 
 	// Secret management commands
 	cmd.AddCommand(NewAddCmd(f))
 	cmd.AddCommand(NewGetCmd(f))
 	cmd.AddCommand(NewListCmd(f))
+	cmd.AddCommand(NewSearchCmd(f))
+	cmd.AddCommand(NewBrowseCmd(f))
 	cmd.AddCommand(NewUpdateCmd(f))
 	cmd.AddCommand(NewDeleteCmd(f))
+	cmd.AddCommand(NewPurgeCmd(f))
+	cmd.AddCommand(NewDuplicateCmd(f))
+	cmd.AddCommand(NewFavCmd(f))
+	cmd.AddCommand(NewUnfavCmd(f))
+	cmd.AddCommand(NewArchiveCmd(f))
+	cmd.AddCommand(NewUnarchiveCmd(f))
+	cmd.AddCommand(NewTagCmd(f))
+	cmd.AddCommand(NewProtectCmd(f))
+	cmd.AddCommand(NewUnprotectCmd(f))
+	cmd.AddCommand(NewUndoCmd(f))
+	cmd.AddCommand(NewRecoverCmd(f))
 
 	// Utility commands
 	cmd.AddCommand(NewGenerateCmd(f))
+	cmd.AddCommand(NewAuditCmd(f))
+	cmd.AddCommand(NewStatsCmd(f))
+	cmd.AddCommand(NewBackupCmd(f))
+	cmd.AddCommand(NewReindexCmd(f))
+	cmd.AddCommand(NewCheckCmd(f))
+	cmd.AddCommand(NewVerifyCmd(f))
+	cmd.AddCommand(NewImportCmd(f))
+	cmd.AddCommand(NewMergeCmd(f))
+	cmd.AddCommand(NewExportCmd(f))
+	cmd.AddCommand(NewShareCmd(f))
+	cmd.AddCommand(NewReceiveCmd(f))
+	cmd.AddCommand(NewAccessLogCmd(f))
 
 	// Configuration commands
 	cmd.AddCommand(NewConfigCmd(f))
+	cmd.AddCommand(newClipboardClearCmd())
+	cmd.AddCommand(newBenchKDFCmd())
 
 	// Version command
 	cmd.AddCommand(&cobra.Command{
@@ -50,32 +134,45 @@ passwords are safe even after full device compromise.`,
 	return cmd
 }
 
+// Exit codes. Scripts can tell a locked vault and a wrong master password
+// apart from other failures.
+const (
+	exitError          = 1
+	exitWrongPassword  = 2
+	exitSessionExpired = 3
+)
+
 func Execute() {
-	cmdFactory, err := factory.New()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "failed to initialize factory: %v\n", err)
-		os.Exit(1)
-	}
+	cmdFactory := &factory.Factory{IO: iostreams.System()}
 	defer cmdFactory.Close()
 
 	w := cmdFactory.IO.ErrOut
-	logger := cmdFactory.Logger
 
 	defer func() {
 		if r := recover(); r != nil {
-			if logger != nil {
-				logger.Error("panic recovered: %v\n%s", r, debug.Stack())
+			if cmdFactory.Logger != nil {
+				cmdFactory.Logger.Error("panic recovered: %v\n%s", r, debug.Stack())
 			}
 			fmt.Fprintln(w, "An unexpected error occurred. Please check the log file for details.")
-			os.Exit(1)
+			os.Exit(exitError)
 		}
 	}()
 
 	rootCmd := NewRootCmd(cmdFactory)
 
-	if err := rootCmd.Execute(); err != nil {
-		logger.Error("Command execution failed: %v", err)
+	if err := executeCommand(cmdFactory, rootCmd); err != nil {
+		if cmdFactory.Logger == nil {
+			// Factory failed to open; cobra has already printed the error.
+			os.Exit(exitError)
+		}
+		cmdFactory.Logger.Error("Command execution failed: %v", err)
+		if errors.Is(err, session.ErrSessionExpired) {
+			os.Exit(exitSessionExpired)
+		}
+		if errors.Is(err, vault.ErrIncorrectPassword) {
+			os.Exit(exitWrongPassword)
+		}
 		fmt.Fprintln(w, "Error: something went wrong. Please check the log file for details.")
-		os.Exit(1)
+		os.Exit(exitError)
 	}
 }
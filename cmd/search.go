@@ -0,0 +1,259 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ompatil-15/coconut/internal/db/model"
+	"github.com/ompatil-15/coconut/internal/factory"
+	"github.com/ompatil-15/coconut/internal/fuzzy"
+	"github.com/ompatil-15/coconut/internal/query"
+	"github.com/spf13/cobra"
+)
+
+// maxFuzzyResults caps how many ranked candidates fuzzy lookups return.
+const maxFuzzyResults = 10
+
+func NewSearchCmd(f *factory.Factory) *cobra.Command {
+	var (
+		useFuzzy     bool
+		archivedOnly bool
+		showAll      bool
+		match        string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "search <query>",
+		Short: "Search secrets by name, username, URL, description or tag",
+		Long: `Search the vault for secrets matching a query (case-insensitive).
+
+A query is one or more terms separated by spaces. A secret must match all
+of them, or with --match any at least one:
+  word            any field contains word
+  field:word      the named field contains word
+  "two words"     quote values that contain spaces, e.g. name:"my bank"
+
+Fields: ` + strings.Join(query.Fields, ", ") + `. A tag: term must equal
+one of the secret's tags; the other fields match anywhere in the value.
+
+Use --fuzzy to tolerate typos and abbreviations in a plain query. Fuzzy
+results are ranked by how closely they match, best first. Passwords are
+never searched.
+
+Archived secrets are not searched unless --archived (only them) or --all
+is given.`,
+		Example: `  coconut search github
+  coconut search github personal
+  coconut search github gitlab --match any
+  coconut search tag:work url:github
+  coconut search 'name:"my bank" alice'
+  coconut search githb --fuzzy`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			raw := strings.Join(args, " ")
+			q, err := query.Parse(raw)
+			if err != nil {
+				return err
+			}
+			if useFuzzy && q.HasFields() {
+				return fmt.Errorf("--fuzzy does not support field:value terms")
+			}
+			match = strings.ToLower(match)
+			if match != "all" && match != "any" {
+				return fmt.Errorf("invalid --match %q: must be all or any", match)
+			}
+			if useFuzzy && match == "any" {
+				return fmt.Errorf("--fuzzy cannot be combined with --match any")
+			}
+			if archivedOnly && showAll {
+				return fmt.Errorf("--archived cannot be combined with --all")
+			}
+
+			if err := EnsureVaultUnlocked(f); err != nil {
+				return err
+			}
+
+			out := f.IO.Out
+
+			secrets, err := f.Secrets.ListMetadata()
+			if err != nil {
+				f.Logger.Error("failed to fetch secrets: %v", err)
+				return fmt.Errorf("failed to fetch secrets: %w", err)
+			}
+
+			var matches []listEntry
+			if useFuzzy {
+				matches = fuzzySearch(secrets, raw, searchFields)
+			} else {
+				matches = querySearch(secrets, q, match == "any")
+			}
+			matches = selectArchived(matches, archivedOnly, showAll)
+
+			f.Logger.Info("Search matched %d of %d secrets (fuzzy=%v)", len(matches), len(secrets), useFuzzy)
+
+			if len(matches) == 0 {
+				fmt.Fprintf(out, "No secrets match %q.\n", raw)
+				return nil
+			}
+
+			columns, _ := parseListFields(defaultListFields)
+			renderList(out, matches, columns, time.Now())
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&useFuzzy, "fuzzy", false, "Tolerate typos and rank results by similarity")
+	cmd.Flags().BoolVar(&archivedOnly, "archived", false, "Search only archived secrets")
+	cmd.Flags().BoolVar(&showAll, "all", false, "Include archived secrets")
+	cmd.Flags().StringVar(&match, "match", "all", "Whether secrets must match all terms or any of them (all, any)")
+
+	return cmd
+}
+
+// searchFields returns the text fields a secret can be found by.
+func searchFields(s *model.Secret) []string {
+	return []string{s.Name, s.Username, s.URL, s.Description}
+}
+
+// lookupFields returns the fields used to resolve 'get <name>'.
+func lookupFields(s *model.Secret) []string {
+	return []string{s.Name, s.Username, s.URL}
+}
+
+// querySearch keeps secrets that match every term of q, or with matchAny
+// at least one.
+func querySearch(secrets []model.Secret, q query.Query, matchAny bool) []listEntry {
+	match := q.Match
+	if matchAny {
+		match = q.MatchAny
+	}
+
+	var matches []listEntry
+	for i := range secrets {
+		if match(&secrets[i]) {
+			matches = append(matches, listEntry{index: i + 1, secret: secrets[i]})
+		}
+	}
+	return matches
+}
+
+// fuzzySearch ranks secrets by their best-scoring field, returning at most
+// maxFuzzyResults entries in descending score order.
+func fuzzySearch(secrets []model.Secret, query string, fields func(*model.Secret) []string) []listEntry {
+	// Rank the best field per secret by flattening to one candidate each
+	best := make([]string, len(secrets))
+	for i := range secrets {
+		topScore := -1
+		for _, field := range fields(&secrets[i]) {
+			if score, ok := fuzzy.Score(query, field); ok && score > topScore {
+				topScore = score
+				best[i] = field
+			}
+		}
+	}
+
+	var matches []listEntry
+	for _, m := range fuzzy.Rank(query, best, maxFuzzyResults) {
+		matches = append(matches, listEntry{index: m.Index + 1, secret: secrets[m.Index]})
+	}
+	return matches
+}
+
+// resolveSecretByName finds the secret a 'get <name>' refers to and returns
+// its 1-based index. A single exact name match is used directly; otherwise
+// ranked fuzzy candidates are offered for the user to pick from.
+func resolveSecretByName(f *factory.Factory, secrets []model.Secret, name string) (int, error) {
+	candidates := exactNameMatches(secrets, name)
+
+	if len(candidates) == 1 {
+		return candidates[0].index, nil
+	}
+
+	out := f.IO.Out
+	if len(candidates) == 0 {
+		candidates = fuzzySearch(secrets, name, lookupFields)
+		if len(candidates) == 0 {
+			return 0, fmt.Errorf("no secret matches %q", name)
+		}
+		fmt.Fprintf(out, "No exact match for %q. Did you mean:\n", name)
+	} else {
+		fmt.Fprintf(out, "Several secrets are named %q:\n", name)
+	}
+
+	for i, c := range candidates {
+		fmt.Fprintf(out, "  %d) %s (%s, %s)  [index %d]\n", i+1, c.secret.Name, c.secret.Username, c.secret.URL, c.index)
+	}
+	fmt.Fprintf(out, "Select an entry [1-%d], or press Enter to cancel: ", len(candidates))
+
+	choice, _ := readLine(f.IO.In)
+	choice = strings.TrimSpace(choice)
+	if choice == "" {
+		return 0, fmt.Errorf("no secret selected")
+	}
+
+	n, err := strconv.Atoi(choice)
+	if err != nil || n < 1 || n > len(candidates) {
+		return 0, fmt.Errorf("invalid selection: %s", choice)
+	}
+
+	return candidates[n-1].index, nil
+}
+
+// resolveSecretArg turns an <index|name> argument into a valid 1-based
+// index into secrets, resolving names as 'get' does.
+func resolveSecretArg(f *factory.Factory, secrets []model.Secret, arg string) (int, error) {
+	index, err := strconv.Atoi(arg)
+	if err != nil {
+		index, err = resolveSecretByName(f, secrets, arg)
+		if err != nil {
+			return 0, err
+		}
+	}
+	if index < 1 || index > len(secrets) {
+		return 0, fmt.Errorf("invalid index: %d (valid range: 1–%d)", index, len(secrets))
+	}
+	return index, nil
+}
+
+// parseIndexList parses a comma-separated list of indexes and ranges
+// such as "1,3,5-8" against a list of n secrets. The result is sorted and
+// has no duplicates.
+func parseIndexList(spec string, n int) ([]int, error) {
+	var indexes []int
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		lo, hi, isRange := strings.Cut(part, "-")
+		first, err := strconv.Atoi(strings.TrimSpace(lo))
+		if err != nil {
+			return nil, fmt.Errorf("invalid index %q in %q", part, spec)
+		}
+		last := first
+		if isRange {
+			if last, err = strconv.Atoi(strings.TrimSpace(hi)); err != nil || last < first {
+				return nil, fmt.Errorf("invalid range %q in %q", part, spec)
+			}
+		}
+		if first < 1 || last > n {
+			return nil, fmt.Errorf("invalid index: %s (valid range: 1–%d)", part, n)
+		}
+		for i := first; i <= last; i++ {
+			indexes = append(indexes, i)
+		}
+	}
+	slices.Sort(indexes)
+	return slices.Compact(indexes), nil
+}
+
+// exactNameMatches returns the secrets named name, ignoring case.
+func exactNameMatches(secrets []model.Secret, name string) []listEntry {
+	var matches []listEntry
+	for i, secret := range secrets {
+		if strings.EqualFold(secret.Name, name) {
+			matches = append(matches, listEntry{index: i + 1, secret: secret})
+		}
+	}
+	return matches
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/ompatil-15/coconut/internal/factory"
+	"github.com/spf13/cobra"
+)
+
+func NewSessionCmd(f *factory.Factory) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "session",
+		Short: "Manage the current unlock session",
+		Long:  `Inspect and control the cached session created when the vault is unlocked.`,
+	}
+
+	cmd.AddCommand(newSessionExtendCmd(f))
+
+	return cmd
+}
+
+func newSessionExtendCmd(f *factory.Factory) *cobra.Command {
+	return &cobra.Command{
+		Use:   "extend",
+		Short: "Reset the inactivity timer of the current session",
+		Long: `Refresh the activity timestamp of the current session without
+running any vault operation.
+
+This never prompts for a password or creates a session. If the vault is
+locked, it does nothing.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out := f.IO.Out
+
+			if !f.Session.IsValid() {
+				fmt.Fprintln(out, "No active session. Run 'coconut unlock' to start one.")
+				return nil
+			}
+
+			if err := f.Session.UpdateActivity(); err != nil {
+				f.Logger.Error("Failed to extend session: %v", err)
+				return fmt.Errorf("failed to extend session: %w", err)
+			}
+
+			f.Logger.Info("Session extended")
+			if f.Config.AutoLockSecs == 0 {
+				fmt.Fprintln(out, "Session extended. Autolock is disabled, so it stays active until you lock.")
+				return nil
+			}
+
+			minutes := int(f.Session.GetRemainingTime().Minutes())
+			fmt.Fprintf(out, "Session extended. It will lock after %d minutes of inactivity.\n", minutes)
+			if expiresAt := f.Session.ExpiresAt(); !expiresAt.IsZero() {
+				fmt.Fprintf(out, "Extending does not move the scheduled lock at %s.\n", expiresAt.Format("15:04"))
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ompatil-15/coconut/internal/config"
+	"github.com/ompatil-15/coconut/internal/crypto"
+	"github.com/ompatil-15/coconut/internal/factory"
+	"github.com/ompatil-15/coconut/internal/strength"
+	"github.com/ompatil-15/coconut/internal/vault"
+	"github.com/spf13/cobra"
+)
+
+// setupClipboardSecs is the clipboard timeout the wizard suggests; the
+// config default of never clearing suits scripts better than new users.
+const setupClipboardSecs = 30
+
+func NewSetupCmd(f *factory.Factory) *cobra.Command {
+	return &cobra.Command{
+		Use:   "setup",
+		Short: "Create a vault step by step (guided first-run setup)",
+		Long: `Walk through creating a new vault: the autolock timeout, clipboard
+clearing and access log first, then the master password. Nothing is
+written until the password is confirmed, and then the vault and the
+chosen settings are saved together. Press Enter to accept the suggested
+value shown in brackets.
+
+Every setting can be changed later with 'coconut config set'. setup needs
+an interactive terminal; use 'coconut init' in scripts. It refuses to run
+when a vault already exists.`,
+		Example: `  coconut setup`,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if vault.CheckVaultExists(f.System) {
+				return fmt.Errorf("a vault already exists; use 'coconut config set' to change its settings")
+			}
+			if !stdinIsTerminal(f) || f.PasswordStdin {
+				return fmt.Errorf("setup is interactive; use 'coconut init' to create a vault from a script")
+			}
+
+			out := f.IO.Out
+			cfg := config.Default()
+
+			fmt.Fprintln(out, "Welcome to coconut! This sets up a new vault in a few steps.")
+			fmt.Fprintln(out, "")
+
+			fmt.Fprintln(out, "1. Autolock: the vault locks after this much inactivity, and the master")
+			fmt.Fprintln(out, "   password is asked for again. 0 keeps it unlocked until 'coconut lock'.")
+			minutes, err := askNumber(f, "   Minutes", cfg.AutoLockSecs/60, 0, 24*60)
+			if err != nil {
+				return err
+			}
+			cfg.AutoLockSecs = minutes * 60
+
+			fmt.Fprintln(out, "2. Clipboard: a copied password is cleared after this many seconds,")
+			fmt.Fprintln(out, "   if it is still on the clipboard. 0 never clears it.")
+			if cfg.ClipboardClearSecs, err = askNumber(f, "   Seconds", setupClipboardSecs, 0, maxClipboardClearSecs); err != nil {
+				return err
+			}
+
+			fmt.Fprintln(out, "3. Access log: record which secrets are read, copied or changed, and")
+			fmt.Fprintln(out, "   when (never their values). Review it with 'coconut access-log'.")
+			if cfg.AccessLog, err = askYesNo(f, "   Keep an access log?", cfg.AccessLog); err != nil {
+				return err
+			}
+
+			fmt.Fprintln(out, "")
+			fmt.Fprintln(out, "4. Master password: it unlocks everything and cannot be recovered, so")
+			fmt.Fprintln(out, "   pick one you will remember. A long passphrase of random words works")
+			fmt.Fprintln(out, "   well ('coconut generate --words 6' makes one once the vault exists).")
+			password, err := askMasterPassword(f)
+			if err != nil {
+				return err
+			}
+
+			// A vault written by a broken build might never open again.
+			if err := crypto.SelfTest(f.Crypto); err != nil {
+				f.Logger.Error("%v", err)
+				return fmt.Errorf("%w; refusing to create a vault with this build", err)
+			}
+
+			params := crypto.DefaultKDFParams()
+			f.IO.StartProgressIndicator("Creating vault...")
+			err = createVault(f.System, params, password, cfg)
+			f.IO.StopProgressIndicator()
+			if err != nil {
+				return err
+			}
+
+			f.Logger.Info("Vault created by setup (AES-%d, %s, autolock=%ds, clipboard-timeout=%ds, access-log=%s)",
+				params.KeyBits(), params.Algorithm, cfg.AutoLockSecs, cfg.ClipboardClearSecs, onOff(cfg.AccessLog))
+			fmt.Fprintln(out, "")
+			fmt.Fprintln(out, "Vault created successfully!")
+			fmt.Fprintln(out, "")
+			fmt.Fprintln(out, "Next steps:")
+			fmt.Fprintln(out, "  - Add a secret:         coconut add -u username -p password")
+			fmt.Fprintln(out, "  - Import from LastPass: coconut import --format lastpass <file.csv>")
+			fmt.Fprintln(out, "  - Change a setting:     coconut config set <setting> <value>")
+			return nil
+		},
+	}
+}
+
+// askMasterPassword reads the master password twice. A password rated
+// weak has to be confirmed before it is used.
+func askMasterPassword(f *factory.Factory) (string, error) {
+	for {
+		password, err := promptPasswordTwice()
+		if err != nil {
+			return "", err
+		}
+		if password == "" {
+			fmt.Fprintln(f.IO.Out, "The master password cannot be empty.")
+			continue
+		}
+
+		estimate := strength.Of(password)
+		if estimate.Rating != strength.VeryWeak && estimate.Rating != strength.Weak {
+			return password, nil
+		}
+		fmt.Fprintf(f.IO.Out, "   That password is %s (about %.0f bits).\n", estimate.Rating, estimate.Bits)
+		ok, err := askYesNo(f, "   Use it anyway?", false)
+		if err != nil || ok {
+			return password, err
+		}
+	}
+}
+
+// askNumber asks for a whole number between lo and hi, offering def.
+func askNumber(f *factory.Factory, prompt string, def, lo, hi int) (int, error) {
+	for {
+		answer, err := ask(f, fmt.Sprintf("%s [%d]: ", prompt, def))
+		if err != nil || answer == "" {
+			return def, err
+		}
+		n, err := strconv.Atoi(answer)
+		if err == nil && n >= lo && n <= hi {
+			return n, nil
+		}
+		fmt.Fprintf(f.IO.Out, "   Enter a number from %d to %d.\n", lo, hi)
+	}
+}
+
+// askYesNo asks a yes/no question, offering def.
+func askYesNo(f *factory.Factory, prompt string, def bool) (bool, error) {
+	hint := "y/N"
+	if def {
+		hint = "Y/n"
+	}
+	for {
+		answer, err := ask(f, fmt.Sprintf("%s [%s]: ", prompt, hint))
+		if err != nil || answer == "" {
+			return def, err
+		}
+		switch strings.ToLower(answer) {
+		case "y":
+			return true, nil
+		case "n":
+			return false, nil
+		}
+		if yes, err := parseOnOff(answer); err == nil {
+			return yes, nil
+		}
+		fmt.Fprintln(f.IO.Out, "   Answer y or n.")
+	}
+}
+
+// ask prints prompt and returns the trimmed answer.
+func ask(f *factory.Factory, prompt string) (string, error) {
+	fmt.Fprint(f.IO.Out, prompt)
+	answer, err := readLine(f.IO.In)
+	if err != nil {
+		return "", errors.New("setup cancelled")
+	}
+	return strings.TrimSpace(answer), nil
+}
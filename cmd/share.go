@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ompatil-15/coconut/internal/db/model"
+	"github.com/ompatil-15/coconut/internal/factory"
+	"github.com/ompatil-15/coconut/internal/merge"
+	"github.com/ompatil-15/coconut/internal/share"
+	"github.com/ompatil-15/coconut/internal/wordlist"
+	"github.com/spf13/cobra"
+)
+
+// sharePassphraseWords is the length of a generated share passphrase,
+// about 77 bits of entropy with the EFF wordlist.
+const sharePassphraseWords = 6
+
+func NewShareCmd(f *factory.Factory) *cobra.Command {
+	var (
+		out   string
+		force bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "share <index|name>",
+		Short: "Encrypt one secret into a file for another coconut user",
+		Long: `Encrypt a single secret into a self-contained file that another coconut
+user can add to their vault with 'coconut receive'.
+
+The file is protected by a one-time passphrase that is generated for it
+and printed once. Send the passphrase to the recipient separately from
+the file, e.g. read it out over a call. The file does not depend on your
+master password or vault key and reveals nothing about them.
+
+The file holds the secret's name, username, password, URL, description,
+tags and expiry. It is written with mode 0600 and is not overwritten
+unless --force is given.`,
+		Example: `  coconut share 3 --out github.coco
+  coconut share github --out github.coco`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := EnsureVaultUnlocked(f); err != nil {
+				return err
+			}
+
+			secrets, err := f.Secrets.List()
+			if err != nil {
+				f.Logger.Error("failed to fetch secrets: %v", err)
+				return fmt.Errorf("failed to fetch secrets: %w", err)
+			}
+
+			index, err := resolveSecretArg(f, secrets, args[0])
+			if err != nil {
+				return err
+			}
+			secret := secrets[index-1]
+			if err := checkPIN(f, index, &secret); err != nil {
+				return err
+			}
+
+			passphrase := generatePassphrase(wordlist.Default(), sharePassphraseWords, "-")
+
+			f.IO.StartProgressIndicator("Encrypting...")
+			data, err := share.Seal(secret, passphrase, time.Now())
+			f.IO.StopProgressIndicator()
+			if err != nil {
+				return fmt.Errorf("failed to encrypt secret: %w", err)
+			}
+
+			if err := writeSecretFile(out, []string{string(data)}, force); err != nil {
+				return err
+			}
+
+			f.Logger.Access("share", accessTarget(index, &secret))
+			fmt.Fprintf(f.IO.Out, "Secret %d (%s) written to %s.\n", index, mergeLabel(secret), out)
+			fmt.Fprintln(f.IO.Out, "")
+			fmt.Fprintf(f.IO.Out, "One-time passphrase: %s\n", passphrase)
+			fmt.Fprintln(f.IO.Out, "")
+			fmt.Fprintln(f.IO.Out, "Send the passphrase separately from the file. It is not stored anywhere")
+			fmt.Fprintln(f.IO.Out, "and cannot be shown again.")
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&out, "out", "o", "", "File to write the encrypted secret to")
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "Overwrite the output file if it exists")
+	cmd.MarkFlagRequired("out")
+
+	return cmd
+}
+
+func NewReceiveCmd(f *factory.Factory) *cobra.Command {
+	var in string
+
+	cmd := &cobra.Command{
+		Use:   "receive",
+		Short: "Add a secret shared with 'coconut share' to this vault",
+		Long: `Decrypt a file written by 'coconut share' and add its secret to this vault.
+
+You are asked for the one-time passphrase the sender gave you. With
+--password-stdin it is read from the line after the master password.
+A secret that is already in the vault unchanged is not added again.`,
+		Example: `  coconut receive --in github.coco`,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := os.ReadFile(in)
+			if err != nil {
+				return fmt.Errorf("failed to read share file: %w", err)
+			}
+
+			if err := EnsureVaultUnlocked(f); err != nil {
+				return err
+			}
+
+			passphrase, err := readExtraPassword(f, "Enter the share passphrase: ")
+			if err != nil {
+				return fmt.Errorf("failed to read passphrase: %w", err)
+			}
+
+			f.IO.StartProgressIndicator("Decrypting...")
+			secret, env, err := share.Open(data, passphrase)
+			f.IO.StopProgressIndicator()
+			if errors.Is(err, share.ErrWrongPassphrase) {
+				f.Logger.Warn("receive of %s failed: %v", in, err)
+				return err
+			}
+			if err != nil {
+				return err
+			}
+
+			existing, err := f.Secrets.List()
+			if err != nil {
+				f.Logger.Error("failed to fetch secrets: %v", err)
+				return fmt.Errorf("failed to fetch secrets: %w", err)
+			}
+			for i, s := range existing {
+				if merge.SameContent(s, withVaultFields(secret, s)) {
+					fmt.Fprintf(f.IO.Out, "Secret %d (%s) is already in your vault; nothing added.\n", i+1, mergeLabel(s))
+					return nil
+				}
+			}
+
+			now := time.Now()
+			secret.ID = uuid.New().String()
+			secret.Tags = model.NormalizeTags(secret.Tags)
+			secret.CreatedAt = now
+			secret.UpdatedAt = now
+
+			if _, err := f.Secrets.Add(secret); err != nil {
+				f.Logger.Error("failed to add shared secret: %v", err)
+				return fmt.Errorf("failed to add secret: %w", err)
+			}
+
+			f.Logger.Info("Received shared secret from %s (shared %s)", in, env.SharedAt.Format(time.RFC3339))
+			fmt.Fprintf(f.IO.Out, "Added '%s' to your vault.\n", mergeLabel(secret))
+			fmt.Fprintf(f.IO.ErrOut, "You can now delete %s.\n", in)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&in, "in", "i", "", "Share file to read")
+	cmd.MarkFlagRequired("in")
+
+	return cmd
+}
+
+// withVaultFields copies the fields a share file leaves out from s into
+// shared, so the two can be compared on shared content alone.
+func withVaultFields(shared, s model.Secret) model.Secret {
+	shared.IsFavorite = s.IsFavorite
+	return shared
+}
@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/ompatil-15/coconut/internal/db/model"
+	"github.com/ompatil-15/coconut/internal/factory"
+	"github.com/spf13/cobra"
+)
+
+// untaggedLabel is the bucket used in tag counts for secrets without tags.
+const untaggedLabel = "(untagged)"
+
+// vaultStats is the report produced by 'coconut stats'. Field names double
+// as the --json schema.
+type vaultStats struct {
+	Total          int            `json:"total"`
+	ByTag          map[string]int `json:"byTag"`
+	AvgLength      float64        `json:"avgPasswordLength"`
+	MedianLength   float64        `json:"medianPasswordLength"`
+	ReusedSecrets  int            `json:"reusedSecrets"`
+	ReusedGroups   int            `json:"reusedGroups"`
+	WithURL        int            `json:"withUrl"`
+	WithURLPercent float64        `json:"withUrlPercent"`
+	Oldest         *statsEntry    `json:"oldest,omitempty"`
+	Newest         *statsEntry    `json:"newest,omitempty"`
+}
+
+// statsEntry identifies a secret in the report without exposing its password.
+type statsEntry struct {
+	Index     int       `json:"index"`
+	Name      string    `json:"name,omitempty"`
+	Username  string    `json:"username"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func NewStatsCmd(f *factory.Factory) *cobra.Command {
+	var asJSON bool
+
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Show statistics about your vault",
+		Long: `Summarise the secrets in your vault: totals, counts by tag, password
+lengths, reuse, URL coverage, and the oldest and newest entries by last
+update. Reuse is detected the same way as 'coconut audit --reused'.
+
+Passwords are never printed.`,
+		Example: `  coconut stats
+  coconut stats --json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if asJSON {
+				f.IO.SetProgressEnabled(false)
+			}
+
+			if err := EnsureVaultUnlocked(f); err != nil {
+				return err
+			}
+
+			secrets, err := f.Secrets.List()
+			if err != nil {
+				f.Logger.Error("failed to fetch secrets: %v", err)
+				return fmt.Errorf("failed to fetch secrets: %w", err)
+			}
+
+			stats := computeStats(secrets)
+			f.Logger.Info("Computed stats over %d secrets", stats.Total)
+
+			if asJSON {
+				enc := json.NewEncoder(f.IO.Out)
+				enc.SetIndent("", "  ")
+				return enc.Encode(stats)
+			}
+
+			printStats(f.IO.Out, stats)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&asJSON, "json", false, "Output the report as JSON")
+
+	return cmd
+}
+
+func computeStats(secrets []model.Secret) vaultStats {
+	stats := vaultStats{
+		Total: len(secrets),
+		ByTag: make(map[string]int),
+	}
+	if len(secrets) == 0 {
+		return stats
+	}
+
+	lengths := make([]int, len(secrets))
+	sum := 0
+	for i, secret := range secrets {
+		lengths[i] = len([]rune(secret.Password))
+		sum += lengths[i]
+
+		if secret.URL != "" {
+			stats.WithURL++
+		}
+
+		if len(secret.Tags) == 0 {
+			stats.ByTag[untaggedLabel]++
+		}
+		for _, tag := range secret.Tags {
+			stats.ByTag[tag]++
+		}
+
+		if stats.Oldest == nil || secret.UpdatedAt.Before(stats.Oldest.UpdatedAt) {
+			stats.Oldest = newStatsEntry(i, &secret)
+		}
+		if stats.Newest == nil || secret.UpdatedAt.After(stats.Newest.UpdatedAt) {
+			stats.Newest = newStatsEntry(i, &secret)
+		}
+	}
+
+	slices.Sort(lengths)
+	mid := len(lengths) / 2
+	if len(lengths)%2 == 0 {
+		stats.MedianLength = float64(lengths[mid-1]+lengths[mid]) / 2
+	} else {
+		stats.MedianLength = float64(lengths[mid])
+	}
+	stats.AvgLength = float64(sum) / float64(len(secrets))
+	stats.WithURLPercent = 100 * float64(stats.WithURL) / float64(len(secrets))
+
+	groups := reusedPasswords(secrets)
+	stats.ReusedGroups = len(groups)
+	for _, group := range groups {
+		stats.ReusedSecrets += len(group)
+	}
+
+	return stats
+}
+
+func newStatsEntry(i int, secret *model.Secret) *statsEntry {
+	return &statsEntry{
+		Index:     i + 1,
+		Name:      secret.Name,
+		Username:  secret.Username,
+		UpdatedAt: secret.UpdatedAt,
+	}
+}
+
+func printStats(out io.Writer, stats vaultStats) {
+	fmt.Fprintf(out, "Total secrets:        %d\n", stats.Total)
+	if stats.Total == 0 {
+		return
+	}
+
+	fmt.Fprintf(out, "Password length:      avg %.1f, median %.1f\n", stats.AvgLength, stats.MedianLength)
+	fmt.Fprintf(out, "Reused passwords:     %d secrets in %d groups\n", stats.ReusedSecrets, stats.ReusedGroups)
+	fmt.Fprintf(out, "With URL:             %d (%.0f%%)\n", stats.WithURL, stats.WithURLPercent)
+	fmt.Fprintf(out, "Oldest (by update):   %s\n", formatStatsEntry(stats.Oldest))
+	fmt.Fprintf(out, "Newest (by update):   %s\n", formatStatsEntry(stats.Newest))
+
+	tags := make([]string, 0, len(stats.ByTag))
+	for tag := range stats.ByTag {
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if stats.ByTag[tags[i]] != stats.ByTag[tags[j]] {
+			return stats.ByTag[tags[i]] > stats.ByTag[tags[j]]
+		}
+		return tags[i] < tags[j]
+	})
+
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "By tag:")
+	for _, tag := range tags {
+		fmt.Fprintf(out, "  %-20s %d\n", tag, stats.ByTag[tag])
+	}
+
+	if stats.ReusedGroups > 0 {
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Run 'coconut audit --reused' to see which secrets share a password.")
+	}
+}
+
+func formatStatsEntry(e *statsEntry) string {
+	label := e.Username
+	if e.Name != "" {
+		label = e.Name + " (" + e.Username + ")"
+	}
+	return fmt.Sprintf("#%d %s, %s", e.Index, label, e.UpdatedAt.Format("2006-01-02"))
+}
@@ -0,0 +1,286 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/ompatil-15/coconut/internal/db"
+	"github.com/ompatil-15/coconut/internal/db/model"
+	"github.com/ompatil-15/coconut/internal/factory"
+	"github.com/spf13/cobra"
+)
+
+// maxTagLen bounds a single tag so it fits the list's TAGS column.
+const maxTagLen = 30
+
+func NewTagCmd(f *factory.Factory) *cobra.Command {
+	var oldTag, newTag string
+
+	cmd := &cobra.Command{
+		Use:   "tag",
+		Short: "Add, remove or rename tags",
+		Long: `Add or remove tags on secrets without going through 'update', or
+rename a tag on every secret that has it with --tag and --rename.
+
+Tags are lowercased and trimmed, and duplicates are dropped, so "Work"
+and "work" are the same tag. A tag may not be blank, contain commas or
+control characters, or be longer than 30 characters.`,
+		Example: `  coconut tag --tag work --rename job`,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if oldTag == "" && newTag == "" {
+				return cmd.Help()
+			}
+			if oldTag == "" || newTag == "" {
+				return fmt.Errorf("--tag and --rename must be used together")
+			}
+			return renameTag(f, oldTag, newTag)
+		},
+	}
+
+	cmd.Flags().StringVar(&oldTag, "tag", "", "Tag to rename on every secret")
+	cmd.Flags().StringVar(&newTag, "rename", "", "New name for the --tag tag")
+
+	cmd.AddCommand(newTagAddCmd(f))
+	cmd.AddCommand(newTagRemoveCmd(f))
+
+	return cmd
+}
+
+func newTagAddCmd(f *factory.Factory) *cobra.Command {
+	var indexes string
+
+	cmd := &cobra.Command{
+		Use:   "add <index|name> <tag>...",
+		Short: "Add tags to one or more secrets",
+		Long: `Add tags to a secret. With --index, every argument is a tag and they
+are added to each secret in the list, e.g. a batch that was just
+imported. Indexes refer to one listing of the vault taken before any
+change, so they stay valid throughout.`,
+		Example: `  coconut tag add 3 work
+  coconut tag add github work shared
+  coconut tag add --index 1,3,5-8 work personal`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if indexes != "" {
+				return cobra.MinimumNArgs(1)(cmd, args)
+			}
+			return cobra.MinimumNArgs(2)(cmd, args)
+		},
+		ValidArgsFunction: completeSecretNames(f),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if indexes != "" {
+				tags, err := parseTagArgs(args)
+				if err != nil {
+					return err
+				}
+				return addTagsToIndexes(f, indexes, tags)
+			}
+
+			tags, err := parseTagArgs(args[1:])
+			if err != nil {
+				return err
+			}
+
+			return editTags(f, args[0], func(secret *model.Secret) []string {
+				return addTags(secret, tags)
+			}, "added to", "already has")
+		},
+	}
+
+	cmd.Flags().StringVar(&indexes, "index", "", "Comma-separated indexes and ranges to tag, e.g. 1,3,5-8")
+
+	return cmd
+}
+
+// addTags adds the tags secret does not have yet and returns them.
+func addTags(secret *model.Secret, tags []string) []string {
+	var added []string
+	for _, tag := range tags {
+		if !slices.Contains(secret.Tags, tag) {
+			secret.Tags = append(secret.Tags, tag)
+			added = append(added, tag)
+		}
+	}
+	return added
+}
+
+func newTagRemoveCmd(f *factory.Factory) *cobra.Command {
+	return &cobra.Command{
+		Use:               "remove <index|name> <tag>...",
+		Aliases:           []string{"rm"},
+		Short:             "Remove tags from a secret",
+		Example:           `  coconut tag remove 3 work`,
+		Args:              cobra.MinimumNArgs(2),
+		ValidArgsFunction: completeSecretNames(f),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tags, err := parseTagArgs(args[1:])
+			if err != nil {
+				return err
+			}
+
+			return editTags(f, args[0], func(secret *model.Secret) []string {
+				var removed []string
+				secret.Tags = slices.DeleteFunc(secret.Tags, func(tag string) bool {
+					if slices.Contains(tags, tag) {
+						removed = append(removed, tag)
+						return true
+					}
+					return false
+				})
+				return removed
+			}, "removed from", "does not have")
+		},
+	}
+}
+
+// editTags applies edit to the normalized tags of the secret arg refers
+// to and saves it if edit reports any changed tags. done and unchanged
+// complete the messages shown for each outcome.
+func editTags(f *factory.Factory, arg string, edit func(secret *model.Secret) []string, done, unchanged string) error {
+	index, secret, err := loadSecretArg(f, arg)
+	if err != nil {
+		return err
+	}
+
+	secret.Tags = model.NormalizeTags(secret.Tags)
+	changed := edit(secret)
+	if len(changed) == 0 {
+		fmt.Fprintf(f.IO.Out, "Secret %d %s those tags.\n", index, unchanged)
+		return nil
+	}
+
+	if err := f.Secrets.Update(*secret); err != nil {
+		f.Logger.Error("failed to update tags: %v", err)
+		return fmt.Errorf("failed to update secret: %w", err)
+	}
+
+	f.Logger.Info("Tags %s secret %d: %s", done, index, strings.Join(changed, ", "))
+	fmt.Fprintf(f.IO.Out, "Tag(s) %s %s secret %d (%s).\n", strings.Join(changed, ", "), done, index, mergeLabel(*secret))
+	return nil
+}
+
+// addTagsToIndexes adds tags to every secret in the index list spec,
+// resolved against a single listing and saved in one transaction.
+func addTagsToIndexes(f *factory.Factory, spec string, tags []string) error {
+	if err := EnsureVaultUnlocked(f); err != nil {
+		return err
+	}
+
+	secrets, err := f.Secrets.List()
+	if err != nil {
+		f.Logger.Error("failed to fetch secrets: %v", err)
+		return fmt.Errorf("failed to fetch secrets: %w", err)
+	}
+	indexes, err := parseIndexList(spec, len(secrets))
+	if err != nil {
+		return err
+	}
+
+	var targets []listEntry
+	for _, index := range indexes {
+		secret := secrets[index-1]
+		secret.Tags = model.NormalizeTags(secret.Tags)
+		if len(addTags(&secret, tags)) > 0 {
+			targets = append(targets, listEntry{index: index, secret: secret})
+		}
+	}
+
+	return saveTagEdits(f, targets, fmt.Sprintf("Tag(s) %s added to", strings.Join(tags, ", ")), len(indexes))
+}
+
+// renameTag replaces oldTag with newTag on every secret that has it.
+func renameTag(f *factory.Factory, oldTag, newTag string) error {
+	tags, err := parseTagArgs([]string{oldTag, newTag})
+	if err != nil {
+		return err
+	}
+	if len(tags) == 1 {
+		return fmt.Errorf("--tag and --rename name the same tag")
+	}
+	oldTag, newTag = tags[0], tags[1]
+
+	if err := EnsureVaultUnlocked(f); err != nil {
+		return err
+	}
+
+	secrets, err := f.Secrets.List()
+	if err != nil {
+		f.Logger.Error("failed to fetch secrets: %v", err)
+		return fmt.Errorf("failed to fetch secrets: %w", err)
+	}
+
+	var targets []listEntry
+	for i, secret := range secrets {
+		secret.Tags = model.NormalizeTags(secret.Tags)
+		at := slices.Index(secret.Tags, oldTag)
+		if at < 0 {
+			continue
+		}
+		secret.Tags[at] = newTag
+		secret.Tags = model.NormalizeTags(secret.Tags)
+		targets = append(targets, listEntry{index: i + 1, secret: secret})
+	}
+	if len(targets) == 0 {
+		fmt.Fprintf(f.IO.Out, "No secrets are tagged %s.\n", oldTag)
+		return nil
+	}
+
+	return saveTagEdits(f, targets, fmt.Sprintf("Tag %s renamed to %s on", oldTag, newTag), len(targets))
+}
+
+// saveTagEdits saves the retagged targets in one transaction, so either
+// all of them change or none do, and reports how many of the considered
+// secrets were affected.
+func saveTagEdits(f *factory.Factory, targets []listEntry, done string, considered int) error {
+	if len(targets) > 0 {
+		err := f.Secrets.Batch(func(repo db.SecretRepository) error {
+			for _, e := range targets {
+				if err := repo.Update(e.secret); err != nil {
+					return fmt.Errorf("update secret %d: %w", e.index, err)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			f.Logger.Error("failed to update tags, nothing changed: %v", err)
+			return fmt.Errorf("failed to update secrets (nothing was changed): %w", err)
+		}
+	}
+
+	f.Logger.Info("%s %d secret(s)", done, len(targets))
+	fmt.Fprintf(f.IO.Out, "%s %d secret(s)", done, len(targets))
+	if skipped := considered - len(targets); skipped > 0 {
+		fmt.Fprintf(f.IO.Out, "; %d already had them", skipped)
+	}
+	fmt.Fprintln(f.IO.Out, ".")
+	return nil
+}
+
+// parseTagArgs validates and normalizes tags given on the command line.
+func parseTagArgs(args []string) ([]string, error) {
+	for _, tag := range args {
+		if err := validateTag(tag); err != nil {
+			return nil, err
+		}
+	}
+	return model.NormalizeTags(args), nil
+}
+
+// validateTag rejects tags that would not survive a round trip through
+// --tag flags and the comma-separated TAGS column.
+func validateTag(tag string) error {
+	trimmed := strings.TrimSpace(tag)
+	switch {
+	case trimmed == "":
+		return fmt.Errorf("tag %q is blank", tag)
+	case strings.Contains(trimmed, ","):
+		return fmt.Errorf("tag %q contains a comma", tag)
+	case strings.ContainsFunc(trimmed, unicode.IsControl):
+		return fmt.Errorf("tag %q contains a control character", tag)
+	case len(trimmed) > maxTagLen:
+		return fmt.Errorf("tag %q is longer than %d characters", tag, maxTagLen)
+	}
+	return nil
+}
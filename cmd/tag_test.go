@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseTagArgs(t *testing.T) {
+	tags, err := parseTagArgs([]string{"Work", " shared ", "work"})
+	if err != nil {
+		t.Fatalf("parseTagArgs failed: %v", err)
+	}
+	if !slices.Equal(tags, []string{"work", "shared"}) {
+		t.Errorf("Expected normalized, de-duplicated tags, got %v", tags)
+	}
+
+	for _, bad := range []string{"", "   ", "a,b", "tab\there", "this-tag-is-far-too-long-to-be-useful"} {
+		if _, err := parseTagArgs([]string{"ok", bad}); err == nil {
+			t.Errorf("Expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestParseIndexList(t *testing.T) {
+	got, err := parseIndexList("5-8, 1,3,6", 10)
+	if err != nil {
+		t.Fatalf("parseIndexList failed: %v", err)
+	}
+	if want := []int{1, 3, 5, 6, 7, 8}; !slices.Equal(got, want) {
+		t.Errorf("parseIndexList = %v, want %v", got, want)
+	}
+
+	for _, bad := range []string{"", "0", "11", "2-11", "4-2", "a", "1,,2", "-3", "1-"} {
+		if _, err := parseIndexList(bad, 10); err == nil {
+			t.Errorf("Expected %q to be rejected", bad)
+		}
+	}
+}
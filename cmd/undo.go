@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ompatil-15/coconut/internal/factory"
+	"github.com/ompatil-15/coconut/internal/undo"
+	"github.com/spf13/cobra"
+)
+
+func NewUndoCmd(f *factory.Factory) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "undo",
+		Short: "Undo the last update or delete",
+		Long: `Restore a secret to the state it was in before the most recent
+update or delete.
+
+Only the single most recent operation can be undone. Once restored, the
+undo history is cleared.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := EnsureVaultUnlocked(f); err != nil {
+				return err
+			}
+
+			out := f.IO.Out
+
+			entry, err := f.Undo.Last()
+			if errors.Is(err, undo.ErrNothingToUndo) {
+				fmt.Fprintln(out, "Nothing to undo.")
+				return nil
+			}
+			if err != nil {
+				f.Logger.Error("failed to load undo entry: %v", err)
+				return fmt.Errorf("failed to load undo entry: %w", err)
+			}
+
+			// Add writes the record under its original ID and keeps its
+			// timestamps, which restores both deleted and updated secrets.
+			if _, err := f.Secrets.Add(entry.Previous); err != nil {
+				f.Logger.Error("failed to restore secret: %v", err)
+				return fmt.Errorf("failed to restore secret: %w", err)
+			}
+
+			if err := f.Undo.Clear(); err != nil {
+				f.Logger.Warn("failed to clear undo entry: %v", err)
+			}
+
+			f.Logger.Info("Undid %s of secret %s", entry.Op, entry.Previous.ID)
+			fmt.Fprintf(out, "Undid %s of '%s' (from %s).\n",
+				entry.Op, entry.Previous.Username, entry.RecordedAt.Format("2006-01-02 15:04"))
+			return nil
+		},
+	}
+
+	return cmd
+}
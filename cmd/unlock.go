@@ -2,12 +2,20 @@ package cmd
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/ompatil-15/coconut/internal/factory"
+	"github.com/ompatil-15/coconut/internal/timeutil"
+	"github.com/ompatil-15/coconut/internal/vault"
 	"github.com/spf13/cobra"
 )
 
 func NewUnlockCmd(f *factory.Factory) *cobra.Command {
+	var (
+		expireIn   string
+		verifyOnly bool
+	)
+
 	cmd := &cobra.Command{
 		Use:   "unlock",
 		Short: "Pre-unlock the vault with your master password (optional)",
@@ -16,8 +24,44 @@ func NewUnlockCmd(f *factory.Factory) *cobra.Command {
 Note: This command is optional. All secret management commands will 
 automatically prompt for your master password if the vault is locked.
 
-Use 'coconut lock' to lock the vault when done.`,
+Use 'coconut lock' to lock the vault when done.
+
+Use --expire-in to also end the session at a fixed time from now, no
+matter how active it is. The inactivity timeout still applies as well.
+
+Use --verify-only to check that a master password still unlocks the
+vault, e.g. from a monitoring job. The password is always read (from
+--password-stdin, $COCONUT_MASTER_PASSWORD or the prompt), even during
+an active session, and the key is discarded afterwards: no session is
+created and nothing is written. The exit code is 0 when the password is
+correct, 2 when it is wrong and 1 for any other failure.`,
+		Example: `  coconut unlock
+  coconut unlock --expire-in 30m
+  echo "$MASTER" | coconut --password-stdin unlock --verify-only`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if verifyOnly {
+				if expireIn != "" {
+					return fmt.Errorf("--verify-only cannot be combined with --expire-in")
+				}
+				if err := verifyMasterPassword(f); err != nil {
+					return err
+				}
+				fmt.Fprintln(f.IO.Out, "Master password verified. No session was created.")
+				return nil
+			}
+
+			var lifetime time.Duration
+			if expireIn != "" {
+				if f.NoSession {
+					return fmt.Errorf("--expire-in cannot be used with --no-session")
+				}
+				d, err := timeutil.ParseDuration(expireIn)
+				if err != nil || d <= 0 {
+					return fmt.Errorf("invalid --expire-in %q: use a positive duration like 30m or 2h", expireIn)
+				}
+				lifetime = d
+			}
+
 			// Check if already unlocked
 			if f.Vault != nil && f.Vault.IsUnlocked() && f.Session.IsValid() {
 				fmt.Println("Vault is already unlocked")
@@ -29,6 +73,27 @@ Use 'coconut lock' to lock the vault when done.`,
 				return err
 			}
 
+			if f.NoSession {
+				f.Logger.Info("Vault unlock verified without creating a session")
+				fmt.Println("Password verified. No session was created (--no-session).")
+				return nil
+			}
+
+			// CreateSession refuses to cache the key where it cannot be
+			// bound to this machine; say so rather than claim a session.
+			if !f.Session.IsValid() {
+				fmt.Println("Password verified, but no session could be created on this machine; see the log for why.")
+				return nil
+			}
+
+			if lifetime > 0 {
+				if err := f.Session.SetExpiry(time.Now().Add(lifetime)); err != nil {
+					f.Logger.Error("Failed to set session expiry: %v", err)
+					return fmt.Errorf("failed to set session expiry: %w", err)
+				}
+				f.Logger.Info("Session scheduled to expire in %s", lifetime)
+			}
+
 			remaining := f.Session.GetRemainingTime()
 			minutes := int(remaining.Minutes())
 
@@ -36,6 +101,9 @@ Use 'coconut lock' to lock the vault when done.`,
 			fmt.Println("Vault unlocked successfully!")
 			fmt.Println("")
 			fmt.Printf("Session created: You won't need to re-enter your password for %d minutes.\n", minutes)
+			if expiresAt := f.Session.ExpiresAt(); !expiresAt.IsZero() {
+				fmt.Printf("The session ends at %s regardless of activity.\n", expiresAt.Format("15:04"))
+			}
 			fmt.Println("")
 			fmt.Println("You can now:")
 			fmt.Println("  - Add secrets:    coconut add -u username -p password")
@@ -48,7 +116,43 @@ Use 'coconut lock' to lock the vault when done.`,
 		},
 	}
 
+	cmd.Flags().StringVar(&expireIn, "expire-in", "", "End the session after this long regardless of activity (e.g. 30m, 2h)")
+	cmd.Flags().BoolVar(&verifyOnly, "verify-only", false, "Check the master password without creating a session or changing anything")
+
 	return cmd
 }
 
+// verifyMasterPassword reads the master password, checks it against the
+// vault's verification token and discards the key. Unlike
+// EnsureVaultUnlocked it ignores any session and leaves the factory's
+// vault and stores untouched.
+func verifyMasterPassword(f *factory.Factory) error {
+	if !vault.CheckVaultExists(f.System) {
+		return vault.ErrVaultNotFound
+	}
+
+	salt, err := f.System.Get("salt")
+	if err != nil {
+		return fmt.Errorf("failed to retrieve vault salt: %w", err)
+	}
+	params, err := vault.LoadKDFParams(f.System)
+	if err != nil {
+		return err
+	}
+
+	key, err := promptForPasswordAndDeriveKey(f, salt, params)
+	if err != nil {
+		return err
+	}
+
+	check := vault.UnlockWithKey(f.Crypto, salt, key)
+	defer check.Lock()
 
+	if err := vault.VerifyVaultPassword(f.System, check); err != nil {
+		f.Logger.Warn("Master password verification failed")
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	f.Logger.Info("Master password verified without unlocking the vault")
+	return nil
+}
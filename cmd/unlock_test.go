@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ompatil-15/coconut/internal/factory"
+	"github.com/spf13/cobra"
+)
+
+func TestNoSession_IgnoresExistingSession(t *testing.T) {
+	f := newTestFactory(t, testPassword+"\n")
+
+	// A session under some other key would fail verification if it were used.
+	if err := f.Session.CreateSession(bytes.Repeat([]byte{7}, 32)); err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	before, err := f.System.Get("session:data")
+	if err != nil {
+		t.Fatalf("Get session:data failed: %v", err)
+	}
+
+	f.NoSession = true
+	if err := executeCommand(f, NewListCmd(f)); err != nil {
+		t.Fatalf("list --no-session failed, so the cached key was used: %v", err)
+	}
+	if !f.Vault.IsUnlocked() {
+		t.Error("Expected the vault unlocked with the password from stdin")
+	}
+	if after, err := f.System.Get("session:data"); err != nil || !bytes.Equal(before, after) {
+		t.Errorf("Expected the existing session left untouched, got %q, %v", after, err)
+	}
+}
+
+func TestNoSession_WritesNoSession(t *testing.T) {
+	tests := []struct {
+		name   string
+		newCmd func(*factory.Factory) *cobra.Command
+	}{
+		{"list", NewListCmd},
+		{"unlock", NewUnlockCmd},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newTestFactory(t, testPassword+"\n")
+			f.NoSession = true
+
+			cmd := tt.newCmd(f)
+			cmd.SetArgs([]string{})
+			if err := executeCommand(f, cmd); err != nil {
+				t.Fatalf("%s --no-session failed: %v", tt.name, err)
+			}
+			if hasSession(t, f) {
+				t.Errorf("%s --no-session wrote session:data or session:key", tt.name)
+			}
+		})
+	}
+}
+
+func TestUnlock_NoSessionRejectsExpireIn(t *testing.T) {
+	f := newTestFactory(t, testPassword+"\n")
+	f.NoSession = true
+
+	cmd := NewUnlockCmd(f)
+	cmd.SetArgs([]string{"--expire-in", "30m"})
+	cmd.SilenceErrors, cmd.SilenceUsage = true, true
+	if err := executeCommand(f, cmd); err == nil {
+		t.Error("Expected unlock --no-session --expire-in to be rejected")
+	}
+	if hasSession(t, f) {
+		t.Error("Expected no session written")
+	}
+}
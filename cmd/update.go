@@ -7,36 +7,60 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/ompatil-15/coconut/internal/db/model"
 	"github.com/ompatil-15/coconut/internal/factory"
+	"github.com/ompatil-15/coconut/internal/journal"
+	"github.com/ompatil-15/coconut/internal/undo"
 	"github.com/spf13/cobra"
 	"golang.org/x/term"
 )
 
 func NewUpdateCmd(f *factory.Factory) *cobra.Command {
 	var (
+		name        string
 		username    string
 		url         string
 		description string
+		expires     string
+		fields      []string
+		removed     []string
+		noNormalize bool
 	)
 
 	cmd := &cobra.Command{
-		Use:     "update <index> [--username USERNAME] [--url URL] [--description DESCRIPTION]",
+		Use:     "update <index> [--name NAME] [--username USERNAME] [--url URL] [--description DESCRIPTION] [--expires DATE|DURATION] [--field NAME=VALUE] [--remove-field NAME]",
 		Aliases: []string{"edit"},
 		Short:   "Update one or more fields of a secret",
 		Long: `Update stored secrets securely. 
 Only provided fields are changed; others remain unchanged.
-If no flags are given, the command will prompt interactively.`,
+If no flags are given, the command will prompt interactively.
+
+--field name=value sets a custom field, replacing the value of a field
+with that name (in any case) in place, or adding it at the end.
+--remove-field name removes one. Both can be repeated.
+
+A new URL without a scheme is stored with https:// in front, as by
+'coconut add'. Use --no-normalize to store it as typed.`,
 
 		Example: `
   coconut update 3
   coconut update 2 --username "new_user" --url "https://coconut.pm"
-  coconut update 1 --username "admin"`,
+  coconut update 1 --username "admin"
+  coconut update 4 --expires 90d
+  coconut update 4 --expires never
+  coconut update 5 --field "pin=4321" --remove-field "old account"`,
 
-		Args: cobra.ExactArgs(1),
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeSecretIndexes(f),
 
 		RunE: func(cmd *cobra.Command, args []string) error {
+			customFields, err := parseFieldArgs(fields)
+			if err != nil {
+				return err
+			}
+
 			if err := EnsureVaultUnlocked(f); err != nil {
 				return err
 			}
@@ -56,11 +80,14 @@ If no flags are given, the command will prompt interactively.`,
 
 			secret := secrets[index-1]
 
-			if username == "" && url == "" && description == "" {
+			if name == "" && username == "" && url == "" && description == "" && expires == "" && len(fields) == 0 && len(removed) == 0 {
 				if err := readInteractive(f, &secret); err != nil {
 					return err
 				}
 			} else {
+				if name != "" {
+					secret.Name = name
+				}
 				if username != "" {
 					secret.Username = username
 				}
@@ -70,20 +97,61 @@ If no flags are given, the command will prompt interactively.`,
 				if description != "" {
 					secret.Description = description
 				}
+				if expires != "" {
+					expiresAt, err := parseExpiry(expires, time.Now())
+					if err != nil {
+						return err
+					}
+					secret.ExpiresAt = expiresAt
+				}
+				for _, field := range customFields {
+					secret.SetField(field.Name, field.Value)
+				}
+				for _, name := range removed {
+					if !secret.RemoveField(name) {
+						return fmt.Errorf("secret %d has no field %q", index, name)
+					}
+				}
 			}
 
-			if err := f.Secrets.Update(secret); err != nil {
+			if secret.URL != secrets[index-1].URL && !noNormalize {
+				if secret.URL, err = normalizeURL(secret.URL); err != nil {
+					return err
+				}
+			}
+
+			if secret.Password != secrets[index-1].Password {
+				if err := checkPasswordPolicy(f, secret.Password); err != nil {
+					return err
+				}
+			}
+
+			if err := f.Undo.Record(undo.OpUpdate, secrets[index-1]); err != nil {
+				f.Logger.Warn("failed to record undo state: %v", err)
+			}
+
+			previous := secrets[index-1]
+			err = journaled(f, journal.Entry{Op: journal.OpUpdate, Secret: &secret, Previous: &previous}, func() error {
+				return f.Secrets.Update(secret)
+			})
+			if err != nil {
 				return fmt.Errorf("failed to update secret: %w", err)
 			}
 
+			f.Logger.Access("update", accessTarget(index, &secret))
 			fmt.Printf("Secret with id %d updated successfully.\n", index)
 			return nil
 		},
 	}
 
+	cmd.Flags().StringVar(&name, "name", "", "New display name")
 	cmd.Flags().StringVar(&username, "username", "", "New username")
 	cmd.Flags().StringVar(&url, "url", "", "New URL")
 	cmd.Flags().StringVar(&description, "description", "", "New description")
+	cmd.Flags().StringArrayVar(&fields, "field", nil, "Set a custom field as name=value (repeatable)")
+	cmd.Flags().StringArrayVar(&removed, "remove-field", nil, "Remove the custom field with this name (repeatable)")
+	cmd.Flags().StringVar(&expires, "expires", "", "New expiry as a date (YYYY-MM-DD), a duration from now (e.g. 90d), or 'never'")
+	cmd.Flags().BoolVar(&noNormalize, "no-normalize", false, "Store a new URL as typed instead of adding https:// and lowercasing the host")
 
 	return cmd
 }
@@ -92,6 +160,13 @@ func readInteractive(f *factory.Factory, secret *model.Secret) error {
 	reader := bufio.NewReader(f.IO.In)
 	out := f.IO.Out
 
+	fmt.Fprintf(out, "Name (leave blank to keep '%s'): ", secret.Name)
+	name, _ := reader.ReadString('\n')
+	name = strings.TrimSpace(name)
+	if name != "" {
+		secret.Name = name
+	}
+
 	fmt.Fprintf(out, "Username (leave blank to keep '%s'): ", secret.Username)
 	username, _ := reader.ReadString('\n')
 	username = strings.TrimSpace(username)
@@ -0,0 +1,187 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ompatil-15/coconut/internal/db"
+	"github.com/ompatil-15/coconut/internal/factory"
+	"github.com/spf13/cobra"
+)
+
+func NewVerifyCmd(f *factory.Factory) *cobra.Command {
+	var accept, repair bool
+
+	cmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Check that no secrets were added, removed or replaced outside coconut",
+		Long: `Check the vault's integrity tag against the stored secrets.
+
+Each secret is encrypted and authenticated on its own, which catches an
+edited record but not one that was deleted, or swapped for an older copy
+from a backup. The integrity tag covers the whole set: a MAC, keyed from
+your master key, over a hash of every encrypted record. coconut updates
+it with every change it makes, so it only disagrees with the secrets
+after changes made some other way, such as editing the database file.
+Replacing the whole file with an older copy brings its tag along, so
+that is not detected.
+
+A vault last written by an older release has no tag yet; it gets one
+with the next change, or now with --accept.
+
+If the check fails, restore from a backup you trust. If you know why the
+secrets changed, e.g. you copied in a backup yourself, --accept records
+the current secrets as the trusted state.
+
+--repair also looks for records that do not decrypt with your key, e.g.
+left by an interrupted 'coconut passwd', and after confirmation moves
+them, still encrypted, to the "quarantine" bucket of the database, so
+list, search and export work again. Nothing is deleted; a backup is
+taken first. The integrity tag is updated for the move only if it
+verified beforehand.`,
+		Example: `  coconut verify
+  coconut verify --accept
+  coconut verify --repair`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := EnsureVaultUnlocked(f); err != nil {
+				return err
+			}
+
+			out := f.IO.Out
+			tag := f.Repo.NewIntegrityTag(f.Config.SystemBucket)
+			records := f.Repo.NewRecordsRepository(f.Config.SecretsBucket)
+
+			report, err := tag.Verify(records)
+			switch {
+			case errors.Is(err, db.ErrNoIntegrityTag):
+				fmt.Fprintln(out, "The vault has no integrity tag yet.")
+			case errors.Is(err, db.ErrIntegrityTagInvalid):
+				f.Logger.Warn("Integrity tag does not verify: %v", err)
+				fmt.Fprintln(out, "WARNING: the integrity tag itself was modified or written without your key.")
+			case err != nil:
+				f.Logger.Error("integrity check failed: %v", err)
+				return fmt.Errorf("integrity check failed: %w", err)
+			case report.OK():
+				f.Logger.Info("Integrity check passed (%d secret(s))", report.Records)
+				fmt.Fprintf(out, "Integrity check passed: %d secret(s), none added, removed or replaced outside coconut.\n", report.Records)
+				if repair {
+					return quarantineUndecryptable(f, true)
+				}
+				return nil
+			default:
+				f.Logger.Warn("Integrity check failed: %d added, %d removed, %d replaced outside coconut", len(report.Added), len(report.Removed), len(report.Changed))
+				fmt.Fprintln(out, "WARNING: the secrets were changed outside coconut.")
+				printIntegrityReport(f, out, report)
+			}
+
+			if repair {
+				if err := quarantineUndecryptable(f, false); err != nil {
+					return err
+				}
+			}
+
+			if !accept {
+				if errors.Is(err, db.ErrNoIntegrityTag) {
+					fmt.Fprintln(out, "It is created with the next change, or now with 'coconut verify --accept'.")
+					return nil
+				}
+				fmt.Fprintln(out, "Restore from a trusted backup, or run 'coconut verify --accept' if you know why they changed.")
+				return errors.New("vault integrity check failed")
+			}
+
+			if err := tag.Update(records); err != nil {
+				f.Logger.Error("failed to update integrity tag: %v", err)
+				return fmt.Errorf("failed to update integrity tag: %w", err)
+			}
+			f.Logger.Warn("Integrity tag reset to the current secrets by --accept")
+			fmt.Fprintln(out, "The current secrets are now recorded as trusted.")
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&accept, "accept", false, "Record the current secrets as the trusted state")
+	cmd.Flags().BoolVar(&repair, "repair", false, "Move records that cannot be decrypted to a quarantine bucket (asks first)")
+
+	return cmd
+}
+
+// quarantiner is implemented by secret repositories that can set aside
+// records they cannot decrypt.
+type quarantiner interface {
+	Undecryptable() ([]string, error)
+	Quarantine(bucket string, keys []string, retag bool) error
+}
+
+// quarantineUndecryptable moves records that do not decrypt to the
+// quarantine bucket once the user confirms. retag says whether the
+// integrity tag verified and may be refreshed after the move.
+func quarantineUndecryptable(f *factory.Factory, retag bool) error {
+	out := f.IO.Out
+	repo, ok := f.Secrets.(quarantiner)
+	if !ok {
+		return fmt.Errorf("this vault does not support --repair")
+	}
+
+	bad, err := repo.Undecryptable()
+	if err != nil {
+		f.Logger.Error("failed to scan records: %v", err)
+		return fmt.Errorf("failed to scan records: %w", err)
+	}
+	if len(bad) == 0 {
+		fmt.Fprintln(out, "Every record decrypts; nothing to repair.")
+		return nil
+	}
+
+	fmt.Fprintf(out, "%d record(s) cannot be decrypted with your key:\n", len(bad))
+	for _, id := range bad {
+		fmt.Fprintf(out, "  %s\n", id)
+	}
+	fmt.Fprintf(out, "Move them to the %q bucket? They are kept, still encrypted. (y/N): ", f.Config.QuarantineBucket)
+	answer, _ := readLine(f.IO.In)
+	if !strings.EqualFold(strings.TrimSpace(answer), "y") {
+		fmt.Fprintln(out, "Repair cancelled.")
+		f.Logger.Info("Repair cancelled")
+		return nil
+	}
+
+	path, err := backupDBFile(f)
+	if err != nil {
+		f.Logger.Error("backup before repair failed: %v", err)
+		return fmt.Errorf("aborting repair, backup failed: %w", err)
+	}
+	if err := repo.Quarantine(f.Config.QuarantineBucket, bad, retag); err != nil {
+		f.Logger.Error("repair failed, nothing was moved: %v", err)
+		return fmt.Errorf("failed to quarantine records (nothing was moved): %w", err)
+	}
+
+	f.Logger.Warn("Quarantined %d undecryptable record(s): %s", len(bad), strings.Join(bad, ", "))
+	fmt.Fprintf(out, "Moved %d record(s) to the %q bucket. A backup was saved to %s.\n", len(bad), f.Config.QuarantineBucket, path)
+	if !retag {
+		fmt.Fprintln(out, "The integrity tag was not updated; it will report them as removed until 'coconut verify --accept'.")
+	}
+	return nil
+}
+
+// printIntegrityReport lists the differences, naming secrets that can
+// still be decrypted.
+func printIntegrityReport(f *factory.Factory, out io.Writer, report *db.IntegrityReport) {
+	label := func(id string) string {
+		if s, err := f.Secrets.Get(id); err == nil {
+			return fmt.Sprintf("%s (%s)", mergeLabel(*s), id)
+		}
+		return id + " (cannot be decrypted)"
+	}
+
+	for _, id := range report.Added {
+		fmt.Fprintf(out, "  added:    %s\n", label(id))
+	}
+	for _, id := range report.Changed {
+		fmt.Fprintf(out, "  replaced: %s\n", label(id))
+	}
+	for _, id := range report.Removed {
+		fmt.Fprintf(out, "  removed:  %s\n", id)
+	}
+}
@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/ompatil-15/coconut/internal/db"
+	"github.com/ompatil-15/coconut/internal/db/boltdb"
+	"github.com/ompatil-15/coconut/internal/factory"
+	"github.com/ompatil-15/coconut/internal/session"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// watchHeartbeat is how often watch resets the session's inactivity timer.
+// It must stay well below the smallest useful autoLockSecs.
+const watchHeartbeat = 15 * time.Second
+
+func NewWatchCmd(f *factory.Factory) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "watch",
+		Short: "Hold the vault unlocked while this command runs",
+		Long: `Unlock the vault and keep the session alive in the foreground.
+
+While watch runs, other coconut commands in any terminal use the session
+without prompting, and the inactivity timeout never fires. Press Ctrl-C
+(or send SIGTERM) to end it: the session is cleared and the vault locked.
+The same happens on SIGHUP, so a dropped SSH connection or a closed
+terminal leaves the vault locked.
+
+An absolute expiry set with 'coconut unlock --expire-in' or
+'coconut lock --timeout' still applies, and watch exits when the session
+ends for any other reason, such as 'coconut lock' in another terminal.`,
+		Example: `  coconut watch`,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if f.NoSession {
+				return fmt.Errorf("watch keeps a session alive and cannot be used with --no-session")
+			}
+
+			if err := EnsureVaultUnlocked(f); err != nil {
+				return err
+			}
+
+			// BoltDB locks the file for as long as it is open, so holding it
+			// here would block every other coconut command. Release it and
+			// reopen briefly on each heartbeat instead.
+			if err := f.DB.Close(); err != nil {
+				return fmt.Errorf("failed to release vault database: %w", err)
+			}
+			f.DB = nil
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), lockSignals...)
+			defer stop()
+
+			f.Logger.Info("Watch started; holding vault unlocked")
+			reason := watchSession(ctx, f)
+
+			if err := lockVault(f); err != nil {
+				f.Logger.Error("Failed to clear session: %v", err)
+				return fmt.Errorf("vault locked in memory but the session could not be cleared; run 'coconut lock': %w", err)
+			}
+
+			f.Logger.Info("Watch ended (%s); vault locked", reason)
+			fmt.Fprintf(f.IO.Out, "Vault locked (%s).\n", reason)
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+// watchSession refreshes the session every heartbeat until ctx is
+// cancelled or the session stops being valid, and reports why it returned.
+// On a terminal the status line is redrawn every second; otherwise it is
+// printed once per heartbeat.
+func watchSession(ctx context.Context, f *factory.Factory) string {
+	out := f.IO.Out
+	live := term.IsTerminal(int(os.Stdout.Fd()))
+	started := time.Now()
+
+	var expiresAt time.Time
+	// refresh resets the inactivity timer, reporting false once the session
+	// is gone or past its absolute expiry. A database busy with another
+	// command is not fatal; the next heartbeat retries.
+	refresh := func() bool {
+		valid := true
+		err := withSession(f, func(m *session.Manager) error {
+			if !m.IsValid() {
+				valid = false
+				return nil
+			}
+			expiresAt = m.ExpiresAt()
+			return m.UpdateActivity()
+		})
+		if err != nil {
+			f.Logger.Warn("watch failed to refresh session: %v", err)
+		}
+		return valid
+	}
+
+	status := func(now time.Time) string {
+		line := fmt.Sprintf("Vault unlocked for %s", now.Sub(started).Truncate(time.Second))
+		if !expiresAt.IsZero() {
+			line += fmt.Sprintf(", locks at %s", expiresAt.Format("15:04:05"))
+		}
+		return line + ". Press Ctrl-C to lock."
+	}
+
+	endLine := func() {
+		if live {
+			fmt.Fprintln(out)
+		}
+	}
+
+	if !refresh() {
+		return "session ended"
+	}
+	if live {
+		fmt.Fprint(out, status(started))
+	} else {
+		fmt.Fprintln(out, status(started))
+	}
+
+	tick := time.NewTicker(time.Second)
+	defer tick.Stop()
+	lastBeat := started
+
+	for {
+		select {
+		case <-ctx.Done():
+			endLine()
+			return "interrupted"
+		case now := <-tick.C:
+			if !expiresAt.IsZero() && !now.Before(expiresAt) {
+				endLine()
+				return "session expired"
+			}
+
+			beat := now.Sub(lastBeat) >= watchHeartbeat
+			if beat {
+				if !refresh() {
+					endLine()
+					return "session ended"
+				}
+				lastBeat = now
+			}
+
+			if live {
+				fmt.Fprintf(out, "\r\033[K%s", status(now))
+			} else if beat {
+				fmt.Fprintln(out, status(now))
+			}
+		}
+	}
+}
+
+// withSession opens the vault database just long enough to run fn against
+// its session.
+func withSession(f *factory.Factory, fn func(m *session.Manager) error) error {
+	store, err := boltdb.NewBoltStore(f.Config.DBPath, boltdb.WithAutoCreateBuckets())
+	if err != nil {
+		return fmt.Errorf("open vault database: %w", err)
+	}
+	defer store.Close()
+
+	return fn(session.NewManager(db.NewBaseRepository(store, f.Config.SystemBucket), f.Config))
+}
@@ -0,0 +1,84 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// timeFormat sorts lexically in chronological order, so listing the
+// directory is enough to find the oldest snapshots.
+const timeFormat = "20060102-150405.000"
+
+// Dir returns the directory holding snapshots of the vault at dbPath.
+func Dir(dbPath string) string {
+	return filepath.Join(filepath.Dir(dbPath), "backups")
+}
+
+// Path returns where a snapshot of dbPath taken at t is stored, e.g.
+// ~/.coconut/backups/coconut-20250102-150405.000.db.
+func Path(dbPath string, t time.Time) string {
+	return filepath.Join(Dir(dbPath), prefix(dbPath)+t.Format(timeFormat)+".db")
+}
+
+// List returns the snapshots of dbPath, oldest first. A missing backup
+// directory is not an error.
+func List(dbPath string) ([]string, error) {
+	entries, err := os.ReadDir(Dir(dbPath))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	p := prefix(dbPath)
+	var paths []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, p) || !strings.HasSuffix(name, ".db") {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, p), ".db")
+		if _, err := time.Parse(timeFormat, stamp); err != nil {
+			continue
+		}
+		paths = append(paths, filepath.Join(Dir(dbPath), name))
+	}
+
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// Prune deletes all but the newest keep snapshots of dbPath and returns
+// the removed paths.
+func Prune(dbPath string, keep int) ([]string, error) {
+	paths, err := List(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	if keep < 0 {
+		keep = 0
+	}
+	if len(paths) <= keep {
+		return nil, nil
+	}
+
+	var removed []string
+	for _, path := range paths[:len(paths)-keep] {
+		if err := os.Remove(path); err != nil {
+			return removed, err
+		}
+		removed = append(removed, path)
+	}
+	return removed, nil
+}
+
+// prefix is the snapshot name prefix for dbPath, so vaults sharing a
+// directory never prune each other's snapshots.
+func prefix(dbPath string) string {
+	base := filepath.Base(dbPath)
+	return strings.TrimSuffix(base, filepath.Ext(base)) + "-"
+}
@@ -0,0 +1,85 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeSnapshot(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatalf("Failed to create backup dir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("snapshot"), 0600); err != nil {
+		t.Fatalf("Failed to write snapshot: %v", err)
+	}
+}
+
+func TestPath(t *testing.T) {
+	at := time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC)
+	got := Path("/home/u/.coconut/coconut.db", at)
+	want := "/home/u/.coconut/backups/coconut-20250102-150405.000.db"
+	if got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestList_OldestFirst(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "coconut.db")
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Written out of order on purpose
+	for _, offset := range []int{2, 0, 1} {
+		writeSnapshot(t, Path(dbPath, base.Add(time.Duration(offset)*time.Hour)))
+	}
+	// Snapshots of another vault and stray files are ignored
+	writeSnapshot(t, Path(filepath.Join(filepath.Dir(dbPath), "work.db"), base))
+	writeSnapshot(t, filepath.Join(Dir(dbPath), "coconut-notes.db"))
+
+	paths, err := List(dbPath)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(paths) != 3 {
+		t.Fatalf("Expected 3 snapshots, got %d: %v", len(paths), paths)
+	}
+	if paths[0] != Path(dbPath, base) {
+		t.Errorf("Expected oldest snapshot first, got %s", paths[0])
+	}
+}
+
+func TestList_MissingDir(t *testing.T) {
+	paths, err := List(filepath.Join(t.TempDir(), "coconut.db"))
+	if err != nil {
+		t.Fatalf("List should not fail without a backup dir: %v", err)
+	}
+	if len(paths) != 0 {
+		t.Errorf("Expected no snapshots, got %v", paths)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "coconut.db")
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		writeSnapshot(t, Path(dbPath, base.Add(time.Duration(i)*time.Minute)))
+	}
+
+	removed, err := Prune(dbPath, 2)
+	if err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	if len(removed) != 3 {
+		t.Errorf("Expected 3 snapshots removed, got %d", len(removed))
+	}
+
+	paths, _ := List(dbPath)
+	if len(paths) != 2 {
+		t.Fatalf("Expected 2 snapshots kept, got %d", len(paths))
+	}
+	if paths[1] != Path(dbPath, base.Add(4*time.Minute)) {
+		t.Errorf("Newest snapshot should be kept, got %v", paths)
+	}
+}
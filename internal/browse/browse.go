@@ -0,0 +1,345 @@
+// Package browse implements the state and drawing of 'coconut browse', an
+// interactive full-screen secret browser. It does no I/O of its own
+// beyond writing frames: the command reads keys, fetches secrets and
+// performs the actions Handle asks for.
+package browse
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ompatil-15/coconut/internal/db/model"
+	"github.com/ompatil-15/coconut/internal/query"
+)
+
+// Mode is what the browser is showing.
+type Mode int
+
+const (
+	ModeList   Mode = iota // moving through secrets
+	ModeSearch             // typing a search query
+	ModeDetail             // showing one secret
+)
+
+// Action is work Handle needs the caller to do.
+type Action int
+
+const (
+	ActionNone Action = iota
+	ActionQuit
+	ActionOpen         // load the selected secret with Show
+	ActionCopy         // copy the selected secret's password
+	ActionShowPassword // the password of the open secret was revealed
+)
+
+// Model is the browser state. The list holds metadata only; the full
+// secret, with its password, exists only while it is open in detail view.
+type Model struct {
+	secrets []model.Secret
+	visible []int // indexes into secrets that match the query
+	cursor  int   // position in visible
+	offset  int   // first visible row drawn
+	query   string
+
+	mode         Mode
+	detail       *model.Secret
+	showPassword bool
+
+	// Status is a one-line message shown at the bottom until the next key.
+	Status string
+
+	// Mask returns what a hidden password is shown as in detail view.
+	// When nil it is eight asterisks, whatever the password's length.
+	Mask func(password string) string
+}
+
+// New returns a browser over secrets, which should not include passwords.
+func New(secrets []model.Secret) *Model {
+	m := &Model{secrets: secrets}
+	m.filter()
+	return m
+}
+
+// Mode returns what the browser is showing.
+func (m *Model) Mode() Mode {
+	return m.mode
+}
+
+// Query returns the current search query.
+func (m *Model) Query() string {
+	return m.query
+}
+
+// Visible returns how many secrets match the query.
+func (m *Model) Visible() int {
+	return len(m.visible)
+}
+
+// Selected returns the secret under the cursor, or the open one in detail
+// view, and its 1-based position in the full list.
+func (m *Model) Selected() (*model.Secret, int, bool) {
+	if len(m.visible) == 0 {
+		return nil, 0, false
+	}
+	i := m.visible[m.cursor]
+	return &m.secrets[i], i + 1, true
+}
+
+// Show opens secret in detail view with the password hidden.
+func (m *Model) Show(secret *model.Secret) {
+	m.detail = secret
+	m.showPassword = false
+	m.mode = ModeDetail
+}
+
+// Close leaves detail view and drops the open secret.
+func (m *Model) Close() {
+	if m.detail != nil {
+		m.detail.Password = ""
+	}
+	m.detail = nil
+	m.showPassword = false
+	m.mode = ModeList
+}
+
+// Handle applies a key press and returns the action it calls for.
+func (m *Model) Handle(k Key) Action {
+	m.Status = ""
+	if k.Code == KeyCtrlC {
+		return ActionQuit
+	}
+
+	switch m.mode {
+	case ModeSearch:
+		return m.handleSearch(k)
+	case ModeDetail:
+		return m.handleDetail(k)
+	default:
+		return m.handleList(k)
+	}
+}
+
+func (m *Model) handleList(k Key) Action {
+	switch {
+	case k.Code == KeyUp, k.Code == KeyRune && k.Rune == 'k':
+		m.move(-1)
+	case k.Code == KeyDown, k.Code == KeyRune && k.Rune == 'j':
+		m.move(1)
+	case k.Code == KeyPageUp:
+		m.move(-10)
+	case k.Code == KeyPageDown:
+		m.move(10)
+	case k.Code == KeyEnter:
+		if len(m.visible) > 0 {
+			return ActionOpen
+		}
+	case k.Code == KeyEscape:
+		m.setQuery("")
+	case k.Code == KeyRune && k.Rune == '/':
+		m.mode = ModeSearch
+	case k.Code == KeyRune && k.Rune == 'c':
+		if len(m.visible) > 0 {
+			return ActionCopy
+		}
+	case k.Code == KeyRune && k.Rune == 'q':
+		return ActionQuit
+	}
+	return ActionNone
+}
+
+func (m *Model) handleSearch(k Key) Action {
+	switch k.Code {
+	case KeyRune:
+		m.setQuery(m.query + string(k.Rune))
+	case KeyBackspace:
+		if m.query != "" {
+			_, size := utf8.DecodeLastRuneInString(m.query)
+			m.setQuery(m.query[:len(m.query)-size])
+		}
+	case KeyUp:
+		m.move(-1)
+	case KeyDown:
+		m.move(1)
+	case KeyEnter:
+		m.mode = ModeList
+	case KeyEscape:
+		m.setQuery("")
+		m.mode = ModeList
+	}
+	return ActionNone
+}
+
+func (m *Model) handleDetail(k Key) Action {
+	switch {
+	case k.Code == KeyEscape, k.Code == KeyEnter, k.Code == KeyBackspace:
+		m.Close()
+	case k.Code == KeyRune && k.Rune == 's':
+		if m.detail.IsProtected() {
+			m.Status = "This secret is protected by a PIN; use 'coconut get' to reveal it."
+			return ActionNone
+		}
+		m.showPassword = !m.showPassword
+		if m.showPassword {
+			return ActionShowPassword
+		}
+	case k.Code == KeyRune && k.Rune == 'c':
+		return ActionCopy
+	case k.Code == KeyRune && k.Rune == 'q':
+		return ActionQuit
+	}
+	return ActionNone
+}
+
+func (m *Model) move(delta int) {
+	m.cursor = max(0, min(m.cursor+delta, len(m.visible)-1))
+}
+
+func (m *Model) setQuery(q string) {
+	m.query = q
+	m.filter()
+}
+
+// filter recomputes the visible secrets. A query that does not parse yet,
+// e.g. an open quote while typing, keeps the previous results.
+func (m *Model) filter() {
+	var q query.Query
+	if strings.TrimSpace(m.query) != "" {
+		parsed, err := query.Parse(m.query)
+		if err != nil {
+			return
+		}
+		q = parsed
+	}
+
+	m.visible = m.visible[:0]
+	for i := range m.secrets {
+		if q == nil || q.Match(&m.secrets[i]) {
+			m.visible = append(m.visible, i)
+		}
+	}
+	m.cursor, m.offset = 0, 0
+}
+
+// Render draws one full frame of width by height cells. Lines end in
+// "\r\n" because the terminal is in raw mode.
+func (m *Model) Render(w io.Writer, width, height int) {
+	height = max(height, 3)
+
+	var lines []string
+	if m.mode == ModeDetail && m.detail != nil {
+		lines = m.renderDetail()
+	} else {
+		lines = m.renderList(height)
+	}
+
+	footer := m.footer()
+	for len(lines) < height-1 {
+		lines = append(lines, "")
+	}
+	lines = append(lines[:height-1], footer)
+
+	var b strings.Builder
+	b.WriteString("\x1b[H\x1b[2J")
+	for i, line := range lines {
+		if i > 0 {
+			b.WriteString("\r\n")
+		}
+		b.WriteString(truncate(line, width))
+	}
+	io.WriteString(w, b.String())
+}
+
+func (m *Model) renderList(height int) []string {
+	header := fmt.Sprintf("coconut: %d of %d secret(s)", len(m.visible), len(m.secrets))
+	if m.query != "" || m.mode == ModeSearch {
+		header += "   /" + m.query
+	}
+	lines := []string{header, ""}
+
+	rows := max(1, height-len(lines)-1)
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+rows {
+		m.offset = m.cursor - rows + 1
+	}
+
+	if len(m.visible) == 0 {
+		return append(lines, "  No matching secrets.")
+	}
+
+	end := min(len(m.visible), m.offset+rows)
+	for pos := m.offset; pos < end; pos++ {
+		s := &m.secrets[m.visible[pos]]
+		marker := "  "
+		if pos == m.cursor {
+			marker = "> "
+		}
+		lines = append(lines, fmt.Sprintf("%s%-24s %-24s %s",
+			marker, truncate(label(s), 24), truncate(s.Username, 24), s.URL))
+	}
+	return lines
+}
+
+func (m *Model) renderDetail() []string {
+	s := m.detail
+	password := "********"
+	if m.showPassword {
+		password = s.Password
+	} else if m.Mask != nil {
+		password = m.Mask(s.Password)
+	}
+
+	lines := []string{
+		label(s),
+		"",
+		"Username:    " + s.Username,
+		"Password:    " + password,
+		"URL:         " + s.URL,
+	}
+	if len(s.Tags) > 0 {
+		lines = append(lines, "Tags:        "+strings.Join(s.Tags, ", "))
+	}
+	if s.Description != "" {
+		lines = append(lines, "Description:")
+		for _, line := range strings.Split(s.Description, "\n") {
+			lines = append(lines, "  "+line)
+		}
+	}
+	return lines
+}
+
+func (m *Model) footer() string {
+	if m.Status != "" {
+		return m.Status
+	}
+	switch m.mode {
+	case ModeSearch:
+		return "Type to search   Enter: done   Esc: clear"
+	case ModeDetail:
+		return "s: show/hide password   c: copy password   Esc: back   q: quit"
+	default:
+		return "Up/Down: move   Enter: open   /: search   c: copy password   q: quit"
+	}
+}
+
+func label(s *model.Secret) string {
+	if s.Name != "" {
+		return s.Name
+	}
+	return s.Username
+}
+
+// truncate shortens s to at most width characters, marking the cut.
+func truncate(s string, width int) string {
+	if width <= 0 || utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	r := []rune(s)
+	if width == 1 {
+		return string(r[:1])
+	}
+	return string(r[:width-1]) + "…"
+}
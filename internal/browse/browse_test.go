@@ -0,0 +1,215 @@
+package browse
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ompatil-15/coconut/internal/db/model"
+)
+
+func testSecrets() []model.Secret {
+	return []model.Secret{
+		{ID: "1", Name: "GitHub", Username: "octocat", URL: "https://github.com", Tags: []string{"work"}},
+		{ID: "2", Name: "Bank", Username: "alice", URL: "https://bank.example"},
+		{ID: "3", Name: "Gitea", Username: "alice", URL: "https://gitea.local", Tags: []string{"work"}},
+	}
+}
+
+func runes(s string) []Key {
+	var keys []Key
+	for _, r := range s {
+		keys = append(keys, Key{Code: KeyRune, Rune: r})
+	}
+	return keys
+}
+
+func TestReadKey(t *testing.T) {
+	input := "a\x1b[A\x1b[B\x1b[5~\x1b[6~\r\x7f\x03é\x1bOA"
+	r := bufio.NewReader(strings.NewReader(input))
+
+	want := []Key{
+		{Code: KeyRune, Rune: 'a'},
+		{Code: KeyUp},
+		{Code: KeyDown},
+		{Code: KeyPageUp},
+		{Code: KeyPageDown},
+		{Code: KeyEnter},
+		{Code: KeyBackspace},
+		{Code: KeyCtrlC},
+		{Code: KeyRune, Rune: 'é'},
+		{Code: KeyUp},
+	}
+	for i, w := range want {
+		got, err := ReadKey(r)
+		if err != nil {
+			t.Fatalf("key %d: ReadKey failed: %v", i, err)
+		}
+		if got != w {
+			t.Errorf("key %d: got %+v, want %+v", i, got, w)
+		}
+	}
+}
+
+func TestReadKey_LoneEscape(t *testing.T) {
+	r := bufio.NewReader(strings.NewReader("\x1b"))
+	got, err := ReadKey(r)
+	if err != nil || got.Code != KeyEscape {
+		t.Errorf("Expected KeyEscape, got %+v, %v", got, err)
+	}
+}
+
+func TestModel_Navigation(t *testing.T) {
+	m := New(testSecrets())
+
+	m.Handle(Key{Code: KeyDown})
+	m.Handle(Key{Code: KeyDown})
+	m.Handle(Key{Code: KeyDown}) // stays on the last entry
+	if s, index, _ := m.Selected(); s.ID != "3" || index != 3 {
+		t.Errorf("Expected the last secret selected, got %s at %d", s.ID, index)
+	}
+
+	m.Handle(Key{Code: KeyPageUp})
+	if s, _, _ := m.Selected(); s.ID != "1" {
+		t.Errorf("Expected the first secret after page up, got %s", s.ID)
+	}
+
+	if action := m.Handle(Key{Code: KeyEnter}); action != ActionOpen {
+		t.Errorf("Expected Enter to open, got %v", action)
+	}
+	if action := m.Handle(Key{Code: KeyRune, Rune: 'c'}); action != ActionCopy {
+		t.Errorf("Expected c to copy, got %v", action)
+	}
+	if action := m.Handle(Key{Code: KeyRune, Rune: 'q'}); action != ActionQuit {
+		t.Errorf("Expected q to quit, got %v", action)
+	}
+}
+
+func TestModel_Search(t *testing.T) {
+	m := New(testSecrets())
+
+	m.Handle(Key{Code: KeyRune, Rune: '/'})
+	if m.Mode() != ModeSearch {
+		t.Fatalf("Expected search mode after '/'")
+	}
+	for _, k := range runes("git q") {
+		if action := m.Handle(k); action != ActionNone {
+			t.Errorf("Typing in search must not trigger actions, got %v", action)
+		}
+	}
+	if m.Visible() != 0 {
+		t.Errorf("Expected no match for 'git q', got %d", m.Visible())
+	}
+
+	m.Handle(Key{Code: KeyBackspace})
+	m.Handle(Key{Code: KeyBackspace})
+	if m.Query() != "git" || m.Visible() != 2 {
+		t.Errorf("Expected 2 matches for 'git', got %d for %q", m.Visible(), m.Query())
+	}
+
+	m.Handle(Key{Code: KeyEnter})
+	if m.Mode() != ModeList || m.Visible() != 2 {
+		t.Errorf("Enter should keep the filter and return to the list")
+	}
+
+	m.Handle(Key{Code: KeyEscape})
+	if m.Query() != "" || m.Visible() != 3 {
+		t.Errorf("Esc in the list should clear the filter, got %d for %q", m.Visible(), m.Query())
+	}
+}
+
+func TestModel_SearchFieldSyntax(t *testing.T) {
+	m := New(testSecrets())
+	m.Handle(Key{Code: KeyRune, Rune: '/'})
+	for _, k := range runes("tag:work user:alice") {
+		m.Handle(k)
+	}
+	// "user:" is not a field, so the last term is a plain value
+	if m.Visible() != 0 {
+		t.Errorf("Expected no match, got %d", m.Visible())
+	}
+
+	m.setQuery("tag:work username:alice")
+	if s, _, ok := m.Selected(); !ok || m.Visible() != 1 || s.ID != "3" {
+		t.Errorf("Expected only Gitea to match, got %d", m.Visible())
+	}
+
+	m.setQuery(`name:"unterminated`)
+	if m.Visible() != 1 {
+		t.Errorf("An unparsable query should keep the previous results, got %d", m.Visible())
+	}
+}
+
+func TestModel_Detail(t *testing.T) {
+	m := New(testSecrets())
+
+	secret := testSecrets()[0]
+	secret.Password = "hunter2"
+	m.Show(&secret)
+
+	var buf bytes.Buffer
+	m.Render(&buf, 80, 20)
+	if strings.Contains(buf.String(), "hunter2") {
+		t.Error("Password must be hidden until revealed")
+	}
+
+	if action := m.Handle(Key{Code: KeyRune, Rune: 's'}); action != ActionShowPassword {
+		t.Errorf("Expected s to reveal the password, got %v", action)
+	}
+	buf.Reset()
+	m.Render(&buf, 80, 20)
+	if !strings.Contains(buf.String(), "hunter2") {
+		t.Error("Expected the revealed password in the frame")
+	}
+
+	m.Handle(Key{Code: KeyEscape})
+	if m.Mode() != ModeList {
+		t.Error("Esc should return to the list")
+	}
+	if secret.Password != "" {
+		t.Error("Closing the detail view should drop the password")
+	}
+}
+
+func TestModel_DetailProtected(t *testing.T) {
+	m := New(testSecrets())
+
+	secret := testSecrets()[0]
+	secret.Password = "hunter2"
+	secret.PinHash = "argon2id$..."
+	m.Show(&secret)
+
+	if action := m.Handle(Key{Code: KeyRune, Rune: 's'}); action != ActionNone {
+		t.Errorf("Expected no reveal for a PIN-protected secret, got %v", action)
+	}
+	var buf bytes.Buffer
+	m.Render(&buf, 80, 20)
+	if strings.Contains(buf.String(), "hunter2") {
+		t.Error("A PIN-protected password must not be revealed")
+	}
+	if m.Status == "" {
+		t.Error("Expected a status explaining why the password stays hidden")
+	}
+}
+
+func TestModel_RenderScrollsToCursor(t *testing.T) {
+	var secrets []model.Secret
+	for i := 0; i < 50; i++ {
+		secrets = append(secrets, model.Secret{Name: strings.Repeat("x", i%5+1) + string(rune('A'+i%26))})
+	}
+	m := New(secrets)
+	for i := 0; i < 40; i++ {
+		m.Handle(Key{Code: KeyDown})
+	}
+
+	var buf bytes.Buffer
+	m.Render(&buf, 40, 10)
+	frame := buf.String()
+	if !strings.Contains(frame, "> "+secrets[40].Name) {
+		t.Errorf("Expected the selected row to be drawn, got:\n%s", frame)
+	}
+	if got := strings.Count(frame, "\r\n") + 1; got != 10 {
+		t.Errorf("Expected exactly 10 lines, got %d", got)
+	}
+}
@@ -0,0 +1,104 @@
+package browse
+
+import (
+	"bufio"
+	"unicode/utf8"
+)
+
+// KeyCode identifies a key press. Printable characters are KeyRune.
+type KeyCode int
+
+const (
+	KeyUnknown KeyCode = iota
+	KeyRune
+	KeyUp
+	KeyDown
+	KeyPageUp
+	KeyPageDown
+	KeyEnter
+	KeyEscape
+	KeyBackspace
+	KeyCtrlC
+)
+
+// Key is one key press read from a terminal in raw mode.
+type Key struct {
+	Code KeyCode
+	Rune rune // set for KeyRune
+}
+
+// ReadKey reads the next key press. Escape sequences for the arrow and
+// page keys are decoded; an ESC with nothing buffered after it is the
+// Escape key itself, since terminals send a sequence in a single write.
+func ReadKey(r *bufio.Reader) (Key, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return Key{}, err
+	}
+
+	switch b {
+	case 3:
+		return Key{Code: KeyCtrlC}, nil
+	case '\r', '\n':
+		return Key{Code: KeyEnter}, nil
+	case 8, 127:
+		return Key{Code: KeyBackspace}, nil
+	case 27:
+		if r.Buffered() == 0 {
+			return Key{Code: KeyEscape}, nil
+		}
+		return readEscape(r)
+	}
+
+	if b < utf8.RuneSelf {
+		if b < ' ' {
+			return Key{Code: KeyUnknown}, nil
+		}
+		return Key{Code: KeyRune, Rune: rune(b)}, nil
+	}
+
+	// Multi-byte UTF-8 character: put the first byte back and decode.
+	if err := r.UnreadByte(); err != nil {
+		return Key{}, err
+	}
+	ch, _, err := r.ReadRune()
+	if err != nil {
+		return Key{}, err
+	}
+	return Key{Code: KeyRune, Rune: ch}, nil
+}
+
+// readEscape decodes the rest of an escape sequence after ESC, e.g.
+// "[A" (up) or "[5~" (page up). Unrecognized sequences are consumed
+// and reported as KeyUnknown.
+func readEscape(r *bufio.Reader) (Key, error) {
+	introducer, err := r.ReadByte()
+	if err != nil {
+		return Key{}, err
+	}
+	if introducer != '[' && introducer != 'O' {
+		return Key{Code: KeyUnknown}, nil
+	}
+
+	var params []byte
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return Key{}, err
+		}
+		if b >= 0x40 && b <= 0x7e {
+			switch {
+			case b == 'A':
+				return Key{Code: KeyUp}, nil
+			case b == 'B':
+				return Key{Code: KeyDown}, nil
+			case b == '~' && string(params) == "5":
+				return Key{Code: KeyPageUp}, nil
+			case b == '~' && string(params) == "6":
+				return Key{Code: KeyPageDown}, nil
+			}
+			return Key{Code: KeyUnknown}, nil
+		}
+		params = append(params, b)
+	}
+}
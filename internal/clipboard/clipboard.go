@@ -0,0 +1,103 @@
+// Package clipboard copies secrets to the clipboard, either through the
+// system clipboard library or through a user-configured command.
+package clipboard
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	atotto "github.com/atotto/clipboard"
+)
+
+// Copy puts value on the clipboard. When command is empty the system
+// clipboard is used; otherwise command is run with value on its stdin.
+func Copy(value, command string) error {
+	if strings.TrimSpace(command) == "" {
+		return atotto.WriteAll(value)
+	}
+	return runCommand(value, command)
+}
+
+// Available reports whether Copy can work: the program of command is
+// installed or, with no command, a system clipboard tool was found.
+func Available(command string) bool {
+	if strings.TrimSpace(command) == "" {
+		return !atotto.Unsupported
+	}
+	args, err := ParseCommand(command)
+	if err != nil {
+		return false
+	}
+	_, err = exec.LookPath(args[0])
+	return err == nil
+}
+
+// Paste returns the text on the system clipboard. Clipboard commands and
+// OSC 52 can only write, so this ignores them.
+func Paste() (string, error) {
+	return atotto.ReadAll()
+}
+
+// Fingerprint identifies value without revealing it, so a later clear can
+// check the clipboard still holds what was copied.
+func Fingerprint(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+// ClearIfUnchanged empties the clipboard if it still holds the value with
+// the given fingerprint, so anything copied since is left alone. Neither a
+// clipboard command nor OSC 52 can be read back; with those the clipboard
+// is cleared without checking.
+func ClearIfUnchanged(fingerprint, command string, osc52 bool) error {
+	if osc52 {
+		return CopyOSC52("")
+	}
+	if strings.TrimSpace(command) != "" {
+		return runCommand("", command)
+	}
+
+	current, err := atotto.ReadAll()
+	if err != nil {
+		return err
+	}
+	if Fingerprint(current) != fingerprint {
+		return nil
+	}
+	return atotto.WriteAll("")
+}
+
+// ParseCommand splits a command template such as
+// "xclip -selection clipboard" into the program and its arguments. The
+// template is split on whitespace; it is not run through a shell.
+func ParseCommand(command string) ([]string, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil, fmt.Errorf("clipboard command is empty")
+	}
+	return args, nil
+}
+
+func runCommand(value, command string) error {
+	args, err := ParseCommand(command)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = strings.NewReader(value)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("clipboard command %q failed: %w: %s", args[0], err, msg)
+		}
+		return fmt.Errorf("clipboard command %q failed: %w", args[0], err)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package clipboard
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseCommand(t *testing.T) {
+	args, err := ParseCommand("  xclip -selection   clipboard ")
+	if err != nil {
+		t.Fatalf("ParseCommand failed: %v", err)
+	}
+	if strings.Join(args, "|") != "xclip|-selection|clipboard" {
+		t.Errorf("Unexpected args: %q", args)
+	}
+
+	if _, err := ParseCommand("   "); err == nil {
+		t.Error("Expected an error for an empty command")
+	}
+}
+
+func TestAvailable_Command(t *testing.T) {
+	if !Available("tee -a /dev/null") {
+		t.Error("Expected a command on PATH to be available")
+	}
+	if Available("coconut-no-such-clipboard-tool --in") {
+		t.Error("Expected a missing command to be unavailable")
+	}
+}
+
+func TestCopy_Command(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "clip")
+
+	// tee writes its stdin to the file, standing in for a clipboard tool
+	if err := Copy("s3cret value", "tee "+out); err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	if string(data) != "s3cret value" {
+		t.Errorf("Expected the value on stdin, got %q", data)
+	}
+}
+
+func TestCopy_CommandFails(t *testing.T) {
+	if err := Copy("x", "false"); err == nil {
+		t.Error("Expected an error when the command fails")
+	}
+	if err := Copy("x", "coconut-no-such-clipboard-tool"); err == nil {
+		t.Error("Expected an error when the command does not exist")
+	}
+}
+
+func env(vars map[string]string) func(string) string {
+	return func(key string) string { return vars[key] }
+}
+
+func TestOSC52(t *testing.T) {
+	// "hunter2" in base64 is aHVudGVyMg==
+	plain := OSC52("hunter2", env(map[string]string{"TERM": "xterm-256color"}))
+	if plain != "\x1b]52;c;aHVudGVyMg==\a" {
+		t.Errorf("Unexpected sequence: %q", plain)
+	}
+
+	tmux := OSC52("hunter2", env(map[string]string{"TERM": "screen", "TMUX": "/tmp/tmux-1000/default,1,0"}))
+	if tmux != "\x1bPtmux;\x1b\x1b]52;c;aHVudGVyMg==\a\x1b\\" {
+		t.Errorf("Unexpected tmux sequence: %q", tmux)
+	}
+
+	screen := OSC52("hunter2", env(map[string]string{"TERM": "screen.xterm-256color"}))
+	if screen != "\x1bP\x1b]52;c;aHVudGVyMg==\a\x1b\\" {
+		t.Errorf("Unexpected screen sequence: %q", screen)
+	}
+}
+
+func TestOSC52Unsupported(t *testing.T) {
+	for _, term := range []string{"", "dumb", "linux"} {
+		if osc52Unsupported(env(map[string]string{"TERM": term})) == "" {
+			t.Errorf("Expected TERM=%q to be unsupported", term)
+		}
+	}
+	if reason := osc52Unsupported(env(map[string]string{"TERM": "xterm-256color"})); reason != "" {
+		t.Errorf("Expected xterm to be supported, got %q", reason)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	if Fingerprint("a") == Fingerprint("b") {
+		t.Error("Different values should have different fingerprints")
+	}
+	if Fingerprint("hunter2") != Fingerprint("hunter2") {
+		t.Error("Fingerprint should be deterministic")
+	}
+	if strings.Contains(Fingerprint("hunter2"), "hunter2") {
+		t.Error("Fingerprint must not contain the value")
+	}
+}
+
+func TestClearIfUnchanged_Command(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "clip")
+	if err := os.WriteFile(out, []byte("s3cret"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ClearIfUnchanged(Fingerprint("s3cret"), "tee "+out, false); err != nil {
+		t.Fatalf("ClearIfUnchanged failed: %v", err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+	if len(data) != 0 {
+		t.Errorf("Expected the command to receive an empty value, got %q", data)
+	}
+}
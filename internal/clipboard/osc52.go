@@ -0,0 +1,63 @@
+package clipboard
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ErrOSC52Unsupported is returned by CopyOSC52 when the terminal clearly
+// cannot take an OSC 52 sequence, so the caller can fall back.
+var ErrOSC52Unsupported = errors.New("terminal does not support OSC 52")
+
+// OSC52 returns the escape sequence that asks the terminal to set its
+// clipboard to value. Inside tmux or GNU screen the sequence is wrapped
+// so the multiplexer passes it through to the outer terminal.
+func OSC52(value string, getenv func(string) string) string {
+	seq := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(value)) + "\a"
+
+	switch {
+	case getenv("TMUX") != "":
+		return "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+	case strings.HasPrefix(getenv("TERM"), "screen"):
+		return "\x1bP" + seq + "\x1b\\"
+	}
+	return seq
+}
+
+// osc52Unsupported explains why the environment cannot use OSC 52, or
+// returns "" if it might.
+func osc52Unsupported(getenv func(string) string) string {
+	switch term := getenv("TERM"); term {
+	case "":
+		return "TERM is not set"
+	case "dumb":
+		return "TERM is dumb"
+	case "linux":
+		return "the Linux console has no clipboard"
+	}
+	return ""
+}
+
+// CopyOSC52 writes the OSC 52 sequence for value to the controlling
+// terminal, which works over SSH as long as the local terminal supports
+// it. Whether the terminal honored it cannot be detected. An empty value
+// clears the clipboard.
+func CopyOSC52(value string) error {
+	if reason := osc52Unsupported(os.Getenv); reason != "" {
+		return fmt.Errorf("%w: %s", ErrOSC52Unsupported, reason)
+	}
+
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("%w: no controlling terminal", ErrOSC52Unsupported)
+	}
+	defer tty.Close()
+
+	if _, err := tty.WriteString(OSC52(value, os.Getenv)); err != nil {
+		return fmt.Errorf("failed to write to terminal: %w", err)
+	}
+	return nil
+}
@@ -1,34 +1,76 @@
 package config
 
 import (
-	"os"
 	"path/filepath"
+
+	"github.com/ompatil-15/coconut/internal/paths"
+	"github.com/ompatil-15/coconut/internal/policy"
 )
 
 type Config struct {
-	DBPath        string
-	SystemBucket  string
-	SecretsBucket string
-	AutoLockSecs  int
-	AppName       string
-	Version       string
-	Author        string
+	DBPath             string
+	SystemBucket       string
+	SecretsBucket      string
+	IndexBucket        string
+	JournalBucket      string
+	QuarantineBucket   string
+	AutoLockSecs       int
+	AccessLog          bool
+	BackupKeep         int
+	Policy             policy.Policy // composition rules for passwords entered on add/update
+	ClipboardCmd       string        // command that receives copied values on stdin; empty uses the system clipboard
+	ClipboardOSC52     bool          // copy by sending an OSC 52 escape sequence to the terminal
+	ClipboardClearSecs int           // clear the clipboard this many seconds after a copy; 0 disables
+	LogFormat          string        // "text" or "json" lines in the log file
+	LogLevel           string        // least severe level written to the log file: info, warn or error
+	GenerateLength     int           // length of generated passwords when --length is not given
+	GenerateWords      int           // words in generated passphrases when --words is not given
+	PlaintextIndex     bool          // keep names, usernames, URLs etc. unencrypted for fast list/search
+	SecretShards       int           // buckets the secret records are spread over; 0 or 1 keeps them in SecretsBucket
+	MaskChar           string        // character a hidden password is shown as
+	MaskLength         int           // characters in a hidden password; 0 uses the password's real length
+	FilePath           string        // config file whose settings override the stored ones, if any
+	AppName            string
+	Version            string
+	Author             string
+
+	stored     *Config         // values before a config file overrode them
+	overridden map[string]bool // settings the config file sets
 }
 
 func Default() *Config {
-	home, err := os.UserHomeDir()
+	base, err := paths.DataDir()
 	if err != nil {
-		home = "."
+		base = filepath.Join(".", ".coconut")
 	}
-	base := filepath.Join(home, ".coconut")
 
 	return &Config{
-		DBPath:        filepath.Join(base, "coconut.db"),
-		SystemBucket:  "system",
-		SecretsBucket: "secrets",
-		AutoLockSecs:  300,
-		AppName:       "coconut",
-		Version:       "1.0.0",
-		Author:        "Om Patil <patilom001@gmail.com>",
+		DBPath:           filepath.Join(base, "coconut.db"),
+		SystemBucket:     "system",
+		SecretsBucket:    "secrets",
+		IndexBucket:      "secrets_index",
+		JournalBucket:    "journal",
+		QuarantineBucket: "quarantine",
+		AutoLockSecs:     300,
+		AccessLog:        true,
+		BackupKeep:       10,
+		LogFormat:        "text",
+		LogLevel:         "info",
+		GenerateLength:   16,
+		GenerateWords:    6,
+		MaskChar:         "*",
+		MaskLength:       8,
+		AppName:          "coconut",
+		Version:          "1.0.0",
+		Author:           "Om Patil <patilom001@gmail.com>",
+	}
+}
+
+// MetadataIndexBucket returns the bucket of the plaintext metadata index,
+// or "" when PlaintextIndex is off and no index is kept.
+func (c *Config) MetadataIndexBucket() string {
+	if !c.PlaintextIndex {
+		return ""
 	}
+	return c.IndexBucket
 }
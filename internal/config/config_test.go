@@ -28,6 +28,11 @@ func (m *mockRepository) Get(key string) ([]byte, error) {
 	return nil, errors.New("key not found")
 }
 
+func (m *mockRepository) Has(key string) (bool, error) {
+	_, exists := m.data[key]
+	return exists, nil
+}
+
 func (m *mockRepository) Delete(key string) error {
 	if m.data == nil {
 		return errors.New("key not found")
@@ -45,6 +50,8 @@ func (m *mockRepository) ListKeys() ([]string, error) {
 }
 
 func TestDefault(t *testing.T) {
+	// Without XDG_DATA_HOME the data directory is ~/.coconut
+	t.Setenv("XDG_DATA_HOME", "")
 	cfg := Default()
 
 	if cfg == nil {
@@ -263,4 +270,272 @@ func TestConfig_DefaultPaths(t *testing.T) {
 
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || (len(s) > len(substr) && (s[:len(substr)+1] == substr+"/" || s[len(s)-len(substr)-1:] == "/"+substr || contains(s[1:], substr))))
-}
\ No newline at end of file
+}
+
+func TestConfig_AccessLogRoundTrip(t *testing.T) {
+	repo := &mockRepository{}
+
+	cfg := Default()
+	if !cfg.AccessLog {
+		t.Fatal("Access log should be enabled by default")
+	}
+
+	cfg.AccessLog = false
+	if err := Save(repo, cfg); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	loaded, err := Load(repo)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if loaded.AccessLog {
+		t.Error("Expected AccessLog to stay disabled after round trip")
+	}
+}
+
+func TestLoad_AccessLogDefaultsWhenMissing(t *testing.T) {
+	// Configs saved before the access log existed have no such field
+	repo := &mockRepository{
+		data: map[string][]byte{
+			"config:data": []byte(`{"autoLockSecs":600}`),
+		},
+	}
+
+	loaded, err := Load(repo)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !loaded.AccessLog {
+		t.Error("Expected AccessLog to default to enabled for older configs")
+	}
+}
+
+func TestConfig_BackupKeepRoundTrip(t *testing.T) {
+	repo := &mockRepository{}
+
+	cfg := Default()
+	if cfg.BackupKeep != 10 {
+		t.Fatalf("Expected default BackupKeep 10, got %d", cfg.BackupKeep)
+	}
+
+	cfg.BackupKeep = 0
+	if err := Save(repo, cfg); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	loaded, err := Load(repo)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if loaded.BackupKeep != 0 {
+		t.Errorf("Expected BackupKeep 0 after round trip, got %d", loaded.BackupKeep)
+	}
+}
+
+func TestConfig_PolicyRoundTrip(t *testing.T) {
+	repo := &mockRepository{}
+
+	cfg := Default()
+	if !cfg.Policy.IsEmpty() {
+		t.Fatalf("Expected no password policy by default, got %s", cfg.Policy)
+	}
+
+	cfg.Policy.MinLength = 14
+	cfg.Policy.RequireDigit = true
+	cfg.Policy.Enforce = true
+	if err := Save(repo, cfg); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	loaded, err := Load(repo)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if loaded.Policy != cfg.Policy {
+		t.Errorf("Expected policy %+v after round trip, got %+v", cfg.Policy, loaded.Policy)
+	}
+}
+
+func TestConfig_ClipboardCmdRoundTrip(t *testing.T) {
+	repo := &mockRepository{}
+
+	cfg := Default()
+	cfg.ClipboardCmd = "xclip -selection clipboard"
+	cfg.ClipboardClearSecs = 45
+	if err := Save(repo, cfg); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	loaded, err := Load(repo)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if loaded.ClipboardCmd != cfg.ClipboardCmd {
+		t.Errorf("Expected ClipboardCmd %q after round trip, got %q", cfg.ClipboardCmd, loaded.ClipboardCmd)
+	}
+	if loaded.ClipboardClearSecs != 45 {
+		t.Errorf("Expected ClipboardClearSecs 45 after round trip, got %d", loaded.ClipboardClearSecs)
+	}
+}
+
+func TestConfig_LogFormatRoundTrip(t *testing.T) {
+	repo := &mockRepository{}
+
+	if loaded, _ := Load(repo); loaded.LogFormat != "text" {
+		t.Errorf("Expected default log format text, got %q", loaded.LogFormat)
+	}
+
+	cfg := Default()
+	cfg.LogFormat = "json"
+	if err := Save(repo, cfg); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	loaded, err := Load(repo)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if loaded.LogFormat != "json" {
+		t.Errorf("Expected LogFormat json after round trip, got %q", loaded.LogFormat)
+	}
+}
+
+func TestConfig_PlaintextIndexRoundTrip(t *testing.T) {
+	repo := &mockRepository{}
+
+	loaded, _ := Load(repo)
+	if loaded.PlaintextIndex || loaded.MetadataIndexBucket() != "" {
+		t.Error("Expected the plaintext index to be off by default")
+	}
+
+	cfg := Default()
+	cfg.PlaintextIndex = true
+	if err := Save(repo, cfg); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	loaded, err := Load(repo)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !loaded.PlaintextIndex {
+		t.Error("Expected PlaintextIndex to survive a round trip")
+	}
+	if loaded.MetadataIndexBucket() != loaded.IndexBucket {
+		t.Errorf("Expected index bucket %q when on, got %q", loaded.IndexBucket, loaded.MetadataIndexBucket())
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	dir := t.TempDir()
+
+	yaml := filepath.Join(dir, "config.yaml")
+	content := "# comment\n---\nautolock: 15m\nclipboard_cmd: \"xclip -selection clipboard\"  # trailing\nmask-char: '•'\nmask-length: actual # real\n"
+	if err := os.WriteFile(yaml, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if got := FindFile(dir); got != yaml {
+		t.Errorf("FindFile = %q, want %q", got, yaml)
+	}
+	settings, err := ReadFile(yaml)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	want := []FileSetting{
+		{Key: "autolock", Value: "15m", Line: 3},
+		{Key: "clipboard-cmd", Value: "xclip -selection clipboard", Line: 4},
+		{Key: "mask-char", Value: "•", Line: 5},
+		{Key: "mask-length", Value: "actual", Line: 6},
+	}
+	if len(settings) != len(want) {
+		t.Fatalf("ReadFile = %+v, want %+v", settings, want)
+	}
+	for i := range want {
+		if settings[i] != want[i] {
+			t.Errorf("setting %d = %+v, want %+v", i, settings[i], want[i])
+		}
+	}
+
+	toml := filepath.Join(dir, "config.toml")
+	if err := os.WriteFile(toml, []byte("clipboard-timeout = 30\ndb = \"~/v.db\"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	settings, err = ReadFile(toml)
+	if err != nil || len(settings) != 2 || settings[0].Value != "30" || settings[1].Value != "~/v.db" {
+		t.Errorf("ReadFile(toml) = %+v, %v", settings, err)
+	}
+
+	for _, bad := range []string{"[section]\n", "autolock\n", "policy:\n  min: 3\n", "mask-char: \"*\n", "autolock:\n"} {
+		if err := os.WriteFile(yaml, []byte(bad), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ReadFile(yaml); err == nil {
+			t.Errorf("Expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestSetFileValue(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+	if err := os.WriteFile(path, []byte("# keep me\nmask_char = \"#\"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetFileValue(path, "mask-char", "•"); err != nil {
+		t.Fatalf("SetFileValue failed: %v", err)
+	}
+	if err := SetFileValue(path, "clipboard-timeout", "30"); err != nil {
+		t.Fatalf("SetFileValue failed: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if want := "# keep me\nmask-char = \"•\"\nclipboard-timeout = 30\n"; string(data) != want {
+		t.Errorf("File = %q, want %q", data, want)
+	}
+	if settings, err := ReadFile(path); err != nil || len(settings) != 2 || settings[0].Value != "•" {
+		t.Errorf("Rewritten file reads back as %+v, %v", settings, err)
+	}
+
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Expected the file mode kept, got %v, %v", info.Mode(), err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("Expected no temporary files left behind, got %v", entries)
+	}
+}
+
+func TestSave_KeepsStoredValueOfOverriddenSettings(t *testing.T) {
+	repo := &mockRepository{}
+	cfg := Default()
+	cfg.AutoLockSecs = 600
+	if err := Save(repo, cfg); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	if err := cfg.Override("autolock", "config.yaml"); err != nil {
+		t.Fatalf("Override failed: %v", err)
+	}
+	cfg.AutoLockSecs = 60
+	cfg.BackupKeep = 3
+	if !cfg.Overridden("autolock") || cfg.Overridden("backup-keep") {
+		t.Error("Expected only autolock to be overridden")
+	}
+	if err := Save(repo, cfg); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	loaded, err := Load(repo)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if loaded.AutoLockSecs != 600 || loaded.BackupKeep != 3 {
+		t.Errorf("Stored autolock %d, backup-keep %d; want 600 and 3", loaded.AutoLockSecs, loaded.BackupKeep)
+	}
+	if cfg.AutoLockSecs != 60 {
+		t.Errorf("Save changed the effective autolock to %d", cfg.AutoLockSecs)
+	}
+
+	if err := cfg.Override("secret-shards", "config.yaml"); err == nil {
+		t.Error("Expected secret-shards to be refused")
+	}
+}
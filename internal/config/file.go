@@ -0,0 +1,305 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// FileNames are the config files looked for in the data directory, in
+// order; the first one found is used.
+var FileNames = []string{"config.yaml", "config.yml", "config.toml"}
+
+// FileDBKey is the config file setting that picks the vault database. It
+// is used before the vault is opened, so it is not one of FileKeys.
+const FileDBKey = "db"
+
+// fileKeys maps each setting a config file may override to a function
+// copying it from src to dst. Storage layout settings such as
+// secret-shards and plaintext-index are left out: they describe what is
+// in the vault, not how coconut behaves.
+var fileKeys = map[string]func(dst, src *Config){
+	"autolock":          func(dst, src *Config) { dst.AutoLockSecs = src.AutoLockSecs },
+	"access-log":        func(dst, src *Config) { dst.AccessLog = src.AccessLog },
+	"backup-keep":       func(dst, src *Config) { dst.BackupKeep = src.BackupKeep },
+	"policy-min-length": func(dst, src *Config) { dst.Policy.MinLength = src.Policy.MinLength },
+	"policy-require": func(dst, src *Config) {
+		dst.Policy.RequireUpper, dst.Policy.RequireLower = src.Policy.RequireUpper, src.Policy.RequireLower
+		dst.Policy.RequireDigit, dst.Policy.RequireSymbol = src.Policy.RequireDigit, src.Policy.RequireSymbol
+	},
+	"policy-enforce":    func(dst, src *Config) { dst.Policy.Enforce = src.Policy.Enforce },
+	"clipboard-cmd":     func(dst, src *Config) { dst.ClipboardCmd = src.ClipboardCmd },
+	"clipboard-osc52":   func(dst, src *Config) { dst.ClipboardOSC52 = src.ClipboardOSC52 },
+	"clipboard-timeout": func(dst, src *Config) { dst.ClipboardClearSecs = src.ClipboardClearSecs },
+	"log-format":        func(dst, src *Config) { dst.LogFormat = src.LogFormat },
+	"log-level":         func(dst, src *Config) { dst.LogLevel = src.LogLevel },
+	"generate-length":   func(dst, src *Config) { dst.GenerateLength = src.GenerateLength },
+	"generate-words":    func(dst, src *Config) { dst.GenerateWords = src.GenerateWords },
+	"mask-char":         func(dst, src *Config) { dst.MaskChar = src.MaskChar },
+	"mask-length":       func(dst, src *Config) { dst.MaskLength = src.MaskLength },
+}
+
+// FileKeys returns the settings a config file may override, sorted.
+func FileKeys() []string {
+	keys := make([]string, 0, len(fileKeys))
+	for key := range fileKeys {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// FileSetting is one "key: value" or "key = value" line of a config file.
+type FileSetting struct {
+	Key   string
+	Value string
+	Line  int
+}
+
+// FindFile returns the path of the first of FileNames in dir, or "" if
+// there is none.
+func FindFile(dir string) string {
+	for _, name := range FileNames {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+// ReadFile parses the config file at path. Only flat files are read:
+// one setting per line, "key: value" in YAML or "key = value" in TOML,
+// with # comments and optionally quoted values. Keys are the names
+// 'config set' takes; underscores may stand in for hyphens.
+func ReadFile(path string) ([]FileSetting, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	sep := fileSeparator(path)
+	var settings []FileSetting
+	scanner := bufio.NewScanner(file)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || trimmed == "---" {
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' || strings.HasPrefix(trimmed, "[") || strings.HasPrefix(trimmed, "- ") {
+			return nil, fmt.Errorf("%s line %d: only flat key%svalue settings are supported", filepath.Base(path), n, sep)
+		}
+
+		key, raw, ok := strings.Cut(trimmed, sep)
+		if !ok {
+			return nil, fmt.Errorf("%s line %d: expected key%svalue", filepath.Base(path), n, sep)
+		}
+		value, err := parseFileValue(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", filepath.Base(path), n, err)
+		}
+		settings = append(settings, FileSetting{Key: normalizeFileKey(key), Value: value, Line: n})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// SetFileValue sets key to value in the config file at path, replacing
+// the line that sets it or appending one. Comments and other lines are
+// kept as they are. The new contents are written to a temporary file that
+// is renamed over path, so a failure never leaves it half written.
+func SetFileValue(path, key, value string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	sep := fileSeparator(path)
+	entry := key + ": " + formatFileValue(value)
+	if sep == "=" {
+		entry = key + " = " + formatFileValue(value)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	replaced := false
+	for i, line := range lines {
+		k, _, ok := strings.Cut(line, sep)
+		if ok && line != "" && line[0] != ' ' && line[0] != '#' && normalizeFileKey(k) == key {
+			lines[i] = entry
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		lines = append(lines, entry)
+	}
+	return replaceFile(path, []byte(strings.Join(lines, "\n")+"\n"), info.Mode().Perm())
+}
+
+// replaceFile writes data to a temporary file with mode perm next to path,
+// syncs it and renames it over path.
+func replaceFile(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	done := false
+	defer func() {
+		if !done {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if err := tmp.Chmod(perm); err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	done = true
+	return nil
+}
+
+// fileSeparator is ":" for YAML files and "=" for TOML files.
+func fileSeparator(path string) string {
+	if strings.EqualFold(filepath.Ext(path), ".toml") {
+		return "="
+	}
+	return ":"
+}
+
+func normalizeFileKey(key string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(key)), "_", "-")
+}
+
+// parseFileValue unquotes a double- or single-quoted value, or strips a
+// trailing # comment from a bare one.
+func parseFileValue(raw string) (string, error) {
+	switch {
+	case strings.HasPrefix(raw, `"`):
+		end := closingQuote(raw)
+		if end < 0 {
+			return "", fmt.Errorf("unterminated quote in %s", raw)
+		}
+		value, err := strconv.Unquote(raw[:end+1])
+		if err != nil {
+			return "", fmt.Errorf("invalid quoted value %s", raw[:end+1])
+		}
+		return value, checkTrailing(raw[end+1:])
+	case strings.HasPrefix(raw, "'"):
+		for i := 1; i < len(raw); i++ {
+			if raw[i] != '\'' {
+				continue
+			}
+			if i+1 < len(raw) && raw[i+1] == '\'' {
+				i++
+				continue
+			}
+			return strings.ReplaceAll(raw[1:i], "''", "'"), checkTrailing(raw[i+1:])
+		}
+		return "", fmt.Errorf("unterminated quote in %s", raw)
+	}
+
+	if i := strings.Index(raw, " #"); i >= 0 {
+		raw = raw[:i]
+	}
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", fmt.Errorf("missing value")
+	}
+	return raw, nil
+}
+
+// closingQuote returns the index of the quote ending the double-quoted
+// string at the start of s, or -1.
+func closingQuote(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+func checkTrailing(rest string) error {
+	rest = strings.TrimSpace(rest)
+	if rest != "" && !strings.HasPrefix(rest, "#") {
+		return fmt.Errorf("unexpected %q after quoted value", rest)
+	}
+	return nil
+}
+
+// bareFileValue matches values written without quotes.
+var bareFileValue = regexp.MustCompile(`^[0-9]+$|^true$|^false$`)
+
+func formatFileValue(value string) string {
+	if bareFileValue.MatchString(value) {
+		return value
+	}
+	return strconv.Quote(value)
+}
+
+// Override marks key as set from the config file at path. Call it before
+// changing the setting on c: Save keeps writing the value c held until
+// then, so a config file's values are never copied into the vault.
+func (c *Config) Override(key, path string) error {
+	if _, ok := fileKeys[key]; !ok {
+		return fmt.Errorf("unknown setting %q", key)
+	}
+	if c.stored == nil {
+		stored := *c
+		c.stored = &stored
+		c.overridden = map[string]bool{}
+	}
+	c.overridden[key] = true
+	c.FilePath = path
+	return nil
+}
+
+// Overridden reports whether the config file sets key. For "policy" it
+// reports whether the file sets any policy-* setting.
+func (c *Config) Overridden(key string) bool {
+	if key == "policy" {
+		return c.overridden["policy-min-length"] || c.overridden["policy-require"] || c.overridden["policy-enforce"]
+	}
+	return c.overridden[key]
+}
+
+// withoutFile returns c with the settings the config file overrides put
+// back to the values it had before.
+func (c *Config) withoutFile() *Config {
+	if c.stored == nil {
+		return c
+	}
+	out := *c
+	for key := range c.overridden {
+		fileKeys[key](&out, c.stored)
+	}
+	return &out
+}
@@ -4,15 +4,30 @@ import (
 	"encoding/json"
 
 	"github.com/ompatil-15/coconut/internal/db"
+	"github.com/ompatil-15/coconut/internal/policy"
 )
 
 const configDataKey = "config:data"
 
 type storedConfig struct {
-	AutoLockSecs  int    `json:"autoLockSecs"`
-	DBPath        string `json:"dbPath"`
-	SystemBucket  string `json:"systemBucket"`
-	SecretsBucket string `json:"secretsBucket"`
+	AutoLockSecs       int            `json:"autoLockSecs"`
+	DBPath             string         `json:"dbPath"`
+	SystemBucket       string         `json:"systemBucket"`
+	SecretsBucket      string         `json:"secretsBucket"`
+	AccessLog          *bool          `json:"accessLog,omitempty"`
+	BackupKeep         *int           `json:"backupKeep,omitempty"`
+	Policy             *policy.Policy `json:"policy,omitempty"`
+	ClipboardCmd       string         `json:"clipboardCmd,omitempty"`
+	ClipboardOSC52     bool           `json:"clipboardOSC52,omitempty"`
+	ClipboardClearSecs int            `json:"clipboardClearSecs,omitempty"`
+	LogFormat          string         `json:"logFormat,omitempty"`
+	LogLevel           string         `json:"logLevel,omitempty"`
+	GenerateLength     int            `json:"generateLength,omitempty"`
+	GenerateWords      int            `json:"generateWords,omitempty"`
+	PlaintextIndex     bool           `json:"plaintextIndex,omitempty"`
+	SecretShards       int            `json:"secretShards,omitempty"`
+	MaskChar           string         `json:"maskChar,omitempty"`
+	MaskLength         *int           `json:"maskLength,omitempty"`
 }
 
 // Load retrieves configuration from the system repository, applying defaults when not present.
@@ -39,17 +54,65 @@ func Load(systemRepo db.Repository) (*Config, error) {
 	if stored.SecretsBucket != "" {
 		cfg.SecretsBucket = stored.SecretsBucket
 	}
+	if stored.AccessLog != nil {
+		cfg.AccessLog = *stored.AccessLog
+	}
+	if stored.BackupKeep != nil {
+		cfg.BackupKeep = *stored.BackupKeep
+	}
+	if stored.Policy != nil {
+		cfg.Policy = *stored.Policy
+	}
+	cfg.ClipboardCmd = stored.ClipboardCmd
+	cfg.ClipboardOSC52 = stored.ClipboardOSC52
+	cfg.ClipboardClearSecs = stored.ClipboardClearSecs
+	if stored.LogFormat != "" {
+		cfg.LogFormat = stored.LogFormat
+	}
+	if stored.LogLevel != "" {
+		cfg.LogLevel = stored.LogLevel
+	}
+	if stored.GenerateLength != 0 {
+		cfg.GenerateLength = stored.GenerateLength
+	}
+	if stored.GenerateWords != 0 {
+		cfg.GenerateWords = stored.GenerateWords
+	}
+	cfg.PlaintextIndex = stored.PlaintextIndex
+	cfg.SecretShards = stored.SecretShards
+	if stored.MaskChar != "" {
+		cfg.MaskChar = stored.MaskChar
+	}
+	if stored.MaskLength != nil {
+		cfg.MaskLength = *stored.MaskLength
+	}
 
 	return cfg, nil
 }
 
 // Save persists configuration values that can change at runtime.
+// Settings a config file overrides keep their stored values.
 func Save(systemRepo db.Repository, cfg *Config) error {
+	cfg = cfg.withoutFile()
 	stored := storedConfig{
-		AutoLockSecs:  cfg.AutoLockSecs,
-		DBPath:        cfg.DBPath,
-		SystemBucket:  cfg.SystemBucket,
-		SecretsBucket: cfg.SecretsBucket,
+		AutoLockSecs:       cfg.AutoLockSecs,
+		DBPath:             cfg.DBPath,
+		SystemBucket:       cfg.SystemBucket,
+		SecretsBucket:      cfg.SecretsBucket,
+		AccessLog:          &cfg.AccessLog,
+		BackupKeep:         &cfg.BackupKeep,
+		Policy:             &cfg.Policy,
+		ClipboardCmd:       cfg.ClipboardCmd,
+		ClipboardOSC52:     cfg.ClipboardOSC52,
+		ClipboardClearSecs: cfg.ClipboardClearSecs,
+		LogFormat:          cfg.LogFormat,
+		LogLevel:           cfg.LogLevel,
+		GenerateLength:     cfg.GenerateLength,
+		GenerateWords:      cfg.GenerateWords,
+		PlaintextIndex:     cfg.PlaintextIndex,
+		SecretShards:       cfg.SecretShards,
+		MaskChar:           cfg.MaskChar,
+		MaskLength:         &cfg.MaskLength,
 	}
 
 	payload, err := json.Marshal(stored)
@@ -59,6 +59,49 @@ func BenchmarkAESGCM_EncryptDecrypt(b *testing.B) {
 	}
 }
 
+// BenchmarkKeyedAESGCM_Encrypt is BenchmarkAESGCM_Encrypt with the cipher
+// set up once, as the repository does for bulk operations.
+func BenchmarkKeyedAESGCM_Encrypt(b *testing.B) {
+	key := make([]byte, 32)
+	rand.Read(key)
+	keyed, err := NewAESGCMWithKey(key)
+	if err != nil {
+		b.Fatalf("NewAESGCMWithKey failed: %v", err)
+	}
+	plaintext := "This is a test message for encryption benchmarking"
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := keyed.Encrypt(plaintext)
+		if err != nil {
+			b.Fatalf("Encrypt failed: %v", err)
+		}
+	}
+}
+
+func BenchmarkKeyedAESGCM_Decrypt(b *testing.B) {
+	key := make([]byte, 32)
+	rand.Read(key)
+	keyed, err := NewAESGCMWithKey(key)
+	if err != nil {
+		b.Fatalf("NewAESGCMWithKey failed: %v", err)
+	}
+	plaintext := "This is a test message for decryption benchmarking"
+
+	ciphertext, err := keyed.Encrypt(plaintext)
+	if err != nil {
+		b.Fatalf("Setup encrypt failed: %v", err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := keyed.Decrypt(ciphertext)
+		if err != nil {
+			b.Fatalf("Decrypt failed: %v", err)
+		}
+	}
+}
+
 func BenchmarkDeriveKey(b *testing.B) {
 	password := "test-password-for-benchmarking"
 	salt := GenerateRandomSalt(16)
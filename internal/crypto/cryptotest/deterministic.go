@@ -0,0 +1,55 @@
+// Package cryptotest provides crypto strategies for tests. Nothing in it
+// is safe to use for a real vault.
+package cryptotest
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/binary"
+	"sync"
+
+	"github.com/ompatil-15/coconut/internal/crypto"
+)
+
+// Deterministic is AES-GCM with nonces taken from a counter instead of
+// crypto/rand, so the same sequence of encryptions always produces the
+// same ciphertexts. It is meant for tests that compare ciphertext or
+// stored bytes. Never use it for a real vault: every process starts the
+// counter again, so nonces repeat under the same key, which breaks
+// AES-GCM. Its output decrypts with crypto.AESGCM and the other way round.
+type Deterministic struct {
+	mu      sync.Mutex
+	counter uint64
+}
+
+func NewDeterministic() *Deterministic {
+	return &Deterministic{}
+}
+
+func (d *Deterministic) Encrypt(key []byte, plaintext string) (string, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+
+	aesgcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+
+	d.mu.Lock()
+	d.counter++
+	n := d.counter
+	d.mu.Unlock()
+
+	nonce := make([]byte, aesgcm.NonceSize())
+	binary.BigEndian.PutUint64(nonce[len(nonce)-8:], n)
+
+	ciphertext := aesgcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.RawStdEncoding.EncodeToString(ciphertext), nil
+}
+
+func (d *Deterministic) Decrypt(key []byte, encoded string) (string, error) {
+	return crypto.NewAESGCM().Decrypt(key, encoded)
+}
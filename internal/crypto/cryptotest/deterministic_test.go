@@ -0,0 +1,39 @@
+package cryptotest
+
+import (
+	"testing"
+
+	"github.com/ompatil-15/coconut/internal/crypto"
+)
+
+func TestDeterministic_Repeatable(t *testing.T) {
+	key := make([]byte, 32)
+
+	encryptAll := func() []string {
+		d := NewDeterministic()
+		var out []string
+		for _, msg := range []string{"one", "two", "one"} {
+			ct, err := d.Encrypt(key, msg)
+			if err != nil {
+				t.Fatalf("Encrypt failed: %v", err)
+			}
+			out = append(out, ct)
+		}
+		return out
+	}
+
+	first, second := encryptAll(), encryptAll()
+	for i := range first {
+		if first[i] != second[i] {
+			t.Errorf("Encryption %d differs between runs: %s vs %s", i, first[i], second[i])
+		}
+	}
+	if first[0] == first[2] {
+		t.Error("Expected each encryption to use a new nonce")
+	}
+
+	plain, err := crypto.NewAESGCM().Decrypt(key, first[1])
+	if err != nil || plain != "two" {
+		t.Errorf("Expected AESGCM to decrypt deterministic output, got %q, %v", plain, err)
+	}
+}
@@ -0,0 +1,209 @@
+package crypto
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Supported key derivation algorithms. Argon2id is the default.
+const (
+	KDFArgon2id = "argon2id"
+	KDFScrypt   = "scrypt"
+)
+
+// KDFParams records how a vault's key is derived from the master password.
+// They are stored per vault so unlock always uses the values chosen at init.
+// Time, MemoryKiB and Threads apply to Argon2id; N, R and P to scrypt.
+type KDFParams struct {
+	Algorithm string `json:"algorithm"`
+	Time      uint32 `json:"time"`
+	MemoryKiB uint32 `json:"memoryKiB"`
+	Threads   uint8  `json:"threads"`
+	N         uint32 `json:"n,omitempty"`
+	R         uint32 `json:"r,omitempty"`
+	P         uint32 `json:"p,omitempty"`
+	KeyLen    uint32 `json:"keyLen"`
+}
+
+// KDF derives a vault key from the master password. Derive is only called
+// with parameters that passed Validate, but still reports any error the
+// underlying function returns rather than panicking.
+type KDF interface {
+	Derive(password string, salt []byte, p KDFParams) ([]byte, error)
+	// DefaultParams returns the algorithm's parameters for a new vault.
+	DefaultParams() KDFParams
+	Validate(p KDFParams) error
+	// Memory returns the bytes one derivation with p needs.
+	Memory(p KDFParams) uint64
+}
+
+var kdfs = map[string]KDF{
+	KDFArgon2id: argon2idKDF{},
+	KDFScrypt:   scryptKDF{},
+}
+
+// KDFs returns the names of the supported algorithms, sorted.
+func KDFs() []string {
+	names := make([]string, 0, len(kdfs))
+	for name := range kdfs {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// LookupKDF returns the implementation of the named algorithm.
+func LookupKDF(algorithm string) (KDF, error) {
+	kdf, ok := kdfs[strings.ToLower(algorithm)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported key derivation algorithm %q (supported: %s)", algorithm, strings.Join(KDFs(), ", "))
+	}
+	return kdf, nil
+}
+
+// DefaultKDFParamsFor returns the default AES-256 parameters of algorithm.
+func DefaultKDFParamsFor(algorithm string) (KDFParams, error) {
+	kdf, err := LookupKDF(algorithm)
+	if err != nil {
+		return KDFParams{}, err
+	}
+	return kdf.DefaultParams(), nil
+}
+
+// DefaultKDFParams returns the parameters used by DeriveKey (AES-256).
+// Vaults created before parameters were recorded use these.
+func DefaultKDFParams() KDFParams {
+	return KDFParams{
+		Algorithm: KDFArgon2id,
+		Time:      3,
+		MemoryKiB: 64 * 1024,
+		Threads:   4,
+		KeyLen:    32,
+	}
+}
+
+// KeyBits returns the derived key size in bits.
+func (p KDFParams) KeyBits() int {
+	return int(p.KeyLen) * 8
+}
+
+// MemoryBytes returns the memory one derivation needs, or 0 for an unknown
+// algorithm.
+func (p KDFParams) MemoryBytes() uint64 {
+	kdf, ok := kdfs[p.Algorithm]
+	if !ok {
+		return 0
+	}
+	return kdf.Memory(p)
+}
+
+// Validate rejects parameters the cipher or KDF cannot use.
+func (p KDFParams) Validate() error {
+	kdf, ok := kdfs[p.Algorithm]
+	if !ok {
+		return fmt.Errorf("unsupported key derivation algorithm %q", p.Algorithm)
+	}
+	if err := kdf.Validate(p); err != nil {
+		return err
+	}
+	return ValidateKeyLen(int(p.KeyLen))
+}
+
+// ValidateKeyLen checks that n is an AES key size this vault format supports:
+// 16 bytes (AES-128) or 32 bytes (AES-256).
+func ValidateKeyLen(n int) error {
+	if n != 16 && n != 32 {
+		return fmt.Errorf("unsupported key length %d bytes: must be 16 (AES-128) or 32 (AES-256)", n)
+	}
+	return nil
+}
+
+// KeyLenForBits converts a --key-bits value into a key length in bytes.
+func KeyLenForBits(bits int) (uint32, error) {
+	switch bits {
+	case 128:
+		return 16, nil
+	case 256:
+		return 32, nil
+	default:
+		return 0, fmt.Errorf("invalid key size %d: must be 128 or 256", bits)
+	}
+}
+
+// DeriveKeyWithParams derives a vault key using the given parameters.
+func DeriveKeyWithParams(password string, salt []byte, p KDFParams) ([]byte, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return kdfs[p.Algorithm].Derive(password, salt, p)
+}
+
+// maxArgon2Time caps the Argon2id passes a vault or file can ask for, so
+// parameters read from disk cannot make unlocking run for hours.
+const maxArgon2Time = 64
+
+type argon2idKDF struct{}
+
+func (argon2idKDF) Derive(password string, salt []byte, p KDFParams) ([]byte, error) {
+	return argon2.IDKey([]byte(password), salt, p.Time, p.MemoryKiB, p.Threads, p.KeyLen), nil
+}
+
+func (argon2idKDF) DefaultParams() KDFParams {
+	return DefaultKDFParams()
+}
+
+func (argon2idKDF) Validate(p KDFParams) error {
+	if p.Time == 0 || p.MemoryKiB == 0 || p.Threads == 0 {
+		return fmt.Errorf("invalid %s parameters: time, memory and threads must be positive", p.Algorithm)
+	}
+	if p.Time > maxArgon2Time {
+		return fmt.Errorf("invalid %s parameters: time must be at most %d", p.Algorithm, maxArgon2Time)
+	}
+	return nil
+}
+
+func (argon2idKDF) Memory(p KDFParams) uint64 {
+	return uint64(p.MemoryKiB) * 1024
+}
+
+// scryptKDF uses N=2^16, r=8, p=1 by default, which like the Argon2id
+// default needs 64 MiB of memory per derivation.
+type scryptKDF struct{}
+
+func (scryptKDF) Derive(password string, salt []byte, p KDFParams) ([]byte, error) {
+	key, err := scrypt.Key([]byte(password), salt, int(p.N), int(p.R), int(p.P), int(p.KeyLen))
+	if err != nil {
+		return nil, fmt.Errorf("scrypt: %w", err)
+	}
+	return key, nil
+}
+
+func (scryptKDF) DefaultParams() KDFParams {
+	return KDFParams{
+		Algorithm: KDFScrypt,
+		N:         1 << 16,
+		R:         8,
+		P:         1,
+		KeyLen:    32,
+	}
+}
+
+func (scryptKDF) Validate(p KDFParams) error {
+	if p.N < 2 || p.N&(p.N-1) != 0 {
+		return fmt.Errorf("invalid %s parameters: N must be a power of two greater than 1", p.Algorithm)
+	}
+	if p.R == 0 || p.P == 0 || uint64(p.R)*uint64(p.P) >= 1<<30 {
+		return fmt.Errorf("invalid %s parameters: r and p must be positive with r*p below 2^30", p.Algorithm)
+	}
+	return nil
+}
+
+// Memory counts scrypt's two large buffers: 128*N*r bytes for the mixing
+// table and 128*r*p for the blocks.
+func (scryptKDF) Memory(p KDFParams) uint64 {
+	return 128 * uint64(p.R) * (uint64(p.N) + uint64(p.P))
+}
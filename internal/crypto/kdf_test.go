@@ -0,0 +1,167 @@
+package crypto
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDeriveKeyWithParams_DefaultMatchesDeriveKey(t *testing.T) {
+	salt := GenerateRandomSalt(16)
+
+	key, err := DeriveKeyWithParams("test-password-123", salt, DefaultKDFParams())
+	if err != nil {
+		t.Fatalf("DeriveKeyWithParams failed: %v", err)
+	}
+
+	// Existing vaults have no recorded params and must keep unlocking
+	if !bytes.Equal(key, DeriveKey("test-password-123", salt)) {
+		t.Error("Default params should derive the same key as DeriveKey")
+	}
+}
+
+func TestDeriveKeyWithParams_AES128(t *testing.T) {
+	params := DefaultKDFParams()
+	params.KeyLen = 16
+
+	key, err := DeriveKeyWithParams("test-password-123", GenerateRandomSalt(16), params)
+	if err != nil {
+		t.Fatalf("DeriveKeyWithParams failed: %v", err)
+	}
+	if len(key) != 16 {
+		t.Fatalf("Expected 16-byte key, got %d", len(key))
+	}
+
+	// The derived key must be usable by the cipher
+	aes := NewAESGCM()
+	ciphertext, err := aes.Encrypt(key, "secret")
+	if err != nil {
+		t.Fatalf("Encrypt with AES-128 key failed: %v", err)
+	}
+	plaintext, err := aes.Decrypt(key, ciphertext)
+	if err != nil || plaintext != "secret" {
+		t.Errorf("Round trip with AES-128 key failed: %q, %v", plaintext, err)
+	}
+}
+
+func TestDeriveKeyWithParams_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(p *KDFParams)
+	}{
+		{"key length 24", func(p *KDFParams) { p.KeyLen = 24 }},
+		{"unknown algorithm", func(p *KDFParams) { p.Algorithm = "md5" }},
+		{"zero time", func(p *KDFParams) { p.Time = 0 }},
+		{"time too high", func(p *KDFParams) { p.Time = maxArgon2Time + 1 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := DefaultKDFParams()
+			tt.modify(&params)
+			if _, err := DeriveKeyWithParams("pw", []byte("salt"), params); err == nil {
+				t.Error("Expected invalid params to be rejected")
+			}
+		})
+	}
+}
+
+func TestKeyLenForBits(t *testing.T) {
+	if n, err := KeyLenForBits(128); err != nil || n != 16 {
+		t.Errorf("Expected 16 bytes for 128 bits, got %d, %v", n, err)
+	}
+	if n, err := KeyLenForBits(256); err != nil || n != 32 {
+		t.Errorf("Expected 32 bytes for 256 bits, got %d, %v", n, err)
+	}
+	if _, err := KeyLenForBits(192); err == nil {
+		t.Error("192-bit keys should be rejected")
+	}
+}
+
+func TestDeriveKeyWithParams_Scrypt(t *testing.T) {
+	params, err := DefaultKDFParamsFor(KDFScrypt)
+	if err != nil {
+		t.Fatalf("DefaultKDFParamsFor failed: %v", err)
+	}
+	params.N = 1 << 10 // keep the test fast
+	salt := GenerateRandomSalt(16)
+
+	key, err := DeriveKeyWithParams("test-password-123", salt, params)
+	if err != nil {
+		t.Fatalf("DeriveKeyWithParams failed: %v", err)
+	}
+	if len(key) != 32 {
+		t.Fatalf("Expected 32-byte key, got %d", len(key))
+	}
+
+	again, _ := DeriveKeyWithParams("test-password-123", salt, params)
+	if !bytes.Equal(key, again) {
+		t.Error("scrypt should derive the same key from the same inputs")
+	}
+
+	argon := DefaultKDFParams()
+	if other, _ := DeriveKeyWithParams("test-password-123", salt, argon); bytes.Equal(key, other) {
+		t.Error("scrypt and argon2id should derive different keys")
+	}
+}
+
+func TestDeriveKeyWithParams_ScryptError(t *testing.T) {
+	params, _ := DefaultKDFParamsFor(KDFScrypt)
+	params.N = 1 << 31
+	params.R = 1 << 29 // passes Validate, but N*r is more than scrypt can address
+
+	if _, err := DeriveKeyWithParams("pw", []byte("salt"), params); err == nil {
+		t.Error("Expected scrypt's error to be returned")
+	}
+}
+
+func TestDeriveKeyWithParams_InvalidScrypt(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(p *KDFParams)
+	}{
+		{"N not a power of two", func(p *KDFParams) { p.N = 1000 }},
+		{"N of 1", func(p *KDFParams) { p.N = 1 }},
+		{"zero r", func(p *KDFParams) { p.R = 0 }},
+		{"zero p", func(p *KDFParams) { p.P = 0 }},
+		{"r*p too large", func(p *KDFParams) { p.R, p.P = 1<<15, 1<<15 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, _ := DefaultKDFParamsFor(KDFScrypt)
+			tt.modify(&params)
+			if _, err := DeriveKeyWithParams("pw", []byte("salt"), params); err == nil {
+				t.Error("Expected invalid params to be rejected")
+			}
+		})
+	}
+}
+
+func TestLookupKDF(t *testing.T) {
+	for _, name := range []string{KDFArgon2id, KDFScrypt, "SCRYPT"} {
+		if _, err := LookupKDF(name); err != nil {
+			t.Errorf("LookupKDF(%q) failed: %v", name, err)
+		}
+	}
+	if _, err := LookupKDF("pbkdf2"); err == nil {
+		t.Error("Expected unknown algorithm to be rejected")
+	}
+}
+
+func TestKDFParams_MemoryBytes(t *testing.T) {
+	if got := DefaultKDFParams().MemoryBytes(); got != 64<<20 {
+		t.Errorf("Expected 64 MiB for the Argon2id default, got %d", got)
+	}
+
+	scrypt, err := DefaultKDFParamsFor(KDFScrypt)
+	if err != nil {
+		t.Fatalf("DefaultKDFParamsFor failed: %v", err)
+	}
+	if got := scrypt.MemoryBytes(); got != 128*8*(1<<16+1) {
+		t.Errorf("Expected 128*r*(N+p) bytes for scrypt, got %d", got)
+	}
+
+	if got := (KDFParams{Algorithm: "pbkdf2"}).MemoryBytes(); got != 0 {
+		t.Errorf("Expected 0 for an unknown algorithm, got %d", got)
+	}
+}
@@ -0,0 +1,94 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io"
+)
+
+// Cipher encrypts and decrypts under a key bound when it was created.
+type Cipher interface {
+	Encrypt(plaintext string) (string, error)
+	Decrypt(ciphertext string) (string, error)
+}
+
+// Keyer is implemented by strategies that can set up a Cipher for one key
+// ahead of time, for callers encrypting or decrypting many values under it.
+type Keyer interface {
+	WithKey(key []byte) (Cipher, error)
+}
+
+// KeyedAESGCM is AESGCM with the key schedule and GCM state built once,
+// rather than on every call. Its output is the same format as AESGCM's,
+// so either decrypts the other. It is safe for concurrent use.
+type KeyedAESGCM struct {
+	aead cipher.AEAD
+}
+
+// NewAESGCMWithKey returns a KeyedAESGCM for key. The key's expansion is
+// held until the KeyedAESGCM is dropped, so keep it no longer than the
+// operation that needs it.
+func NewAESGCMWithKey(key []byte) (*KeyedAESGCM, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	aesgcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &KeyedAESGCM{aead: aesgcm}, nil
+}
+
+// WithKey returns a KeyedAESGCM for key.
+func (a *AESGCM) WithKey(key []byte) (Cipher, error) {
+	return NewAESGCMWithKey(key)
+}
+
+func (k *KeyedAESGCM) Encrypt(plaintext string) (string, error) {
+	return k.EncryptWithData(plaintext, nil)
+}
+
+func (k *KeyedAESGCM) Decrypt(encoded string) (string, error) {
+	return k.DecryptWithData(encoded, nil)
+}
+
+// EncryptWithData is Encrypt that also authenticates additional, which is not
+// encrypted or included in the output. DecryptWithData must be given the
+// same data.
+func (k *KeyedAESGCM) EncryptWithData(plaintext string, additional []byte) (string, error) {
+	nonce := make([]byte, k.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	ciphertext := k.aead.Seal(nonce, nonce, []byte(plaintext), additional)
+	return base64.RawStdEncoding.EncodeToString(ciphertext), nil
+}
+
+// DecryptWithData decrypts what EncryptWithData wrote, failing if additional is
+// not what it was given.
+func (k *KeyedAESGCM) DecryptWithData(encoded string, additional []byte) (string, error) {
+	data, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", err
+	}
+
+	if len(data) < k.aead.NonceSize() {
+		return "", errors.New("malformed ciphertext")
+	}
+
+	nonce := data[:k.aead.NonceSize()]
+	ciphertext := data[k.aead.NonceSize():]
+
+	plaintext, err := k.aead.Open(nil, nonce, ciphertext, additional)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
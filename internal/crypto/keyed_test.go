@@ -0,0 +1,53 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"testing"
+)
+
+func TestKeyedAESGCM_MatchesAESGCM(t *testing.T) {
+	key := make([]byte, 32)
+	rand.Read(key)
+
+	keyed, err := NewAESGCMWithKey(key)
+	if err != nil {
+		t.Fatalf("NewAESGCMWithKey failed: %v", err)
+	}
+	plain := NewAESGCM()
+
+	ciphertext, err := keyed.Encrypt("hello world")
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	if got, err := plain.Decrypt(key, ciphertext); err != nil || got != "hello world" {
+		t.Errorf("AESGCM.Decrypt of keyed ciphertext = %q, %v", got, err)
+	}
+
+	ciphertext, err = plain.Encrypt(key, "and back")
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	if got, err := keyed.Decrypt(ciphertext); err != nil || got != "and back" {
+		t.Errorf("Keyed Decrypt of AESGCM ciphertext = %q, %v", got, err)
+	}
+
+	other := make([]byte, 32)
+	rand.Read(other)
+	if _, err := NewAESGCM().Decrypt(other, ciphertext); err == nil {
+		t.Error("Expected a different key to fail")
+	}
+	for _, bad := range []string{"not base64!", "", "YWJj"} {
+		if _, err := keyed.Decrypt(bad); err == nil {
+			t.Errorf("Expected Decrypt(%q) to fail", bad)
+		}
+	}
+}
+
+func TestNewAESGCMWithKey_InvalidKey(t *testing.T) {
+	if _, err := NewAESGCMWithKey(make([]byte, 7)); err == nil {
+		t.Error("Expected an invalid key size to be rejected")
+	}
+	if _, err := NewAESGCM().WithKey(make([]byte, 7)); err == nil {
+		t.Error("Expected WithKey to reject an invalid key size")
+	}
+}
@@ -0,0 +1,156 @@
+package crypto
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// gcmVector is a published AES-GCM test case (McGrew and Viega, "The
+// Galois/Counter Mode of Operation", test cases 3 and 15).
+type gcmVector struct {
+	name               string
+	key, iv, plaintext string
+	ciphertextAndTag   string
+}
+
+const gcmPlaintext = "d9313225f88406e5a55909c5aff5269a86a7a9531534f7da2e4c303d8a318a721c3c0c95956809532fcf0e2449a6b525b16aedf5aa0de657ba637b391aafd255"
+
+var gcmVectors = []gcmVector{
+	{
+		name:             "AES-128-GCM",
+		key:              "feffe9928665731c6d6a8f9467308308",
+		iv:               "cafebabefacedbaddecaf888",
+		plaintext:        gcmPlaintext,
+		ciphertextAndTag: "42831ec2217774244b7221b784d0d49ce3aa212f2c02a4e035c17e2329aca12e21d514b25466931c7d8f6a5aac84aa051ba30b396a0aac973d58e091473f59854d5c2af327cd64a62cf35abd2ba6fab4",
+	},
+	{
+		name:             "AES-256-GCM",
+		key:              "feffe9928665731c6d6a8f9467308308feffe9928665731c6d6a8f9467308308",
+		iv:               "cafebabefacedbaddecaf888",
+		plaintext:        gcmPlaintext,
+		ciphertextAndTag: "522dc1f099567d07f47f37a32a84427d643a8cdcbfe5c0c97598a2bd2555d1aa8cb08e48590dbb3da7b08b1056828838c5f61e6393ba7a0abcc9f662898015adb094dac5d93471bdec1a502270e3cc6c",
+	},
+}
+
+// kdfVector is a key derived once with a known-good build. The scrypt value
+// also matches Python's hashlib.scrypt.
+type kdfVector struct {
+	params KDFParams // zero Algorithm means DeriveKey
+	key    string
+}
+
+const (
+	selfTestPassword = "password"
+	selfTestSalt     = "coconut-selftest"
+)
+
+var kdfVectors = []kdfVector{
+	{key: "40ae0758557e05a5fdc6b9a6c123f6086605cc9809b643479e39ed93a6381516"},
+	{
+		params: KDFParams{Algorithm: KDFScrypt, N: 1024, R: 8, P: 1, KeyLen: 32},
+		key:    "f5951bba8079148b55cd4340248d7961f9babba8a2bea108b6f680b2cfb6188b",
+	},
+}
+
+// SelfTest checks that the cipher and key derivation in this build give
+// the expected results, so a broken build or dependency fails loudly
+// instead of writing data no correct build can read. It decrypts published
+// AES-GCM test vectors, round-trips a value through strategy with fixed
+// 128- and 256-bit keys, checks that a tampered ciphertext is rejected, and
+// compares derived keys against known answers. It takes well under a
+// second, most of it the default Argon2id derivation.
+func SelfTest(strategy CryptoStrategy) error {
+	for _, v := range gcmVectors {
+		if err := v.check(); err != nil {
+			return fmt.Errorf("crypto self-test: %s known answer: %w", v.name, err)
+		}
+	}
+
+	for _, size := range []int{16, 32} {
+		if err := roundTrip(strategy, size); err != nil {
+			return fmt.Errorf("crypto self-test: %d-bit round trip: %w", size*8, err)
+		}
+	}
+
+	for _, v := range kdfVectors {
+		if err := v.check(); err != nil {
+			return fmt.Errorf("crypto self-test: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (v gcmVector) check() error {
+	key, _ := hex.DecodeString(v.key)
+	iv, _ := hex.DecodeString(v.iv)
+	sealed, _ := hex.DecodeString(v.ciphertextAndTag)
+	want, _ := hex.DecodeString(v.plaintext)
+
+	got, err := NewAESGCM().Decrypt(key, base64.RawStdEncoding.EncodeToString(append(iv, sealed...)))
+	if err != nil {
+		return err
+	}
+	if got != string(want) {
+		return errors.New("decrypted plaintext does not match")
+	}
+	return nil
+}
+
+func roundTrip(strategy CryptoStrategy, size int) error {
+	key := bytes.Repeat([]byte{0x42}, size)
+	const value = "coconut self-test value"
+
+	enc, err := strategy.Encrypt(key, value)
+	if err != nil {
+		return fmt.Errorf("encrypt: %w", err)
+	}
+	dec, err := strategy.Decrypt(key, enc)
+	if err != nil {
+		return fmt.Errorf("decrypt: %w", err)
+	}
+	if dec != value {
+		return errors.New("decrypted value does not match")
+	}
+
+	other := bytes.Repeat([]byte{0x43}, size)
+	if _, err := strategy.Decrypt(other, enc); err == nil {
+		return errors.New("a different key decrypted the value")
+	}
+
+	// Change one character in the middle, which always alters the
+	// encoded bytes, and expect authentication to fail.
+	tampered := []byte(enc)
+	mid := len(tampered) / 2
+	if tampered[mid] == 'A' {
+		tampered[mid] = 'B'
+	} else {
+		tampered[mid] = 'A'
+	}
+	if _, err := strategy.Decrypt(key, string(tampered)); err == nil {
+		return errors.New("a tampered ciphertext was accepted")
+	}
+	return nil
+}
+
+func (v kdfVector) check() error {
+	name := "DeriveKey"
+	var key []byte
+	if v.params.Algorithm == "" {
+		key = DeriveKey(selfTestPassword, []byte(selfTestSalt))
+	} else {
+		name = v.params.Algorithm
+		var err error
+		if key, err = DeriveKeyWithParams(selfTestPassword, []byte(selfTestSalt), v.params); err != nil {
+			return fmt.Errorf("%s known answer: %w", name, err)
+		}
+	}
+
+	if hex.EncodeToString(key) != v.key {
+		return fmt.Errorf("%s known answer: derived key does not match", name)
+	}
+	return nil
+}
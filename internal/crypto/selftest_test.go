@@ -0,0 +1,38 @@
+package crypto
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSelfTest(t *testing.T) {
+	if err := SelfTest(NewAESGCM()); err != nil {
+		t.Fatalf("SelfTest failed: %v", err)
+	}
+}
+
+// plaintextStrategy "encrypts" by returning the plaintext, as a broken
+// build might.
+type plaintextStrategy struct{}
+
+func (plaintextStrategy) Encrypt(key []byte, plaintext string) (string, error) { return plaintext, nil }
+func (plaintextStrategy) Decrypt(key []byte, ciphertext string) (string, error) {
+	return ciphertext, nil
+}
+
+func TestSelfTest_BrokenStrategy(t *testing.T) {
+	err := SelfTest(plaintextStrategy{})
+	if err == nil || !strings.Contains(err.Error(), "round trip") {
+		t.Errorf("Expected a round trip failure, got %v", err)
+	}
+}
+
+func TestSelfTest_WrongKnownAnswer(t *testing.T) {
+	saved := kdfVectors[1].key
+	kdfVectors[1].key = strings.Repeat("0", 64)
+	defer func() { kdfVectors[1].key = saved }()
+
+	if err := SelfTest(NewAESGCM()); err == nil || !strings.Contains(err.Error(), "scrypt known answer") {
+		t.Errorf("Expected a scrypt known answer failure, got %v", err)
+	}
+}
@@ -1,7 +1,7 @@
 package db
 
 type BaseRepository struct {
-	db     DB
+	db     Tx // the DB itself, or a transaction inside Batch
 	bucket string
 }
 
@@ -20,6 +20,10 @@ func (r *BaseRepository) Get(key string) ([]byte, error) {
 	return r.db.Get(r.bucket, key)
 }
 
+func (r *BaseRepository) Has(key string) (bool, error) {
+	return r.db.Has(r.bucket, key)
+}
+
 func (r *BaseRepository) Delete(key string) error {
 	return r.db.Delete(r.bucket, key)
 }
@@ -27,3 +31,35 @@ func (r *BaseRepository) Delete(key string) error {
 func (r *BaseRepository) ListKeys() ([]string, error) {
 	return r.db.ListKeys(r.bucket)
 }
+
+// ForEach visits every entry of the bucket in a single read.
+func (r *BaseRepository) ForEach(fn func(key string, value []byte) error) error {
+	return r.db.ForEach(r.bucket, fn)
+}
+
+// withTx returns a copy of the repository that goes through tx.
+func (r *BaseRepository) withTx(tx Tx) Repository {
+	return &BaseRepository{db: tx, bucket: r.bucket}
+}
+
+// txBinder is implemented by repositories that can be rebound to a
+// transaction for EncryptedRepository.Batch.
+type txBinder interface {
+	withTx(tx Tx) Repository
+}
+
+// bindTx returns repo rebound to tx, or ErrBatchUnsupported when repo
+// cannot be, rather than letting writes escape the transaction.
+func bindTx(repo Repository, tx Tx) (Repository, error) {
+	r, ok := repo.(txBinder)
+	if !ok {
+		return nil, ErrBatchUnsupported
+	}
+	return r.withTx(tx), nil
+}
+
+// NewTxRepository returns a repository over bucket that goes through tx,
+// for code that writes several buckets in one DB.Batch.
+func NewTxRepository(tx Tx, bucket string) Repository {
+	return &BaseRepository{db: tx, bucket: bucket}
+}
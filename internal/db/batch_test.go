@@ -0,0 +1,168 @@
+package db_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/ompatil-15/coconut/internal/crypto"
+	"github.com/ompatil-15/coconut/internal/db"
+	"github.com/ompatil-15/coconut/internal/db/boltdb"
+	"github.com/ompatil-15/coconut/internal/db/model"
+	"github.com/ompatil-15/coconut/internal/vault"
+)
+
+func newBatchRepo(t *testing.T) db.SecretRepository {
+	t.Helper()
+
+	store, err := boltdb.NewBoltStore(filepath.Join(t.TempDir(), "batch.db"))
+	if err != nil {
+		t.Fatalf("NewBoltStore failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	v := vault.NewVault(crypto.NewAESGCM(), []byte("salt"))
+	v.Unlock(make([]byte, 32))
+
+	factory, err := db.NewRepositoryFactory(store, v, "secrets", "secrets_index")
+	if err != nil {
+		t.Fatalf("NewRepositoryFactory failed: %v", err)
+	}
+	return factory.NewIndexedRepository("secrets", "secrets_index")
+}
+
+func TestEncryptedRepository_Batch(t *testing.T) {
+	repo := newBatchRepo(t)
+
+	err := repo.Batch(func(tx db.SecretRepository) error {
+		for _, id := range []string{"a", "b", "c"} {
+			if _, err := tx.Add(model.Secret{ID: id, Username: id, Password: "pw-" + id}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Batch failed: %v", err)
+	}
+
+	secrets, err := repo.List()
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(secrets) != 3 {
+		t.Errorf("Expected 3 secrets after batch, got %d", len(secrets))
+	}
+
+	meta, err := repo.ListMetadata()
+	if err != nil {
+		t.Fatalf("ListMetadata failed: %v", err)
+	}
+	if len(meta) != 3 {
+		t.Errorf("Expected index entries for all 3 secrets, got %d", len(meta))
+	}
+}
+
+func TestEncryptedRepository_BatchRollback(t *testing.T) {
+	repo := newBatchRepo(t)
+
+	if _, err := repo.Add(model.Secret{ID: "keep", Username: "keep", Password: "original"}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	errSimulated := errors.New("simulated crash")
+	err := repo.Batch(func(tx db.SecretRepository) error {
+		if _, err := tx.Add(model.Secret{ID: "new", Username: "new", Password: "pw"}); err != nil {
+			return err
+		}
+		if err := tx.Update(model.Secret{ID: "keep", Username: "keep", Password: "changed"}); err != nil {
+			return err
+		}
+		return errSimulated
+	})
+	if !errors.Is(err, errSimulated) {
+		t.Fatalf("Expected the simulated error, got %v", err)
+	}
+
+	secrets, err := repo.List()
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(secrets) != 1 {
+		t.Fatalf("Expected only the original secret after rollback, got %d", len(secrets))
+	}
+	if secrets[0].Password != "original" {
+		t.Errorf("Update inside a failed batch should be rolled back, got password %q", secrets[0].Password)
+	}
+
+	meta, err := repo.ListMetadata()
+	if err != nil {
+		t.Fatalf("ListMetadata failed: %v", err)
+	}
+	if len(meta) != 1 {
+		t.Errorf("Index should be rolled back too, got %d entries", len(meta))
+	}
+}
+
+func TestEncryptedRepository_BatchUnsupported(t *testing.T) {
+	repo := db.NewEncryptedRepository(nil, nil, "secrets")
+
+	err := repo.Batch(func(db.SecretRepository) error { return nil })
+	if !errors.Is(err, db.ErrBatchUnsupported) {
+		t.Errorf("Expected ErrBatchUnsupported, got %v", err)
+	}
+}
+
+func TestRekey(t *testing.T) {
+	store, err := boltdb.NewBoltStore(filepath.Join(t.TempDir(), "rekey.db"))
+	if err != nil {
+		t.Fatalf("NewBoltStore failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	oldKey := make([]byte, 32)
+	newKey := make([]byte, 32)
+	newKey[0] = 1
+	from := vault.UnlockWithKey(crypto.NewAESGCM(), []byte("old-salt"), oldKey)
+	to := vault.UnlockWithKey(crypto.NewAESGCM(), []byte("new-salt"), newKey)
+
+	factory, err := db.NewRepositoryFactory(store, from, "secrets", "secrets_index")
+	if err != nil {
+		t.Fatalf("NewRepositoryFactory failed: %v", err)
+	}
+	repo := factory.NewIndexedRepository("secrets", "secrets_index")
+	for _, id := range []string{"a", "b"} {
+		if _, err := repo.Add(model.Secret{ID: id, Username: id, Password: "pw-" + id}); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+
+	var n int
+	err = store.Batch(func(tx db.Tx) error {
+		n, err = db.Rekey(tx, "secrets", from, to)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Rekey failed: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("Expected 2 records rekeyed, got %d", n)
+	}
+
+	if _, err := repo.Get("a"); err == nil {
+		t.Error("Old key should no longer decrypt the records")
+	}
+
+	factory.SetVault(to)
+	repo = factory.NewIndexedRepository("secrets", "secrets_index")
+	if _, err := repo.Reindex(); err != nil {
+		t.Fatalf("Reindex failed: %v", err)
+	}
+	secret, err := repo.Get("b")
+	if err != nil {
+		t.Fatalf("Get with new key failed: %v", err)
+	}
+	if secret.Password != "pw-b" {
+		t.Errorf("Expected password pw-b, got %q", secret.Password)
+	}
+}
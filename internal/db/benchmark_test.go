@@ -106,3 +106,109 @@ func BenchmarkEncryptedRepository_Get(b *testing.B) {
 		}
 	}
 }
+
+// setupListBench fills a vault with numSecrets secrets and returns a repository
+// that maintains the metadata index, so List and ListMetadata can be compared.
+func setupListBench(b *testing.B, numSecrets int) db.SecretRepository {
+	tmpDir := b.TempDir()
+
+	boltStore, err := boltdb.NewBoltStore(filepath.Join(tmpDir, "bench_list.db"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { boltStore.Close() })
+
+	v := vault.NewVault(crypto.NewAESGCM(), []byte("salt"))
+	v.Unlock(make([]byte, 32))
+
+	factory, err := db.NewRepositoryFactory(boltStore, v, "secrets", "secrets_index")
+	if err != nil {
+		b.Fatal(err)
+	}
+	repo := factory.NewIndexedRepository("secrets", "secrets_index")
+	addBenchSecrets(b, repo, numSecrets)
+	return repo
+}
+
+// addBenchSecrets adds numSecrets secrets to repo.
+func addBenchSecrets(b *testing.B, repo db.SecretRepository, numSecrets int) {
+	for i := 0; i < numSecrets; i++ {
+		secret := model.Secret{
+			ID:          fmt.Sprintf("bench-%d", i),
+			Name:        fmt.Sprintf("Site %d", i),
+			Username:    "user",
+			Password:    "benchpassword123!",
+			URL:         "https://benchmark.com",
+			Description: "A secret for benchmarking",
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+		}
+		if _, err := repo.Add(secret); err != nil {
+			b.Fatalf("Add failed: %v", err)
+		}
+	}
+}
+
+func BenchmarkEncryptedRepository_List(b *testing.B) {
+	repo := setupListBench(b, 1000)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := repo.List(); err != nil {
+			b.Fatalf("List failed: %v", err)
+		}
+	}
+}
+
+// BenchmarkEncryptedRepository_ListSequential is List decrypting one
+// record at a time, the baseline for the concurrent default.
+func BenchmarkEncryptedRepository_ListSequential(b *testing.B) {
+	repo := setupListBench(b, 1000)
+	repo.(*db.EncryptedRepository).SetParallelism(1)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := repo.List(); err != nil {
+			b.Fatalf("List failed: %v", err)
+		}
+	}
+}
+
+// uncachedVault hides the vault's WithCachedCipher, so every record sets
+// up its own cipher.
+type uncachedVault struct{ db.Vault }
+
+// BenchmarkEncryptedRepository_ListUncached is ListSequential without the
+// cached cipher, the baseline for setting it up once per List.
+func BenchmarkEncryptedRepository_ListUncached(b *testing.B) {
+	boltStore, err := boltdb.NewBoltStore(filepath.Join(b.TempDir(), "bench_list.db"), boltdb.WithAutoCreateBuckets())
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { boltStore.Close() })
+
+	v := vault.NewVault(crypto.NewAESGCM(), []byte("salt"))
+	v.Unlock(make([]byte, 32))
+
+	repo := db.NewEncryptedRepository(db.NewBaseRepository(boltStore, "secrets"), uncachedVault{v}, "secrets")
+	repo.SetParallelism(1)
+	addBenchSecrets(b, repo, 1000)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := repo.List(); err != nil {
+			b.Fatalf("List failed: %v", err)
+		}
+	}
+}
+
+func BenchmarkEncryptedRepository_ListMetadata(b *testing.B) {
+	repo := setupListBench(b, 1000)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := repo.ListMetadata(); err != nil {
+			b.Fatalf("ListMetadata failed: %v", err)
+		}
+	}
+}
@@ -7,34 +7,66 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/ompatil-15/coconut/internal/db"
 	bolt "go.etcd.io/bbolt"
 )
 
 type BoltStore struct {
-	db *bolt.DB
+	db         *bolt.DB
+	autoCreate bool
+	readOnly   bool
 }
 
-func NewBoltStore(path string) (*BoltStore, error) {
-	dir := filepath.Dir(path)
-	if err := os.MkdirAll(dir, 0700); err != nil {
-		return nil, err
+// Option configures a BoltStore.
+type Option func(*BoltStore)
+
+// WithAutoCreateBuckets makes Put create a missing bucket in the same
+// transaction as the write. Reads of a missing bucket then behave as an
+// empty bucket instead of failing, so a newly introduced bucket works
+// without every entry point having to create it first.
+func WithAutoCreateBuckets() Option {
+	return func(b *BoltStore) {
+		b.autoCreate = true
+	}
+}
+
+// WithReadOnly opens an existing database without write access. Other
+// processes can read the file at the same time, and every write fails.
+func WithReadOnly() Option {
+	return func(b *BoltStore) {
+		b.readOnly = true
+	}
+}
+
+func NewBoltStore(path string, opts ...Option) (*BoltStore, error) {
+	store := &BoltStore{}
+	for _, opt := range opts {
+		opt(store)
+	}
+
+	if !store.readOnly {
+		dir := filepath.Dir(path)
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return nil, err
+		}
 	}
 
-	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 1 * time.Second})
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 1 * time.Second, ReadOnly: store.readOnly})
 	if err != nil {
 		return nil, err
 	}
 
-	return &BoltStore{db: db}, nil
+	store.db = db
+	return store, nil
 }
 
+// errBucketNotFound is returned for a missing bucket unless auto-creation
+// is on, in which case the bucket is treated as empty.
+var errBucketNotFound = errors.New("bucket not found")
+
 func (b *BoltStore) Put(bucket string, key string, value []byte) error {
 	return b.db.Update(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte(bucket))
-		if bucket == nil {
-			return errors.New("bucket not found")
-		}
-		return bucket.Put([]byte(key), value)
+		return b.wrap(tx).Put(bucket, key, value)
 	})
 }
 
@@ -42,32 +74,29 @@ func (b *BoltStore) Get(bucket string, key string) ([]byte, error) {
 	var val []byte
 
 	err := b.db.View(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte(bucket))
-		if bucket == nil {
-			return errors.New("bucket not found")
-		}
+		var err error
+		val, err = b.wrap(tx).Get(bucket, key)
+		return err
+	})
 
-		v := bucket.Get([]byte(key))
-		if v == nil {
-			return errors.New("key not found")
-		}
+	return val, err
+}
 
-		val = make([]byte, len(v))
-		copy(val, v)
+func (b *BoltStore) Has(bucket string, key string) (bool, error) {
+	var found bool
 
-		return nil
+	err := b.db.View(func(tx *bolt.Tx) error {
+		var err error
+		found, err = b.wrap(tx).Has(bucket, key)
+		return err
 	})
 
-	return val, err
+	return found, err
 }
 
 func (b *BoltStore) Delete(bucket string, key string) error {
 	return b.db.Update(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte(bucket))
-		if bucket == nil {
-			return errors.New("bucket not found")
-		}
-		return bucket.Delete([]byte(key))
+		return b.wrap(tx).Delete(bucket, key)
 	})
 }
 
@@ -75,20 +104,32 @@ func (b *BoltStore) ListKeys(bucket string) ([]string, error) {
 	var keys []string
 
 	err := b.db.View(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte(bucket))
-		if bucket == nil {
-			return errors.New("bucket not found")
-		}
-
-		return bucket.ForEach(func(k, _ []byte) error {
-			keys = append(keys, string(k))
-			return nil
-		})
+		var err error
+		keys, err = b.wrap(tx).ListKeys(bucket)
+		return err
 	})
 
 	return keys, err
 }
 
+// ForEach calls fn for every key in bucket, in key order, within a single
+// read transaction. value is only valid during the call.
+func (b *BoltStore) ForEach(bucket string, fn func(key string, value []byte) error) error {
+	return b.db.View(func(tx *bolt.Tx) error {
+		return b.wrap(tx).ForEach(bucket, fn)
+	})
+}
+
+// Batch runs fn inside a single read-write transaction. Everything fn
+// writes through tx is committed together when it returns nil, and rolled
+// back if it returns an error or panics. fn must not use the store itself,
+// which would deadlock waiting for the transaction to finish.
+func (b *BoltStore) Batch(fn func(tx db.Tx) error) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return fn(b.wrap(tx))
+	})
+}
+
 func (b *BoltStore) CreateBucket(bucket string) error {
 	return b.db.Update(func(tx *bolt.Tx) error {
 		_, err := tx.CreateBucketIfNotExists([]byte(bucket))
@@ -99,6 +140,26 @@ func (b *BoltStore) CreateBucket(bucket string) error {
 	})
 }
 
+// HasBucket reports whether bucket exists. It is not part of db.DB: with
+// auto-creation a missing bucket reads as empty, and only diagnostics
+// care about the difference.
+func (b *BoltStore) HasBucket(bucket string) (bool, error) {
+	var found bool
+	err := b.db.View(func(tx *bolt.Tx) error {
+		found = tx.Bucket([]byte(bucket)) != nil
+		return nil
+	})
+	return found, err
+}
+
+// Backup writes a consistent copy of the database to path. The copy is
+// taken inside a read transaction, so it is safe while the store is open.
+func (b *BoltStore) Backup(path string) error {
+	return b.db.View(func(tx *bolt.Tx) error {
+		return tx.CopyFile(path, 0600)
+	})
+}
+
 func (b *BoltStore) Close() error {
 	if b.db != nil {
 		return b.db.Close()
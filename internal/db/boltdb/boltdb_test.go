@@ -1,10 +1,13 @@
 package boltdb
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/ompatil-15/coconut/internal/db"
 )
 
 func TestNewBoltStore(t *testing.T) {
@@ -48,12 +51,20 @@ func TestBoltStore_CreateBucket(t *testing.T) {
 	}
 	defer store.Close()
 
+	if found, err := store.HasBucket("test-bucket"); err != nil || found {
+		t.Errorf("HasBucket before CreateBucket = %v, %v; want false, nil", found, err)
+	}
+
 	// Test creating bucket
 	err = store.CreateBucket("test-bucket")
 	if err != nil {
 		t.Fatalf("CreateBucket failed: %v", err)
 	}
 
+	if found, err := store.HasBucket("test-bucket"); err != nil || !found {
+		t.Errorf("HasBucket after CreateBucket = %v, %v; want true, nil", found, err)
+	}
+
 	// Test creating same bucket again (should not error)
 	err = store.CreateBucket("test-bucket")
 	if err != nil {
@@ -227,6 +238,83 @@ func TestBoltStore_ListKeys(t *testing.T) {
 	}
 }
 
+func TestBoltStore_ForEach(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "boltdb-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	store, err := NewBoltStore(filepath.Join(tempDir, "test.db"))
+	if err != nil {
+		t.Fatalf("NewBoltStore failed: %v", err)
+	}
+	defer store.Close()
+
+	bucket := "test-bucket"
+	if err := store.CreateBucket(bucket); err != nil {
+		t.Fatalf("CreateBucket failed: %v", err)
+	}
+	for _, key := range []string{"b", "a", "c"} {
+		if err := store.Put(bucket, key, []byte("value-"+key)); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+
+	var keys []string
+	err = store.ForEach(bucket, func(key string, value []byte) error {
+		if string(value) != "value-"+key {
+			t.Errorf("Unexpected value %q for key %q", value, key)
+		}
+		keys = append(keys, key)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ForEach failed: %v", err)
+	}
+
+	// Keys are visited in sorted order, matching ListKeys
+	if len(keys) != 3 || keys[0] != "a" || keys[2] != "c" {
+		t.Errorf("Expected keys [a b c], got %v", keys)
+	}
+
+	if err := store.ForEach("non-existent", func(string, []byte) error { return nil }); err == nil {
+		t.Error("ForEach should fail for non-existent bucket")
+	}
+}
+
+func TestBoltStore_Has(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "boltdb-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	store, err := NewBoltStore(filepath.Join(tempDir, "test.db"))
+	if err != nil {
+		t.Fatalf("NewBoltStore failed: %v", err)
+	}
+	defer store.Close()
+
+	bucket := "test-bucket"
+	if err := store.CreateBucket(bucket); err != nil {
+		t.Fatalf("CreateBucket failed: %v", err)
+	}
+	if err := store.Put(bucket, "present", []byte("value")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	if found, err := store.Has(bucket, "present"); err != nil || !found {
+		t.Errorf("Has(present) = %v, %v; want true, nil", found, err)
+	}
+	if found, err := store.Has(bucket, "missing"); err != nil || found {
+		t.Errorf("Has(missing) = %v, %v; want false, nil", found, err)
+	}
+	if _, err := store.Has("non-existent", "present"); err == nil {
+		t.Error("Has should fail for non-existent bucket")
+	}
+}
+
 func TestBoltStore_NonExistentBucket(t *testing.T) {
 	tempDir, err := os.MkdirTemp("", "boltdb-test-*")
 	if err != nil {
@@ -263,6 +351,190 @@ func TestBoltStore_NonExistentBucket(t *testing.T) {
 	}
 }
 
+func TestBoltStore_AutoCreateBuckets(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "boltdb-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	dbPath := filepath.Join(tempDir, "test.db")
+	store, err := NewBoltStore(dbPath, WithAutoCreateBuckets())
+	if err != nil {
+		t.Fatalf("NewBoltStore failed: %v", err)
+	}
+	defer store.Close()
+
+	// Reads of a missing bucket behave like an empty bucket
+	if _, err := store.Get("lazy", "key"); err == nil {
+		t.Error("Get should report a missing key")
+	}
+	keys, err := store.ListKeys("lazy")
+	if err != nil || len(keys) != 0 {
+		t.Errorf("ListKeys on missing bucket = %v, %v; want empty, nil", keys, err)
+	}
+	if err := store.ForEach("lazy", func(string, []byte) error { return nil }); err != nil {
+		t.Errorf("ForEach on missing bucket failed: %v", err)
+	}
+	if err := store.Delete("lazy", "key"); err != nil {
+		t.Errorf("Delete on missing bucket failed: %v", err)
+	}
+
+	// The first write creates the bucket
+	if err := store.Put("lazy", "key", []byte("value")); err != nil {
+		t.Fatalf("Put should create the bucket: %v", err)
+	}
+	value, err := store.Get("lazy", "key")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if string(value) != "value" {
+		t.Errorf("Expected 'value', got '%s'", string(value))
+	}
+}
+
+func TestBoltStore_Batch(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "boltdb-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	store, err := NewBoltStore(filepath.Join(tempDir, "test.db"))
+	if err != nil {
+		t.Fatalf("NewBoltStore failed: %v", err)
+	}
+	defer store.Close()
+
+	if err := store.CreateBucket("test"); err != nil {
+		t.Fatalf("CreateBucket failed: %v", err)
+	}
+	if err := store.Put("test", "old", []byte("value")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	// All writes of a successful batch are committed
+	err = store.Batch(func(tx db.Tx) error {
+		if err := tx.Put("test", "a", []byte("1")); err != nil {
+			return err
+		}
+		if err := tx.Put("test", "b", []byte("2")); err != nil {
+			return err
+		}
+		return tx.Delete("test", "old")
+	})
+	if err != nil {
+		t.Fatalf("Batch failed: %v", err)
+	}
+
+	keys, _ := store.ListKeys("test")
+	if strings.Join(keys, ",") != "a,b" {
+		t.Errorf("Expected keys a,b after batch, got %v", keys)
+	}
+
+	// A failing batch leaves nothing behind, including writes before the failure
+	err = store.Batch(func(tx db.Tx) error {
+		if err := tx.Put("test", "c", []byte("3")); err != nil {
+			return err
+		}
+		if err := tx.Delete("test", "a"); err != nil {
+			return err
+		}
+		return errors.New("simulated failure")
+	})
+	if err == nil || err.Error() != "simulated failure" {
+		t.Fatalf("Expected the batch error to be returned, got %v", err)
+	}
+
+	keys, _ = store.ListKeys("test")
+	if strings.Join(keys, ",") != "a,b" {
+		t.Errorf("Failed batch should be rolled back, got keys %v", keys)
+	}
+}
+
+func TestBoltStore_ReadOnly(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "boltdb-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	dbPath := filepath.Join(tempDir, "test.db")
+
+	// Opening a missing file read-only must not create it
+	if _, err := NewBoltStore(dbPath, WithReadOnly()); err == nil {
+		t.Error("Read-only open of a missing database should fail")
+	}
+
+	store, err := NewBoltStore(dbPath)
+	if err != nil {
+		t.Fatalf("NewBoltStore failed: %v", err)
+	}
+	if err := store.CreateBucket("test"); err != nil {
+		t.Fatalf("CreateBucket failed: %v", err)
+	}
+	if err := store.Put("test", "key", []byte("value")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	store.Close()
+
+	ro, err := NewBoltStore(dbPath, WithReadOnly())
+	if err != nil {
+		t.Fatalf("Read-only open failed: %v", err)
+	}
+	defer ro.Close()
+
+	value, err := ro.Get("test", "key")
+	if err != nil || string(value) != "value" {
+		t.Errorf("Read-only Get = %q, %v; want 'value'", value, err)
+	}
+	if err := ro.Put("test", "key", []byte("changed")); err == nil {
+		t.Error("Put should fail on a read-only store")
+	}
+}
+
+func TestBoltStore_Backup(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "boltdb-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	store, err := NewBoltStore(filepath.Join(tempDir, "test.db"))
+	if err != nil {
+		t.Fatalf("NewBoltStore failed: %v", err)
+	}
+	defer store.Close()
+
+	bucket := "test-bucket"
+	if err := store.CreateBucket(bucket); err != nil {
+		t.Fatalf("CreateBucket failed: %v", err)
+	}
+	if err := store.Put(bucket, "key", []byte("value")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	backupPath := filepath.Join(tempDir, "backup.db")
+	if err := store.Backup(backupPath); err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+
+	// The copy must open independently and hold the same data
+	copied, err := NewBoltStore(backupPath)
+	if err != nil {
+		t.Fatalf("Failed to open backup: %v", err)
+	}
+	defer copied.Close()
+
+	value, err := copied.Get(bucket, "key")
+	if err != nil {
+		t.Fatalf("Get from backup failed: %v", err)
+	}
+	if string(value) != "value" {
+		t.Errorf("Expected 'value', got '%s'", string(value))
+	}
+}
+
 func TestBoltStore_Close(t *testing.T) {
 	tempDir, err := os.MkdirTemp("", "boltdb-test-*")
 	if err != nil {
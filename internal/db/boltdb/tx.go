@@ -0,0 +1,102 @@
+package boltdb
+
+import (
+	"errors"
+	"fmt"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// boltTx implements db.Tx on top of an open bolt transaction. The
+// BoltStore methods run through it too, so single writes and batches
+// behave the same.
+type boltTx struct {
+	tx         *bolt.Tx
+	autoCreate bool
+}
+
+func (b *BoltStore) wrap(tx *bolt.Tx) *boltTx {
+	return &boltTx{tx: tx, autoCreate: b.autoCreate}
+}
+
+func (t *boltTx) Put(bucket string, key string, value []byte) error {
+	if t.autoCreate {
+		bkt, err := t.tx.CreateBucketIfNotExists([]byte(bucket))
+		if err != nil {
+			return fmt.Errorf("create bucket %q: %w", bucket, err)
+		}
+		return bkt.Put([]byte(key), value)
+	}
+
+	bkt := t.tx.Bucket([]byte(bucket))
+	if bkt == nil {
+		return errBucketNotFound
+	}
+	return bkt.Put([]byte(key), value)
+}
+
+func (t *boltTx) Get(bucket string, key string) ([]byte, error) {
+	bkt := t.tx.Bucket([]byte(bucket))
+	if bkt == nil {
+		if t.autoCreate {
+			return nil, errors.New("key not found")
+		}
+		return nil, errBucketNotFound
+	}
+
+	v := bkt.Get([]byte(key))
+	if v == nil {
+		return nil, errors.New("key not found")
+	}
+
+	val := make([]byte, len(v))
+	copy(val, v)
+	return val, nil
+}
+
+// Has reports whether key is in bucket. Unlike Get it does not copy the
+// value out of the transaction.
+func (t *boltTx) Has(bucket string, key string) (bool, error) {
+	bkt := t.tx.Bucket([]byte(bucket))
+	if bkt == nil {
+		if t.autoCreate {
+			return false, nil
+		}
+		return false, errBucketNotFound
+	}
+	return bkt.Get([]byte(key)) != nil, nil
+}
+
+func (t *boltTx) Delete(bucket string, key string) error {
+	bkt := t.tx.Bucket([]byte(bucket))
+	if bkt == nil {
+		if t.autoCreate {
+			return nil
+		}
+		return errBucketNotFound
+	}
+	return bkt.Delete([]byte(key))
+}
+
+func (t *boltTx) ListKeys(bucket string) ([]string, error) {
+	var keys []string
+	err := t.ForEach(bucket, func(k string, _ []byte) error {
+		keys = append(keys, k)
+		return nil
+	})
+	return keys, err
+}
+
+func (t *boltTx) ForEach(bucket string, fn func(key string, value []byte) error) error {
+	bkt := t.tx.Bucket([]byte(bucket))
+	if bkt == nil {
+		if t.autoCreate {
+			return nil
+		}
+		return errBucketNotFound
+	}
+
+	return bkt.ForEach(func(k, v []byte) error {
+		return fn(string(k), v)
+	})
+}
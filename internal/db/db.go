@@ -3,8 +3,23 @@ package db
 type DB interface {
 	Put(bucket string, key string, value []byte) error
 	Get(bucket string, key string) ([]byte, error)
+	Has(bucket string, key string) (bool, error)
 	Delete(bucket string, key string) error
 	ListKeys(bucket string) ([]string, error)
+	ForEach(bucket string, fn func(key string, value []byte) error) error
 	CreateBucket(bucket string) error
+	Batch(fn func(tx Tx) error) error
+	Backup(path string) error
 	Close() error
 }
+
+// Tx is the view of a DB inside Batch. Writes made through it are applied
+// together if the batch function returns nil and discarded otherwise.
+type Tx interface {
+	Put(bucket string, key string, value []byte) error
+	Get(bucket string, key string) ([]byte, error)
+	Has(bucket string, key string) (bool, error)
+	Delete(bucket string, key string) error
+	ListKeys(bucket string) ([]string, error)
+	ForEach(bucket string, fn func(key string, value []byte) error) error
+}
@@ -2,13 +2,29 @@ package db
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ompatil-15/coconut/internal/db/model"
 	"github.com/ompatil-15/coconut/internal/vault"
 )
 
+// ErrSecretNotFound is returned when the requested secret does not exist.
+var ErrSecretNotFound = errors.New("secret not found")
+
+// ErrNoIndex is returned by Reindex when the repository keeps no metadata
+// index.
+var ErrNoIndex = errors.New("repository has no metadata index")
+
+// ErrBatchUnsupported is returned by Batch when the repository was not
+// created over a DB, or its storage cannot be bound to a transaction, and
+// so cannot group writes into one.
+var ErrBatchUnsupported = errors.New("repository does not support batches")
+
 type Vault interface {
 	IsUnlocked() bool
 	Encrypt(plaintext string) (string, error)
@@ -19,6 +35,27 @@ type EncryptedRepository struct {
 	repo   Repository
 	vault  Vault
 	bucket string
+	index  *SecretIndex  // optional plaintext metadata index; nil disables it
+	tag    *IntegrityTag // optional whole-vault integrity tag; nil disables it
+	db     DB            // set by RepositoryFactory; required for Batch
+
+	parallelism int // records List decrypts at once; < 1 means one per CPU
+}
+
+// cipherCacher is implemented by vaults that can set up their cipher once
+// for an operation over many records.
+type cipherCacher interface {
+	WithCachedCipher() (*vault.Vault, func())
+}
+
+// withCachedCipher returns v with its cipher set up once, if it supports
+// that, for the duration of a bulk operation, and a func that releases
+// the cached key when the operation is done.
+func withCachedCipher(v Vault) (Vault, func()) {
+	if c, ok := v.(cipherCacher); ok {
+		return c.WithCachedCipher()
+	}
+	return v, func() {}
 }
 
 func (f *RepositoryFactory) SetVault(v *vault.Vault) {
@@ -33,6 +70,80 @@ func NewEncryptedRepository(repo Repository, v Vault, bucket string) *EncryptedR
 	}
 }
 
+// SetIndex enables maintaining a metadata index alongside the encrypted
+// records, used by ListMetadata.
+func (e *EncryptedRepository) SetIndex(index *SecretIndex) {
+	e.index = index
+}
+
+// SetIntegrityTag enables keeping tag up to date with every write, in the
+// same transaction as the write.
+func (e *EncryptedRepository) SetIntegrityTag(tag *IntegrityTag) {
+	e.tag = tag
+}
+
+// SetParallelism sets how many records List decrypts at once. 1 decrypts
+// them one after another; n < 1 restores the default of one per CPU.
+func (e *EncryptedRepository) SetParallelism(n int) {
+	e.parallelism = n
+}
+
+// Batch runs fn with a repository whose writes all happen in a single
+// database transaction, so either every change is stored or none is. fn
+// must only use the repository it is given.
+func (e *EncryptedRepository) Batch(fn func(repo SecretRepository) error) error {
+	if e.db == nil {
+		return ErrBatchUnsupported
+	}
+
+	return e.db.Batch(func(tx Tx) error {
+		bound := *e
+		var release func()
+		bound.vault, release = withCachedCipher(e.vault)
+		defer release()
+		bound.db = nil  // no nested batches
+		bound.tag = nil // refreshed once below rather than per write
+		repo, err := bindTx(e.repo, tx)
+		if err != nil {
+			return err
+		}
+		bound.repo = repo
+		if e.index != nil {
+			if bound.index, err = e.index.withTx(tx); err != nil {
+				return err
+			}
+		}
+		if err := fn(&bound); err != nil {
+			return err
+		}
+		if e.tag != nil {
+			tag, err := e.tag.withTx(tx)
+			if err != nil {
+				return err
+			}
+			return tag.Update(bound.repo)
+		}
+		return nil
+	})
+}
+
+// write runs fn, which changes records, and refreshes the integrity tag
+// in the same transaction.
+func (e *EncryptedRepository) write(fn func(r *EncryptedRepository) error) error {
+	if e.tag == nil {
+		return fn(e)
+	}
+	if e.db == nil {
+		if err := fn(e); err != nil {
+			return err
+		}
+		return e.tag.Update(e.repo)
+	}
+	return e.Batch(func(repo SecretRepository) error {
+		return fn(repo.(*EncryptedRepository))
+	})
+}
+
 func (e *EncryptedRepository) Add(secret model.Secret) (string, error) {
 	if !e.vault.IsUnlocked() {
 		return "", fmt.Errorf("vault is locked")
@@ -49,8 +160,15 @@ func (e *EncryptedRepository) Add(secret model.Secret) (string, error) {
 	}
 
 	key := fmt.Sprintf("%v", secret.ID)
-	if err := e.repo.Put(key, []byte(enc)); err != nil {
-		return "", fmt.Errorf("store secret: %w", err)
+	err = e.write(func(r *EncryptedRepository) error {
+		if err := r.repo.Put(key, []byte(enc)); err != nil {
+			return fmt.Errorf("store secret: %w", err)
+		}
+		r.updateIndex(secret, []byte(enc))
+		return nil
+	})
+	if err != nil {
+		return "", err
 	}
 
 	return secret.ID, nil
@@ -66,6 +184,10 @@ func (e *EncryptedRepository) Get(key string) (*model.Secret, error) {
 		return nil, err
 	}
 
+	return e.decryptRecord(data)
+}
+
+func (e *EncryptedRepository) decryptRecord(data []byte) (*model.Secret, error) {
 	dec, err := e.vault.Decrypt(string(data))
 	if err != nil {
 		return nil, fmt.Errorf("decrypt secret: %w", err)
@@ -85,6 +207,34 @@ func (e *EncryptedRepository) Update(secret model.Secret) error {
 	}
 
 	secret.UpdatedAt = time.Now()
+	return e.store(secret)
+}
+
+// Replace stores secret exactly as given. Unlike Update it keeps
+// UpdatedAt, for writes that carry over a version made elsewhere, such as
+// the winner of a merge.
+func (e *EncryptedRepository) Replace(secret model.Secret) error {
+	if !e.vault.IsUnlocked() {
+		return fmt.Errorf("vault is locked")
+	}
+
+	return e.store(secret)
+}
+
+// MarkUsed records that the secret was just used. Unlike Update it leaves
+// UpdatedAt alone, which tracks changes to the secret's content.
+func (e *EncryptedRepository) MarkUsed(key string, at time.Time) error {
+	secret, err := e.Get(key)
+	if err != nil {
+		return err
+	}
+
+	secret.LastUsedAt = at
+	return e.store(*secret)
+}
+
+// store encrypts and writes an existing secret as is.
+func (e *EncryptedRepository) store(secret model.Secret) error {
 	data, err := json.Marshal(secret)
 	if err != nil {
 		return fmt.Errorf("marshal secret: %w", err)
@@ -96,27 +246,366 @@ func (e *EncryptedRepository) Update(secret model.Secret) error {
 	}
 
 	key := fmt.Sprintf("%v", secret.ID)
-	return e.repo.Put(key, []byte(enc))
+	return e.write(func(r *EncryptedRepository) error {
+		if err := r.repo.Put(key, []byte(enc)); err != nil {
+			return err
+		}
+		r.updateIndex(secret, []byte(enc))
+		return nil
+	})
 }
 
 func (e *EncryptedRepository) Delete(key string) error {
-	return e.repo.Delete(key)
+	if !e.vault.IsUnlocked() {
+		return fmt.Errorf("vault is locked")
+	}
+
+	return e.write(func(r *EncryptedRepository) error {
+		found, err := r.repo.Has(key)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return fmt.Errorf("%w: %s", ErrSecretNotFound, key)
+		}
+
+		if err := r.repo.Delete(key); err != nil {
+			return err
+		}
+		if r.index != nil {
+			_ = r.index.Delete(key)
+		}
+		return nil
+	})
 }
 
+// List returns every secret in key order. Records are read in one pass
+// and decrypted concurrently, see SetParallelism.
 func (e *EncryptedRepository) List() ([]model.Secret, error) {
-	keys, err := e.repo.ListKeys()
+	if !e.vault.IsUnlocked() {
+		return nil, fmt.Errorf("vault is locked")
+	}
+	e, release := e.bulk()
+	defer release()
+
+	keys, records, err := readAll(e.repo)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	workers := e.parallelism
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	secrets := make([]model.Secret, len(keys))
+	err = forEachParallel(len(keys), workers, func(i int) error {
+		secret, err := e.decryptRecord(records[keys[i]])
+		if err != nil {
+			return fmt.Errorf("failed to load secret %s: %w", keys[i], err)
+		}
+		secrets[i] = *secret
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return secrets, nil
+}
+
+// forEachParallel calls fn(i) for i from 0 to n-1 on up to workers
+// goroutines. Indexes are handed out in order and none are started after
+// a failure, so the error returned is the one of the lowest failing
+// index, the same a sequential loop would return. Every goroutine has
+// finished by the time it returns.
+func forEachParallel(n, workers int, fn func(i int) error) error {
+	if workers > n {
+		workers = n
+	}
+	if workers <= 1 {
+		for i := range n {
+			if err := fn(i); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var (
+		next   atomic.Int64
+		failed atomic.Bool
+		wg     sync.WaitGroup
+	)
+	errs := make([]error, n)
+	for range workers {
+		wg.Go(func() {
+			for !failed.Load() {
+				i := int(next.Add(1) - 1)
+				if i >= n {
+					return
+				}
+				if errs[i] = fn(i); errs[i] != nil {
+					failed.Store(true)
+				}
+			}
+		})
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListMetadata returns every secret without its password, in the same order
+// as List. With an index, records whose index entry verifies are not
+// decrypted; missing or stale entries fall back to decryption and are
+// rewritten. Without an index it decrypts everything like List.
+func (e *EncryptedRepository) ListMetadata() ([]model.Secret, error) {
+	if !e.vault.IsUnlocked() {
+		return nil, fmt.Errorf("vault is locked")
+	}
+	e, release := e.bulk()
+	defer release()
+
+	keys, records, err := readAll(e.repo)
 	if err != nil {
 		return nil, err
 	}
 
+	var entries map[string][]byte
+	if e.index != nil {
+		// An unreadable index only costs speed; every record still decrypts.
+		entries, _ = e.index.entries()
+	}
+
 	var secrets []model.Secret
 	for _, k := range keys {
-		secret, err := e.Get(k)
+		data := records[k]
+
+		if entry, ok := entries[k]; ok {
+			if meta, err := e.index.verify(k, entry, data); err == nil {
+				secrets = append(secrets, *meta)
+				continue
+			}
+		}
+
+		secret, err := e.decryptRecord(data)
 		if err != nil {
 			return nil, fmt.Errorf("failed to load secret %s: %w", k, err)
 		}
-		secrets = append(secrets, *secret)
+		e.updateIndex(*secret, data)
+		secrets = append(secrets, metadataOf(*secret))
 	}
 
 	return secrets, nil
 }
+
+// ReindexReport summarizes a Reindex run.
+type ReindexReport struct {
+	Indexed int      // entries rewritten from decrypted records
+	Removed int      // stale entries whose record no longer exists
+	Failed  []string // IDs of records that could not be decrypted
+}
+
+// Reindex rebuilds the metadata index from the encrypted records, which are
+// authoritative. A record that fails to decrypt loses its index entry and
+// is reported in Failed instead of stopping the rebuild.
+func (e *EncryptedRepository) Reindex() (*ReindexReport, error) {
+	if !e.vault.IsUnlocked() {
+		return nil, fmt.Errorf("vault is locked")
+	}
+	if e.index == nil {
+		return nil, ErrNoIndex
+	}
+	e, release := e.bulk()
+	defer release()
+
+	keys, records, err := readAll(e.repo)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := e.index.entries()
+	if err != nil {
+		return nil, fmt.Errorf("read index: %w", err)
+	}
+
+	report := &ReindexReport{}
+	for _, k := range keys {
+		secret, err := e.decryptRecord(records[k])
+		if err != nil {
+			report.Failed = append(report.Failed, k)
+			if err := e.index.Delete(k); err != nil {
+				return report, fmt.Errorf("remove index entry %s: %w", k, err)
+			}
+			continue
+		}
+		if err := e.index.Put(*secret, records[k]); err != nil {
+			return report, fmt.Errorf("index secret %s: %w", k, err)
+		}
+		report.Indexed++
+	}
+
+	for k := range entries {
+		if _, ok := records[k]; ok {
+			continue
+		}
+		if err := e.index.Delete(k); err != nil {
+			return report, fmt.Errorf("remove index entry %s: %w", k, err)
+		}
+		report.Removed++
+	}
+
+	return report, nil
+}
+
+// Undecryptable returns the IDs of records that do not decrypt with the
+// vault key, in key order, e.g. leftovers of an interrupted rekey.
+func (e *EncryptedRepository) Undecryptable() ([]string, error) {
+	if !e.vault.IsUnlocked() {
+		return nil, fmt.Errorf("vault is locked")
+	}
+	e, release := e.bulk()
+	defer release()
+
+	keys, records, err := readAll(e.repo)
+	if err != nil {
+		return nil, err
+	}
+	var bad []string
+	for _, k := range keys {
+		if _, err := e.decryptRecord(records[k]); err != nil {
+			bad = append(bad, k)
+		}
+	}
+	return bad, nil
+}
+
+// Quarantine moves the records keys, still encrypted, from the vault into
+// bucket and drops their index entries, all in one transaction. Nothing
+// is destroyed: a key already in bucket gets a numbered suffix. With
+// retag the integrity tag is refreshed to cover the remaining records;
+// pass false when it did not verify before, so the move does not
+// vouch for other changes.
+func (e *EncryptedRepository) Quarantine(bucket string, keys []string, retag bool) error {
+	if e.db == nil {
+		return ErrBatchUnsupported
+	}
+
+	return e.db.Batch(func(tx Tx) error {
+		records, err := bindTx(e.repo, tx)
+		if err != nil {
+			return err
+		}
+		var index *SecretIndex
+		if e.index != nil {
+			if index, err = e.index.withTx(tx); err != nil {
+				return err
+			}
+		}
+		quarantine := &BaseRepository{db: tx, bucket: bucket}
+
+		for _, k := range keys {
+			data, err := records.Get(k)
+			if err != nil {
+				return fmt.Errorf("read record %s: %w", k, err)
+			}
+			dest := k
+			for n := 2; ; n++ {
+				if found, err := quarantine.Has(dest); err != nil {
+					return err
+				} else if !found {
+					break
+				}
+				dest = fmt.Sprintf("%s.%d", k, n)
+			}
+			if err := quarantine.Put(dest, data); err != nil {
+				return fmt.Errorf("quarantine record %s: %w", k, err)
+			}
+			if err := records.Delete(k); err != nil {
+				return fmt.Errorf("remove record %s: %w", k, err)
+			}
+			if index != nil {
+				if err := index.Delete(k); err != nil {
+					return fmt.Errorf("remove index entry %s: %w", k, err)
+				}
+			}
+		}
+
+		if retag && e.tag != nil {
+			tag, err := e.tag.withTx(tx)
+			if err != nil {
+				return err
+			}
+			return tag.Update(records)
+		}
+		return nil
+	})
+}
+
+// bulk returns a copy of e whose vault sets up its cipher once, for
+// methods that decrypt every record, and a func releasing it.
+func (e *EncryptedRepository) bulk() (*EncryptedRepository, func()) {
+	bound := *e
+	var release func()
+	bound.vault, release = withCachedCipher(e.vault)
+	return &bound, release
+}
+
+// updateIndex refreshes the index entry for secret. Failures are ignored:
+// a stale entry fails verification on read and is rebuilt from the record.
+func (e *EncryptedRepository) updateIndex(secret model.Secret, ciphertext []byte) {
+	if e.index != nil {
+		_ = e.index.Put(secret, ciphertext)
+	}
+}
+
+// Rekey re-encrypts every record in bucket, written by a repository using
+// from, so it can be read with to instead. It goes through tx so a vault
+// is never left half under one key. It returns the number of records
+// rewritten. Index entries are bound to the old key; rebuild them with
+// Reindex afterwards.
+func Rekey(tx Tx, bucket string, from, to Vault) (int, error) {
+	return RekeyRecords(NewTxRepository(tx, bucket), from, to)
+}
+
+// RekeyRecords is Rekey over any records repository, such as a sharded
+// one bound to the transaction.
+func RekeyRecords(repo Repository, from, to Vault) (int, error) {
+	if !from.IsUnlocked() || !to.IsUnlocked() {
+		return 0, fmt.Errorf("vault is locked")
+	}
+	from, releaseFrom := withCachedCipher(from)
+	defer releaseFrom()
+	to, releaseTo := withCachedCipher(to)
+	defer releaseTo()
+
+	keys, records, err := readAll(repo)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, key := range keys {
+		data := records[key]
+		dec, err := from.Decrypt(string(data))
+		if err != nil {
+			return 0, fmt.Errorf("decrypt secret %s: %w", key, err)
+		}
+		enc, err := to.Encrypt(dec)
+		if err != nil {
+			return 0, fmt.Errorf("encrypt secret %s: %w", key, err)
+		}
+		if err := repo.Put(key, []byte(enc)); err != nil {
+			return 0, fmt.Errorf("store secret %s: %w", key, err)
+		}
+	}
+
+	return len(keys), nil
+}
@@ -1,7 +1,13 @@
 package db
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
 	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -28,6 +34,11 @@ func (m *mockRepository) Get(key string) ([]byte, error) {
 	return nil, errors.New("key not found")
 }
 
+func (m *mockRepository) Has(key string) (bool, error) {
+	_, exists := m.data[key]
+	return exists, nil
+}
+
 func (m *mockRepository) Delete(key string) error {
 	if m.data == nil {
 		return errors.New("key not found")
@@ -44,6 +55,7 @@ func (m *mockRepository) ListKeys() ([]string, error) {
 	for k := range m.data {
 		keys = append(keys, k)
 	}
+	slices.Sort(keys) // in key order, like BoltDB
 	return keys, nil
 }
 
@@ -71,6 +83,15 @@ func (m *mockVault) Encrypt(plaintext string) (string, error) {
 	return "encrypted:" + plaintext, nil
 }
 
+func (m *mockVault) MAC(data []byte) ([]byte, error) {
+	if !m.unlocked {
+		return nil, errors.New("vault locked")
+	}
+	mac := hmac.New(sha256.New, []byte("mock-mac-key"))
+	mac.Write(data)
+	return mac.Sum(nil), nil
+}
+
 func (m *mockVault) Decrypt(ciphertext string) (string, error) {
 	if !m.unlocked {
 		return "", errors.New("vault locked")
@@ -217,6 +238,79 @@ func TestEncryptedRepository_Update(t *testing.T) {
 	}
 }
 
+func TestEncryptedRepository_ReplaceKeepsUpdatedAt(t *testing.T) {
+	baseRepo := &mockRepository{}
+	vault := &mockVault{unlocked: true}
+	repo := NewEncryptedRepository(baseRepo, vault, "test-bucket")
+
+	updated := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	secret := model.Secret{ID: "test-id", Username: "testuser", Password: "old", UpdatedAt: updated}
+	if _, err := repo.Add(secret); err != nil {
+		t.Fatalf("Failed to add secret: %v", err)
+	}
+
+	secret.Password = "new"
+	secret.UpdatedAt = updated.Add(time.Hour)
+	if err := repo.Replace(secret); err != nil {
+		t.Fatalf("Replace failed: %v", err)
+	}
+
+	got, err := repo.Get("test-id")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.Password != "new" || !got.UpdatedAt.Equal(secret.UpdatedAt) {
+		t.Errorf("Expected the given version stored as is, got %q updated %v", got.Password, got.UpdatedAt)
+	}
+
+	vault.unlocked = false
+	if err := repo.Replace(secret); err == nil {
+		t.Error("Replace should fail when vault is locked")
+	}
+}
+
+func TestEncryptedRepository_MarkUsed(t *testing.T) {
+	baseRepo := &mockRepository{}
+	vault := &mockVault{unlocked: true}
+	repo := NewEncryptedRepository(baseRepo, vault, "test-bucket")
+
+	updated := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	secret := model.Secret{
+		ID:        "test-id",
+		Username:  "testuser",
+		Password:  "testpass",
+		UpdatedAt: updated,
+	}
+	if _, err := repo.Add(secret); err != nil {
+		t.Fatalf("Failed to add secret: %v", err)
+	}
+
+	used := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	if err := repo.MarkUsed("test-id", used); err != nil {
+		t.Fatalf("MarkUsed failed: %v", err)
+	}
+
+	retrieved, err := repo.Get("test-id")
+	if err != nil {
+		t.Fatalf("Failed to get secret: %v", err)
+	}
+	if !retrieved.LastUsedAt.Equal(used) {
+		t.Errorf("Expected LastUsedAt %v, got %v", used, retrieved.LastUsedAt)
+	}
+	if !retrieved.UpdatedAt.Equal(updated) {
+		t.Errorf("MarkUsed should not change UpdatedAt, got %v", retrieved.UpdatedAt)
+	}
+
+	if err := repo.MarkUsed("missing", used); err == nil {
+		t.Error("MarkUsed should fail for a missing secret")
+	}
+
+	vault.unlocked = false
+	if err := repo.MarkUsed("test-id", used); err == nil {
+		t.Error("MarkUsed should fail when vault is locked")
+	}
+}
+
 func TestEncryptedRepository_Delete(t *testing.T) {
 	baseRepo := &mockRepository{}
 	vault := &mockVault{unlocked: true}
@@ -257,6 +351,38 @@ func TestEncryptedRepository_Delete(t *testing.T) {
 	if err == nil {
 		t.Error("Delete should fail with non-existent key")
 	}
+	if !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("Expected ErrSecretNotFound, got %v", err)
+	}
+}
+
+func TestEncryptedRepository_Delete_Locked(t *testing.T) {
+	baseRepo := &mockRepository{}
+	vault := &mockVault{unlocked: true}
+	repo := NewEncryptedRepository(baseRepo, vault, "test-bucket")
+
+	secret := model.Secret{
+		ID:       "test-id",
+		Username: "testuser",
+		Password: "testpass",
+	}
+
+	key, err := repo.Add(secret)
+	if err != nil {
+		t.Fatalf("Failed to add secret: %v", err)
+	}
+
+	// Test delete when vault is locked
+	vault.unlocked = false
+	err = repo.Delete(key)
+	if err == nil {
+		t.Error("Delete should fail when vault is locked")
+	}
+
+	// Secret must survive the rejected delete
+	if _, exists := baseRepo.data[key]; !exists {
+		t.Error("Secret should not be removed while vault is locked")
+	}
 }
 
 func TestEncryptedRepository_List(t *testing.T) {
@@ -308,6 +434,55 @@ func TestEncryptedRepository_List(t *testing.T) {
 	}
 }
 
+func TestEncryptedRepository_List_Parallel(t *testing.T) {
+	baseRepo := &mockRepository{}
+	vault := &mockVault{unlocked: true}
+	repo := NewEncryptedRepository(baseRepo, vault, "test-bucket")
+	repo.SetParallelism(4)
+
+	for i := range 50 {
+		if _, err := repo.Add(model.Secret{ID: fmt.Sprintf("%02d", i), Username: "user"}); err != nil {
+			t.Fatalf("Failed to add secret: %v", err)
+		}
+	}
+
+	keys, _ := baseRepo.ListKeys()
+	listed, err := repo.List()
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	for i, secret := range listed {
+		if secret.ID != keys[i] {
+			t.Fatalf("listed[%d] = %s, want %s (key order)", i, secret.ID, keys[i])
+		}
+	}
+
+	// A corrupt record fails the whole list.
+	baseRepo.data[keys[30]] = []byte("not encrypted")
+	if _, err := repo.List(); err == nil || !strings.Contains(err.Error(), keys[30]) {
+		t.Errorf("List error = %v, want one naming %s", err, keys[30])
+	}
+}
+
+func TestForEachParallel(t *testing.T) {
+	for _, workers := range []int{1, 3, 16} {
+		var calls atomic.Int64
+		err := forEachParallel(100, workers, func(i int) error {
+			calls.Add(1)
+			if i == 20 || i == 60 {
+				return fmt.Errorf("failed at %d", i)
+			}
+			return nil
+		})
+		if err == nil || err.Error() != "failed at 20" {
+			t.Errorf("workers=%d: error = %v, want the lowest failing index", workers, err)
+		}
+		if n := calls.Load(); n == 100 {
+			t.Errorf("workers=%d: kept going after the failure", workers)
+		}
+	}
+}
+
 func TestEncryptedRepository_EncryptionFailure(t *testing.T) {
 	baseRepo := &mockRepository{}
 	vault := &mockVault{
@@ -375,3 +550,27 @@ func TestEncryptedRepository_InvalidJSON(t *testing.T) {
 		t.Error("Get should fail with invalid JSON")
 	}
 }
+
+// batchDB runs batches with no transaction, for repositories that cannot
+// be bound to one.
+type batchDB struct{ DB }
+
+func (batchDB) Batch(fn func(tx Tx) error) error { return fn(nil) }
+
+func TestEncryptedRepository_BatchUnboundRepo(t *testing.T) {
+	baseRepo := &mockRepository{}
+	repo := NewEncryptedRepository(baseRepo, &mockVault{unlocked: true}, "test-bucket")
+	repo.db = batchDB{}
+
+	called := false
+	err := repo.Batch(func(SecretRepository) error {
+		called = true
+		return nil
+	})
+	if !errors.Is(err, ErrBatchUnsupported) {
+		t.Errorf("Expected ErrBatchUnsupported, got %v", err)
+	}
+	if called {
+		t.Error("fn should not run outside a transaction")
+	}
+}
@@ -7,21 +7,24 @@ import (
 )
 
 type RepositoryFactory struct {
-	db    DB
-	vault *vault.Vault
+	db     DB
+	vault  *vault.Vault
+	shards int // buckets the secret records are spread over; < 2 means one
 }
 
-func NewRepositoryFactory(db DB, v *vault.Vault, buckets ...string) *RepositoryFactory {
+// NewRepositoryFactory creates the given buckets if they do not exist yet.
+// It fails if any of them cannot be created, e.g. on a read-only file.
+func NewRepositoryFactory(db DB, v *vault.Vault, buckets ...string) (*RepositoryFactory, error) {
 	for _, bucket := range buckets {
 		if err := db.CreateBucket(bucket); err != nil {
-			panic(fmt.Sprintf("failed to create bucket %q: %v", bucket, err))
+			return nil, fmt.Errorf("failed to create bucket %q: %w", bucket, err)
 		}
 	}
 
 	return &RepositoryFactory{
 		db:    db,
 		vault: v,
-	}
+	}, nil
 }
 
 func (f *RepositoryFactory) NewBaseRepository(bucket string) *BaseRepository {
@@ -31,14 +34,56 @@ func (f *RepositoryFactory) NewBaseRepository(bucket string) *BaseRepository {
 	}
 }
 
+// SetSecretShards sets how many buckets the secret records of later
+// repositories are spread over. See ShardedRepository.
+func (f *RepositoryFactory) SetSecretShards(n int) {
+	f.shards = n
+}
+
+// NewRecordsRepository returns the raw, still encrypted secret records
+// kept under bucket, split over the configured number of shards.
+func (f *RepositoryFactory) NewRecordsRepository(bucket string) Repository {
+	return NewShardedRepository(f.db, bucket, f.shards)
+}
+
 func (f *RepositoryFactory) NewEncryptedRepository(bucket string) SecretRepository {
-	base := &BaseRepository{
-		db:     f.db,
-		bucket: bucket,
+	return &EncryptedRepository{
+		repo:  f.NewRecordsRepository(bucket),
+		vault: f.vault,
+		db:    f.db,
 	}
+}
 
-	return &EncryptedRepository{
-		repo:  base,
+// NewIndexedRepository returns an encrypted repository that maintains a
+// metadata index in indexBucket for fast listing.
+func (f *RepositoryFactory) NewIndexedRepository(bucket, indexBucket string) SecretRepository {
+	repo := &EncryptedRepository{
+		repo:  f.NewRecordsRepository(bucket),
 		vault: f.vault,
+		db:    f.db,
 	}
+	repo.SetIndex(NewSecretIndex(&BaseRepository{db: f.db, bucket: indexBucket}, f.vault))
+	return repo
+}
+
+// NewVerifiedRepository returns an encrypted repository that keeps the
+// vault's integrity tag in tagBucket current with every write, and a
+// metadata index in indexBucket unless it is empty.
+func (f *RepositoryFactory) NewVerifiedRepository(bucket, indexBucket, tagBucket string) SecretRepository {
+	repo := &EncryptedRepository{
+		repo:  f.NewRecordsRepository(bucket),
+		vault: f.vault,
+		db:    f.db,
+	}
+	if indexBucket != "" {
+		repo.SetIndex(NewSecretIndex(&BaseRepository{db: f.db, bucket: indexBucket}, f.vault))
+	}
+	repo.SetIntegrityTag(f.NewIntegrityTag(tagBucket))
+	return repo
+}
+
+// NewIntegrityTag returns the integrity tag kept in bucket, authenticated
+// with the current vault.
+func (f *RepositoryFactory) NewIntegrityTag(bucket string) *IntegrityTag {
+	return NewIntegrityTag(&BaseRepository{db: f.db, bucket: bucket}, f.vault)
 }
@@ -0,0 +1,52 @@
+package db
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// mockDB records created buckets and fails to create the one named in failOn.
+type mockDB struct {
+	DB
+	created []string
+	failOn  string
+}
+
+func (m *mockDB) CreateBucket(bucket string) error {
+	if bucket == m.failOn {
+		return errors.New("read-only file system")
+	}
+	m.created = append(m.created, bucket)
+	return nil
+}
+
+func TestNewRepositoryFactory(t *testing.T) {
+	mdb := &mockDB{}
+
+	factory, err := NewRepositoryFactory(mdb, nil, "system", "secrets")
+	if err != nil {
+		t.Fatalf("NewRepositoryFactory failed: %v", err)
+	}
+	if factory == nil {
+		t.Fatal("Factory should not be nil")
+	}
+	if len(mdb.created) != 2 {
+		t.Errorf("Expected 2 buckets created, got %v", mdb.created)
+	}
+}
+
+func TestNewRepositoryFactory_BucketError(t *testing.T) {
+	mdb := &mockDB{failOn: "secrets"}
+
+	factory, err := NewRepositoryFactory(mdb, nil, "system", "secrets")
+	if err == nil {
+		t.Fatal("Expected an error when a bucket cannot be created")
+	}
+	if factory != nil {
+		t.Error("Factory should be nil on error")
+	}
+	if !strings.Contains(err.Error(), "secrets") {
+		t.Errorf("Error should name the failing bucket, got %v", err)
+	}
+}
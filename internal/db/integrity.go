@@ -0,0 +1,163 @@
+package db
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ErrNoIntegrityTag is returned by IntegrityTag.Verify for a vault that has
+// not been written to since the tag was introduced.
+var ErrNoIntegrityTag = errors.New("vault has no integrity tag yet")
+
+// ErrIntegrityTagInvalid means the stored tag itself fails its MAC: it was
+// edited, or written by someone without the vault key.
+var ErrIntegrityTagInvalid = errors.New("integrity tag does not verify")
+
+const integrityTagKey = "integrity:tag"
+
+// IntegrityTag authenticates the set of encrypted records as a whole. Each
+// record is already authenticated by AES-GCM, but that cannot reveal a
+// record that was deleted, or one restored from an older copy of the
+// file. The tag lists the SHA-256 of every record with a MAC over the
+// sorted list, keyed by the vault's MAC subkey, and is rewritten whenever
+// coconut changes a record, so only changes made outside coconut make it
+// disagree with the records.
+type IntegrityTag struct {
+	repo Repository // where the tag is kept, normally the system bucket
+	auth Authenticator
+}
+
+// storedTag is the JSON form of the tag. Digests are not sensitive: they
+// are hashes of ciphertext that is stored next to them anyway.
+type storedTag struct {
+	Records map[string]string `json:"records"` // ID -> hex SHA-256 of the encrypted record
+	MAC     []byte            `json:"mac"`
+}
+
+// IntegrityReport lists how the records differ from the integrity tag.
+type IntegrityReport struct {
+	Records int      // records currently stored
+	Added   []string // IDs of records the tag does not know
+	Removed []string // IDs the tag lists that no longer exist
+	Changed []string // IDs whose encrypted record was replaced
+}
+
+// OK reports whether the records match the tag exactly.
+func (r *IntegrityReport) OK() bool {
+	return len(r.Added) == 0 && len(r.Removed) == 0 && len(r.Changed) == 0
+}
+
+func NewIntegrityTag(repo Repository, auth Authenticator) *IntegrityTag {
+	return &IntegrityTag{repo: repo, auth: auth}
+}
+
+// withTx returns a copy of the tag that reads and writes through tx.
+func (t *IntegrityTag) withTx(tx Tx) (*IntegrityTag, error) {
+	repo, err := bindTx(t.repo, tx)
+	if err != nil {
+		return nil, err
+	}
+	bound := *t
+	bound.repo = repo
+	return &bound, nil
+}
+
+// Update rewrites the tag to cover the current contents of records.
+func (t *IntegrityTag) Update(records Repository) error {
+	_, values, err := readAll(records)
+	if err != nil {
+		return fmt.Errorf("read records: %w", err)
+	}
+
+	tag := storedTag{Records: make(map[string]string, len(values))}
+	for k, v := range values {
+		digest := sha256.Sum256(v)
+		tag.Records[k] = hex.EncodeToString(digest[:])
+	}
+	if tag.MAC, err = t.mac(tag.Records); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(tag)
+	if err != nil {
+		return fmt.Errorf("marshal integrity tag: %w", err)
+	}
+	return t.repo.Put(integrityTagKey, data)
+}
+
+// Verify checks the stored tag and compares it with records. It returns
+// ErrNoIntegrityTag when there is no tag and ErrIntegrityTagInvalid when
+// the tag has been tampered with; otherwise the report says which records
+// differ.
+func (t *IntegrityTag) Verify(records Repository) (*IntegrityReport, error) {
+	data, err := t.repo.Get(integrityTagKey)
+	if err != nil || len(data) == 0 {
+		return nil, ErrNoIntegrityTag
+	}
+
+	var tag storedTag
+	if err := json.Unmarshal(data, &tag); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrIntegrityTagInvalid, err)
+	}
+	want, err := t.mac(tag.Records)
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal(tag.MAC, want) {
+		return nil, ErrIntegrityTagInvalid
+	}
+
+	keys, values, err := readAll(records)
+	if err != nil {
+		return nil, fmt.Errorf("read records: %w", err)
+	}
+
+	report := &IntegrityReport{Records: len(keys)}
+	for _, k := range keys {
+		digest := sha256.Sum256(values[k])
+		known, ok := tag.Records[k]
+		switch {
+		case !ok:
+			report.Added = append(report.Added, k)
+		case known != hex.EncodeToString(digest[:]):
+			report.Changed = append(report.Changed, k)
+		}
+	}
+	for k := range tag.Records {
+		if _, ok := values[k]; !ok {
+			report.Removed = append(report.Removed, k)
+		}
+	}
+	sort.Strings(report.Removed)
+
+	return report, nil
+}
+
+// integrityContext starts every tag message, so no index entry MAC, made
+// with the same subkey, can pass for a tag.
+const integrityContext = "coconut-integrity-v1\n"
+
+// mac authenticates the record list in sorted ID order, each entry being
+// the ID, a zero byte and the digest, so the result does not depend on
+// map or bucket order.
+func (t *IntegrityTag) mac(records map[string]string) ([]byte, error) {
+	keys := make([]string, 0, len(records))
+	for k := range records {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	msg := []byte(integrityContext)
+	for _, k := range keys {
+		msg = append(msg, k...)
+		msg = append(msg, 0)
+		msg = append(msg, records[k]...)
+		msg = append(msg, '\n')
+	}
+	return t.auth.MAC(msg)
+}
@@ -0,0 +1,249 @@
+package db_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/ompatil-15/coconut/internal/crypto"
+	"github.com/ompatil-15/coconut/internal/db"
+	"github.com/ompatil-15/coconut/internal/db/boltdb"
+	"github.com/ompatil-15/coconut/internal/db/model"
+	"github.com/ompatil-15/coconut/internal/vault"
+)
+
+func newVerifiedRepo(t *testing.T) (*boltdb.BoltStore, *db.RepositoryFactory, db.SecretRepository) {
+	t.Helper()
+
+	store, err := boltdb.NewBoltStore(filepath.Join(t.TempDir(), "integrity.db"))
+	if err != nil {
+		t.Fatalf("NewBoltStore failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	v := vault.UnlockWithKey(crypto.NewAESGCM(), []byte("salt"), make([]byte, 32))
+	factory, err := db.NewRepositoryFactory(store, v, "system", "secrets", "secrets_index")
+	if err != nil {
+		t.Fatalf("NewRepositoryFactory failed: %v", err)
+	}
+	return store, factory, factory.NewVerifiedRepository("secrets", "secrets_index", "system")
+}
+
+func verifyTag(t *testing.T, factory *db.RepositoryFactory) *db.IntegrityReport {
+	t.Helper()
+
+	report, err := factory.NewIntegrityTag("system").Verify(factory.NewBaseRepository("secrets"))
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	return report
+}
+
+func TestIntegrityTag_FollowsWrites(t *testing.T) {
+	_, factory, repo := newVerifiedRepo(t)
+
+	if _, err := factory.NewIntegrityTag("system").Verify(factory.NewBaseRepository("secrets")); !errors.Is(err, db.ErrNoIntegrityTag) {
+		t.Errorf("Expected ErrNoIntegrityTag before any write, got %v", err)
+	}
+
+	for _, id := range []string{"a", "b", "c"} {
+		if _, err := repo.Add(model.Secret{ID: id, Username: id, Password: "pw-" + id}); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+	if err := repo.Update(model.Secret{ID: "b", Username: "b", Password: "changed"}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if err := repo.Delete("c"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	err := repo.Batch(func(tx db.SecretRepository) error {
+		_, err := tx.Add(model.Secret{ID: "d", Username: "d"})
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Batch failed: %v", err)
+	}
+
+	report := verifyTag(t, factory)
+	if !report.OK() {
+		t.Errorf("Expected writes through the repository to keep the tag valid, got %+v", report)
+	}
+	if report.Records != 3 {
+		t.Errorf("Expected 3 records, got %d", report.Records)
+	}
+}
+
+func TestIntegrityTag_DetectsOutOfBandChanges(t *testing.T) {
+	store, factory, repo := newVerifiedRepo(t)
+
+	for _, id := range []string{"a", "b", "c"} {
+		if _, err := repo.Add(model.Secret{ID: id, Username: id, Password: "pw-" + id}); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+	old, err := store.Get("secrets", "a")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if err := repo.Update(model.Secret{ID: "a", Username: "a", Password: "rotated"}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	// Roll a back, drop b and copy in a record, all behind coconut's back.
+	if err := store.Put("secrets", "a", old); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if err := store.Delete("secrets", "b"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := store.Put("secrets", "z", old); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	report := verifyTag(t, factory)
+	if report.OK() {
+		t.Fatal("Expected the out-of-band changes to be detected")
+	}
+	if len(report.Changed) != 1 || report.Changed[0] != "a" {
+		t.Errorf("Expected a reported as replaced, got %v", report.Changed)
+	}
+	if len(report.Removed) != 1 || report.Removed[0] != "b" {
+		t.Errorf("Expected b reported as removed, got %v", report.Removed)
+	}
+	if len(report.Added) != 1 || report.Added[0] != "z" {
+		t.Errorf("Expected z reported as added, got %v", report.Added)
+	}
+}
+
+func TestIntegrityTag_RejectsForgedTag(t *testing.T) {
+	store, factory, repo := newVerifiedRepo(t)
+
+	if _, err := repo.Add(model.Secret{ID: "a", Username: "a"}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	// A tag written with another key must not verify.
+	other := vault.UnlockWithKey(crypto.NewAESGCM(), []byte("salt"), append(make([]byte, 31), 1))
+	forged := db.NewIntegrityTag(db.NewBaseRepository(store, "system"), other)
+	if err := forged.Update(db.NewBaseRepository(store, "secrets")); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	if _, err := factory.NewIntegrityTag("system").Verify(factory.NewBaseRepository("secrets")); !errors.Is(err, db.ErrIntegrityTagInvalid) {
+		t.Errorf("Expected ErrIntegrityTagInvalid for a forged tag, got %v", err)
+	}
+}
+
+func TestEncryptedRepository_Quarantine(t *testing.T) {
+	store, factory, repo := newVerifiedRepo(t)
+	if err := store.CreateBucket("quarantine"); err != nil {
+		t.Fatalf("CreateBucket failed: %v", err)
+	}
+
+	for _, id := range []string{"a", "b", "c"} {
+		if _, err := repo.Add(model.Secret{ID: id, Username: id, Password: "pw-" + id}); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+	q, ok := repo.(interface {
+		Undecryptable() ([]string, error)
+		Quarantine(bucket string, keys []string, retag bool) error
+	})
+	if !ok {
+		t.Fatal("Expected the verified repository to support quarantine")
+	}
+
+	// A record no key opens, as a half-finished rekey might leave.
+	if err := store.Put("secrets", "b", []byte("not-a-ciphertext")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	bad, err := q.Undecryptable()
+	if err != nil || len(bad) != 1 || bad[0] != "b" {
+		t.Fatalf("Undecryptable = %v, %v; want [b]", bad, err)
+	}
+
+	// The tag no longer verified, so it must not be refreshed.
+	if err := q.Quarantine("quarantine", bad, false); err != nil {
+		t.Fatalf("Quarantine failed: %v", err)
+	}
+	if kept, err := store.Get("quarantine", "b"); err != nil || string(kept) != "not-a-ciphertext" {
+		t.Errorf("Expected the record kept in quarantine, got %q, %v", kept, err)
+	}
+	if ok, _ := store.Has("secrets", "b"); ok {
+		t.Error("Expected b removed from the vault")
+	}
+	if secrets, err := repo.List(); err != nil || len(secrets) != 2 {
+		t.Errorf("List after quarantine = %d secrets, %v", len(secrets), err)
+	}
+	if report := verifyTag(t, factory); len(report.Removed) != 1 || report.Removed[0] != "b" {
+		t.Errorf("Expected the untouched tag to report b removed, got %+v", report)
+	}
+
+	// A second bad b must not overwrite the first one.
+	if err := store.Put("secrets", "b", []byte("second")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if err := q.Quarantine("quarantine", []string{"b"}, true); err != nil {
+		t.Fatalf("Quarantine failed: %v", err)
+	}
+	if kept, _ := store.Get("quarantine", "b.2"); string(kept) != "second" {
+		t.Errorf("Expected the second record under b.2, got %q", kept)
+	}
+	if report := verifyTag(t, factory); !report.OK() || report.Records != 2 {
+		t.Errorf("Expected the refreshed tag to cover the 2 remaining records, got %+v", report)
+	}
+}
+
+// failingDeleteDB fails every delete from bucket made inside a batch.
+type failingDeleteDB struct {
+	db.DB
+	bucket string
+}
+
+func (d failingDeleteDB) Batch(fn func(tx db.Tx) error) error {
+	return d.DB.Batch(func(tx db.Tx) error { return fn(failingDeleteTx{tx, d.bucket}) })
+}
+
+type failingDeleteTx struct {
+	db.Tx
+	bucket string
+}
+
+func (t failingDeleteTx) Delete(bucket, key string) error {
+	if bucket == t.bucket {
+		return errors.New("disk full")
+	}
+	return t.Tx.Delete(bucket, key)
+}
+
+func TestEncryptedRepository_QuarantineIndexError(t *testing.T) {
+	store, err := boltdb.NewBoltStore(filepath.Join(t.TempDir(), "integrity.db"), boltdb.WithAutoCreateBuckets())
+	if err != nil {
+		t.Fatalf("NewBoltStore failed: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	v := vault.UnlockWithKey(crypto.NewAESGCM(), []byte("salt"), make([]byte, 32))
+	factory, err := db.NewRepositoryFactory(failingDeleteDB{store, "secrets_index"}, v, "system", "secrets", "secrets_index")
+	if err != nil {
+		t.Fatalf("NewRepositoryFactory failed: %v", err)
+	}
+	repo := factory.NewVerifiedRepository("secrets", "secrets_index", "system")
+	if _, err := repo.Add(model.Secret{ID: "a", Username: "a", Password: "pw"}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+
+	q := repo.(interface {
+		Quarantine(bucket string, keys []string, retag bool) error
+	})
+	if err := q.Quarantine("quarantine", []string{"a"}, true); err == nil {
+		t.Fatal("Expected the index error to be returned")
+	}
+	if ok, _ := store.Has("secrets", "a"); !ok {
+		t.Error("Expected the record left in the vault when the batch fails")
+	}
+	if keys, _ := store.ListKeys("quarantine"); len(keys) != 0 {
+		t.Errorf("Expected nothing quarantined, got %v", keys)
+	}
+}
@@ -1,13 +1,100 @@
 package model
 
-import "time"
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// SecretTypeNote marks a secure note: free text in Description with no
+// login credentials. The zero Type is a regular login.
+const SecretTypeNote = "note"
 
 type Secret struct {
 	ID          string    `json:"id"`
+	Type        string    `json:"type,omitempty"`
+	Name        string    `json:"name"`
 	Username    string    `json:"username"`
 	Password    string    `json:"password"`
 	URL         string    `json:"url"`
 	Description string    `json:"description"`
+	Tags        []string  `json:"tags,omitempty"`
+	Fields      []Field   `json:"fields,omitempty"` // Custom fields, in the order they were added
+	IsFavorite  bool      `json:"isFavorite,omitempty"`
+	IsArchived  bool      `json:"isArchived,omitempty"` // Hidden from list and search unless asked for
 	CreatedAt   time.Time `json:"createdAt"`
 	UpdatedAt   time.Time `json:"updatedAt"`
+	ExpiresAt   time.Time `json:"expiresAt,omitzero"`  // Zero value means the secret never expires
+	LastUsedAt  time.Time `json:"lastUsedAt,omitzero"` // Set when the password is read or copied; not a content change
+	PinHash     string    `json:"pinHash,omitempty"`   // Argon2id hash of the access PIN; empty when the secret has none
+}
+
+// Field is a custom name/value pair, such as a security question's answer
+// or an account number. It is encrypted with the rest of the secret.
+type Field struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// FieldValue returns the value of the custom field called name, matched
+// case-insensitively.
+func (s *Secret) FieldValue(name string) (string, bool) {
+	for _, f := range s.Fields {
+		if strings.EqualFold(f.Name, name) {
+			return f.Value, true
+		}
+	}
+	return "", false
+}
+
+// SetField sets the custom field called name, matched case-insensitively,
+// keeping its place. A new field is added at the end.
+func (s *Secret) SetField(name, value string) {
+	// Copies of a secret share the array; leave theirs alone.
+	s.Fields = slices.Clone(s.Fields)
+	for i, f := range s.Fields {
+		if strings.EqualFold(f.Name, name) {
+			s.Fields[i].Value = value
+			return
+		}
+	}
+	s.Fields = append(s.Fields, Field{Name: name, Value: value})
+}
+
+// RemoveField removes the custom field called name and reports whether the
+// secret had it.
+func (s *Secret) RemoveField(name string) bool {
+	for i, f := range s.Fields {
+		if strings.EqualFold(f.Name, name) {
+			s.Fields = append(s.Fields[:i:i], s.Fields[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// IsProtected reports whether revealing the password needs the secret's PIN.
+func (s *Secret) IsProtected() bool {
+	return s.PinHash != ""
+}
+
+// IsExpired reports whether the secret has an expiry that is at or before now.
+func (s *Secret) IsExpired(now time.Time) bool {
+	return !s.ExpiresAt.IsZero() && !now.Before(s.ExpiresAt)
+}
+
+// NormalizeTags lowercases and trims tags, dropping blanks and duplicates
+// so "Work" and "work " group together.
+func NormalizeTags(tags []string) []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		out = append(out, tag)
+	}
+	return out
 }
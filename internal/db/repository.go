@@ -1,10 +1,15 @@
 package db
 
-import "github.com/ompatil-15/coconut/internal/db/model"
+import (
+	"time"
+
+	"github.com/ompatil-15/coconut/internal/db/model"
+)
 
 type Repository interface {
 	Put(key string, value []byte) error
 	Get(key string) ([]byte, error)
+	Has(key string) (bool, error) // reports whether key exists without copying its value
 	Delete(key string) error
 	ListKeys() ([]string, error)
 }
@@ -13,6 +18,11 @@ type SecretRepository interface {
 	Add(secret model.Secret) (string, error)
 	Get(key string) (*model.Secret, error)
 	Update(secret model.Secret) error
+	Replace(secret model.Secret) error       // like Update, but keeps the secret's UpdatedAt
+	MarkUsed(key string, at time.Time) error // sets LastUsedAt without touching UpdatedAt
 	Delete(key string) error
 	List() ([]model.Secret, error)
+	ListMetadata() ([]model.Secret, error) // like List, but passwords are left empty
+	Batch(fn func(repo SecretRepository) error) error
+	Reindex() (*ReindexReport, error) // rebuilds derived metadata from the encrypted records
 }
@@ -0,0 +1,189 @@
+package db
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ompatil-15/coconut/internal/db/model"
+)
+
+// Authenticator computes a keyed MAC. The vault implements it with a subkey
+// of the master key, so only someone holding the key can produce valid tags.
+type Authenticator interface {
+	MAC(data []byte) ([]byte, error)
+}
+
+// errIndexMismatch means an index entry does not belong to the current
+// encrypted record and must not be trusted.
+var errIndexMismatch = errors.New("index entry does not match secret")
+
+// SecretIndex stores the non-sensitive fields of each secret (everything but
+// the password) in plaintext, so listing and searching need not decrypt every
+// record. Each entry carries a MAC over the secret ID, the metadata and a
+// hash of the encrypted record, so edited metadata or a replaced record is
+// detected and the caller falls back to decrypting.
+type SecretIndex struct {
+	repo Repository
+	auth Authenticator
+}
+
+// An index entry is stored as the MAC followed by the metadata JSON, so the
+// MAC can be checked over the stored bytes without re-encoding them.
+const indexMACSize = sha256.Size
+
+func NewSecretIndex(repo Repository, auth Authenticator) *SecretIndex {
+	return &SecretIndex{repo: repo, auth: auth}
+}
+
+// withTx returns a copy of the index that writes through tx.
+func (x *SecretIndex) withTx(tx Tx) (*SecretIndex, error) {
+	repo, err := bindTx(x.repo, tx)
+	if err != nil {
+		return nil, err
+	}
+	bound := *x
+	bound.repo = repo
+	return &bound, nil
+}
+
+// Put records the metadata of secret, bound to its encrypted record.
+func (x *SecretIndex) Put(secret model.Secret, ciphertext []byte) error {
+	meta, err := json.Marshal(metadataOf(secret))
+	if err != nil {
+		return fmt.Errorf("marshal metadata: %w", err)
+	}
+
+	mac, err := x.mac(secret.ID, meta, ciphertext)
+	if err != nil {
+		return err
+	}
+
+	return x.repo.Put(secret.ID, append(mac, meta...))
+}
+
+// Get returns the indexed metadata for key after checking it against the
+// current encrypted record.
+func (x *SecretIndex) Get(key string, ciphertext []byte) (*model.Secret, error) {
+	entry, err := x.repo.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	return x.verify(key, entry, ciphertext)
+}
+
+// entries reads the whole index in one pass.
+func (x *SecretIndex) entries() (map[string][]byte, error) {
+	_, values, err := readAll(x.repo)
+	return values, err
+}
+
+// Unverified returns the metadata of every entry, in key order, without
+// checking the MACs. It needs no vault key, so it suits uses where a
+// tampered value is harmless, like shell completion. Never use it to
+// decide what to change.
+func (x *SecretIndex) Unverified() ([]model.Secret, error) {
+	keys, values, err := readAll(x.repo)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := make([]model.Secret, 0, len(keys))
+	for _, k := range keys {
+		entry := values[k]
+		if len(entry) < indexMACSize {
+			return nil, errIndexMismatch
+		}
+		var secret model.Secret
+		if err := json.Unmarshal(entry[indexMACSize:], &secret); err != nil {
+			return nil, fmt.Errorf("unmarshal index entry: %w", err)
+		}
+		secrets = append(secrets, secret)
+	}
+	return secrets, nil
+}
+
+// verify checks a stored entry against the encrypted record and decodes it.
+func (x *SecretIndex) verify(key string, entry, ciphertext []byte) (*model.Secret, error) {
+	if len(entry) < indexMACSize {
+		return nil, errIndexMismatch
+	}
+
+	stored, meta := entry[:indexMACSize], entry[indexMACSize:]
+	want, err := x.mac(key, meta, ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal(stored, want) {
+		return nil, errIndexMismatch
+	}
+
+	var secret model.Secret
+	if err := json.Unmarshal(meta, &secret); err != nil {
+		return nil, fmt.Errorf("unmarshal index entry: %w", err)
+	}
+	return &secret, nil
+}
+
+// Delete removes the entry for key. A missing entry is not an error.
+func (x *SecretIndex) Delete(key string) error {
+	return x.repo.Delete(key)
+}
+
+func (x *SecretIndex) mac(key string, meta, ciphertext []byte) ([]byte, error) {
+	digest := sha256.Sum256(ciphertext)
+
+	msg := make([]byte, 0, len(key)+1+len(digest)+len(meta))
+	msg = append(msg, key...)
+	msg = append(msg, 0)
+	msg = append(msg, digest[:]...)
+	msg = append(msg, meta...)
+
+	return x.auth.MAC(msg)
+}
+
+// metadataOf returns secret without its password, PIN hash or custom
+// fields. A short PIN's hash could be brute-forced from the plaintext
+// index, and custom fields often hold answers as sensitive as passwords.
+func metadataOf(secret model.Secret) model.Secret {
+	secret.Password = ""
+	secret.PinHash = ""
+	secret.Fields = nil
+	return secret
+}
+
+// scanner is implemented by repositories that can read every entry in one
+// pass, which is far cheaper than a Get per key.
+type scanner interface {
+	ForEach(fn func(key string, value []byte) error) error
+}
+
+// readAll returns the keys of r in order along with their values.
+func readAll(r Repository) ([]string, map[string][]byte, error) {
+	values := make(map[string][]byte)
+	var keys []string
+
+	if s, ok := r.(scanner); ok {
+		err := s.ForEach(func(key string, value []byte) error {
+			keys = append(keys, key)
+			values[key] = append([]byte(nil), value...)
+			return nil
+		})
+		return keys, values, err
+	}
+
+	keys, err := r.ListKeys()
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, k := range keys {
+		v, err := r.Get(k)
+		if err != nil {
+			return nil, nil, err
+		}
+		values[k] = v
+	}
+	return keys, values, nil
+}
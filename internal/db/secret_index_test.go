@@ -0,0 +1,205 @@
+package db
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/ompatil-15/coconut/internal/db/model"
+)
+
+func newIndexedTestRepo() (*EncryptedRepository, *mockRepository, *mockRepository, *mockVault) {
+	baseRepo := &mockRepository{}
+	indexRepo := &mockRepository{}
+	vault := &mockVault{unlocked: true}
+
+	repo := NewEncryptedRepository(baseRepo, vault, "test-bucket")
+	repo.SetIndex(NewSecretIndex(indexRepo, vault))
+	return repo, baseRepo, indexRepo, vault
+}
+
+func TestEncryptedRepository_ListMetadata_UsesIndex(t *testing.T) {
+	repo, _, _, vault := newIndexedTestRepo()
+
+	secret := model.Secret{ID: "1", Name: "GitHub", Username: "user1", Password: "pass1", PinHash: "argon2id$pin"}
+	if _, err := repo.Add(secret); err != nil {
+		t.Fatalf("Failed to add secret: %v", err)
+	}
+
+	// Listing from a valid index must not decrypt any record
+	vault.decryptFunc = func(string) (string, error) {
+		return "", errors.New("decrypt should not be called")
+	}
+
+	secrets, err := repo.ListMetadata()
+	if err != nil {
+		t.Fatalf("ListMetadata failed: %v", err)
+	}
+	if len(secrets) != 1 {
+		t.Fatalf("Expected 1 secret, got %d", len(secrets))
+	}
+	if secrets[0].Name != "GitHub" || secrets[0].Username != "user1" {
+		t.Errorf("Unexpected metadata: %+v", secrets[0])
+	}
+	if secrets[0].Password != "" {
+		t.Error("ListMetadata must not return passwords")
+	}
+	if secrets[0].PinHash != "" {
+		t.Error("ListMetadata must not return PIN hashes")
+	}
+}
+
+func TestEncryptedRepository_ListMetadata_TamperedMetadata(t *testing.T) {
+	repo, _, indexRepo, _ := newIndexedTestRepo()
+
+	if _, err := repo.Add(model.Secret{ID: "1", Username: "user1", Password: "pass1"}); err != nil {
+		t.Fatalf("Failed to add secret: %v", err)
+	}
+
+	// Rewrite the plaintext username without a valid MAC
+	entry := indexRepo.data["1"]
+	meta := bytes.Replace(entry[indexMACSize:], []byte("user1"), []byte("attacker"), 1)
+	indexRepo.data["1"] = append(entry[:indexMACSize:indexMACSize], meta...)
+
+	secrets, err := repo.ListMetadata()
+	if err != nil {
+		t.Fatalf("ListMetadata failed: %v", err)
+	}
+	if secrets[0].Username != "user1" {
+		t.Errorf("Tampered metadata should be ignored, got username %q", secrets[0].Username)
+	}
+
+	// The entry is rebuilt from the encrypted record
+	if !bytes.Contains(indexRepo.data["1"], []byte(`"username":"user1"`)) {
+		t.Error("Index entry should be repaired from the encrypted record")
+	}
+}
+
+func TestEncryptedRepository_ListMetadata_ReplacedRecord(t *testing.T) {
+	repo, baseRepo, _, _ := newIndexedTestRepo()
+
+	if _, err := repo.Add(model.Secret{ID: "1", Username: "user1", Password: "pass1"}); err != nil {
+		t.Fatalf("Failed to add secret: %v", err)
+	}
+
+	// Swap the encrypted record behind the index's back
+	data, _ := json.Marshal(model.Secret{ID: "1", Username: "user2", Password: "pass2"})
+	baseRepo.data["1"] = []byte("encrypted:" + string(data))
+
+	secrets, err := repo.ListMetadata()
+	if err != nil {
+		t.Fatalf("ListMetadata failed: %v", err)
+	}
+	if secrets[0].Username != "user2" {
+		t.Errorf("Metadata should follow the encrypted record, got %q", secrets[0].Username)
+	}
+}
+
+func TestEncryptedRepository_ListMetadata_Maintained(t *testing.T) {
+	repo, _, indexRepo, _ := newIndexedTestRepo()
+
+	secret := model.Secret{ID: "1", Username: "user1", Password: "pass1"}
+	if _, err := repo.Add(secret); err != nil {
+		t.Fatalf("Failed to add secret: %v", err)
+	}
+
+	secret.Username = "renamed"
+	if err := repo.Update(secret); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	secrets, err := repo.ListMetadata()
+	if err != nil {
+		t.Fatalf("ListMetadata failed: %v", err)
+	}
+	if secrets[0].Username != "renamed" {
+		t.Errorf("Index should reflect updates, got %q", secrets[0].Username)
+	}
+
+	if err := repo.Delete("1"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, exists := indexRepo.data["1"]; exists {
+		t.Error("Index entry should be removed with the secret")
+	}
+}
+
+func TestEncryptedRepository_ListMetadata_Locked(t *testing.T) {
+	repo, _, _, vault := newIndexedTestRepo()
+	vault.unlocked = false
+
+	if _, err := repo.ListMetadata(); err == nil {
+		t.Error("ListMetadata should fail when vault is locked")
+	}
+}
+
+func TestEncryptedRepository_Reindex(t *testing.T) {
+	repo, baseRepo, indexRepo, _ := newIndexedTestRepo()
+
+	for _, id := range []string{"1", "2", "3"} {
+		if _, err := repo.Add(model.Secret{ID: id, Username: "user" + id, Password: "pass"}); err != nil {
+			t.Fatalf("Failed to add secret: %v", err)
+		}
+	}
+
+	// Desync the index: drop one entry, corrupt one record, leave an orphan
+	delete(indexRepo.data, "1")
+	baseRepo.data["2"] = []byte("garbage")
+	indexRepo.data["gone"] = []byte("stale entry")
+
+	report, err := repo.Reindex()
+	if err != nil {
+		t.Fatalf("Reindex failed: %v", err)
+	}
+
+	if report.Indexed != 2 {
+		t.Errorf("Expected 2 entries reindexed, got %d", report.Indexed)
+	}
+	if report.Removed != 1 {
+		t.Errorf("Expected 1 stale entry removed, got %d", report.Removed)
+	}
+	if len(report.Failed) != 1 || report.Failed[0] != "2" {
+		t.Errorf("Expected record 2 reported as undecryptable, got %v", report.Failed)
+	}
+
+	if _, ok := indexRepo.data["1"]; !ok {
+		t.Error("Missing index entry should be rebuilt")
+	}
+	if _, ok := indexRepo.data["2"]; ok {
+		t.Error("Index entry for an undecryptable record should be removed")
+	}
+	if _, ok := indexRepo.data["gone"]; ok {
+		t.Error("Orphaned index entry should be removed")
+	}
+}
+
+func TestEncryptedRepository_Reindex_NoIndex(t *testing.T) {
+	repo := NewEncryptedRepository(&mockRepository{}, &mockVault{unlocked: true}, "test-bucket")
+
+	if _, err := repo.Reindex(); !errors.Is(err, ErrNoIndex) {
+		t.Errorf("Expected ErrNoIndex, got %v", err)
+	}
+}
+
+func TestSecretIndex_Unverified(t *testing.T) {
+	repo, _, indexRepo, vault := newIndexedTestRepo()
+
+	if _, err := repo.Add(model.Secret{ID: "1", Name: "GitHub", Username: "user1", Password: "pass1"}); err != nil {
+		t.Fatalf("Failed to add secret: %v", err)
+	}
+
+	// No key is needed to read the names
+	vault.unlocked = false
+
+	secrets, err := NewSecretIndex(indexRepo, nil).Unverified()
+	if err != nil {
+		t.Fatalf("Unverified failed: %v", err)
+	}
+	if len(secrets) != 1 || secrets[0].Name != "GitHub" || secrets[0].ID != "1" {
+		t.Errorf("Unexpected metadata: %+v", secrets)
+	}
+	if secrets[0].Password != "" {
+		t.Error("Unverified must not return passwords")
+	}
+}
@@ -0,0 +1,143 @@
+package db
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+)
+
+// MaxShards bounds the number of buckets secrets can be spread over.
+const MaxShards = 256
+
+// ShardBuckets returns the buckets that hold the records of bucket when it
+// is split into shards: bucket itself for 0 or 1 shards, otherwise
+// bucket_0 to bucket_<shards-1>.
+func ShardBuckets(bucket string, shards int) []string {
+	if shards <= 1 {
+		return []string{bucket}
+	}
+	buckets := make([]string, shards)
+	for i := range buckets {
+		buckets[i] = fmt.Sprintf("%s_%d", bucket, i)
+	}
+	return buckets
+}
+
+// ShardedRepository spreads keys over several buckets by an FNV-1a hash of
+// the key, so no single bucket grows with the whole vault. Reads of all
+// keys merge the shards back into one sorted sequence, the order a single
+// bucket would give.
+type ShardedRepository struct {
+	db      Tx // the DB itself, or a transaction inside Batch
+	buckets []string
+}
+
+// NewShardedRepository returns the records of bucket split into shards.
+// With 0 or 1 shards that is a plain BaseRepository over bucket.
+func NewShardedRepository(db Tx, bucket string, shards int) Repository {
+	buckets := ShardBuckets(bucket, shards)
+	if len(buckets) == 1 {
+		return &BaseRepository{db: db, bucket: bucket}
+	}
+	return &ShardedRepository{db: db, buckets: buckets}
+}
+
+// shard returns the bucket key belongs in.
+func (r *ShardedRepository) shard(key string) string {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return r.buckets[h.Sum32()%uint32(len(r.buckets))]
+}
+
+func (r *ShardedRepository) Put(key string, value []byte) error {
+	return r.db.Put(r.shard(key), key, value)
+}
+
+func (r *ShardedRepository) Get(key string) ([]byte, error) {
+	return r.db.Get(r.shard(key), key)
+}
+
+func (r *ShardedRepository) Has(key string) (bool, error) {
+	return r.db.Has(r.shard(key), key)
+}
+
+func (r *ShardedRepository) Delete(key string) error {
+	return r.db.Delete(r.shard(key), key)
+}
+
+func (r *ShardedRepository) ListKeys() ([]string, error) {
+	var keys []string
+	for _, bucket := range r.buckets {
+		shard, err := r.db.ListKeys(bucket)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, shard...)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// ForEach visits every entry of every shard in key order. Unlike a single
+// bucket's ForEach, value stays valid after the call.
+func (r *ShardedRepository) ForEach(fn func(key string, value []byte) error) error {
+	type entry struct {
+		key   string
+		value []byte
+	}
+	var entries []entry
+	for _, bucket := range r.buckets {
+		err := r.db.ForEach(bucket, func(key string, value []byte) error {
+			entries = append(entries, entry{key, append([]byte(nil), value...)})
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+	for _, e := range entries {
+		if err := fn(e.key, e.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withTx returns a copy of the repository that goes through tx.
+func (r *ShardedRepository) withTx(tx Tx) Repository {
+	return &ShardedRepository{db: tx, buckets: r.buckets}
+}
+
+// Reshard moves the records of bucket from one number of shards to
+// another inside tx and returns how many were moved. Records are moved as
+// they are, still encrypted; their keys do not change, so the metadata
+// index and integrity tag stay valid. Emptied buckets are left behind.
+func Reshard(tx Tx, bucket string, from, to int) (int, error) {
+	if to > MaxShards {
+		return 0, fmt.Errorf("at most %d shards are supported", MaxShards)
+	}
+
+	src := NewShardedRepository(tx, bucket, from)
+	keys, records, err := readAll(src)
+	if err != nil {
+		return 0, fmt.Errorf("read records: %w", err)
+	}
+
+	// Old and new buckets can overlap (e.g. 4 shards to 2), so everything
+	// is removed before anything is written back.
+	for _, k := range keys {
+		if err := src.Delete(k); err != nil {
+			return 0, fmt.Errorf("remove secret %s: %w", k, err)
+		}
+	}
+	dst := NewShardedRepository(tx, bucket, to)
+	for _, k := range keys {
+		if err := dst.Put(k, records[k]); err != nil {
+			return 0, fmt.Errorf("store secret %s: %w", k, err)
+		}
+	}
+
+	return len(keys), nil
+}
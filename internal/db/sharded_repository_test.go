@@ -0,0 +1,106 @@
+package db_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/ompatil-15/coconut/internal/db"
+	"github.com/ompatil-15/coconut/internal/db/boltdb"
+)
+
+func TestShardedRepository(t *testing.T) {
+	store, err := boltdb.NewBoltStore(filepath.Join(t.TempDir(), "shards.db"), boltdb.WithAutoCreateBuckets())
+	if err != nil {
+		t.Fatalf("NewBoltStore failed: %v", err)
+	}
+	defer store.Close()
+
+	repo := db.NewShardedRepository(store, "secrets", 4)
+	var want []string
+	for i := range 40 {
+		key := fmt.Sprintf("id-%02d", i)
+		want = append(want, key)
+		if err := repo.Put(key, []byte("ct-"+key)); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+
+	if keys, _ := store.ListKeys("secrets"); len(keys) != 0 {
+		t.Errorf("Expected nothing in the unsharded bucket, got %v", keys)
+	}
+	used := 0
+	for _, bucket := range db.ShardBuckets("secrets", 4) {
+		if keys, _ := store.ListKeys(bucket); len(keys) > 0 {
+			used++
+		}
+	}
+	if used < 2 {
+		t.Errorf("Expected keys spread over several shards, %d used", used)
+	}
+
+	keys, err := repo.ListKeys()
+	if err != nil || !slices.Equal(keys, want) {
+		t.Errorf("ListKeys = %v, %v; want all keys in order", keys, err)
+	}
+	if v, err := repo.Get("id-07"); err != nil || string(v) != "ct-id-07" {
+		t.Errorf("Get = %q, %v", v, err)
+	}
+	if err := repo.Delete("id-07"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if ok, _ := repo.Has("id-07"); ok {
+		t.Error("Expected the deleted key to be gone")
+	}
+}
+
+func TestReshard(t *testing.T) {
+	store, err := boltdb.NewBoltStore(filepath.Join(t.TempDir(), "reshard.db"), boltdb.WithAutoCreateBuckets())
+	if err != nil {
+		t.Fatalf("NewBoltStore failed: %v", err)
+	}
+	defer store.Close()
+
+	plain := db.NewShardedRepository(store, "secrets", 1)
+	for i := range 25 {
+		key := fmt.Sprintf("id-%02d", i)
+		if err := plain.Put(key, []byte("ct-"+key)); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+	want, _ := plain.ListKeys()
+
+	// 1 -> 8 -> 3 -> 1, including a shrink whose buckets overlap.
+	layout := 1
+	for _, shards := range []int{8, 3, 1} {
+		err := store.Batch(func(tx db.Tx) error {
+			n, err := db.Reshard(tx, "secrets", layout, shards)
+			if err == nil && n != len(want) {
+				t.Errorf("Reshard %d -> %d moved %d records, want %d", layout, shards, n, len(want))
+			}
+			return err
+		})
+		if err != nil {
+			t.Fatalf("Reshard %d -> %d failed: %v", layout, shards, err)
+		}
+		layout = shards
+
+		repo := db.NewShardedRepository(store, "secrets", layout)
+		keys, _ := repo.ListKeys()
+		if !slices.Equal(keys, want) {
+			t.Fatalf("After resharding to %d, keys = %v", layout, keys)
+		}
+		for _, k := range keys {
+			if v, err := repo.Get(k); err != nil || string(v) != "ct-"+k {
+				t.Errorf("After resharding to %d, Get(%s) = %q, %v", layout, k, v, err)
+			}
+		}
+	}
+
+	for _, bucket := range db.ShardBuckets("secrets", 8) {
+		if keys, _ := store.ListKeys(bucket); len(keys) != 0 {
+			t.Errorf("Expected %s emptied, got %v", bucket, keys)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package db
+
+import "fmt"
+
+// Wipe empties buckets, first overwriting every value with zeros of the
+// same length in one transaction and then deleting the keys in another,
+// and returns how many entries were removed. The overwrite only helps
+// stores that write in place; BoltDB copies pages on write, so old values
+// can survive in free pages until they are reused. Destroy the file
+// itself when that matters.
+func Wipe(store DB, buckets []string) (int, error) {
+	sizes := make(map[string]map[string]int, len(buckets))
+	total := 0
+	for _, bucket := range buckets {
+		sizes[bucket] = map[string]int{}
+		err := store.ForEach(bucket, func(key string, value []byte) error {
+			sizes[bucket][key] = len(value)
+			return nil
+		})
+		if err != nil {
+			return 0, fmt.Errorf("read %s: %w", bucket, err)
+		}
+		total += len(sizes[bucket])
+	}
+
+	err := store.Batch(func(tx Tx) error {
+		for bucket, keys := range sizes {
+			for key, n := range keys {
+				if err := tx.Put(bucket, key, make([]byte, n)); err != nil {
+					return fmt.Errorf("overwrite %s/%s: %w", bucket, key, err)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	err = store.Batch(func(tx Tx) error {
+		for bucket, keys := range sizes {
+			for key := range keys {
+				if err := tx.Delete(bucket, key); err != nil {
+					return fmt.Errorf("delete %s/%s: %w", bucket, key, err)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// WipeTx empties buckets inside tx, overwriting every value with zeros
+// before deleting it, and returns how many entries were removed. Unlike
+// Wipe it commits with the caller's other writes, so a failure later in
+// the batch leaves the buckets as they were. Both steps commit together,
+// so on BoltDB only the deletion reaches the file.
+func WipeTx(tx Tx, buckets []string) (int, error) {
+	total := 0
+	for _, bucket := range buckets {
+		sizes := map[string]int{}
+		err := tx.ForEach(bucket, func(key string, value []byte) error {
+			sizes[key] = len(value)
+			return nil
+		})
+		if err != nil {
+			return 0, fmt.Errorf("read %s: %w", bucket, err)
+		}
+
+		for key, n := range sizes {
+			if err := tx.Put(bucket, key, make([]byte, n)); err != nil {
+				return 0, fmt.Errorf("overwrite %s/%s: %w", bucket, key, err)
+			}
+			if err := tx.Delete(bucket, key); err != nil {
+				return 0, fmt.Errorf("delete %s/%s: %w", bucket, key, err)
+			}
+		}
+		total += len(sizes)
+	}
+	return total, nil
+}
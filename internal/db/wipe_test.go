@@ -0,0 +1,90 @@
+package db_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/ompatil-15/coconut/internal/db"
+	"github.com/ompatil-15/coconut/internal/db/boltdb"
+)
+
+func TestWipe(t *testing.T) {
+	store, err := boltdb.NewBoltStore(filepath.Join(t.TempDir(), "wipe.db"), boltdb.WithAutoCreateBuckets())
+	if err != nil {
+		t.Fatalf("NewBoltStore failed: %v", err)
+	}
+	defer store.Close()
+
+	for _, kv := range [][3]string{{"system", "salt", "s"}, {"secrets", "a", "ct-a"}, {"secrets", "b", "ct-b"}, {"other", "keep", "x"}} {
+		if err := store.Put(kv[0], kv[1], []byte(kv[2])); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+
+	n, err := db.Wipe(store, []string{"system", "secrets", "missing"})
+	if err != nil {
+		t.Fatalf("Wipe failed: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("Expected 3 entries wiped, got %d", n)
+	}
+
+	for _, bucket := range []string{"system", "secrets"} {
+		if keys, _ := store.ListKeys(bucket); len(keys) != 0 {
+			t.Errorf("Expected %s to be empty, got %v", bucket, keys)
+		}
+	}
+	if v, err := store.Get("other", "keep"); err != nil || string(v) != "x" {
+		t.Errorf("Expected buckets not listed to be left alone, got %q, %v", v, err)
+	}
+}
+
+func TestWipeTx(t *testing.T) {
+	store, err := boltdb.NewBoltStore(filepath.Join(t.TempDir(), "wipe.db"), boltdb.WithAutoCreateBuckets())
+	if err != nil {
+		t.Fatalf("NewBoltStore failed: %v", err)
+	}
+	defer store.Close()
+
+	for _, kv := range [][2]string{{"salt", "old"}, {"token", "old"}} {
+		if err := store.Put("system", kv[0], []byte(kv[1])); err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+	}
+
+	// A failure after the wipe rolls it back
+	err = store.Batch(func(tx db.Tx) error {
+		if _, err := db.WipeTx(tx, []string{"system"}); err != nil {
+			return err
+		}
+		return errors.New("create failed")
+	})
+	if err == nil {
+		t.Fatal("Expected the batch error")
+	}
+	if v, err := store.Get("system", "salt"); err != nil || string(v) != "old" {
+		t.Fatalf("Expected the old entries kept after a failed batch, got %q, %v", v, err)
+	}
+
+	var n int
+	err = store.Batch(func(tx db.Tx) error {
+		var err error
+		if n, err = db.WipeTx(tx, []string{"system", "missing"}); err != nil {
+			return err
+		}
+		return tx.Put("system", "salt", []byte("new"))
+	})
+	if err != nil {
+		t.Fatalf("Batch failed: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("Expected 2 entries wiped, got %d", n)
+	}
+	if keys, _ := store.ListKeys("system"); len(keys) != 1 {
+		t.Errorf("Expected only the new entry left, got %v", keys)
+	}
+	if v, _ := store.Get("system", "salt"); string(v) != "new" {
+		t.Errorf("Expected the write after the wipe to stick, got %q", v)
+	}
+}
@@ -0,0 +1,119 @@
+// Package envelope encrypts data under a key derived from a passphrase
+// alone. It is the format shared by share files and encrypted exports:
+// each keeps its own outer JSON, but the key derivation, the limits put on
+// it and the sealing of the data are done here.
+package envelope
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ompatil-15/coconut/internal/crypto"
+)
+
+// MaxMemoryBytes caps the memory a file's key derivation can ask for, so
+// a crafted file cannot make coconut exhaust memory opening it.
+const MaxMemoryBytes = 1 << 30
+
+// AuthenticatedVersion is the first format version whose header is
+// authenticated along with its data. Earlier versions only seal the data.
+const AuthenticatedVersion = 2
+
+// ErrDecrypt is returned by Open when the passphrase does not decrypt the
+// data, or the data or its header were tampered with.
+var ErrDecrypt = errors.New("envelope does not decrypt")
+
+// Header is what a file records about how its data was sealed. From
+// AuthenticatedVersion on, all of it is authenticated with the data, so
+// changing any field makes Open fail.
+type Header struct {
+	Magic   string           `json:"magic"`
+	Version int              `json:"version"`
+	KDF     crypto.KDFParams `json:"kdf"`
+	Salt    []byte           `json:"salt"`
+}
+
+// NewHeader returns a Header with the default key derivation and a fresh
+// salt.
+func NewHeader(magic string, version int) Header {
+	return Header{
+		Magic:   magic,
+		Version: version,
+		KDF:     crypto.DefaultKDFParams(),
+		Salt:    crypto.GenerateRandomSalt(16),
+	}
+}
+
+// Check reports whether h is safe to derive a key from: a known algorithm
+// within its limits, no more than MaxMemoryBytes of memory, and a salt.
+func (h Header) Check() error {
+	if err := h.KDF.Validate(); err != nil {
+		return fmt.Errorf("key derivation: %w", err)
+	}
+	if mem := h.KDF.MemoryBytes(); mem > MaxMemoryBytes {
+		return fmt.Errorf("key derivation asks for %d MiB of memory; refusing more than %d", mem>>20, MaxMemoryBytes>>20)
+	}
+	if len(h.Salt) == 0 {
+		return errors.New("missing salt")
+	}
+	return nil
+}
+
+// additionalData is the header as authenticated with the data, or nil for
+// versions before AuthenticatedVersion.
+func (h Header) additionalData() ([]byte, error) {
+	if h.Version < AuthenticatedVersion {
+		return nil, nil
+	}
+	return json.Marshal(h)
+}
+
+// Seal encrypts plaintext under a key derived from passphrase with h.
+func Seal(h Header, passphrase string, plaintext []byte) (string, error) {
+	if err := h.Check(); err != nil {
+		return "", err
+	}
+	aead, err := newCipher(h, passphrase)
+	if err != nil {
+		return "", err
+	}
+	ad, err := h.additionalData()
+	if err != nil {
+		return "", err
+	}
+	return aead.EncryptWithData(string(plaintext), ad)
+}
+
+// Open checks h and decrypts data written by Seal with the same header
+// and passphrase.
+func Open(h Header, passphrase string, data string) ([]byte, error) {
+	if err := h.Check(); err != nil {
+		return nil, err
+	}
+	if data == "" {
+		return nil, errors.New("missing data")
+	}
+	aead, err := newCipher(h, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	ad, err := h.additionalData()
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.DecryptWithData(data, ad)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return []byte(plaintext), nil
+}
+
+func newCipher(h Header, passphrase string) (*crypto.KeyedAESGCM, error) {
+	key, err := crypto.DeriveKeyWithParams(passphrase, h.Salt, h.KDF)
+	if err != nil {
+		return nil, fmt.Errorf("key derivation: %w", err)
+	}
+	defer clear(key)
+	return crypto.NewAESGCMWithKey(key)
+}
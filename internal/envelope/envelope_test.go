@@ -0,0 +1,93 @@
+package envelope
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ompatil-15/coconut/internal/crypto"
+)
+
+// testHeader is a header with a cheap key derivation, to keep tests fast.
+func testHeader(version int) Header {
+	h := NewHeader("coconut-test", version)
+	h.KDF = crypto.KDFParams{Algorithm: crypto.KDFArgon2id, Time: 1, MemoryKiB: 8 * 1024, Threads: 1, KeyLen: 32}
+	return h
+}
+
+func TestSealOpen_RoundTrip(t *testing.T) {
+	for _, version := range []int{1, AuthenticatedVersion} {
+		h := testHeader(version)
+		data, err := Seal(h, "pw", []byte("hello"))
+		if err != nil {
+			t.Fatalf("Seal (version %d) failed: %v", version, err)
+		}
+
+		got, err := Open(h, "pw", data)
+		if err != nil || string(got) != "hello" {
+			t.Errorf("Open (version %d) = %q, %v; want hello", version, got, err)
+		}
+		if _, err := Open(h, "wrong", data); !errors.Is(err, ErrDecrypt) {
+			t.Errorf("Open (version %d) with the wrong passphrase: expected ErrDecrypt, got %v", version, err)
+		}
+	}
+}
+
+func TestOpen_TamperedHeader(t *testing.T) {
+	h := testHeader(AuthenticatedVersion)
+	data, err := Seal(h, "pw", []byte("hello"))
+	if err != nil {
+		t.Fatalf("Seal failed: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(h *Header)
+	}{
+		{"magic", func(h *Header) { h.Magic = "coconut-other" }},
+		{"downgraded version", func(h *Header) { h.Version = 1 }},
+		{"newer version", func(h *Header) { h.Version++ }},
+		{"kdf time", func(h *Header) { h.KDF.Time++ }},
+		{"salt", func(h *Header) { h.Salt = append([]byte{0}, h.Salt[1:]...) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tampered := h
+			tt.modify(&tampered)
+			if _, err := Open(tampered, "pw", data); !errors.Is(err, ErrDecrypt) {
+				t.Errorf("Expected ErrDecrypt, got %v", err)
+			}
+		})
+	}
+}
+
+func TestHeader_Check(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(h *Header)
+	}{
+		{"excessive memory", func(h *Header) { h.KDF.MemoryKiB = 4 * 1024 * 1024 }},
+		{"excessive time", func(h *Header) { h.KDF.Time = 1 << 20 }},
+		{"excessive scrypt parallelism", func(h *Header) {
+			h.KDF = crypto.KDFParams{Algorithm: crypto.KDFScrypt, N: 1 << 10, R: 8, P: 1 << 20, KeyLen: 32}
+		}},
+		{"unknown algorithm", func(h *Header) { h.KDF.Algorithm = "md5" }},
+		{"missing salt", func(h *Header) { h.Salt = nil }},
+	}
+
+	if err := testHeader(AuthenticatedVersion).Check(); err != nil {
+		t.Fatalf("Check rejected a valid header: %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := testHeader(AuthenticatedVersion)
+			tt.modify(&h)
+			if err := h.Check(); err == nil {
+				t.Error("Expected header to be rejected")
+			}
+			if _, err := Open(h, "pw", "data"); err == nil || errors.Is(err, ErrDecrypt) {
+				t.Errorf("Expected Open to refuse the header before decrypting, got %v", err)
+			}
+		})
+	}
+}
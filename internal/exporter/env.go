@@ -0,0 +1,74 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ompatil-15/coconut/internal/db/model"
+)
+
+// writeEnv writes one KEY='password' line per secret, keyed by EnvKey of
+// its name. Notes, unnamed secrets and later secrets whose key is already
+// taken are skipped.
+func writeEnv(w io.Writer, secrets []model.Secret) ([]Skip, error) {
+	var skipped []Skip
+	seen := make(map[string]string)
+
+	for _, s := range secrets {
+		if s.Type == model.SecretTypeNote {
+			skipped = append(skipped, Skip{Name: s.Name, Reason: "secure note has no password"})
+			continue
+		}
+
+		key := EnvKey(s.Name)
+		if key == "" {
+			skipped = append(skipped, Skip{Name: s.Username, Reason: "no name to derive a key from"})
+			continue
+		}
+		if first, ok := seen[key]; ok {
+			skipped = append(skipped, Skip{Name: s.Name, Reason: fmt.Sprintf("key %s already used by %q", key, first)})
+			continue
+		}
+		seen[key] = s.Name
+
+		if _, err := fmt.Fprintf(w, "%s=%s\n", key, QuoteEnv(s.Password)); err != nil {
+			return skipped, err
+		}
+	}
+
+	return skipped, nil
+}
+
+// EnvKey turns a secret name into an environment variable name: letters
+// are uppercased and every run of other characters becomes one
+// underscore, e.g. "GitHub (work)" -> "GITHUB_WORK". A leading digit gets
+// an underscore prefix. Names with no letters or digits yield "".
+func EnvKey(name string) string {
+	var b strings.Builder
+	pendingUnderscore := false
+	for _, r := range name {
+		isAlnum := (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+		if !isAlnum {
+			pendingUnderscore = b.Len() > 0
+			continue
+		}
+		if pendingUnderscore {
+			b.WriteByte('_')
+			pendingUnderscore = false
+		}
+		if b.Len() == 0 && r >= '0' && r <= '9' {
+			b.WriteByte('_')
+		}
+		b.WriteRune(r)
+	}
+	return strings.ToUpper(b.String())
+}
+
+// QuoteEnv single-quotes value for a .env file, so that a POSIX shell
+// sourcing it reads back the exact value. Nothing inside single quotes is
+// expanded, newlines included; a quote in the value closes the string,
+// is written escaped and opens a new one.
+func QuoteEnv(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
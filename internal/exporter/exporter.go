@@ -0,0 +1,44 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/ompatil-15/coconut/internal/db/model"
+)
+
+// Skip describes a secret that was left out of an export.
+type Skip struct {
+	Name   string
+	Reason string
+}
+
+// Writer writes secrets in one export format, returning the secrets it
+// had to leave out.
+type Writer func(w io.Writer, secrets []model.Secret) ([]Skip, error)
+
+var writers = map[string]Writer{
+	"env":  writeEnv,
+	"json": writeJSON,
+}
+
+// Formats returns the supported --format names, sorted.
+func Formats() []string {
+	names := make([]string, 0, len(writers))
+	for name := range writers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Write dispatches to the writer for format.
+func Write(format string, w io.Writer, secrets []model.Secret) ([]Skip, error) {
+	write, ok := writers[strings.ToLower(format)]
+	if !ok {
+		return nil, fmt.Errorf("unknown export format %q (supported: %s)", format, strings.Join(Formats(), ", "))
+	}
+	return write(w, secrets)
+}
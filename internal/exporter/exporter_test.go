@@ -0,0 +1,148 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/ompatil-15/coconut/internal/db/model"
+)
+
+func TestEnvKey(t *testing.T) {
+	tests := map[string]string{
+		"GitHub":           "GITHUB",
+		"GitHub (work)":    "GITHUB_WORK",
+		"aws-prod.db_pass": "AWS_PROD_DB_PASS",
+		"  spaced  out  ":  "SPACED_OUT",
+		"1password":        "_1PASSWORD",
+		"Café":             "CAF",
+		"***":              "",
+		"":                 "",
+	}
+
+	for name, want := range tests {
+		if got := EnvKey(name); got != want {
+			t.Errorf("EnvKey(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestQuoteEnv(t *testing.T) {
+	tests := map[string]string{
+		"plain":         `'plain'`,
+		`say "hi"`:      `'say "hi"'`,
+		`back\slash`:    `'back\slash'`,
+		"$HOME and `x`": "'$HOME and `x`'",
+		"two\nlines":    "'two\nlines'",
+		"it's # fine":   `'it'\''s # fine'`,
+		"":              `''`,
+	}
+
+	for value, want := range tests {
+		if got := QuoteEnv(value); got != want {
+			t.Errorf("QuoteEnv(%q) = %s, want %s", value, got, want)
+		}
+	}
+}
+
+func TestQuoteEnv_ShellRoundTrip(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no POSIX shell available")
+	}
+
+	for _, value := range []string{
+		`p@ss"word`, "it's", "''", `a\b\\c`, "$HOME `id` $(id)", "two\nlines\r\n", "tab\there", " # spaces ",
+	} {
+		script := "V=" + QuoteEnv(value) + "\nprintf '%s' \"$V\""
+		out, err := exec.Command(sh, "-c", script).Output()
+		if err != nil {
+			t.Fatalf("sh failed for %q: %v", value, err)
+		}
+		if string(out) != value {
+			t.Errorf("sh read back %q, want %q", out, value)
+		}
+	}
+}
+
+func TestWrite_Env(t *testing.T) {
+	secrets := []model.Secret{
+		{Name: "GitHub Token", Password: "ghp_123"},
+		{Name: "db password", Password: `p@ss"word`},
+		{Name: "github-token", Password: "other"},
+		{Name: "Door codes", Type: model.SecretTypeNote, Description: "1234"},
+		{Username: "nameless", Password: "x"},
+	}
+
+	var buf bytes.Buffer
+	skipped, err := Write("env", &buf, secrets)
+	if err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	want := "GITHUB_TOKEN='ghp_123'\nDB_PASSWORD='p@ss\"word'\n"
+	if buf.String() != want {
+		t.Errorf("Unexpected output:\n%s\nwant:\n%s", buf.String(), want)
+	}
+	if len(skipped) != 3 {
+		t.Errorf("Expected the duplicate key, the note and the unnamed secret to be skipped, got %+v", skipped)
+	}
+}
+
+func TestWrite_UnknownFormat(t *testing.T) {
+	if _, err := Write("xml", &bytes.Buffer{}, nil); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
+
+func TestObject_Bitwarden(t *testing.T) {
+	login := model.Secret{Name: "GitHub", Username: "me", Password: "pw", URL: "https://github.com", Description: "work"}
+
+	obj, err := Object(SchemaBitwarden, login, true)
+	if err != nil {
+		t.Fatalf("Object failed: %v", err)
+	}
+	got, _ := json.Marshal(obj)
+	want := `{"type":1,"name":"GitHub","notes":"work","favorite":false,"login":{"uris":[{"match":null,"uri":"https://github.com"}],"username":"me","password":"pw"}}`
+	if string(got) != want {
+		t.Errorf("Unexpected login item:\n%s\nwant:\n%s", got, want)
+	}
+
+	obj, _ = Object(SchemaBitwarden, login, false)
+	if got, _ := json.Marshal(obj); strings.Contains(string(got), `"pw"`) {
+		t.Errorf("Expected the password to be left out, got %s", got)
+	}
+
+	note := model.Secret{Name: "Door codes", Type: model.SecretTypeNote, Description: "1234",
+		Fields: []model.Field{{Name: "alarm", Value: "5678"}}}
+	obj, _ = Object(SchemaBitwarden, note, true)
+	got, _ = json.Marshal(obj)
+	want = `{"type":2,"name":"Door codes","notes":"1234","favorite":false,"fields":[{"name":"alarm","value":"5678","type":0}],"secureNote":{"type":0}}`
+	if string(got) != want {
+		t.Errorf("Unexpected note item:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWriteJSON_BitwardenDocument(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := WriteJSON(&buf, []model.Secret{{Name: "a", Password: "x"}}, SchemaBitwarden); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+
+	var doc struct {
+		Encrypted bool             `json:"encrypted"`
+		Items     []map[string]any `json:"items"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("Output is not JSON: %v", err)
+	}
+	if doc.Encrypted || len(doc.Items) != 1 {
+		t.Errorf("Expected one unencrypted item, got %+v", doc)
+	}
+
+	if _, err := WriteJSON(&bytes.Buffer{}, nil, "keepass"); err == nil {
+		t.Error("Expected an error for an unknown schema")
+	}
+}
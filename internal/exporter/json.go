@@ -0,0 +1,190 @@
+package exporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ompatil-15/coconut/internal/db/model"
+)
+
+// JSON schemas for 'get --json' and 'export --format json'.
+const (
+	SchemaCoconut   = "coconut"
+	SchemaBitwarden = "bitwarden"
+)
+
+// Schemas returns the supported --schema names, coconut's own first.
+func Schemas() []string {
+	return []string{SchemaCoconut, SchemaBitwarden}
+}
+
+// coconutObject is a secret in coconut's own schema. Unlike model.Secret
+// it leaves out the ID and PIN hash, which mean nothing outside the vault.
+type coconutObject struct {
+	Type        string        `json:"type,omitempty"`
+	Name        string        `json:"name"`
+	Username    string        `json:"username"`
+	Password    *string       `json:"password,omitempty"`
+	URL         string        `json:"url"`
+	Description string        `json:"description"`
+	Tags        []string      `json:"tags,omitempty"`
+	Fields      []model.Field `json:"fields,omitempty"`
+	IsFavorite  bool          `json:"isFavorite,omitempty"`
+	CreatedAt   time.Time     `json:"createdAt"`
+	UpdatedAt   time.Time     `json:"updatedAt"`
+	ExpiresAt   time.Time     `json:"expiresAt,omitzero"`
+}
+
+// Bitwarden item types, as in its unencrypted JSON export.
+const (
+	bitwardenLogin      = 1
+	bitwardenSecureNote = 2
+)
+
+// bitwardenItem is one entry of the "items" array of a Bitwarden
+// unencrypted JSON export, with just the fields coconut can fill.
+type bitwardenItem struct {
+	Type       int                 `json:"type"`
+	Name       string              `json:"name"`
+	Notes      *string             `json:"notes"`
+	Favorite   bool                `json:"favorite"`
+	Fields     []bitwardenField    `json:"fields,omitempty"`
+	Login      *bitwardenLoginData `json:"login,omitempty"`
+	SecureNote *bitwardenNoteData  `json:"secureNote,omitempty"`
+}
+
+type bitwardenLoginData struct {
+	URIs     []bitwardenURI `json:"uris,omitempty"`
+	Username *string        `json:"username"`
+	Password *string        `json:"password"`
+}
+
+// bitwardenField is a custom field; coconut's are all plain text.
+type bitwardenField struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	Type  int    `json:"type"`
+}
+
+type bitwardenURI struct {
+	Match *int   `json:"match"`
+	URI   string `json:"uri"`
+}
+
+type bitwardenNoteData struct {
+	Type int `json:"type"`
+}
+
+// Object converts a secret to schema. The password is only included with
+// withPassword; it is left out of coconut objects and null in Bitwarden
+// ones otherwise.
+func Object(schema string, s model.Secret, withPassword bool) (any, error) {
+	var password *string
+	if withPassword {
+		password = &s.Password
+	}
+
+	switch strings.ToLower(schema) {
+	case SchemaCoconut:
+		return coconutObject{
+			Type:        s.Type,
+			Name:        s.Name,
+			Username:    s.Username,
+			Password:    password,
+			URL:         s.URL,
+			Description: s.Description,
+			Tags:        s.Tags,
+			Fields:      s.Fields,
+			IsFavorite:  s.IsFavorite,
+			CreatedAt:   s.CreatedAt,
+			UpdatedAt:   s.UpdatedAt,
+			ExpiresAt:   s.ExpiresAt,
+		}, nil
+	case SchemaBitwarden:
+		return bitwardenObject(s, password), nil
+	default:
+		return nil, fmt.Errorf("unknown schema %q (supported: %s)", schema, strings.Join(Schemas(), ", "))
+	}
+}
+
+// bitwardenObject maps a secret the way Bitwarden's importer reads it:
+// Name to name, Username and Password to login, URL to the first login
+// URI, Description to notes and custom fields to text fields. Secure
+// notes become Bitwarden secure notes.
+func bitwardenObject(s model.Secret, password *string) bitwardenItem {
+	item := bitwardenItem{
+		Name:     s.Name,
+		Notes:    optional(s.Description),
+		Favorite: s.IsFavorite,
+	}
+	if item.Name == "" {
+		// Bitwarden requires a name; fall back to the username.
+		item.Name = s.Username
+	}
+	for _, f := range s.Fields {
+		item.Fields = append(item.Fields, bitwardenField{Name: f.Name, Value: f.Value})
+	}
+
+	if s.Type == model.SecretTypeNote {
+		item.Type = bitwardenSecureNote
+		item.SecureNote = &bitwardenNoteData{}
+		return item
+	}
+
+	item.Type = bitwardenLogin
+	item.Login = &bitwardenLoginData{
+		Username: optional(s.Username),
+		Password: password,
+	}
+	if s.URL != "" {
+		item.Login.URIs = []bitwardenURI{{URI: s.URL}}
+	}
+	return item
+}
+
+// optional returns nil for "", which Bitwarden writes as null.
+func optional(v string) *string {
+	if v == "" {
+		return nil
+	}
+	return &v
+}
+
+// WriteJSON writes secrets, passwords included, as one indented JSON
+// document in schema: an array of objects for coconut, and for Bitwarden
+// a file its "Bitwarden (json)" import accepts.
+func WriteJSON(w io.Writer, secrets []model.Secret, schema string) ([]Skip, error) {
+	if !slices.Contains(Schemas(), strings.ToLower(schema)) {
+		return nil, fmt.Errorf("unknown schema %q (supported: %s)", schema, strings.Join(Schemas(), ", "))
+	}
+
+	items := make([]any, 0, len(secrets))
+	for _, s := range secrets {
+		obj, err := Object(schema, s, true)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, obj)
+	}
+
+	var doc any = items
+	if strings.EqualFold(schema, SchemaBitwarden) {
+		doc = map[string]any{
+			"encrypted": false,
+			"folders":   []any{},
+			"items":     items,
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return nil, enc.Encode(doc)
+}
+
+func writeJSON(w io.Writer, secrets []model.Secret) ([]Skip, error) {
+	return WriteJSON(w, secrets, SchemaCoconut)
+}
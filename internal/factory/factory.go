@@ -2,14 +2,18 @@ package factory
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 
 	"github.com/ompatil-15/coconut/internal/config"
 	"github.com/ompatil-15/coconut/internal/crypto"
 	"github.com/ompatil-15/coconut/internal/db"
 	"github.com/ompatil-15/coconut/internal/db/boltdb"
 	"github.com/ompatil-15/coconut/internal/iostreams"
+	"github.com/ompatil-15/coconut/internal/journal"
 	"github.com/ompatil-15/coconut/internal/logger"
 	"github.com/ompatil-15/coconut/internal/session"
+	"github.com/ompatil-15/coconut/internal/undo"
 	"github.com/ompatil-15/coconut/internal/vault"
 )
 
@@ -24,41 +28,148 @@ type Factory struct {
 	System  db.Repository
 	Secrets db.SecretRepository
 	Session *session.Manager
+	Undo    *undo.Store
+	Journal *journal.Store
+
+	// PasswordStdin makes unlocking read the master password from IO.In
+	// instead of prompting on the terminal.
+	PasswordStdin bool
+
+	// StdinPassword is the master password once read from IO.In with
+	// PasswordStdin, so a command that checks it again does not read a
+	// second line.
+	StdinPassword string
+
+	// NoSession disables reading and writing the cached session key, so
+	// every command prompts and the key never touches disk.
+	NoSession bool
+
+	// RequireSession makes unlocking fail with session.ErrSessionExpired
+	// instead of asking for the master password when there is no valid
+	// session, so unattended jobs never block on a prompt.
+	RequireSession bool
+}
+
+// options holds what New would otherwise construct itself.
+type options struct {
+	io     *iostreams.IOStreams
+	db     db.DB
+	crypto crypto.CryptoStrategy
+}
+
+// Option replaces one of the dependencies New constructs, e.g. in tests.
+type Option func(*options)
+
+// WithIOStreams uses io instead of the process's stdin, stdout and stderr.
+func WithIOStreams(io *iostreams.IOStreams) Option {
+	return func(o *options) {
+		o.io = io
+	}
+}
+
+// WithDB uses store instead of opening a BoltDB file at dbPath. The
+// factory takes ownership and closes it in Close. The store should create
+// missing buckets on write, as boltdb.WithAutoCreateBuckets does.
+func WithDB(store db.DB) Option {
+	return func(o *options) {
+		o.db = store
+	}
+}
+
+// WithCrypto uses strategy instead of AES-GCM, e.g. cryptotest.NewDeterministic
+// for reproducible ciphertext in tests.
+func WithCrypto(strategy crypto.CryptoStrategy) Option {
+	return func(o *options) {
+		o.crypto = strategy
+	}
 }
 
-func New() (*Factory, error) {
-	io := iostreams.System()
+// New wires up all dependencies against the vault database at dbPath.
+// An empty dbPath uses the default location from config. opts replace
+// the real IO streams, database or crypto strategy.
+func New(dbPath string, opts ...Option) (*Factory, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	io := o.io
+	if io == nil {
+		io = iostreams.System()
+	}
 	log, err := logger.New()
 	if err != nil {
 		return nil, fmt.Errorf("logger init: %w", err)
 	}
+	// Close the log file on every error return below; once New succeeds
+	// it belongs to the Factory and is closed in Close.
+	opened := false
+	defer func() {
+		if !opened {
+			log.Close()
+		}
+	}()
 
 	cfg := config.Default()
+	if dbPath != "" {
+		cfg.DBPath = dbPath
+	}
 
-	bdb, err := boltdb.NewBoltStore(cfg.DBPath)
-	if err != nil {
-		return nil, fmt.Errorf("db open: %w", err)
+	bdb := o.db
+	if bdb == nil {
+		if err := os.MkdirAll(filepath.Dir(cfg.DBPath), 0700); err != nil {
+			return nil, fmt.Errorf("db dir %q: %w", filepath.Dir(cfg.DBPath), err)
+		}
+
+		store, err := boltdb.NewBoltStore(cfg.DBPath, boltdb.WithAutoCreateBuckets())
+		if err != nil {
+			return nil, fmt.Errorf("db open %q: %w", cfg.DBPath, err)
+		}
+		bdb = store
 	}
 
-	repoFactory := db.NewRepositoryFactory(bdb, nil, cfg.SystemBucket, cfg.SecretsBucket)
+	repoFactory, err := db.NewRepositoryFactory(bdb, nil, cfg.SystemBucket, cfg.SecretsBucket, cfg.IndexBucket, cfg.JournalBucket)
+	if err != nil {
+		_ = bdb.Close()
+		return nil, fmt.Errorf("db setup %q: %w", cfg.DBPath, err)
+	}
 
 	systemRepo := repoFactory.NewBaseRepository(cfg.SystemBucket)
 
+	openedPath := cfg.DBPath
 	cfg, err = config.Load(systemRepo)
 	if err != nil {
+		_ = bdb.Close()
 		return nil, fmt.Errorf("config load: %w", err)
 	}
+	log.SetAccessLogEnabled(cfg.AccessLog)
+	log.SetFormat(cfg.LogFormat)
+	if level, err := logger.ParseLevel(cfg.LogLevel); err == nil {
+		log.SetLevel(level)
+	}
+	// The stored path may be stale (e.g. a copied vault); report the file
+	// that was actually opened so backups and reopening hit the same one.
+	cfg.DBPath = openedPath
 
-	strategy := crypto.NewAESGCM()
+	strategy := o.crypto
+	if strategy == nil {
+		strategy = crypto.NewAESGCM()
+	}
 	v := vault.NewVault(strategy, nil)
 
 	repoFactory.SetVault(v)
+	repoFactory.SetSecretShards(cfg.SecretShards)
 
-	secretRepo := repoFactory.NewEncryptedRepository(cfg.SecretsBucket)
+	secretRepo := repoFactory.NewVerifiedRepository(cfg.SecretsBucket, cfg.MetadataIndexBucket(), cfg.SystemBucket)
 
+	// Sessions live in the system bucket of the opened DB, so each vault
+	// file gets its own independent session.
 	sessionRepo := systemRepo
 	sessionMgr := session.NewManager(sessionRepo, cfg)
 
+	log.Info("Opened vault database %s", cfg.DBPath)
+	opened = true
+
 	return &Factory{
 		IO:      io,
 		Logger:  log,
@@ -70,10 +181,15 @@ func New() (*Factory, error) {
 		System:  systemRepo,
 		Secrets: secretRepo,
 		Session: sessionMgr,
+		Undo:    undo.NewStore(systemRepo, v),
+		Journal: journal.NewStore(repoFactory.NewBaseRepository(cfg.JournalBucket), v),
 	}, nil
 }
 
 func (f *Factory) Close() {
+	if f.Vault != nil {
+		f.Vault.Lock()
+	}
 	if f.Logger != nil {
 		f.Logger.Close()
 	}
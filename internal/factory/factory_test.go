@@ -1,9 +1,16 @@
 package factory
 
 import (
+	"bytes"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/ompatil-15/coconut/internal/config"
+	"github.com/ompatil-15/coconut/internal/crypto/cryptotest"
+	"github.com/ompatil-15/coconut/internal/db/boltdb"
+	"github.com/ompatil-15/coconut/internal/iostreams"
 )
 
 func TestNew(t *testing.T) {
@@ -34,7 +41,7 @@ func TestNew(t *testing.T) {
 	}
 
 	// Test factory creation
-	factory, err := New()
+	factory, err := New("")
 	if err != nil {
 		t.Fatalf("New() failed: %v", err)
 	}
@@ -109,7 +116,7 @@ func TestFactory_Close(t *testing.T) {
 		t.Fatalf("Failed to create logs dir: %v", err)
 	}
 
-	factory, err := New()
+	factory, err := New("")
 	if err != nil {
 		t.Fatalf("New() failed: %v", err)
 	}
@@ -128,7 +135,7 @@ func TestFactory_InvalidPath(t *testing.T) {
 	defer os.Setenv("HOME", originalHome)
 
 	// Factory creation should still work (it creates directories)
-	factory, err := New()
+	factory, err := New("")
 	if err != nil {
 		// This might fail due to permissions, which is expected
 		t.Logf("Factory creation failed as expected: %v", err)
@@ -169,7 +176,7 @@ func TestFactory_ComponentIntegration(t *testing.T) {
 		t.Fatalf("Failed to create logs dir: %v", err)
 	}
 
-	factory, err := New()
+	factory, err := New("")
 	if err != nil {
 		t.Fatalf("New() failed: %v", err)
 	}
@@ -200,4 +207,113 @@ func TestFactory_ComponentIntegration(t *testing.T) {
 	if factory.Vault.IsUnlocked() {
 		t.Error("Vault should not be unlocked initially")
 	}
-}
\ No newline at end of file
+}
+
+func TestNew_CustomDBPath(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "factory-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	originalHome := os.Getenv("HOME")
+	os.Setenv("HOME", tempDir)
+	defer os.Setenv("HOME", originalHome)
+
+	// Parent directory does not exist yet and must be created
+	dbPath := filepath.Join(tempDir, "other", "nested", "vault.db")
+
+	factory, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	defer factory.Close()
+
+	if factory.Config.DBPath != dbPath {
+		t.Errorf("Expected DBPath '%s', got '%s'", dbPath, factory.Config.DBPath)
+	}
+
+	if _, err := os.Stat(dbPath); err != nil {
+		t.Errorf("Database file should be created at custom path: %v", err)
+	}
+
+	defaultDB := filepath.Join(tempDir, ".coconut", "coconut.db")
+	if _, err := os.Stat(defaultDB); !os.IsNotExist(err) {
+		t.Error("Default database should not be created when a custom path is given")
+	}
+}
+
+func TestNew_IgnoresStoredDBPath(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "factory-test-*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	originalHome := os.Getenv("HOME")
+	os.Setenv("HOME", tempDir)
+	defer os.Setenv("HOME", originalHome)
+
+	dbPath := filepath.Join(tempDir, "vault.db")
+
+	factory, err := New(dbPath)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	// Simulate a vault file copied from elsewhere
+	factory.Config.DBPath = "/somewhere/else/coconut.db"
+	if err := config.Save(factory.System, factory.Config); err != nil {
+		t.Fatalf("config.Save failed: %v", err)
+	}
+	factory.Close()
+
+	factory, err = New(dbPath)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	defer factory.Close()
+
+	if factory.Config.DBPath != dbPath {
+		t.Errorf("Expected DBPath to be the opened file '%s', got '%s'", dbPath, factory.Config.DBPath)
+	}
+}
+
+func TestNew_WithOptions(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("HOME", tempDir)
+
+	store, err := boltdb.NewBoltStore(filepath.Join(tempDir, "injected.db"), boltdb.WithAutoCreateBuckets())
+	if err != nil {
+		t.Fatalf("NewBoltStore failed: %v", err)
+	}
+	strategy := cryptotest.NewDeterministic()
+	var out bytes.Buffer
+	streams := &iostreams.IOStreams{In: strings.NewReader(""), Out: &out, ErrOut: &out}
+
+	dbPath := filepath.Join(tempDir, "unused", "vault.db")
+	factory, err := New(dbPath, WithDB(store), WithCrypto(strategy), WithIOStreams(streams))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	defer factory.Close()
+
+	if factory.DB != store {
+		t.Error("Expected the injected DB to be used")
+	}
+	if factory.Crypto != strategy {
+		t.Error("Expected the injected crypto strategy to be used")
+	}
+	if factory.IO != streams {
+		t.Error("Expected the injected IO streams to be used")
+	}
+	if _, err := os.Stat(filepath.Dir(dbPath)); !os.IsNotExist(err) {
+		t.Error("No database file should be opened when a DB is injected")
+	}
+
+	if err := factory.System.Put("test-key", []byte("test-value")); err != nil {
+		t.Fatalf("System.Put failed: %v", err)
+	}
+	if value, err := store.Get("system", "test-key"); err != nil || string(value) != "test-value" {
+		t.Errorf("Expected writes to reach the injected DB, got %q, %v", value, err)
+	}
+}
@@ -0,0 +1,136 @@
+package fuzzy
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Score tiers. Any substring match outranks any subsequence match, which in
+// turn outranks a typo-tolerant (edit distance) match. Penalties within a
+// tier are capped so a score never reaches the tier below, nor 0.
+const (
+	exactScore       = 300
+	prefixScore      = 250
+	substringScore   = 200
+	subsequenceScore = 100
+	typoScore        = 50
+)
+
+// Match is a ranked candidate returned by Rank.
+type Match struct {
+	Index int // position in the candidates slice passed to Rank
+	Score int
+}
+
+// Score rates how well query matches target, case-insensitively. Higher is
+// better; ok is false when target does not match at all.
+func Score(query, target string) (score int, ok bool) {
+	q := strings.ToLower(strings.TrimSpace(query))
+	t := strings.ToLower(target)
+	if q == "" || t == "" {
+		return 0, false
+	}
+
+	switch {
+	case q == t:
+		return exactScore, true
+	case strings.HasPrefix(t, q):
+		return prefixScore - min(len(t)-len(q), prefixScore-substringScore-1), true
+	case strings.Contains(t, q):
+		return substringScore - min(len(t)-len(q), substringScore-subsequenceScore-1), true
+	}
+
+	if gaps, ok := subsequenceGaps(q, t); ok {
+		return max(subsequenceScore-gaps*5, typoScore+1), true
+	}
+
+	// Typos: compare against the whole target and each word in it,
+	// tolerating roughly one edit per three characters.
+	best := levenshtein(q, t)
+	for _, word := range strings.FieldsFunc(t, isSeparator) {
+		best = min(best, levenshtein(q, word))
+	}
+
+	allowed := max(len([]rune(q))/3, 1)
+	if best <= allowed {
+		return max(typoScore-best*10, 1), true
+	}
+
+	return 0, false
+}
+
+// Rank scores every candidate against query and returns the matches sorted
+// by descending score, keeping at most limit results (limit <= 0 means all).
+// Ties keep the original candidate order.
+func Rank(query string, candidates []string, limit int) []Match {
+	var matches []Match
+	for i, candidate := range candidates {
+		if score, ok := Score(query, candidate); ok {
+			matches = append(matches, Match{Index: i, Score: score})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// subsequenceGaps reports whether every rune of q appears in t in order, and
+// how many characters of t were skipped between the first and last match.
+func subsequenceGaps(q, t string) (int, bool) {
+	qr := []rune(q)
+	qi, gaps, started := 0, 0, false
+
+	for _, r := range t {
+		if qi == len(qr) {
+			break
+		}
+		if r == qr[qi] {
+			qi++
+			started = true
+		} else if started {
+			gaps++
+		}
+	}
+
+	return gaps, qi == len(qr)
+}
+
+// levenshtein returns the edit distance between a and b, counting an
+// adjacent transposition ("gmial" vs "gmail") as a single edit.
+func levenshtein(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	rows := make([][]int, len(ar)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(br)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+
+	for i := 1; i <= len(ar); i++ {
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			rows[i][j] = min(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ar[i-1] == br[j-2] && ar[i-2] == br[j-1] {
+				rows[i][j] = min(rows[i][j], rows[i-2][j-2]+1)
+			}
+		}
+	}
+
+	return rows[len(ar)][len(br)]
+}
+
+func isSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
@@ -0,0 +1,131 @@
+package fuzzy
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScore_Matches(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		target string
+	}{
+		{"exact", "github", "GitHub"},
+		{"prefix", "git", "GitHub"},
+		{"substring", "hub", "GitHub"},
+		{"subsequence", "gthb", "GitHub"},
+		{"typo", "githib", "GitHub"},
+		{"typo in word", "gmial", "work gmail account"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := Score(tt.query, tt.target); !ok {
+				t.Errorf("Score(%q, %q) should match", tt.query, tt.target)
+			}
+		})
+	}
+}
+
+func TestScore_NoMatch(t *testing.T) {
+	tests := []struct {
+		query  string
+		target string
+	}{
+		{"amazon", "GitHub"},
+		{"", "GitHub"},
+		{"github", ""},
+		{"xyz", "abc"},
+	}
+
+	for _, tt := range tests {
+		if _, ok := Score(tt.query, tt.target); ok {
+			t.Errorf("Score(%q, %q) should not match", tt.query, tt.target)
+		}
+	}
+}
+
+func TestScore_Ordering(t *testing.T) {
+	exact, _ := Score("github", "github")
+	prefix, _ := Score("git", "github")
+	substring, _ := Score("hub", "github")
+	subsequence, _ := Score("gthb", "github")
+	typo, _ := Score("githib", "github")
+
+	if !(exact > prefix && prefix > substring && substring > subsequence && subsequence > typo) {
+		t.Errorf("Unexpected score ordering: exact=%d prefix=%d substring=%d subsequence=%d typo=%d",
+			exact, prefix, substring, subsequence, typo)
+	}
+}
+
+func TestScore_LongTargetKeepsTier(t *testing.T) {
+	long := "git " + strings.Repeat("x", 300)
+	prefix, _ := Score("git", long)
+	substring, _ := Score("hub", "github")
+	if prefix <= substring {
+		t.Errorf("Prefix match on a long name scored %d, not above substring %d", prefix, substring)
+	}
+
+	inner, _ := Score("hub", strings.Repeat("y", 300)+"hub")
+	subsequence, _ := Score("gthb", "github")
+	if inner <= subsequence {
+		t.Errorf("Substring match on a long name scored %d, not above subsequence %d", inner, subsequence)
+	}
+
+	// Six edits are allowed for an 18 character query.
+	typo, ok := Score("abcdefghijklmnopqr", "zbcdzfghzjklznopzrz")
+	if !ok || typo <= 0 {
+		t.Errorf("Typo match scored %d, %v; want a positive score", typo, ok)
+	}
+}
+
+func TestRank(t *testing.T) {
+	candidates := []string{"GitLab", "GitHub", "Gmail", "GitHub Enterprise"}
+
+	matches := Rank("github", candidates, 0)
+	if len(matches) < 2 {
+		t.Fatalf("Expected at least 2 matches, got %d", len(matches))
+	}
+
+	if candidates[matches[0].Index] != "GitHub" {
+		t.Errorf("Expected best match 'GitHub', got '%s'", candidates[matches[0].Index])
+	}
+	if candidates[matches[1].Index] != "GitHub Enterprise" {
+		t.Errorf("Expected second match 'GitHub Enterprise', got '%s'", candidates[matches[1].Index])
+	}
+
+	for i := 1; i < len(matches); i++ {
+		if matches[i].Score > matches[i-1].Score {
+			t.Error("Matches should be sorted by descending score")
+		}
+	}
+}
+
+func TestRank_Limit(t *testing.T) {
+	candidates := []string{"a1", "a2", "a3", "a4", "a5"}
+
+	matches := Rank("a", candidates, 3)
+	if len(matches) != 3 {
+		t.Errorf("Expected 3 matches, got %d", len(matches))
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"kitten", "sitting", 3},
+		{"github", "githib", 1},
+		{"gmail", "gmial", 1},
+	}
+
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.expected {
+			t.Errorf("levenshtein(%q, %q) = %d, expected %d", tt.a, tt.b, got, tt.expected)
+		}
+	}
+}
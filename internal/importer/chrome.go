@@ -0,0 +1,75 @@
+package importer
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/ompatil-15/coconut/internal/db/model"
+)
+
+// chromeColumns are the columns of a Chrome or Edge password export.
+// Newer exports also carry note, which becomes the description.
+var chromeColumns = []string{"name", "url", "username", "password"}
+
+// parseChrome reads the password CSV exported by Chrome, Edge and other
+// Chromium browsers. A blank name is taken from the site's host, and rows
+// repeating an earlier row's site, username and password, which browsers
+// often export, are skipped.
+func parseChrome(r io.Reader) (*Plan, error) {
+	export, err := openCSV(r, "chrome", "Chrome", chromeColumns)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{}
+	seen := make(map[[3]string]int)
+	for row := 1; ; row++ {
+		get, err := export.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			plan.Skipped = append(plan.Skipped, Skip{Row: row, Reason: err.Error()})
+			continue
+		}
+
+		secret := model.Secret{
+			Name:        strings.TrimSpace(get("name")),
+			Username:    strings.TrimSpace(get("username")),
+			Password:    get("password"),
+			URL:         strings.TrimSpace(get("url")),
+			Description: get("note"),
+		}
+		if secret.Username == "" && secret.Password == "" {
+			plan.Skipped = append(plan.Skipped, Skip{Row: row, Reason: "no username or password"})
+			continue
+		}
+
+		key := [3]string{secret.URL, secret.Username, secret.Password}
+		if first, ok := seen[key]; ok {
+			plan.Skipped = append(plan.Skipped, Skip{Row: row, Reason: fmt.Sprintf("duplicate of row %d", first)})
+			continue
+		}
+		seen[key] = row
+
+		if secret.Name == "" {
+			secret.Name = siteName(secret.URL)
+		}
+		plan.Records = append(plan.Records, Record{Row: row, Secret: secret})
+	}
+
+	return plan, nil
+}
+
+// siteName derives a display name from a login URL: its host without a
+// leading "www.", e.g. "github.com". For an Android app entry
+// (android://<hash>@com.example.app/) that is the package name.
+func siteName(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Hostname() == "" {
+		return raw
+	}
+	return strings.TrimPrefix(u.Hostname(), "www.")
+}
@@ -0,0 +1,65 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// csvExport reads a CSV export whose columns are located by header name,
+// so exports with extra or reordered columns still parse.
+type csvExport struct {
+	reader *csv.Reader
+	cols   map[string]int
+}
+
+// openCSV reads the header of a CSV export and checks it has the required
+// columns. format prefixes errors; product names the exporting software.
+func openCSV(r io.Reader, format, product string, required []string) (*csvExport, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("%s: file is empty", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: read header: %w", format, err)
+	}
+
+	cols := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		cols[name] = i
+	}
+	for _, name := range required {
+		if _, ok := cols[name]; !ok {
+			return nil, fmt.Errorf("%s: missing column %q (is this a %s CSV export?)", format, name, product)
+		}
+	}
+
+	return &csvExport{reader: reader, cols: cols}, nil
+}
+
+// next reads the next row and returns a getter for its columns as they
+// are in the file; a column the file lacks reads as "". Parsers trim the
+// columns that cannot carry meaningful whitespace, never passwords or
+// notes. It returns io.EOF after the last row.
+func (c *csvExport) next() (func(name string) string, error) {
+	fields, err := c.reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("malformed CSV: %v", err)
+	}
+
+	return func(name string) string {
+		if i, ok := c.cols[name]; ok && i < len(fields) {
+			return fields[i]
+		}
+		return ""
+	}, nil
+}
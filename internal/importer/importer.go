@@ -0,0 +1,90 @@
+package importer
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ompatil-15/coconut/internal/db/model"
+)
+
+// Record is a secret parsed from an import file. Row is the 1-based data
+// row it came from (the header is not counted).
+type Record struct {
+	Row    int
+	Secret model.Secret
+}
+
+// Skip describes an input row that will not be imported.
+type Skip struct {
+	Row    int    `json:"row"`
+	Reason string `json:"reason"`
+}
+
+// Plan is the parsed content of an import file. Nothing is written until
+// Apply, so a plan can be shown as a dry run first.
+type Plan struct {
+	Format  string
+	Records []Record
+	Skipped []Skip
+}
+
+// Parser reads one export format into a plan.
+type Parser func(r io.Reader) (*Plan, error)
+
+var parsers = map[string]Parser{
+	"chrome":   parseChrome,
+	"json":     parseJSON,
+	"lastpass": parseLastPass,
+}
+
+// Formats returns the supported --format names, sorted.
+func Formats() []string {
+	names := make([]string, 0, len(parsers))
+	for name := range parsers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Parse dispatches to the parser for format.
+func Parse(format string, r io.Reader) (*Plan, error) {
+	parse, ok := parsers[strings.ToLower(format)]
+	if !ok {
+		return nil, fmt.Errorf("unknown import format %q (supported: %s)", format, strings.Join(Formats(), ", "))
+	}
+
+	plan, err := parse(r)
+	if err != nil {
+		return nil, err
+	}
+	plan.Format = strings.ToLower(format)
+	return plan, nil
+}
+
+// Adder is the part of the secret repository Apply needs.
+type Adder interface {
+	Add(secret model.Secret) (string, error)
+}
+
+// Apply stores every planned record as a new secret with a fresh ID and
+// timestamps, returning how many were added. It stops at the first error.
+func (p *Plan) Apply(repo Adder, now time.Time) (int, error) {
+	added := 0
+	for _, rec := range p.Records {
+		secret := rec.Secret
+		secret.ID = uuid.New().String()
+		secret.CreatedAt = now
+		secret.UpdatedAt = now
+
+		if _, err := repo.Add(secret); err != nil {
+			return added, fmt.Errorf("row %d: %w", rec.Row, err)
+		}
+		added++
+	}
+	return added, nil
+}
@@ -0,0 +1,233 @@
+package importer
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ompatil-15/coconut/internal/db/model"
+	"github.com/ompatil-15/coconut/internal/exporter"
+)
+
+const lastPassCSV = `url,username,password,totp,extra,name,grouping,fav
+https://github.com,octocat,gh-pass,,work account,GitHub,Work\Dev,1
+http://sn,,,,"Door code: 1234
+Alarm: 5678",Home codes,Personal,0
+,,,,,,,
+https://example.com,bob,ex-pass,,,Example,,0
+`
+
+func TestParse_LastPass(t *testing.T) {
+	plan, err := Parse("lastpass", strings.NewReader(lastPassCSV))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if len(plan.Records) != 3 {
+		t.Fatalf("Expected 3 records, got %d", len(plan.Records))
+	}
+
+	gh := plan.Records[0].Secret
+	if gh.Name != "GitHub" || gh.Username != "octocat" || gh.Password != "gh-pass" || gh.URL != "https://github.com" {
+		t.Errorf("Unexpected login mapping: %+v", gh)
+	}
+	if gh.Description != "work account" {
+		t.Errorf("Expected extra to map to description, got %q", gh.Description)
+	}
+	if !gh.IsFavorite {
+		t.Error("Expected fav=1 to mark the secret as favorite")
+	}
+	if len(gh.Tags) != 1 || gh.Tags[0] != "work/dev" {
+		t.Errorf("Expected grouping to become tag 'work/dev', got %v", gh.Tags)
+	}
+
+	note := plan.Records[1].Secret
+	if note.Type != model.SecretTypeNote {
+		t.Errorf("Expected secure note type, got %q", note.Type)
+	}
+	if note.URL != "" {
+		t.Errorf("Secure note should not keep the placeholder URL, got %q", note.URL)
+	}
+	if !strings.Contains(note.Description, "Alarm: 5678") {
+		t.Errorf("Multi-line note text should be preserved, got %q", note.Description)
+	}
+
+	if len(plan.Skipped) != 1 || plan.Skipped[0].Row != 3 {
+		t.Errorf("Expected empty row 3 to be skipped, got %+v", plan.Skipped)
+	}
+}
+
+func TestParse_LastPassKeepsPasswordWhitespace(t *testing.T) {
+	csv := "url,username,password,extra,name,grouping,fav\n" +
+		" https://github.com , octocat ,\"  padded pass \",\"note \", GitHub , Work ,1\n"
+	plan, err := Parse("lastpass", strings.NewReader(csv))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(plan.Records) != 1 {
+		t.Fatalf("Expected 1 record, got %d", len(plan.Records))
+	}
+
+	gh := plan.Records[0].Secret
+	if gh.Password != "  padded pass " {
+		t.Errorf("Password whitespace must be kept, got %q", gh.Password)
+	}
+	if gh.Description != "note " {
+		t.Errorf("Note whitespace must be kept, got %q", gh.Description)
+	}
+	if gh.Name != "GitHub" || gh.Username != "octocat" || gh.URL != "https://github.com" || !gh.IsFavorite {
+		t.Errorf("Expected trimmed metadata, got %+v", gh)
+	}
+	if len(gh.Tags) != 1 || gh.Tags[0] != "work" {
+		t.Errorf("Expected trimmed grouping tag 'work', got %v", gh.Tags)
+	}
+}
+
+func TestParse_LastPassMissingColumn(t *testing.T) {
+	_, err := Parse("lastpass", strings.NewReader("name,url,username,password\nGitHub,https://github.com,u,p\n"))
+	if err == nil {
+		t.Error("Expected an error for a CSV without LastPass columns")
+	}
+}
+
+func TestParse_UnknownFormat(t *testing.T) {
+	_, err := Parse("onepassword", strings.NewReader(""))
+	if err == nil || !strings.Contains(err.Error(), "lastpass") {
+		t.Errorf("Expected unknown format error listing supported formats, got %v", err)
+	}
+}
+
+const chromeCSV = `name,url,username,password,note
+GitHub,https://github.com/login,octocat,gh-pass,
+,https://www.example.com/,bob,ex-pass,old account
+,https://www.example.com/,bob,ex-pass,old account
+,android://abc==@com.example.app/,bob,app-pass,
+,https://nothing.example,,,
+`
+
+func TestParse_Chrome(t *testing.T) {
+	plan, err := Parse("chrome", strings.NewReader(chromeCSV))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	var names []string
+	for _, rec := range plan.Records {
+		names = append(names, rec.Secret.Name)
+	}
+	if want := []string{"GitHub", "example.com", "com.example.app"}; !slices.Equal(names, want) {
+		t.Errorf("Expected names %v, got %v", want, names)
+	}
+
+	ex := plan.Records[1].Secret
+	if ex.Username != "bob" || ex.Password != "ex-pass" || ex.URL != "https://www.example.com/" || ex.Description != "old account" {
+		t.Errorf("Unexpected mapping: %+v", ex)
+	}
+
+	if len(plan.Skipped) != 2 || plan.Skipped[0].Reason != "duplicate of row 2" || plan.Skipped[1].Row != 5 {
+		t.Errorf("Expected the duplicate and the empty row to be skipped, got %+v", plan.Skipped)
+	}
+
+	if _, err := Parse("chrome", strings.NewReader("origin,login,secret\n")); err == nil {
+		t.Error("Expected an error for a CSV without Chrome columns")
+	}
+}
+
+func TestParse_JSONRoundTrip(t *testing.T) {
+	exported := []model.Secret{
+		{
+			ID:         "x",
+			Name:       "Bank",
+			Username:   "me",
+			Password:   "pw",
+			Tags:       []string{"money"},
+			Fields:     []model.Field{{Name: "account", Value: "12345678"}, {Name: "memorable word", Value: "coconut"}},
+			IsFavorite: true,
+		},
+		{Type: model.SecretTypeNote, Name: "Wifi", Description: "door code 1234"},
+		{},
+	}
+	var buf bytes.Buffer
+	if _, err := exporter.WriteJSON(&buf, exported, exporter.SchemaCoconut); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+
+	plan, err := Parse("json", &buf)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(plan.Records) != 2 || len(plan.Skipped) != 1 || plan.Skipped[0].Row != 3 {
+		t.Fatalf("Expected 2 records and row 3 skipped, got %d records, skipped %v", len(plan.Records), plan.Skipped)
+	}
+
+	bank := plan.Records[0].Secret
+	if !slices.Equal(bank.Fields, exported[0].Fields) {
+		t.Errorf("Custom fields = %v, want %v in order", bank.Fields, exported[0].Fields)
+	}
+	if bank.Password != "pw" || !bank.IsFavorite || bank.ID != "" {
+		t.Errorf("Unexpected imported secret %+v", bank)
+	}
+	if plan.Records[1].Secret.Type != model.SecretTypeNote {
+		t.Errorf("Expected the note to stay a note, got %+v", plan.Records[1].Secret)
+	}
+
+	if _, err := Parse("json", strings.NewReader(`{"items": []}`)); err == nil {
+		t.Error("Expected an error for JSON that is not an array")
+	}
+}
+
+type fakeAdder struct {
+	added []model.Secret
+	fail  int // fail on this call number (1-based); 0 never fails
+}
+
+func (f *fakeAdder) Add(secret model.Secret) (string, error) {
+	if f.fail == len(f.added)+1 {
+		return "", errors.New("store failed")
+	}
+	f.added = append(f.added, secret)
+	return secret.ID, nil
+}
+
+func TestPlan_Apply(t *testing.T) {
+	plan, err := Parse("lastpass", strings.NewReader(lastPassCSV))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	repo := &fakeAdder{}
+	n, err := plan.Apply(repo, now)
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("Expected 3 secrets added, got %d", n)
+	}
+
+	ids := make(map[string]bool)
+	for _, s := range repo.added {
+		if s.ID == "" || ids[s.ID] {
+			t.Errorf("Each imported secret needs a unique ID, got %q", s.ID)
+		}
+		ids[s.ID] = true
+		if !s.CreatedAt.Equal(now) || !s.UpdatedAt.Equal(now) {
+			t.Errorf("Timestamps should be set to import time, got %v / %v", s.CreatedAt, s.UpdatedAt)
+		}
+	}
+}
+
+func TestPlan_ApplyStopsOnError(t *testing.T) {
+	plan, _ := Parse("lastpass", strings.NewReader(lastPassCSV))
+
+	n, err := plan.Apply(&fakeAdder{fail: 2}, time.Now())
+	if err == nil || !strings.Contains(err.Error(), "row 2") {
+		t.Errorf("Expected error naming row 2, got %v", err)
+	}
+	if n != 1 {
+		t.Errorf("Expected 1 secret added before the failure, got %d", n)
+	}
+}
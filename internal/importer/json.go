@@ -0,0 +1,79 @@
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/ompatil-15/coconut/internal/db/model"
+)
+
+// parseJSON reads coconut's own JSON export ('export --format json'), an
+// array of secrets. Custom fields, tags and favorites come along; IDs and
+// timestamps are assigned afresh by Apply.
+func parseJSON(r io.Reader) (*Plan, error) {
+	var entries []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("json: file is empty")
+		}
+		return nil, fmt.Errorf("json: not a coconut JSON export (an array of secrets): %w", err)
+	}
+
+	plan := &Plan{}
+	for i, raw := range entries {
+		row := i + 1
+
+		var secret model.Secret
+		if err := json.Unmarshal(raw, &secret); err != nil {
+			plan.Skipped = append(plan.Skipped, Skip{Row: row, Reason: fmt.Sprintf("malformed entry: %v", err)})
+			continue
+		}
+
+		// Only content is imported; vault state starts fresh.
+		secret.ID = ""
+		secret.PinHash = ""
+		secret.LastUsedAt = time.Time{}
+		secret.IsArchived = false
+		secret.Tags = model.NormalizeTags(secret.Tags)
+
+		switch {
+		case secret.Type != "" && secret.Type != model.SecretTypeNote:
+			plan.Skipped = append(plan.Skipped, Skip{Row: row, Reason: fmt.Sprintf("unknown type %q", secret.Type)})
+			continue
+		case secret.Type == model.SecretTypeNote && secret.Name == "":
+			plan.Skipped = append(plan.Skipped, Skip{Row: row, Reason: "secure note without a name"})
+			continue
+		case secret.Name == "" && secret.Username == "" && secret.Password == "":
+			plan.Skipped = append(plan.Skipped, Skip{Row: row, Reason: "empty entry"})
+			continue
+		}
+		if reason := checkFields(secret.Fields); reason != "" {
+			plan.Skipped = append(plan.Skipped, Skip{Row: row, Reason: reason})
+			continue
+		}
+
+		plan.Records = append(plan.Records, Record{Row: row, Secret: secret})
+	}
+
+	return plan, nil
+}
+
+// checkFields returns why custom fields cannot be imported, or "".
+func checkFields(fields []model.Field) string {
+	seen := make(map[string]bool)
+	for _, f := range fields {
+		name := strings.ToLower(f.Name)
+		if strings.TrimSpace(name) == "" {
+			return "custom field without a name"
+		}
+		if seen[name] {
+			return fmt.Sprintf("custom field %q appears twice", f.Name)
+		}
+		seen[name] = true
+	}
+	return ""
+}
@@ -0,0 +1,65 @@
+package importer
+
+import (
+	"io"
+	"strings"
+
+	"github.com/ompatil-15/coconut/internal/db/model"
+)
+
+// lastPassNoteURL is the placeholder URL LastPass exports for secure notes.
+const lastPassNoteURL = "http://sn"
+
+// lastPassColumns are the columns a LastPass export must contain. Newer
+// exports also carry totp, which is ignored.
+var lastPassColumns = []string{"url", "username", "password", "extra", "name", "grouping", "fav"}
+
+// parseLastPass reads LastPass's CSV export. Columns are located by header
+// name so exports with extra or reordered columns still parse.
+func parseLastPass(r io.Reader) (*Plan, error) {
+	export, err := openCSV(r, "lastpass", "LastPass", lastPassColumns)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{}
+	for row := 1; ; row++ {
+		get, err := export.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			plan.Skipped = append(plan.Skipped, Skip{Row: row, Reason: err.Error()})
+			continue
+		}
+
+		secret := model.Secret{
+			Name:        strings.TrimSpace(get("name")),
+			Username:    strings.TrimSpace(get("username")),
+			Password:    get("password"),
+			URL:         strings.TrimSpace(get("url")),
+			Description: get("extra"),
+			IsFavorite:  strings.TrimSpace(get("fav")) == "1",
+		}
+		if grouping := strings.TrimSpace(get("grouping")); grouping != "" {
+			// Nested folders are exported as "Parent\Child"
+			secret.Tags = model.NormalizeTags([]string{strings.ReplaceAll(grouping, "\\", "/")})
+		}
+
+		if secret.URL == lastPassNoteURL {
+			secret.Type = model.SecretTypeNote
+			secret.URL = ""
+			if secret.Name == "" {
+				plan.Skipped = append(plan.Skipped, Skip{Row: row, Reason: "secure note without a name"})
+				continue
+			}
+		} else if secret.Name == "" && secret.Username == "" && secret.Password == "" {
+			plan.Skipped = append(plan.Skipped, Skip{Row: row, Reason: "empty row"})
+			continue
+		}
+
+		plan.Records = append(plan.Records, Record{Row: row, Secret: secret})
+	}
+
+	return plan, nil
+}
@@ -1,20 +1,96 @@
 package iostreams
 
 import (
+	"fmt"
 	"io"
 	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
 )
 
+// spinnerFrames are drawn in order while a progress indicator runs.
+var spinnerFrames = []string{"|", "/", "-", "\\"}
+
+const spinnerInterval = 100 * time.Millisecond
+
 type IOStreams struct {
 	In     io.Reader
 	Out    io.Writer
 	ErrOut io.Writer
+
+	// progressEnabled gates the spinner on ErrOut; it is only on when
+	// stderr is a terminal, so pipes and logs never see control codes.
+	progressEnabled bool
+	progressMu      sync.Mutex
+	progressStop    chan struct{}
+	progressDone    chan struct{}
 }
 
 func System() *IOStreams {
 	return &IOStreams{
-		In:     os.Stdin,
-		Out:    os.Stdout,
-		ErrOut: os.Stderr,
+		In:              os.Stdin,
+		Out:             os.Stdout,
+		ErrOut:          os.Stderr,
+		progressEnabled: term.IsTerminal(int(os.Stderr.Fd())),
+	}
+}
+
+// SetProgressEnabled turns the progress indicator on or off, e.g. to keep
+// machine-readable output modes free of terminal noise.
+func (s *IOStreams) SetProgressEnabled(enabled bool) {
+	s.progressMu.Lock()
+	defer s.progressMu.Unlock()
+	s.progressEnabled = enabled
+}
+
+// StartProgressIndicator draws a spinner with label on ErrOut until
+// StopProgressIndicator is called. It is a no-op when progress is disabled
+// or an indicator is already running.
+func (s *IOStreams) StartProgressIndicator(label string) {
+	s.progressMu.Lock()
+	defer s.progressMu.Unlock()
+
+	if !s.progressEnabled || s.progressStop != nil {
+		return
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	s.progressStop = stop
+	s.progressDone = done
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(spinnerInterval)
+		defer ticker.Stop()
+
+		for i := 0; ; i++ {
+			fmt.Fprintf(s.ErrOut, "\r%s %s", spinnerFrames[i%len(spinnerFrames)], label)
+			select {
+			case <-stop:
+				// Erase the spinner line so following output starts clean
+				fmt.Fprint(s.ErrOut, "\r\033[K")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// StopProgressIndicator stops a running spinner and clears its line. It
+// returns once the line is cleared and is safe to call when none is running.
+func (s *IOStreams) StopProgressIndicator() {
+	s.progressMu.Lock()
+	stop, done := s.progressStop, s.progressDone
+	s.progressStop, s.progressDone = nil, nil
+	s.progressMu.Unlock()
+
+	if stop == nil {
+		return
 	}
+	close(stop)
+	<-done
 }
@@ -0,0 +1,41 @@
+package iostreams
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestProgressIndicator_Disabled(t *testing.T) {
+	errOut := &bytes.Buffer{}
+	s := &IOStreams{ErrOut: errOut}
+
+	s.StartProgressIndicator("Unlocking vault")
+	s.StopProgressIndicator()
+
+	if errOut.Len() != 0 {
+		t.Errorf("Expected no output when progress is disabled, got %q", errOut.String())
+	}
+}
+
+func TestProgressIndicator_StartStop(t *testing.T) {
+	errOut := &bytes.Buffer{}
+	s := &IOStreams{ErrOut: errOut}
+	s.SetProgressEnabled(true)
+
+	s.StartProgressIndicator("Unlocking vault")
+	// A second start while running must not spawn another spinner
+	s.StartProgressIndicator("Unlocking vault")
+	s.StopProgressIndicator()
+
+	out := errOut.String()
+	if !strings.Contains(out, "Unlocking vault") {
+		t.Errorf("Expected label in output, got %q", out)
+	}
+	if !strings.HasSuffix(out, "\r\033[K") {
+		t.Errorf("Expected spinner line to be cleared on stop, got %q", out)
+	}
+
+	// Stopping again is a no-op
+	s.StopProgressIndicator()
+}
@@ -0,0 +1,161 @@
+// Package journal keeps a write-ahead record of the secret change in
+// progress. An intent is written before a change is applied and cleared
+// once it succeeds, so a change cut short by a crash or power loss can be
+// completed or rolled back on the next run.
+package journal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ompatil-15/coconut/internal/db"
+	"github.com/ompatil-15/coconut/internal/db/model"
+)
+
+const pendingKey = "pending"
+
+// Operation names recorded in the journal.
+const (
+	OpAdd    = "add"
+	OpUpdate = "update"
+	OpDelete = "delete"
+)
+
+var ErrNoPending = errors.New("no interrupted operation")
+
+// Entry is the intent of one change. Secret is the state being written
+// (nil for a delete) and Previous the state it replaces (nil for an add).
+// The whole entry is encrypted at rest because it contains secrets.
+type Entry struct {
+	Op        string        `json:"op"`
+	StartedAt time.Time     `json:"startedAt"`
+	Secret    *model.Secret `json:"secret,omitempty"`
+	Previous  *model.Secret `json:"previous,omitempty"`
+}
+
+// Target returns the secret the entry changes.
+func (e *Entry) Target() *model.Secret {
+	if e.Secret != nil {
+		return e.Secret
+	}
+	return e.Previous
+}
+
+// Writer is the part of the secret repository Complete and Rollback need.
+type Writer interface {
+	Add(secret model.Secret) (string, error)
+	Update(secret model.Secret) error
+	Delete(key string) error
+}
+
+// Complete applies the entry's change again. Each step is idempotent, so
+// it is safe whether the interrupted write landed or not.
+func (e *Entry) Complete(repo Writer) error {
+	switch e.Op {
+	case OpAdd:
+		_, err := repo.Add(*e.Secret)
+		return err
+	case OpUpdate:
+		return repo.Update(*e.Secret)
+	case OpDelete:
+		return ignoreNotFound(repo.Delete(e.Previous.ID))
+	default:
+		return fmt.Errorf("unknown journal operation %q", e.Op)
+	}
+}
+
+// Rollback restores the state from before the entry's change.
+func (e *Entry) Rollback(repo Writer) error {
+	switch e.Op {
+	case OpAdd:
+		return ignoreNotFound(repo.Delete(e.Secret.ID))
+	case OpUpdate, OpDelete:
+		// Add writes the record under its original ID and keeps its
+		// timestamps, as undo does.
+		_, err := repo.Add(*e.Previous)
+		return err
+	default:
+		return fmt.Errorf("unknown journal operation %q", e.Op)
+	}
+}
+
+func ignoreNotFound(err error) error {
+	if errors.Is(err, db.ErrSecretNotFound) {
+		return nil
+	}
+	return err
+}
+
+// Store keeps the single pending entry in its own bucket.
+type Store struct {
+	repo  db.Repository
+	vault db.Vault
+}
+
+func NewStore(repo db.Repository, v db.Vault) *Store {
+	return &Store{
+		repo:  repo,
+		vault: v,
+	}
+}
+
+// Begin records the intent of a change before it is applied.
+func (s *Store) Begin(entry Entry) error {
+	if !s.vault.IsUnlocked() {
+		return fmt.Errorf("vault is locked")
+	}
+	if entry.StartedAt.IsZero() {
+		entry.StartedAt = time.Now()
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("marshal journal entry: %w", err)
+	}
+
+	enc, err := s.vault.Encrypt(string(data))
+	if err != nil {
+		return fmt.Errorf("encrypt journal entry: %w", err)
+	}
+
+	return s.repo.Put(pendingKey, []byte(enc))
+}
+
+// Commit clears the entry once its change has been applied.
+func (s *Store) Commit() error {
+	return s.repo.Delete(pendingKey)
+}
+
+// HasPending reports whether an entry was left behind. It does not need
+// the vault to be unlocked.
+func (s *Store) HasPending() bool {
+	found, err := s.repo.Has(pendingKey)
+	return err == nil && found
+}
+
+// Pending returns the entry left behind by an interrupted change, or
+// ErrNoPending.
+func (s *Store) Pending() (*Entry, error) {
+	if !s.vault.IsUnlocked() {
+		return nil, fmt.Errorf("vault is locked")
+	}
+
+	data, err := s.repo.Get(pendingKey)
+	if err != nil || len(data) == 0 {
+		return nil, ErrNoPending
+	}
+
+	dec, err := s.vault.Decrypt(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("decrypt journal entry: %w", err)
+	}
+
+	var entry Entry
+	if err := json.Unmarshal([]byte(dec), &entry); err != nil {
+		return nil, fmt.Errorf("unmarshal journal entry: %w", err)
+	}
+
+	return &entry, nil
+}
@@ -0,0 +1,194 @@
+package journal
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ompatil-15/coconut/internal/db"
+	"github.com/ompatil-15/coconut/internal/db/model"
+)
+
+// Mock repository for testing
+type mockRepository struct {
+	data map[string][]byte
+}
+
+func (m *mockRepository) Put(key string, value []byte) error {
+	if m.data == nil {
+		m.data = make(map[string][]byte)
+	}
+	m.data[key] = value
+	return nil
+}
+
+func (m *mockRepository) Get(key string) ([]byte, error) {
+	if data, exists := m.data[key]; exists {
+		return data, nil
+	}
+	return nil, errors.New("key not found")
+}
+
+func (m *mockRepository) Has(key string) (bool, error) {
+	_, exists := m.data[key]
+	return exists, nil
+}
+
+func (m *mockRepository) Delete(key string) error {
+	delete(m.data, key)
+	return nil
+}
+
+func (m *mockRepository) ListKeys() ([]string, error) {
+	keys := make([]string, 0, len(m.data))
+	for k := range m.data {
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+// Mock vault for testing
+type mockVault struct {
+	unlocked bool
+}
+
+func (m *mockVault) IsUnlocked() bool {
+	return m.unlocked
+}
+
+func (m *mockVault) Encrypt(plaintext string) (string, error) {
+	return "encrypted:" + plaintext, nil
+}
+
+func (m *mockVault) Decrypt(ciphertext string) (string, error) {
+	if !strings.HasPrefix(ciphertext, "encrypted:") {
+		return "", errors.New("invalid ciphertext")
+	}
+	return strings.TrimPrefix(ciphertext, "encrypted:"), nil
+}
+
+// mockSecrets is an in-memory Writer.
+type mockSecrets map[string]model.Secret
+
+func (m mockSecrets) Add(s model.Secret) (string, error) {
+	m[s.ID] = s
+	return s.ID, nil
+}
+
+func (m mockSecrets) Update(s model.Secret) error {
+	if _, ok := m[s.ID]; !ok {
+		return fmt.Errorf("%w: %s", db.ErrSecretNotFound, s.ID)
+	}
+	m[s.ID] = s
+	return nil
+}
+
+func (m mockSecrets) Delete(id string) error {
+	if _, ok := m[id]; !ok {
+		return fmt.Errorf("%w: %s", db.ErrSecretNotFound, id)
+	}
+	delete(m, id)
+	return nil
+}
+
+func TestStore_BeginPendingCommit(t *testing.T) {
+	repo := &mockRepository{}
+	store := NewStore(repo, &mockVault{unlocked: true})
+
+	if store.HasPending() {
+		t.Fatal("New store should have nothing pending")
+	}
+	if _, err := store.Pending(); !errors.Is(err, ErrNoPending) {
+		t.Fatalf("Expected ErrNoPending, got %v", err)
+	}
+
+	secret := model.Secret{ID: "id-1", Username: "alice", Password: "s3cret"}
+	if err := store.Begin(Entry{Op: OpAdd, Secret: &secret}); err != nil {
+		t.Fatalf("Begin failed: %v", err)
+	}
+	if !strings.HasPrefix(string(repo.data[pendingKey]), "encrypted:") {
+		t.Error("Journal entry should be stored encrypted")
+	}
+	if !store.HasPending() {
+		t.Fatal("Expected a pending entry after Begin")
+	}
+
+	entry, err := store.Pending()
+	if err != nil {
+		t.Fatalf("Pending failed: %v", err)
+	}
+	if entry.Op != OpAdd || entry.Target().Password != "s3cret" || entry.StartedAt.IsZero() {
+		t.Errorf("Unexpected entry: %+v", entry)
+	}
+
+	if err := store.Commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	if store.HasPending() {
+		t.Error("Commit should clear the entry")
+	}
+}
+
+func TestStore_Locked(t *testing.T) {
+	store := NewStore(&mockRepository{}, &mockVault{})
+	if err := store.Begin(Entry{Op: OpDelete, Previous: &model.Secret{ID: "x"}}); err == nil {
+		t.Error("Begin should fail while the vault is locked")
+	}
+}
+
+func TestEntry_CompleteAndRollback(t *testing.T) {
+	old := model.Secret{ID: "a", Password: "old"}
+	updated := model.Secret{ID: "a", Password: "new"}
+	added := model.Secret{ID: "b", Password: "added"}
+
+	tests := []struct {
+		name         string
+		entry        Entry
+		before       mockSecrets // state when the write was interrupted
+		wantComplete string      // password of the target after Complete, "" if absent
+		wantRollback string
+	}{
+		{"add not applied", Entry{Op: OpAdd, Secret: &added}, mockSecrets{}, "added", ""},
+		{"add applied", Entry{Op: OpAdd, Secret: &added}, mockSecrets{"b": added}, "added", ""},
+		{"update not applied", Entry{Op: OpUpdate, Secret: &updated, Previous: &old}, mockSecrets{"a": old}, "new", "old"},
+		{"update applied", Entry{Op: OpUpdate, Secret: &updated, Previous: &old}, mockSecrets{"a": updated}, "new", "old"},
+		{"delete not applied", Entry{Op: OpDelete, Previous: &old}, mockSecrets{"a": old}, "", "old"},
+		{"delete applied", Entry{Op: OpDelete, Previous: &old}, mockSecrets{}, "", "old"},
+	}
+
+	check := func(t *testing.T, step string, repo mockSecrets, id, want string) {
+		t.Helper()
+		got, ok := repo[id]
+		switch {
+		case want == "" && ok:
+			t.Errorf("%s: secret %s should be absent", step, id)
+		case want != "" && got.Password != want:
+			t.Errorf("%s: expected password %q, got %q", step, want, got.Password)
+		}
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := tt.entry.Target().ID
+
+			repo := mockSecrets{}
+			for k, v := range tt.before {
+				repo[k] = v
+			}
+			if err := tt.entry.Complete(repo); err != nil {
+				t.Fatalf("Complete failed: %v", err)
+			}
+			check(t, "Complete", repo, id, tt.wantComplete)
+
+			repo = mockSecrets{}
+			for k, v := range tt.before {
+				repo[k] = v
+			}
+			if err := tt.entry.Rollback(repo); err != nil {
+				t.Fatalf("Rollback failed: %v", err)
+			}
+			check(t, "Rollback", repo, id, tt.wantRollback)
+		})
+	}
+}
@@ -1,11 +1,17 @@
 package logger
 
 import (
+	"bufio"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/ompatil-15/coconut/internal/paths"
 )
 
 type LogLevel int
@@ -29,18 +35,55 @@ func (l LogLevel) String() string {
 	}
 }
 
+// ParseLevel returns the level named name (info, warn or error), ignoring
+// case.
+func ParseLevel(name string) (LogLevel, error) {
+	for _, level := range []LogLevel{InfoLevel, WarnLevel, ErrorLevel} {
+		if strings.EqualFold(name, level.String()) {
+			return level, nil
+		}
+	}
+	return InfoLevel, fmt.Errorf("unknown log level %q (must be info, warn or error)", name)
+}
+
+// Formats of the lines written to the log file.
+const (
+	FormatText = "text" // timestamp [LEVEL] message
+	FormatJSON = "json" // one {"ts","level","msg"} object per line
+)
+
+// jsonLine is a log line in FormatJSON.
+type jsonLine struct {
+	TS    string `json:"ts"`
+	Level string `json:"level"`
+	Msg   string `json:"msg"`
+}
+
 type Logger struct {
-	file *os.File
-	mu   sync.Mutex
+	file   *os.File
+	format string   // FormatText unless set to FormatJSON
+	level  LogLevel // lines less severe than this are not written to file
+	mu     sync.Mutex
+
+	// mirror, when set, also receives every log line (e.g. stderr for
+	// --verbose). Access events are not mirrored.
+	mirror io.Writer
+
+	// Access events go to a separate audit log so they can be reviewed
+	// (or disabled) independently of debug output.
+	accessFile    *os.File
+	accessPath    string
+	accessEnabled bool
 }
 
 func New() (*Logger, error) {
-	home, err := os.UserHomeDir()
+	// Logs live with the vault in the same data directory.
+	dataDir, err := paths.DataDir()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get home dir: %w", err)
 	}
 
-	logDir := filepath.Join(home, ".coconut", "logs")
+	logDir := filepath.Join(dataDir, "logs")
 	if err := os.MkdirAll(logDir, 0700); err != nil {
 		return nil, fmt.Errorf("failed to create log dir: %w", err)
 	}
@@ -51,29 +94,158 @@ func New() (*Logger, error) {
 		return nil, fmt.Errorf("failed to open log file: %w", err)
 	}
 
-	return &Logger{file: f}, nil
+	accessPath := filepath.Join(logDir, "audit.log")
+	af, err := os.OpenFile(accessPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("failed to open audit log file: %w", err)
+	}
+
+	return &Logger{
+		file:          f,
+		accessFile:    af,
+		accessPath:    accessPath,
+		accessEnabled: true,
+	}, nil
 }
 
 func (lg *Logger) log(level LogLevel, format string, args ...interface{}) {
 	lg.mu.Lock()
 	defer lg.mu.Unlock()
 
-	if lg.file == nil {
+	if lg.file == nil && lg.mirror == nil {
 		return
 	}
 
-	timestamp := time.Now().Format("2006-01-02 15:04:05")
+	now := time.Now()
 	message := fmt.Sprintf(format, args...)
 
-	fmt.Fprintf(lg.file, "%s [%s] %s\n", timestamp, level.String(), message)
+	if lg.file != nil && level >= lg.level {
+		if lg.format == FormatJSON {
+			// Marshal escapes newlines in the message, so each entry
+			// stays on one line.
+			line, err := json.Marshal(jsonLine{TS: now.Format(time.RFC3339), Level: level.String(), Msg: message})
+			if err == nil {
+				lg.file.Write(append(line, '\n'))
+			}
+		} else {
+			fmt.Fprintf(lg.file, "%s [%s] %s\n", now.Format("2006-01-02 15:04:05"), level.String(), message)
+		}
+	}
+	if lg.mirror != nil {
+		fmt.Fprintf(lg.mirror, "[%s] %s\n", level.String(), message)
+	}
+}
+
+// SetMirror sends a copy of every subsequent log line to w, or stops
+// mirroring when w is nil. Log messages never contain secret values, so
+// this is safe to point at a terminal.
+func (lg *Logger) SetMirror(w io.Writer) {
+	lg.mu.Lock()
+	defer lg.mu.Unlock()
+	lg.mirror = w
+}
+
+// SetFormat selects how lines are written to the log file, FormatText or
+// FormatJSON. Mirrored lines are always plain text for reading in a
+// terminal, and the audit log keeps its own format.
+func (lg *Logger) SetFormat(format string) {
+	lg.mu.Lock()
+	defer lg.mu.Unlock()
+	lg.format = format
+}
+
+// SetLevel stops lines less severe than level from being written to the
+// log file. Mirrored lines are not filtered, so --verbose shows them all.
+func (lg *Logger) SetLevel(level LogLevel) {
+	lg.mu.Lock()
+	defer lg.mu.Unlock()
+	lg.level = level
 }
 
 func (lg *Logger) Info(format string, args ...interface{})  { lg.log(InfoLevel, format, args...) }
 func (lg *Logger) Warn(format string, args ...interface{})  { lg.log(WarnLevel, format, args...) }
 func (lg *Logger) Error(format string, args ...interface{}) { lg.log(ErrorLevel, format, args...) }
 
+// Access records a vault access event (e.g. "read", "copy", "update",
+// "delete") in the audit log. Callers must describe the target by index,
+// name or ID only; secret values never belong here.
+func (lg *Logger) Access(op string, target string) {
+	lg.mu.Lock()
+	defer lg.mu.Unlock()
+
+	if lg.accessFile == nil || !lg.accessEnabled {
+		return
+	}
+
+	timestamp := time.Now().Format(accessTimeFormat)
+	fmt.Fprintf(lg.accessFile, "%s %-7s %s\n", timestamp, op, target)
+}
+
+// accessTimeFormat is the local-time stamp that starts each audit log line.
+const accessTimeFormat = "2006-01-02 15:04:05"
+
+// AccessEvent is one entry of the audit log.
+type AccessEvent struct {
+	Time   time.Time
+	Op     string
+	Target string
+}
+
+// ReadAccessEvents parses the audit log at path in file order. A missing
+// log has no events; lines that do not parse are skipped.
+func ReadAccessEvents(path string) ([]AccessEvent, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var events []AccessEvent
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if event, ok := parseAccessLine(scanner.Text()); ok {
+			events = append(events, event)
+		}
+	}
+	return events, scanner.Err()
+}
+
+func parseAccessLine(line string) (AccessEvent, bool) {
+	if len(line) <= len(accessTimeFormat) {
+		return AccessEvent{}, false
+	}
+	ts, err := time.ParseInLocation(accessTimeFormat, line[:len(accessTimeFormat)], time.Local)
+	if err != nil {
+		return AccessEvent{}, false
+	}
+	op, target, _ := strings.Cut(strings.TrimSpace(line[len(accessTimeFormat):]), " ")
+	if op == "" {
+		return AccessEvent{}, false
+	}
+	return AccessEvent{Time: ts, Op: op, Target: strings.TrimSpace(target)}, true
+}
+
+// SetAccessLogEnabled turns audit logging of access events on or off.
+func (lg *Logger) SetAccessLogEnabled(enabled bool) {
+	lg.mu.Lock()
+	defer lg.mu.Unlock()
+	lg.accessEnabled = enabled
+}
+
+// AccessLogPath returns the location of the audit log file.
+func (lg *Logger) AccessLogPath() string {
+	return lg.accessPath
+}
+
 func (lg *Logger) Close() {
 	if lg.file != nil {
 		_ = lg.file.Close()
 	}
+	if lg.accessFile != nil {
+		_ = lg.accessFile.Close()
+	}
 }
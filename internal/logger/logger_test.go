@@ -0,0 +1,137 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogger_Mirror(t *testing.T) {
+	lg := &Logger{}
+
+	var buf bytes.Buffer
+	lg.SetMirror(&buf)
+	lg.Info("opened %s", "vault.db")
+	lg.Error("failed: %v", "boom")
+	lg.Access("read", "index=1")
+
+	out := buf.String()
+	if !strings.Contains(out, "[INFO] opened vault.db\n") || !strings.Contains(out, "[ERROR] failed: boom\n") {
+		t.Errorf("Expected mirrored log lines, got %q", out)
+	}
+	if strings.Contains(out, "index=1") {
+		t.Error("Access events must not be mirrored")
+	}
+
+	lg.SetMirror(nil)
+	lg.Info("after")
+	if strings.Contains(buf.String(), "after") {
+		t.Error("Nothing should be mirrored after SetMirror(nil)")
+	}
+}
+
+func TestLogger_JSONFormat(t *testing.T) {
+	file, err := os.CreateTemp(t.TempDir(), "coconut.log")
+	if err != nil {
+		t.Fatalf("CreateTemp failed: %v", err)
+	}
+	lg := &Logger{file: file}
+	defer lg.Close()
+
+	lg.SetFormat(FormatJSON)
+	lg.Info("opened %s", "vault.db")
+	lg.Warn("quote \" and\nnewline")
+
+	data, err := os.ReadFile(file.Name())
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %d: %q", len(lines), data)
+	}
+
+	for _, line := range lines {
+		var entry map[string]string
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Line is not valid JSON: %q: %v", line, err)
+		}
+		if _, err := time.Parse(time.RFC3339, entry["ts"]); err != nil {
+			t.Errorf("Expected an RFC 3339 ts, got %q", entry["ts"])
+		}
+	}
+
+	var first map[string]string
+	_ = json.Unmarshal([]byte(lines[0]), &first)
+	if first["level"] != "INFO" || first["msg"] != "opened vault.db" {
+		t.Errorf("Unexpected entry: %v", first)
+	}
+}
+
+func TestLogger_SetLevel(t *testing.T) {
+	file, err := os.CreateTemp(t.TempDir(), "coconut.log")
+	if err != nil {
+		t.Fatalf("CreateTemp failed: %v", err)
+	}
+	lg := &Logger{file: file}
+	defer lg.Close()
+
+	var mirror bytes.Buffer
+	lg.SetMirror(&mirror)
+	level, err := ParseLevel("Warn")
+	if err != nil {
+		t.Fatalf("ParseLevel failed: %v", err)
+	}
+	lg.SetLevel(level)
+	lg.Info("routine")
+	lg.Warn("odd")
+	lg.Error("broken")
+
+	data, _ := os.ReadFile(file.Name())
+	if strings.Contains(string(data), "routine") || !strings.Contains(string(data), "odd") || !strings.Contains(string(data), "broken") {
+		t.Errorf("Expected only warnings and errors in the file, got %q", data)
+	}
+	if !strings.Contains(mirror.String(), "routine") {
+		t.Error("The mirror should still get every line")
+	}
+
+	if _, err := ParseLevel("debug"); err == nil {
+		t.Error("Expected an unknown level to be rejected")
+	}
+}
+
+func TestReadAccessEvents(t *testing.T) {
+	file, err := os.CreateTemp(t.TempDir(), "audit.log")
+	if err != nil {
+		t.Fatalf("CreateTemp failed: %v", err)
+	}
+	lg := &Logger{accessFile: file, accessEnabled: true}
+	lg.Access("read", `index=1 name="a b" id=x1`)
+	lg.Access("reveal-all", "count=2")
+	file.WriteString("not a log line\n")
+	lg.Close()
+
+	events, err := ReadAccessEvents(file.Name())
+	if err != nil {
+		t.Fatalf("ReadAccessEvents failed: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %+v", events)
+	}
+	if events[0].Op != "read" || events[0].Target != `index=1 name="a b" id=x1` {
+		t.Errorf("Unexpected first event %+v", events[0])
+	}
+	if events[1].Op != "reveal-all" || events[1].Target != "count=2" {
+		t.Errorf("Unexpected second event %+v", events[1])
+	}
+	if time.Since(events[0].Time) > time.Minute {
+		t.Errorf("Expected a recent timestamp, got %v", events[0].Time)
+	}
+
+	if events, err := ReadAccessEvents(file.Name() + ".missing"); err != nil || events != nil {
+		t.Errorf("Expected no events and no error for a missing log, got %v, %v", events, err)
+	}
+}
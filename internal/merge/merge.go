@@ -0,0 +1,186 @@
+package merge
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/ompatil-15/coconut/internal/db/model"
+)
+
+// Strategy decides which side wins when both vaults hold the same login.
+type Strategy string
+
+const (
+	Newest     Strategy = "newest"      // the version with the later UpdatedAt wins
+	KeepBoth   Strategy = "keep-both"   // their version is added next to mine
+	KeepMine   Strategy = "keep-mine"   // my version is left as is
+	KeepTheirs Strategy = "keep-theirs" // their version replaces mine
+)
+
+// Strategies returns the valid --strategy names, default first.
+func Strategies() []string {
+	return []string{string(Newest), string(KeepBoth), string(KeepMine), string(KeepTheirs)}
+}
+
+// ParseStrategy validates a --strategy value.
+func ParseStrategy(name string) (Strategy, error) {
+	for _, s := range Strategies() {
+		if strings.EqualFold(name, s) {
+			return Strategy(s), nil
+		}
+	}
+	return "", fmt.Errorf("unknown merge strategy %q (supported: %s)", name, strings.Join(Strategies(), ", "))
+}
+
+// Plan lists the writes that merge one vault into another. Nothing is
+// written until Apply, so a plan can be shown as a dry run first.
+type Plan struct {
+	Added   []model.Secret // secrets to add, with IDs unused in my vault
+	Updated []model.Secret // my secrets, by ID, with their content replaced
+	Skipped int            // their secrets that were already present or lost a conflict
+}
+
+// Key identifies a login across vaults: username and URL, ignoring case.
+// Entries with neither, such as notes, are matched by type and name.
+func Key(s model.Secret) string {
+	if s.Username == "" && s.URL == "" {
+		return "name\x00" + s.Type + "\x00" + strings.ToLower(s.Name)
+	}
+	return strings.ToLower(s.Username) + "\x00" + strings.ToLower(s.URL)
+}
+
+// entry is a secret in the merged result and where it came from.
+type entry struct {
+	secret  model.Secret
+	added   bool // new in this merge
+	updated bool // one of mine, replaced by their version
+}
+
+// Build works out how to merge theirs into mine. Secrets with the same Key
+// are duplicates: one identical to any secret already under that key is
+// skipped, otherwise the conflict with the most recently updated one is
+// resolved by strategy. Duplicates within theirs are resolved the same way
+// against whatever the merge has already produced.
+func Build(mine, theirs []model.Secret, strategy Strategy) *Plan {
+	plan := &Plan{}
+
+	var merged []*entry
+	byKey := make(map[string][]*entry)
+	ids := make(map[string]bool)
+	for _, s := range mine {
+		e := &entry{secret: s}
+		merged = append(merged, e)
+		ids[s.ID] = true
+		byKey[Key(s)] = append(byKey[Key(s)], e)
+	}
+
+	add := func(s model.Secret) *entry {
+		if s.ID == "" || ids[s.ID] {
+			s.ID = uuid.New().String()
+		}
+		ids[s.ID] = true
+		e := &entry{secret: s, added: true}
+		merged = append(merged, e)
+		return e
+	}
+
+	for _, s := range theirs {
+		key := Key(s)
+		candidates := byKey[key]
+		if len(candidates) == 0 {
+			byKey[key] = append(byKey[key], add(s))
+			continue
+		}
+		if slices.ContainsFunc(candidates, func(e *entry) bool { return SameContent(e.secret, s) }) {
+			plan.Skipped++
+			continue
+		}
+
+		existing := slices.MaxFunc(candidates, func(a, b *entry) int {
+			return a.secret.UpdatedAt.Compare(b.secret.UpdatedAt)
+		})
+
+		switch strategy {
+		case KeepMine:
+			plan.Skipped++
+		case KeepBoth:
+			byKey[key] = append(byKey[key], add(s))
+		case Newest:
+			if !s.UpdatedAt.After(existing.secret.UpdatedAt) {
+				plan.Skipped++
+				continue
+			}
+			replace(existing, s)
+		case KeepTheirs:
+			replace(existing, s)
+		}
+	}
+
+	for _, e := range merged {
+		switch {
+		case e.added:
+			plan.Added = append(plan.Added, e.secret)
+		case e.updated:
+			plan.Updated = append(plan.Updated, e.secret)
+		}
+	}
+	return plan
+}
+
+// replace gives e the content of s while keeping e's identity, so an
+// updated secret keeps its ID and its earliest creation time.
+func replace(e *entry, s model.Secret) {
+	s.ID = e.secret.ID
+	if e.secret.CreatedAt.Before(s.CreatedAt) {
+		s.CreatedAt = e.secret.CreatedAt
+	}
+	if e.secret.LastUsedAt.After(s.LastUsedAt) {
+		s.LastUsedAt = e.secret.LastUsedAt
+	}
+	e.secret = s
+	if !e.added {
+		e.updated = true
+	}
+}
+
+// SameContent reports whether a and b hold the same user-visible data,
+// ignoring IDs and timestamps other than the expiry.
+func SameContent(a, b model.Secret) bool {
+	return a.Type == b.Type &&
+		a.Name == b.Name &&
+		a.Username == b.Username &&
+		a.Password == b.Password &&
+		a.URL == b.URL &&
+		a.Description == b.Description &&
+		slices.Equal(model.NormalizeTags(a.Tags), model.NormalizeTags(b.Tags)) &&
+		slices.Equal(a.Fields, b.Fields) &&
+		a.IsFavorite == b.IsFavorite &&
+		a.IsArchived == b.IsArchived &&
+		a.ExpiresAt.Equal(b.ExpiresAt)
+}
+
+// Writer is the part of the secret repository Apply needs.
+type Writer interface {
+	Add(secret model.Secret) (string, error)
+	Replace(secret model.Secret) error
+}
+
+// Apply stores the plan's additions and updates. Updated secrets keep the
+// UpdatedAt of the version that won, so a later merge compares the same
+// times. It stops at the first error; run it inside a batch to make the
+// merge all or nothing.
+func (p *Plan) Apply(repo Writer) error {
+	for _, s := range p.Added {
+		if _, err := repo.Add(s); err != nil {
+			return fmt.Errorf("add %q: %w", s.Name, err)
+		}
+	}
+	for _, s := range p.Updated {
+		if err := repo.Replace(s); err != nil {
+			return fmt.Errorf("update %q: %w", s.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+package merge
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ompatil-15/coconut/internal/db/model"
+)
+
+var (
+	older = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer = time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+)
+
+func login(id, username, url, password string, updated time.Time) model.Secret {
+	return model.Secret{
+		ID:        id,
+		Name:      url,
+		Username:  username,
+		Password:  password,
+		URL:       url,
+		CreatedAt: older,
+		UpdatedAt: updated,
+	}
+}
+
+func TestParseStrategy(t *testing.T) {
+	for _, name := range Strategies() {
+		if _, err := ParseStrategy(name); err != nil {
+			t.Errorf("ParseStrategy(%q) failed: %v", name, err)
+		}
+	}
+	if s, err := ParseStrategy("Keep-Mine"); err != nil || s != KeepMine {
+		t.Errorf("Expected case-insensitive match, got %q, %v", s, err)
+	}
+	if _, err := ParseStrategy("newest-first"); err == nil {
+		t.Error("Expected an error for an unknown strategy")
+	}
+}
+
+func TestBuild_AddsAndSkipsIdentical(t *testing.T) {
+	mine := []model.Secret{login("1", "alice", "github.com", "pw", older)}
+	theirs := []model.Secret{
+		login("a", "alice", "github.com", "pw", newer), // same content, only the timestamps differ
+		login("b", "bob", "example.com", "pw2", older),
+	}
+
+	plan := Build(mine, theirs, Newest)
+
+	if len(plan.Added) != 1 || plan.Added[0].Username != "bob" {
+		t.Errorf("Expected bob to be added, got %+v", plan.Added)
+	}
+	if len(plan.Updated) != 0 {
+		t.Errorf("Expected no updates, got %+v", plan.Updated)
+	}
+	if plan.Skipped != 1 {
+		t.Errorf("Expected 1 skipped, got %d", plan.Skipped)
+	}
+}
+
+func TestBuild_Strategies(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy Strategy
+		mineAt   time.Time
+		theirsAt time.Time
+		added    int
+		updated  int
+		skipped  int
+	}{
+		{"newest, theirs newer", Newest, older, newer, 0, 1, 0},
+		{"newest, mine newer", Newest, newer, older, 0, 0, 1},
+		{"newest, tie keeps mine", Newest, older, older, 0, 0, 1},
+		{"keep-mine", KeepMine, older, newer, 0, 0, 1},
+		{"keep-theirs", KeepTheirs, newer, older, 0, 1, 0},
+		{"keep-both", KeepBoth, older, newer, 1, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mine := []model.Secret{login("1", "alice", "github.com", "mine", tt.mineAt)}
+			theirs := []model.Secret{login("2", "alice", "github.com", "theirs", tt.theirsAt)}
+
+			plan := Build(mine, theirs, tt.strategy)
+
+			if len(plan.Added) != tt.added || len(plan.Updated) != tt.updated || plan.Skipped != tt.skipped {
+				t.Errorf("Expected added=%d updated=%d skipped=%d, got %d/%d/%d",
+					tt.added, tt.updated, tt.skipped, len(plan.Added), len(plan.Updated), plan.Skipped)
+			}
+		})
+	}
+}
+
+func TestBuild_UpdateKeepsMyIdentity(t *testing.T) {
+	mine := []model.Secret{login("1", "alice", "github.com", "mine", older)}
+	mine[0].CreatedAt = older.Add(-time.Hour)
+	theirs := []model.Secret{login("2", "alice", "github.com", "theirs", newer)}
+
+	plan := Build(mine, theirs, Newest)
+
+	if len(plan.Updated) != 1 {
+		t.Fatalf("Expected 1 update, got %d", len(plan.Updated))
+	}
+	got := plan.Updated[0]
+	if got.ID != "1" {
+		t.Errorf("Expected my ID to be kept, got %q", got.ID)
+	}
+	if got.Password != "theirs" {
+		t.Errorf("Expected their password, got %q", got.Password)
+	}
+	if !got.CreatedAt.Equal(mine[0].CreatedAt) {
+		t.Errorf("Expected the earlier CreatedAt, got %v", got.CreatedAt)
+	}
+}
+
+func TestBuild_NewIDOnCollision(t *testing.T) {
+	mine := []model.Secret{login("1", "alice", "github.com", "pw", older)}
+	theirs := []model.Secret{login("1", "bob", "example.com", "pw", older)}
+
+	plan := Build(mine, theirs, Newest)
+
+	if len(plan.Added) != 1 {
+		t.Fatalf("Expected 1 addition, got %d", len(plan.Added))
+	}
+	if plan.Added[0].ID == "1" || plan.Added[0].ID == "" {
+		t.Errorf("Expected a fresh ID for a colliding secret, got %q", plan.Added[0].ID)
+	}
+}
+
+func TestBuild_DuplicatesWithinTheirs(t *testing.T) {
+	theirs := []model.Secret{
+		login("a", "bob", "example.com", "first", older),
+		login("b", "bob", "example.com", "second", newer),
+	}
+
+	plan := Build(nil, theirs, Newest)
+
+	if len(plan.Added) != 1 || plan.Added[0].Password != "second" {
+		t.Errorf("Expected one addition with the newer password, got %+v", plan.Added)
+	}
+	if len(plan.Updated) != 0 {
+		t.Errorf("A secret added by the merge must not also be an update, got %+v", plan.Updated)
+	}
+}
+
+func TestBuild_NotesMatchByName(t *testing.T) {
+	note := func(id, name, text string) model.Secret {
+		return model.Secret{ID: id, Type: model.SecretTypeNote, Name: name, Description: text, UpdatedAt: older}
+	}
+	mine := []model.Secret{note("1", "Door codes", "1234")}
+	theirs := []model.Secret{note("2", "door codes", "1234"), note("3", "Wifi", "hunter2")}
+
+	plan := Build(mine, theirs, KeepMine)
+
+	if len(plan.Added) != 1 || plan.Added[0].Name != "Wifi" {
+		t.Errorf("Expected only the Wifi note to be added, got %+v", plan.Added)
+	}
+	if plan.Skipped != 1 {
+		t.Errorf("Expected the matching note to be skipped, got %d", plan.Skipped)
+	}
+}
+
+type recordingWriter struct {
+	added   []model.Secret
+	updated []model.Secret
+}
+
+func (w *recordingWriter) Add(s model.Secret) (string, error) {
+	w.added = append(w.added, s)
+	return s.ID, nil
+}
+
+func (w *recordingWriter) Replace(s model.Secret) error {
+	w.updated = append(w.updated, s)
+	return nil
+}
+
+func TestPlan_Apply(t *testing.T) {
+	plan := &Plan{
+		Added:   []model.Secret{login("2", "bob", "example.com", "pw", older)},
+		Updated: []model.Secret{login("1", "alice", "github.com", "pw", newer)},
+	}
+
+	w := &recordingWriter{}
+	if err := plan.Apply(w); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if len(w.added) != 1 || len(w.updated) != 1 {
+		t.Fatalf("Expected 1 add and 1 update, got %d and %d", len(w.added), len(w.updated))
+	}
+	if !w.updated[0].UpdatedAt.Equal(newer) {
+		t.Errorf("Update should keep the winner's UpdatedAt %v, got %v", newer, w.updated[0].UpdatedAt)
+	}
+}
+
+func TestBuild_MineHasDuplicates(t *testing.T) {
+	mine := []model.Secret{
+		login("1", "alice", "github.com", "old", older),
+		login("2", "alice", "github.com", "current", newer),
+	}
+
+	// Identical to one of my duplicates: nothing to do
+	plan := Build(mine, []model.Secret{login("a", "alice", "github.com", "old", newer.Add(time.Hour))}, Newest)
+	if plan.Skipped != 1 || len(plan.Updated) != 0 {
+		t.Errorf("Expected a match on any duplicate to be skipped, got %+v", plan)
+	}
+
+	// Conflicts are resolved against my most recent version
+	plan = Build(mine, []model.Secret{login("a", "alice", "github.com", "other", older.Add(time.Hour))}, Newest)
+	if plan.Skipped != 1 || len(plan.Updated) != 0 {
+		t.Errorf("Expected their older version to lose to my newest, got %+v", plan)
+	}
+}
@@ -0,0 +1,49 @@
+// Package paths decides where coconut keeps its files on disk.
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// appName is the directory name used under the platform data directory.
+const appName = "coconut"
+
+// DataDir returns the directory holding the vault, its backups and logs.
+// An existing ~/.coconut is always used so vaults created before other
+// locations were supported are still found. Otherwise it is
+// %APPDATA%\coconut on Windows, $XDG_DATA_HOME/coconut when that is set,
+// and ~/.coconut as the fallback.
+func DataDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return resolveDataDir(runtime.GOOS, os.Getenv, home, isDir), nil
+}
+
+func resolveDataDir(goos string, getenv func(string) string, home string, exists func(string) bool) string {
+	legacy := filepath.Join(home, "."+appName)
+	if exists(legacy) {
+		return legacy
+	}
+
+	if goos == "windows" {
+		if appData := getenv("APPDATA"); appData != "" {
+			return filepath.Join(appData, appName)
+		}
+	}
+
+	// The XDG spec says relative paths are invalid and must be ignored.
+	if xdg := getenv("XDG_DATA_HOME"); xdg != "" && filepath.IsAbs(xdg) {
+		return filepath.Join(xdg, appName)
+	}
+
+	return legacy
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
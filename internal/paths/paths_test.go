@@ -0,0 +1,38 @@
+package paths
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveDataDir(t *testing.T) {
+	home := filepath.FromSlash("/home/u")
+	legacy := filepath.Join(home, ".coconut")
+
+	tests := []struct {
+		name         string
+		goos         string
+		env          map[string]string
+		legacyExists bool
+		want         string
+	}{
+		{"default", "linux", nil, false, legacy},
+		{"xdg data home", "linux", map[string]string{"XDG_DATA_HOME": "/data"}, false, filepath.Join("/data", "coconut")},
+		{"relative xdg ignored", "linux", map[string]string{"XDG_DATA_HOME": "data"}, false, legacy},
+		{"existing vault wins over xdg", "linux", map[string]string{"XDG_DATA_HOME": "/data"}, true, legacy},
+		{"windows appdata", "windows", map[string]string{"APPDATA": `C:\Users\u\AppData\Roaming`}, false, filepath.Join(`C:\Users\u\AppData\Roaming`, "coconut")},
+		{"windows existing vault", "windows", map[string]string{"APPDATA": `C:\AppData`}, true, legacy},
+		{"appdata ignored off windows", "darwin", map[string]string{"APPDATA": "/appdata"}, false, legacy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getenv := func(key string) string { return tt.env[key] }
+			exists := func(path string) bool { return tt.legacyExists && path == legacy }
+
+			if got := resolveDataDir(tt.goos, getenv, home, exists); got != tt.want {
+				t.Errorf("resolveDataDir() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+// Package pin hashes and checks the optional access PIN of a secret. Only
+// an Argon2id hash is stored, inside the encrypted record, never the PIN.
+package pin
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ompatil-15/coconut/internal/crypto"
+	"golang.org/x/crypto/argon2"
+)
+
+// MinLength is the shortest PIN accepted.
+const MinLength = 4
+
+// Argon2id parameters for new hashes. A PIN has little entropy, so the
+// cost matches the vault key derivation; older hashes keep the parameters
+// recorded in them.
+const (
+	hashTime    = 3
+	hashMemory  = 64 * 1024
+	hashThreads = 4
+	hashLen     = 32
+	saltLen     = 16
+)
+
+var ErrWrongPIN = errors.New("incorrect PIN")
+
+// Hash returns an encoded Argon2id hash of pin in the form
+// argon2id$time$memoryKiB$threads$salt$hash.
+func Hash(pin string) (string, error) {
+	if len([]rune(pin)) < MinLength {
+		return "", fmt.Errorf("PIN must be at least %d characters", MinLength)
+	}
+
+	salt := crypto.GenerateRandomSalt(saltLen)
+	sum := argon2.IDKey([]byte(pin), salt, hashTime, hashMemory, hashThreads, hashLen)
+
+	return fmt.Sprintf("argon2id$%d$%d$%d$%s$%s", hashTime, hashMemory, hashThreads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(sum)), nil
+}
+
+// Verify checks pin against an encoded hash from Hash. It returns
+// ErrWrongPIN for a wrong PIN and another error for a malformed hash.
+func Verify(encoded, pin string) error {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[0] != "argon2id" {
+		return errors.New("unrecognized PIN hash")
+	}
+
+	var time, memory uint32
+	var threads uint8
+	if _, err := fmt.Sscanf(parts[1]+" "+parts[2]+" "+parts[3], "%d %d %d", &time, &memory, &threads); err != nil {
+		return fmt.Errorf("unrecognized PIN hash: %w", err)
+	}
+	if time == 0 || memory == 0 || threads == 0 || memory > 1024*1024 {
+		return errors.New("unrecognized PIN hash parameters")
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return fmt.Errorf("unrecognized PIN hash: %w", err)
+	}
+	want, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(want) == 0 {
+		return errors.New("unrecognized PIN hash")
+	}
+
+	got := argon2.IDKey([]byte(pin), salt, time, memory, threads, uint32(len(want)))
+	if subtle.ConstantTimeCompare(got, want) != 1 {
+		return ErrWrongPIN
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package pin
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestHashVerify(t *testing.T) {
+	hash, err := Hash("2468")
+	if err != nil {
+		t.Fatalf("Hash failed: %v", err)
+	}
+	if strings.Contains(hash, "2468") {
+		t.Error("Hash must not contain the PIN")
+	}
+
+	if err := Verify(hash, "2468"); err != nil {
+		t.Errorf("Expected the right PIN to verify, got %v", err)
+	}
+	if err := Verify(hash, "2469"); !errors.Is(err, ErrWrongPIN) {
+		t.Errorf("Expected ErrWrongPIN, got %v", err)
+	}
+}
+
+func TestHash_Salted(t *testing.T) {
+	a, _ := Hash("2468")
+	b, _ := Hash("2468")
+	if a == b {
+		t.Error("Two hashes of the same PIN should differ")
+	}
+}
+
+func TestHash_TooShort(t *testing.T) {
+	if _, err := Hash("123"); err == nil {
+		t.Error("Expected a PIN shorter than MinLength to be rejected")
+	}
+}
+
+func TestVerify_Malformed(t *testing.T) {
+	for _, hash := range []string{"", "plain", "argon2id$3$65536$4$salt", "argon2id$0$65536$4$c2FsdA$aGFzaA"} {
+		if err := Verify(hash, "2468"); err == nil || errors.Is(err, ErrWrongPIN) {
+			t.Errorf("Verify(%q) should report a malformed hash, got %v", hash, err)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Policy is a password composition policy. The zero value has no rules.
+type Policy struct {
+	MinLength     int  `json:"minLength,omitempty"`
+	RequireUpper  bool `json:"requireUpper,omitempty"`
+	RequireLower  bool `json:"requireLower,omitempty"`
+	RequireDigit  bool `json:"requireDigit,omitempty"`
+	RequireSymbol bool `json:"requireSymbol,omitempty"`
+
+	// Enforce rejects passwords that break a rule; otherwise they are
+	// stored with a warning.
+	Enforce bool `json:"enforce,omitempty"`
+}
+
+// Classes are the character class names accepted by SetRequired.
+var Classes = []string{"upper", "lower", "digit", "symbol"}
+
+// IsEmpty reports whether the policy has no rules at all.
+func (p Policy) IsEmpty() bool {
+	return p.MinLength == 0 && len(p.Required()) == 0
+}
+
+// Required returns the names of the character classes the policy requires,
+// in the order of Classes.
+func (p Policy) Required() []string {
+	var names []string
+	for i, on := range []bool{p.RequireUpper, p.RequireLower, p.RequireDigit, p.RequireSymbol} {
+		if on {
+			names = append(names, Classes[i])
+		}
+	}
+	return names
+}
+
+// SetRequired replaces the required classes with names, a list of Classes
+// entries. "none" or an empty list clears them.
+func (p *Policy) SetRequired(names []string) error {
+	var upper, lower, digit, symbol bool
+	for _, name := range names {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "upper":
+			upper = true
+		case "lower":
+			lower = true
+		case "digit":
+			digit = true
+		case "symbol":
+			symbol = true
+		case "none", "":
+		default:
+			return fmt.Errorf("unknown character class %q (valid: %s, none)", name, strings.Join(Classes, ", "))
+		}
+	}
+	p.RequireUpper, p.RequireLower, p.RequireDigit, p.RequireSymbol = upper, lower, digit, symbol
+	return nil
+}
+
+// Validate returns a description of each rule pw does not meet, or nil if
+// it meets them all.
+func (p Policy) Validate(pw string) []string {
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	length := 0
+	for _, r := range pw {
+		length++
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case !unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+
+	var unmet []string
+	if length < p.MinLength {
+		unmet = append(unmet, fmt.Sprintf("at least %d characters (has %d)", p.MinLength, length))
+	}
+	if p.RequireUpper && !hasUpper {
+		unmet = append(unmet, "an uppercase letter")
+	}
+	if p.RequireLower && !hasLower {
+		unmet = append(unmet, "a lowercase letter")
+	}
+	if p.RequireDigit && !hasDigit {
+		unmet = append(unmet, "a digit")
+	}
+	if p.RequireSymbol && !hasSymbol {
+		unmet = append(unmet, "a symbol")
+	}
+	return unmet
+}
+
+// String summarizes the rules, e.g. "min length 12; requires upper, digit".
+func (p Policy) String() string {
+	if p.IsEmpty() {
+		return "none"
+	}
+
+	var parts []string
+	if p.MinLength > 0 {
+		parts = append(parts, fmt.Sprintf("min length %d", p.MinLength))
+	}
+	if required := p.Required(); len(required) > 0 {
+		parts = append(parts, "requires "+strings.Join(required, ", "))
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,77 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	p := Policy{MinLength: 10, RequireUpper: true, RequireLower: true, RequireDigit: true, RequireSymbol: true}
+
+	tests := []struct {
+		password string
+		unmet    []string
+	}{
+		{"Abcdefgh1!", nil},
+		{"abcdefgh1!", []string{"an uppercase letter"}},
+		{"ABCDEFGH1!", []string{"a lowercase letter"}},
+		{"Abcdefghi!", []string{"a digit"}},
+		{"Abcdefghi1", []string{"a symbol"}},
+		{"Ab1!", []string{"at least 10 characters (has 4)"}},
+		{"", []string{"at least 10 characters (has 0)", "an uppercase letter", "a lowercase letter", "a digit", "a symbol"}},
+		{"Äbcdéfgh1!", nil}, // length counts characters, not bytes
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			got := p.Validate(tt.password)
+			if strings.Join(got, "|") != strings.Join(tt.unmet, "|") {
+				t.Errorf("Validate(%q) = %q, want %q", tt.password, got, tt.unmet)
+			}
+		})
+	}
+}
+
+func TestValidate_EmptyPolicy(t *testing.T) {
+	var p Policy
+	if !p.IsEmpty() {
+		t.Error("Zero policy should be empty")
+	}
+	if unmet := p.Validate("a"); unmet != nil {
+		t.Errorf("Zero policy should accept anything, got %q", unmet)
+	}
+	if p.String() != "none" {
+		t.Errorf("Expected 'none', got %q", p.String())
+	}
+}
+
+func TestSetRequired(t *testing.T) {
+	var p Policy
+	if err := p.SetRequired([]string{"Upper", " digit"}); err != nil {
+		t.Fatalf("SetRequired failed: %v", err)
+	}
+	if !p.RequireUpper || !p.RequireDigit || p.RequireLower || p.RequireSymbol {
+		t.Errorf("Unexpected classes: %+v", p)
+	}
+	if got := strings.Join(p.Required(), ","); got != "upper,digit" {
+		t.Errorf("Required() = %q", got)
+	}
+
+	if err := p.SetRequired([]string{"none"}); err != nil {
+		t.Fatalf("SetRequired(none) failed: %v", err)
+	}
+	if len(p.Required()) != 0 {
+		t.Errorf("Expected no classes after 'none', got %v", p.Required())
+	}
+
+	if err := p.SetRequired([]string{"emoji"}); err == nil {
+		t.Error("Expected an error for an unknown class")
+	}
+}
+
+func TestString(t *testing.T) {
+	p := Policy{MinLength: 12, RequireUpper: true, RequireSymbol: true}
+	if got := p.String(); got != "min length 12; requires upper, symbol" {
+		t.Errorf("String() = %q", got)
+	}
+}
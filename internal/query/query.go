@@ -0,0 +1,153 @@
+// Package query parses and evaluates the search language used by
+// 'coconut search': whitespace-separated terms that must all match, or
+// with MatchAny at least one.
+//
+//	term  = [field ":"] value
+//	field = "name" | "username" | "url" | "tag" | "description"
+//	value = word | '"' text '"'
+//
+// A term without a field matches if any field contains the value. Matching
+// is case-insensitive; tag must equal one of the secret's tags, the other
+// fields only need to contain the value. A prefix that is not a known field
+// is part of the value, so "https://example.com" is a plain term.
+package query
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ompatil-15/coconut/internal/db/model"
+)
+
+// Fields lists the field names a term can be restricted to.
+var Fields = []string{"name", "username", "url", "tag", "description"}
+
+// Term is one condition of a query. An empty Field matches any field.
+type Term struct {
+	Field string
+	Value string
+}
+
+// Query is a list of terms that must all match.
+type Query []Term
+
+// Parse splits s into terms. Double quotes group words into one value,
+// e.g. name:"my bank".
+func Parse(s string) (Query, error) {
+	var (
+		q       Query
+		word    strings.Builder
+		inQuote bool
+		hasWord bool
+	)
+
+	flush := func() {
+		if hasWord {
+			q = append(q, newTerm(word.String()))
+		}
+		word.Reset()
+		hasWord = false
+	}
+
+	for _, r := range s {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+			hasWord = true
+		case !inQuote && (r == ' ' || r == '\t' || r == '\n'):
+			flush()
+		default:
+			word.WriteRune(r)
+			hasWord = true
+		}
+	}
+	if inQuote {
+		return nil, fmt.Errorf("unterminated quote in query %q", s)
+	}
+	flush()
+
+	for _, t := range q {
+		if t.Value == "" {
+			return nil, fmt.Errorf("empty value for %s: in query %q", t.Field, s)
+		}
+	}
+	if len(q) == 0 {
+		return nil, fmt.Errorf("empty query")
+	}
+
+	return q, nil
+}
+
+func newTerm(word string) Term {
+	if field, value, ok := strings.Cut(word, ":"); ok && slices.Contains(Fields, strings.ToLower(field)) {
+		return Term{Field: strings.ToLower(field), Value: value}
+	}
+	return Term{Value: word}
+}
+
+// HasFields reports whether any term is restricted to a field.
+func (q Query) HasFields() bool {
+	for _, t := range q {
+		if t.Field != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Match reports whether s satisfies every term. Passwords are never
+// searched.
+func (q Query) Match(s *model.Secret) bool {
+	for _, t := range q {
+		if !t.match(s) {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchAny reports whether s satisfies at least one term.
+func (q Query) MatchAny(s *model.Secret) bool {
+	for _, t := range q {
+		if t.match(s) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t Term) match(s *model.Secret) bool {
+	value := strings.ToLower(t.Value)
+	contains := func(field string) bool {
+		return strings.Contains(strings.ToLower(field), value)
+	}
+
+	switch t.Field {
+	case "name":
+		return contains(s.Name)
+	case "username":
+		return contains(s.Username)
+	case "url":
+		return contains(s.URL)
+	case "description":
+		return contains(s.Description)
+	case "tag":
+		for _, tag := range s.Tags {
+			if strings.EqualFold(tag, t.Value) {
+				return true
+			}
+		}
+		return false
+	default:
+		if contains(s.Name) || contains(s.Username) || contains(s.URL) || contains(s.Description) {
+			return true
+		}
+		for _, tag := range s.Tags {
+			if contains(tag) {
+				return true
+			}
+		}
+		return false
+	}
+}
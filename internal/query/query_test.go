@@ -0,0 +1,118 @@
+package query
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ompatil-15/coconut/internal/db/model"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Query
+	}{
+		{"github", Query{{Value: "github"}}},
+		{"tag:work url:github", Query{{Field: "tag", Value: "work"}, {Field: "url", Value: "github"}}},
+		{"  Name:Bank   alice ", Query{{Field: "name", Value: "Bank"}, {Value: "alice"}}},
+		{`name:"my bank" "two words"`, Query{{Field: "name", Value: "my bank"}, {Value: "two words"}}},
+		{"https://example.com", Query{{Value: "https://example.com"}}},
+		{"url:https://example.com", Query{{Field: "url", Value: "https://example.com"}}},
+		{"foo:bar", Query{{Value: "foo:bar"}}},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse(tt.input)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", tt.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, input := range []string{"", "   ", `name:"open`, "tag:", `url:""`} {
+		if _, err := Parse(input); err == nil {
+			t.Errorf("Parse(%q) should fail", input)
+		}
+	}
+}
+
+func TestQuery_Match(t *testing.T) {
+	secret := &model.Secret{
+		Name:        "GitHub",
+		Username:    "octocat",
+		Password:    "hunter2",
+		URL:         "https://github.com",
+		Description: "Work account",
+		Tags:        []string{"work", "dev"},
+	}
+
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"github", true},
+		{"GITHUB", true},
+		{"tag:work url:github", true},
+		{"tag:work url:gitlab", false},
+		{"tag:wor", false}, // tags match whole
+		{"wor", true},      // bare terms match inside tags and description
+		{"username:octo", true},
+		{"name:octo", false},
+		{"description:account", true},
+		{"hunter2", false}, // passwords are never searched
+		{"octocat dev", true},
+	}
+
+	for _, tt := range tests {
+		q, err := Parse(tt.query)
+		if err != nil {
+			t.Fatalf("Parse(%q) failed: %v", tt.query, err)
+		}
+		if got := q.Match(secret); got != tt.want {
+			t.Errorf("%q matched = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestQuery_MatchAny(t *testing.T) {
+	secret := &model.Secret{Name: "GitHub", Username: "octocat", Tags: []string{"work"}}
+
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"github gitlab", true},
+		{"gitlab bitbucket", false},
+		{"tag:personal octo", true},
+		{"tag:personal name:octo", false},
+	}
+
+	for _, tt := range tests {
+		q, err := Parse(tt.query)
+		if err != nil {
+			t.Fatalf("Parse(%q) failed: %v", tt.query, err)
+		}
+		if got := q.MatchAny(secret); got != tt.want {
+			t.Errorf("%q matched any = %v, want %v", tt.query, got, tt.want)
+		}
+		if all := q.Match(secret); all && !q.MatchAny(secret) {
+			t.Errorf("%q matched all terms but not any", tt.query)
+		}
+	}
+}
+
+func TestQuery_HasFields(t *testing.T) {
+	q, _ := Parse("github")
+	if q.HasFields() {
+		t.Error("Bare query should not report fields")
+	}
+	q, _ = Parse("github tag:work")
+	if !q.HasFields() {
+		t.Error("Query with tag: should report fields")
+	}
+}
@@ -0,0 +1,85 @@
+// Package sealed wraps a whole coconut JSON export in a passphrase
+// protected file, written by 'export --encrypted' and read by
+// 'import --encrypted'. Like a share envelope, the file is encrypted with
+// a key derived from its passphrase alone, so it never depends on, or
+// reveals anything about, the master key of the vault it came from.
+package sealed
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ompatil-15/coconut/internal/crypto"
+	"github.com/ompatil-15/coconut/internal/envelope"
+)
+
+// Kind identifies a sealed export among coconut's JSON files.
+const Kind = "coconut-export"
+
+// Version is the file format written by Seal. Version 2 authenticates
+// the header; Open still reads version 1, and refuses newer versions
+// rather than guessing at them.
+const Version = 2
+
+// ErrWrongPassphrase is returned by Open when the passphrase does not
+// decrypt the file, or the file was tampered with.
+var ErrWrongPassphrase = errors.New("wrong passphrase or corrupted export file")
+
+// File is the on-disk form of a sealed export.
+type File struct {
+	Kind       string           `json:"kind"`
+	Version    int              `json:"version"`
+	ExportedAt time.Time        `json:"exportedAt"`
+	Count      int              `json:"count"` // secrets in Data, shown before decrypting
+	KDF        crypto.KDFParams `json:"kdf"`
+	Salt       []byte           `json:"salt"`
+	Data       string           `json:"data"` // AES-256-GCM sealed export
+}
+
+// Seal encrypts export, a JSON export of count secrets, with passphrase
+// and returns the file as JSON.
+func Seal(export []byte, count int, passphrase string, now time.Time) ([]byte, error) {
+	if passphrase == "" {
+		return nil, errors.New("passphrase is empty")
+	}
+
+	h := envelope.NewHeader(Kind, Version)
+	file := File{
+		Kind:       h.Magic,
+		Version:    h.Version,
+		ExportedAt: now.UTC(),
+		Count:      count,
+		KDF:        h.KDF,
+		Salt:       h.Salt,
+	}
+	var err error
+	file.Data, err = envelope.Seal(h, passphrase, export)
+	if err != nil {
+		return nil, fmt.Errorf("encrypt export: %w", err)
+	}
+
+	return json.MarshalIndent(file, "", "  ")
+}
+
+// Open decrypts a file written by Seal and returns the JSON export in it.
+func Open(data []byte, passphrase string) ([]byte, *File, error) {
+	var file File
+	if err := json.Unmarshal(data, &file); err != nil || file.Kind != Kind {
+		return nil, nil, errors.New("not a coconut encrypted export")
+	}
+	if file.Version < 1 || file.Version > Version {
+		return nil, nil, fmt.Errorf("unsupported export format version %d (this coconut reads up to version %d)", file.Version, Version)
+	}
+
+	h := envelope.Header{Magic: file.Kind, Version: file.Version, KDF: file.KDF, Salt: file.Salt}
+	export, err := envelope.Open(h, passphrase, file.Data)
+	if errors.Is(err, envelope.ErrDecrypt) {
+		return nil, nil, ErrWrongPassphrase
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("export file: %w", err)
+	}
+	return export, &file, nil
+}
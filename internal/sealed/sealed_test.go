@@ -0,0 +1,78 @@
+package sealed
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testExport = `[{"name":"GitHub","username":"alice","password":"s3cret-pass"}]`
+
+func TestSealOpen_RoundTrip(t *testing.T) {
+	exportedAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	data, err := Seal([]byte(testExport), 1, "correct-horse", exportedAt)
+	if err != nil {
+		t.Fatalf("Seal failed: %v", err)
+	}
+	if strings.Contains(string(data), "s3cret-pass") || strings.Contains(string(data), "alice") {
+		t.Fatal("Sealed file must not contain the export in plaintext")
+	}
+
+	export, file, err := Open(data, "correct-horse")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if string(export) != testExport {
+		t.Errorf("Round trip changed the export: %s", export)
+	}
+	if file.Kind != Kind || file.Version != Version || file.Count != 1 || !file.ExportedAt.Equal(exportedAt) {
+		t.Errorf("Unexpected file header: %+v", file)
+	}
+
+	if _, _, err := Open(data, "wrong"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Expected ErrWrongPassphrase, got %v", err)
+	}
+	if _, err := Seal([]byte(testExport), 1, "", exportedAt); err == nil {
+		t.Error("Expected an empty passphrase to be rejected")
+	}
+}
+
+func TestOpen_Rejects(t *testing.T) {
+	data, err := Seal([]byte(testExport), 1, "pw", time.Now())
+	if err != nil {
+		t.Fatalf("Seal failed: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(file *File)
+	}{
+		{"other kind", func(file *File) { file.Kind = "coconut-share" }},
+		{"future version", func(file *File) { file.Version = Version + 1 }},
+		{"downgraded version", func(file *File) { file.Version = 1 }},
+		{"excessive memory", func(file *File) { file.KDF.MemoryKiB = 4 * 1024 * 1024 }},
+		{"missing salt", func(file *File) { file.Salt = nil }},
+		{"unknown algorithm", func(file *File) { file.KDF.Algorithm = "md5" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var file File
+			if err := json.Unmarshal(data, &file); err != nil {
+				t.Fatal(err)
+			}
+			tt.modify(&file)
+			modified, _ := json.Marshal(file)
+			if _, _, err := Open(modified, "pw"); err == nil {
+				t.Error("Expected file to be rejected")
+			}
+		})
+	}
+
+	if _, _, err := Open([]byte("not json"), "pw"); err == nil {
+		t.Error("Expected garbage to be rejected")
+	}
+}
@@ -0,0 +1,121 @@
+package session
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// ErrNoMachineBinding is returned when no boot or machine identifier can be
+// read. Hostname and user ID alone are easy to guess, so no session is
+// cached rather than one a copied database could unlock.
+var ErrNoMachineBinding = errors.New("no boot or machine identifier found to bind a session to")
+
+// bindingSources are read in order; every one that exists is mixed into the
+// binding. boot_id changes on reboot, machine-id differs per host.
+var bindingSources = []string{
+	"/proc/sys/kernel/random/boot_id",
+	"/etc/machine-id",
+	"/var/lib/dbus/machine-id",
+}
+
+// machineBinding returns an identifier for the current boot, host and user.
+// A copied database carries the session key but not this value, so the
+// cached key cannot be recovered elsewhere or after a reboot. It fails with
+// ErrNoMachineBinding when neither a boot nor a machine identifier exists.
+func machineBinding() ([]byte, error) {
+	var parts []string
+	for _, path := range bindingSources {
+		if data, err := os.ReadFile(path); err == nil {
+			if id := strings.TrimSpace(string(data)); id != "" {
+				parts = append(parts, id)
+			}
+		}
+	}
+	if id := platformMachineID(); id != "" {
+		parts = append(parts, id)
+	}
+	if len(parts) == 0 {
+		return nil, ErrNoMachineBinding
+	}
+
+	if hostname, err := os.Hostname(); err == nil {
+		parts = append(parts, hostname)
+	}
+	parts = append(parts, strconv.Itoa(os.Getuid()))
+
+	return []byte(strings.Join(parts, "\x00")), nil
+}
+
+// platformMachineID returns the machine identifier of systems without the
+// files in bindingSources: IOPlatformUUID on macOS and MachineGuid on
+// Windows. It returns "" elsewhere or when the identifier cannot be read.
+func platformMachineID() string {
+	switch runtime.GOOS {
+	case "darwin":
+		out, err := exec.Command("ioreg", "-rd1", "-c", "IOPlatformExpertDevice").Output()
+		if err != nil {
+			return ""
+		}
+		return parseIOPlatformUUID(string(out))
+	case "windows":
+		out, err := exec.Command("reg", "query", `HKLM\SOFTWARE\Microsoft\Cryptography`, "/v", "MachineGuid").Output()
+		if err != nil {
+			return ""
+		}
+		return parseMachineGUID(string(out))
+	}
+	return ""
+}
+
+// parseIOPlatformUUID finds the value of a line such as
+//
+//	"IOPlatformUUID" = "564D7A1B-..."
+//
+// in ioreg output.
+func parseIOPlatformUUID(out string) string {
+	for _, line := range strings.Split(out, "\n") {
+		key, value, ok := strings.Cut(line, "=")
+		if ok && strings.Trim(strings.TrimSpace(key), `"`) == "IOPlatformUUID" {
+			return strings.Trim(strings.TrimSpace(value), `"`)
+		}
+	}
+	return ""
+}
+
+// parseMachineGUID finds the value of a line such as
+//
+//	MachineGuid    REG_SZ    8f3c...
+//
+// in reg query output.
+func parseMachineGUID(out string) string {
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 3 && fields[0] == "MachineGuid" {
+			return fields[2]
+		}
+	}
+	return ""
+}
+
+// bindSessionKey derives the key actually used to encrypt the cached vault
+// key from the stored random session key and the machine binding.
+func bindSessionKey(sessionKey, binding []byte) []byte {
+	mac := hmac.New(sha256.New, sessionKey)
+	mac.Write([]byte("coconut-session-v1\x00"))
+	mac.Write(binding)
+	return mac.Sum(nil)
+}
+
+// bindingFingerprint is stored alongside the session so a mismatch can be
+// reported clearly instead of surfacing as a decryption failure.
+func bindingFingerprint(binding []byte) string {
+	sum := sha256.Sum256(binding)
+	return hex.EncodeToString(sum[:8])
+}
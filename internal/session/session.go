@@ -4,6 +4,7 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -15,11 +16,15 @@ import (
 // Session represents an authenticated vault session with cached credentials.
 // The session expires after TimeoutSeconds of inactivity (no commands executed).
 // Each command execution updates LastActivityAt, extending the session.
+// An optional ExpiresAt additionally ends the session at a fixed wall-clock
+// time, regardless of activity.
 type Session struct {
-	UnlockedAt     time.Time `json:"unlocked_at"`      // When the vault was first unlocked
-	LastActivityAt time.Time `json:"last_activity_at"` // Last command execution time (used for inactivity timeout)
-	TimeoutSeconds int       `json:"timeout_seconds"`  // Inactivity timeout in seconds
-	EncryptedKey   string    `json:"encrypted_key"`    // Vault key encrypted with session key (nonce embedded)
+	UnlockedAt     time.Time `json:"unlocked_at"`         // When the vault was first unlocked
+	LastActivityAt time.Time `json:"last_activity_at"`    // Last command execution time (used for inactivity timeout)
+	TimeoutSeconds int       `json:"timeout_seconds"`     // Inactivity timeout in seconds
+	EncryptedKey   string    `json:"encrypted_key"`       // Vault key encrypted with session key (nonce embedded)
+	Binding        string    `json:"binding"`             // Fingerprint of the boot/machine the session is bound to
+	ExpiresAt      time.Time `json:"expires_at,omitzero"` // Absolute expiry; zero means inactivity timeout only
 }
 
 const (
@@ -27,15 +32,25 @@ const (
 	sessionKeyKey  = "session:key"
 )
 
+// ErrBindingMismatch is returned when a session was created on another
+// machine or before a reboot.
+var ErrBindingMismatch = errors.New("session belongs to a different machine or boot")
+
+// ErrSessionExpired is returned when a command must not prompt for the
+// master password and there is no valid session to use instead.
+var ErrSessionExpired = errors.New("no valid session")
+
 type Manager struct {
-	repo db.Repository
-	cfg  *config.Config
+	repo    db.Repository
+	cfg     *config.Config
+	binding func() ([]byte, error)
 }
 
 func NewManager(repo db.Repository, cfg *config.Config) *Manager {
 	return &Manager{
-		repo: repo,
-		cfg:  cfg,
+		repo:    repo,
+		cfg:     cfg,
+		binding: machineBinding,
 	}
 }
 
@@ -45,8 +60,13 @@ func (m *Manager) CreateSession(vaultKey []byte) error {
 		return fmt.Errorf("failed to generate session key: %w", err)
 	}
 
+	binding, err := m.binding()
+	if err != nil {
+		return err
+	}
+
 	aesGCM := crypto.NewAESGCM()
-	encryptedKey, err := aesGCM.Encrypt(sessionKey, base64.StdEncoding.EncodeToString(vaultKey))
+	encryptedKey, err := aesGCM.Encrypt(bindSessionKey(sessionKey, binding), base64.StdEncoding.EncodeToString(vaultKey))
 	if err != nil {
 		return fmt.Errorf("failed to encrypt vault key: %w", err)
 	}
@@ -57,6 +77,7 @@ func (m *Manager) CreateSession(vaultKey []byte) error {
 		LastActivityAt: now,
 		TimeoutSeconds: m.cfg.AutoLockSecs,
 		EncryptedKey:   encryptedKey,
+		Binding:        bindingFingerprint(binding),
 	}
 
 	if err := m.saveSession(&session); err != nil {
@@ -71,13 +92,18 @@ func (m *Manager) CreateSession(vaultKey []byte) error {
 }
 
 // IsValid checks if the current session is still valid (not expired).
-// A session is valid if the time since LastActivityAt is less than the timeout.
+// A session is valid if the time since LastActivityAt is less than the timeout
+// and its absolute expiry, if any, has not passed.
 func (m *Manager) IsValid() bool {
 	session, err := m.loadSession()
 	if err != nil {
 		return false
 	}
 
+	if !session.ExpiresAt.IsZero() && !time.Now().Before(session.ExpiresAt) {
+		return false
+	}
+
 	if m.cfg.AutoLockSecs == 0 {
 		return true
 	}
@@ -106,6 +132,28 @@ func (m *Manager) UpdateActivity() error {
 	return m.saveSession(session)
 }
 
+// SetExpiry makes the current session end at the given time, independent
+// of the inactivity timeout. A zero time removes the absolute expiry.
+func (m *Manager) SetExpiry(at time.Time) error {
+	session, err := m.loadSession()
+	if err != nil {
+		return fmt.Errorf("no active session to schedule: %w", err)
+	}
+
+	session.ExpiresAt = at
+	return m.saveSession(session)
+}
+
+// ExpiresAt returns the absolute expiry of the current session, or the zero
+// time when there is none.
+func (m *Manager) ExpiresAt() time.Time {
+	session, err := m.loadSession()
+	if err != nil {
+		return time.Time{}
+	}
+	return session.ExpiresAt
+}
+
 // GetCachedKey retrieves the vault key from the session cache
 // Returns nil if session is invalid or expired
 func (m *Manager) GetCachedKey() ([]byte, error) {
@@ -118,13 +166,21 @@ func (m *Manager) GetCachedKey() ([]byte, error) {
 		return nil, err
 	}
 
+	binding, err := m.binding()
+	if err != nil {
+		return nil, err
+	}
+	if session.Binding != bindingFingerprint(binding) {
+		return nil, ErrBindingMismatch
+	}
+
 	sessionKey, err := m.loadSessionKey()
 	if err != nil {
 		return nil, fmt.Errorf("failed to load session key: %w", err)
 	}
 
 	aesGCM := crypto.NewAESGCM()
-	decryptedStr, err := aesGCM.Decrypt(sessionKey, session.EncryptedKey)
+	decryptedStr, err := aesGCM.Decrypt(bindSessionKey(sessionKey, binding), session.EncryptedKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decrypt vault key: %w", err)
 	}
@@ -139,13 +195,11 @@ func (m *Manager) GetCachedKey() ([]byte, error) {
 
 // Clear removes the session data (explicit lock)
 func (m *Manager) Clear() error {
-	_ = m.repo.Delete(sessionDataKey)
-	_ = m.repo.Delete(sessionKeyKey)
-	return nil
+	return errors.Join(m.repo.Delete(sessionDataKey), m.repo.Delete(sessionKeyKey))
 }
 
 // GetRemainingTime returns the time remaining before session expires due to inactivity.
-// Calculated as: timeout - (now - LastActivityAt)
+// Calculated as: timeout - (now - LastActivityAt), capped by the absolute expiry if set.
 func (m *Manager) GetRemainingTime() time.Duration {
 	session, err := m.loadSession()
 	if err != nil {
@@ -161,6 +215,13 @@ func (m *Manager) GetRemainingTime() time.Duration {
 	timeout := time.Duration(timeoutSeconds) * time.Second
 	remaining := timeout - elapsed
 
+	if !session.ExpiresAt.IsZero() {
+		untilExpiry := time.Until(session.ExpiresAt)
+		if timeoutSeconds == 0 || untilExpiry < remaining {
+			remaining = untilExpiry
+		}
+	}
+
 	if remaining < 0 {
 		return 0
 	}
@@ -29,6 +29,11 @@ func (m *mockRepository) Get(key string) ([]byte, error) {
 	return nil, errors.New("key not found")
 }
 
+func (m *mockRepository) Has(key string) (bool, error) {
+	_, exists := m.data[key]
+	return exists, nil
+}
+
 func (m *mockRepository) Delete(key string) error {
 	if m.data == nil {
 		return errors.New("key not found")
@@ -364,4 +369,148 @@ func TestManager_GetRemainingTime(t *testing.T) {
 	if remaining > 300*time.Second {
 		t.Error("Remaining time should not exceed timeout")
 	}
-}
\ No newline at end of file
+}
+
+func TestManager_GetCachedKey_BindingMismatch(t *testing.T) {
+	repo := &mockRepository{}
+	cfg := &config.Config{AutoLockSecs: 300}
+	manager := NewManager(repo, cfg)
+	manager.binding = func() ([]byte, error) { return []byte("boot-a"), nil }
+
+	key := []byte("test-session-key-32-bytes-long")
+	if err := manager.CreateSession(key); err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+
+	// Same binding still works
+	if _, err := manager.GetCachedKey(); err != nil {
+		t.Fatalf("GetCachedKey should succeed with same binding: %v", err)
+	}
+
+	// Simulate the DB being copied to another machine or a reboot
+	other := NewManager(repo, cfg)
+	other.binding = func() ([]byte, error) { return []byte("boot-b"), nil }
+
+	_, err := other.GetCachedKey()
+	if !errors.Is(err, ErrBindingMismatch) {
+		t.Errorf("Expected ErrBindingMismatch, got %v", err)
+	}
+}
+
+func TestManager_GetCachedKey_ForgedFingerprint(t *testing.T) {
+	repo := &mockRepository{}
+	cfg := &config.Config{AutoLockSecs: 300}
+	manager := NewManager(repo, cfg)
+	manager.binding = func() ([]byte, error) { return []byte("boot-a"), nil }
+
+	if err := manager.CreateSession([]byte("test-session-key-32-bytes-long")); err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+
+	// An attacker rewriting the stored fingerprint still cannot decrypt
+	var session Session
+	json.Unmarshal(repo.data["session:data"], &session)
+	session.Binding = bindingFingerprint([]byte("boot-b"))
+	data, _ := json.Marshal(session)
+	repo.data["session:data"] = data
+
+	other := NewManager(repo, cfg)
+	other.binding = func() ([]byte, error) { return []byte("boot-b"), nil }
+
+	if _, err := other.GetCachedKey(); err == nil {
+		t.Error("GetCachedKey should fail when the fingerprint is forged")
+	}
+}
+
+func TestManager_CreateSession_NoMachineBinding(t *testing.T) {
+	repo := &mockRepository{}
+	manager := NewManager(repo, &config.Config{AutoLockSecs: 300})
+	manager.binding = func() ([]byte, error) { return nil, ErrNoMachineBinding }
+
+	if err := manager.CreateSession([]byte("test-session-key-32-bytes-long")); !errors.Is(err, ErrNoMachineBinding) {
+		t.Fatalf("Expected ErrNoMachineBinding, got %v", err)
+	}
+	if len(repo.data) != 0 {
+		t.Errorf("Expected nothing cached without a machine binding, got %d entries", len(repo.data))
+	}
+}
+
+func TestParsePlatformMachineID(t *testing.T) {
+	tests := []struct {
+		name  string
+		parse func(string) string
+		out   string
+		want  string
+	}{
+		{"ioreg", parseIOPlatformUUID, "+-o J314sAP  <class IOPlatformExpertDevice>\n  {\n    \"IOPlatformSerialNumber\" = \"C02XX\"\n    \"IOPlatformUUID\" = \"564D7A1B-0C3E-4B2A-9F1D-2E8A6B7C9D01\"\n  }\n", "564D7A1B-0C3E-4B2A-9F1D-2E8A6B7C9D01"},
+		{"ioreg without uuid", parseIOPlatformUUID, "  \"IOPlatformSerialNumber\" = \"C02XX\"\n", ""},
+		{"reg query", parseMachineGUID, "\r\nHKEY_LOCAL_MACHINE\\SOFTWARE\\Microsoft\\Cryptography\r\n    MachineGuid    REG_SZ    8f3c2a10-5b6d-4e7f-9a0b-1c2d3e4f5a6b\r\n\r\n", "8f3c2a10-5b6d-4e7f-9a0b-1c2d3e4f5a6b"},
+		{"reg query error", parseMachineGUID, "ERROR: The system was unable to find the specified registry key or value.\r\n", ""},
+	}
+
+	for _, tt := range tests {
+		if got := tt.parse(tt.out); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestManager_SetExpiry(t *testing.T) {
+	repo := &mockRepository{}
+	cfg := &config.Config{AutoLockSecs: 300}
+	manager := NewManager(repo, cfg)
+
+	if err := manager.CreateSession([]byte("test-session-key-32-bytes-long")); err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+
+	// Absolute expiry shorter than the inactivity timeout caps remaining time
+	if err := manager.SetExpiry(time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("SetExpiry failed: %v", err)
+	}
+	if remaining := manager.GetRemainingTime(); remaining > time.Minute {
+		t.Errorf("Remaining time should be capped by expiry, got %v", remaining)
+	}
+
+	// Activity does not push back an absolute expiry
+	if err := manager.SetExpiry(time.Now().Add(-time.Second)); err != nil {
+		t.Fatalf("SetExpiry failed: %v", err)
+	}
+	if err := manager.UpdateActivity(); err != nil {
+		t.Fatalf("UpdateActivity failed: %v", err)
+	}
+	if manager.IsValid() {
+		t.Error("Session should be invalid after its absolute expiry")
+	}
+	if _, err := manager.GetCachedKey(); err == nil {
+		t.Error("GetCachedKey should fail after absolute expiry")
+	}
+}
+
+func TestManager_SetExpiry_NoAutoLock(t *testing.T) {
+	repo := &mockRepository{}
+	cfg := &config.Config{AutoLockSecs: 0}
+	manager := NewManager(repo, cfg)
+
+	if err := manager.CreateSession([]byte("test-session-key-32-bytes-long")); err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	if err := manager.SetExpiry(time.Now().Add(-time.Second)); err != nil {
+		t.Fatalf("SetExpiry failed: %v", err)
+	}
+
+	// Expiry applies even when the inactivity timeout is disabled
+	if manager.IsValid() {
+		t.Error("Session should expire even with autolock disabled")
+	}
+}
+
+func TestManager_SetExpiry_NoSession(t *testing.T) {
+	repo := &mockRepository{}
+	cfg := &config.Config{AutoLockSecs: 300}
+	manager := NewManager(repo, cfg)
+
+	if err := manager.SetExpiry(time.Now().Add(time.Minute)); err == nil {
+		t.Error("SetExpiry should fail when no session exists")
+	}
+}
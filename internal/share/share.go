@@ -0,0 +1,121 @@
+// Package share seals a single secret into a self-contained envelope that
+// another coconut user can open with a one-time passphrase. The envelope
+// is encrypted with a key derived from that passphrase alone, so it never
+// depends on, or reveals anything about, the sharer's master key.
+package share
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ompatil-15/coconut/internal/crypto"
+	"github.com/ompatil-15/coconut/internal/db/model"
+	"github.com/ompatil-15/coconut/internal/envelope"
+)
+
+// Version is the envelope format written by Seal. Version 2 authenticates
+// the header; Open still reads version 1.
+const Version = 2
+
+// magic identifies share envelopes in their authenticated header. It is
+// not stored in the file.
+const magic = "coconut-share"
+
+// ErrWrongPassphrase is returned by Open when the passphrase does not
+// decrypt the envelope, or the envelope was tampered with.
+var ErrWrongPassphrase = errors.New("wrong passphrase or corrupted envelope")
+
+// Envelope is the on-disk form of a shared secret.
+type Envelope struct {
+	Version  int              `json:"version"`
+	SharedAt time.Time        `json:"sharedAt"`
+	KDF      crypto.KDFParams `json:"kdf"`
+	Salt     []byte           `json:"salt"`
+	Data     string           `json:"data"` // AES-256-GCM sealed Payload
+}
+
+// Payload is the shared secret. Fields that only make sense in the
+// sharer's vault, such as the ID, timestamps and favorite flag, are left out.
+type Payload struct {
+	Type        string        `json:"type,omitempty"`
+	Name        string        `json:"name"`
+	Username    string        `json:"username"`
+	Password    string        `json:"password"`
+	URL         string        `json:"url"`
+	Description string        `json:"description"`
+	Tags        []string      `json:"tags,omitempty"`
+	Fields      []model.Field `json:"fields,omitempty"`
+	ExpiresAt   time.Time     `json:"expiresAt,omitzero"`
+}
+
+// Seal encrypts secret with passphrase and returns the envelope as JSON.
+func Seal(secret model.Secret, passphrase string, now time.Time) ([]byte, error) {
+	plaintext, err := json.Marshal(Payload{
+		Type:        secret.Type,
+		Name:        secret.Name,
+		Username:    secret.Username,
+		Password:    secret.Password,
+		URL:         secret.URL,
+		Description: secret.Description,
+		Tags:        secret.Tags,
+		Fields:      secret.Fields,
+		ExpiresAt:   secret.ExpiresAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("encode secret: %w", err)
+	}
+
+	h := envelope.NewHeader(magic, Version)
+	env := Envelope{
+		Version:  h.Version,
+		SharedAt: now.UTC(),
+		KDF:      h.KDF,
+		Salt:     h.Salt,
+	}
+	env.Data, err = envelope.Seal(h, passphrase, plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("encrypt secret: %w", err)
+	}
+
+	return json.MarshalIndent(env, "", "  ")
+}
+
+// Open decrypts an envelope written by Seal. The secret has no ID or
+// timestamps; the caller sets them when adding it to a vault.
+func Open(data []byte, passphrase string) (model.Secret, *Envelope, error) {
+	var env Envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return model.Secret{}, nil, fmt.Errorf("not a coconut share file: %w", err)
+	}
+	if env.Version < 1 || env.Version > Version {
+		return model.Secret{}, nil, fmt.Errorf("unsupported share format version %d (this coconut reads up to version %d)", env.Version, Version)
+	}
+
+	h := envelope.Header{Magic: magic, Version: env.Version, KDF: env.KDF, Salt: env.Salt}
+	plaintext, err := envelope.Open(h, passphrase, env.Data)
+	if errors.Is(err, envelope.ErrDecrypt) {
+		return model.Secret{}, nil, ErrWrongPassphrase
+	}
+	if err != nil {
+		return model.Secret{}, nil, fmt.Errorf("share file: %w", err)
+	}
+
+	var p Payload
+	if err := json.Unmarshal(plaintext, &p); err != nil {
+		return model.Secret{}, nil, fmt.Errorf("decode shared secret: %w", err)
+	}
+
+	return model.Secret{
+		Type:        p.Type,
+		Name:        p.Name,
+		Username:    p.Username,
+		Password:    p.Password,
+		URL:         p.URL,
+		Description: p.Description,
+		Tags:        p.Tags,
+		Fields:      p.Fields,
+		ExpiresAt:   p.ExpiresAt,
+	}, &env, nil
+}
@@ -0,0 +1,108 @@
+package share
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ompatil-15/coconut/internal/crypto"
+	"github.com/ompatil-15/coconut/internal/db/model"
+)
+
+func testSecret() model.Secret {
+	now := time.Now()
+	return model.Secret{
+		ID:          "abc",
+		Name:        "GitHub",
+		Username:    "alice",
+		Password:    "s3cret-pass",
+		URL:         "https://github.com",
+		Description: "team account",
+		Tags:        []string{"work"},
+		IsFavorite:  true,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		ExpiresAt:   now.Add(24 * time.Hour).Truncate(time.Second),
+	}
+}
+
+func TestSealOpen_RoundTrip(t *testing.T) {
+	secret := testSecret()
+	sharedAt := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	data, err := Seal(secret, "correct-horse", sharedAt)
+	if err != nil {
+		t.Fatalf("Seal failed: %v", err)
+	}
+	if strings.Contains(string(data), secret.Password) || strings.Contains(string(data), secret.Username) {
+		t.Fatal("Envelope must not contain the secret in plaintext")
+	}
+
+	got, env, err := Open(data, "correct-horse")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if env.Version != Version || !env.SharedAt.Equal(sharedAt) {
+		t.Errorf("Unexpected envelope header: version %d, shared %v", env.Version, env.SharedAt)
+	}
+	if got.Name != secret.Name || got.Username != secret.Username || got.Password != secret.Password ||
+		got.URL != secret.URL || got.Description != secret.Description || !got.ExpiresAt.Equal(secret.ExpiresAt) ||
+		len(got.Tags) != 1 || got.Tags[0] != "work" {
+		t.Errorf("Round trip lost data: %+v", got)
+	}
+	if got.ID != "" || got.IsFavorite || !got.CreatedAt.IsZero() {
+		t.Errorf("Vault-specific fields should not be shared: %+v", got)
+	}
+}
+
+func TestOpen_WrongPassphrase(t *testing.T) {
+	data, err := Seal(testSecret(), "correct-horse", time.Now())
+	if err != nil {
+		t.Fatalf("Seal failed: %v", err)
+	}
+
+	if _, _, err := Open(data, "wrong"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Expected ErrWrongPassphrase, got %v", err)
+	}
+}
+
+func TestOpen_Rejects(t *testing.T) {
+	data, err := Seal(testSecret(), "pw", time.Now())
+	if err != nil {
+		t.Fatalf("Seal failed: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(env *Envelope)
+	}{
+		{"future version", func(env *Envelope) { env.Version = Version + 1 }},
+		{"downgraded version", func(env *Envelope) { env.Version = 1 }},
+		{"excessive memory", func(env *Envelope) { env.KDF.MemoryKiB = 4 * 1024 * 1024 }},
+		{"excessive scrypt parallelism", func(env *Envelope) {
+			env.KDF = crypto.KDFParams{Algorithm: crypto.KDFScrypt, N: 1 << 10, R: 8, P: 1 << 20, KeyLen: 32}
+		}},
+		{"missing salt", func(env *Envelope) { env.Salt = nil }},
+		{"unknown algorithm", func(env *Envelope) { env.KDF.Algorithm = "md5" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var env Envelope
+			if err := json.Unmarshal(data, &env); err != nil {
+				t.Fatal(err)
+			}
+			tt.modify(&env)
+			modified, _ := json.Marshal(env)
+			if _, _, err := Open(modified, "pw"); err == nil {
+				t.Error("Expected envelope to be rejected")
+			}
+		})
+	}
+
+	if _, _, err := Open([]byte("not json"), "pw"); err == nil {
+		t.Error("Expected garbage to be rejected")
+	}
+}
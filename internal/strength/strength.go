@@ -0,0 +1,175 @@
+// Package strength estimates how hard a password is to guess.
+package strength
+
+import (
+	"fmt"
+	"math"
+	"unicode"
+)
+
+// Rating is a coarse strength label for an estimate.
+type Rating string
+
+const (
+	VeryWeak Rating = "very weak"
+	Weak     Rating = "weak"
+	Fair     Rating = "fair"
+	Strong   Rating = "strong"
+)
+
+// StrongBits is the entropy at which a password stops counting as weak
+// for prioritising fixes.
+const StrongBits = 100
+
+// Estimate is the estimated strength of a password.
+type Estimate struct {
+	Bits   float64
+	Rating Rating
+}
+
+// Weakness is how many bits the password falls short of StrongBits,
+// never less than 1 so even strong passwords still rank among themselves.
+func (e Estimate) Weakness() float64 {
+	return math.Max(1, StrongBits-e.Bits)
+}
+
+// Of estimates the entropy of password from the character classes it uses
+// and its length. Characters that repeat the previous one or continue a
+// run such as "abc" or "321" add a single bit, as guessers try those
+// patterns first. It is an upper bound for human-chosen passwords.
+func Of(password string) Estimate {
+	runes := []rune(password)
+
+	var lower, upper, digit, symbol, other bool
+	for _, r := range runes {
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= '0' && r <= '9':
+			digit = true
+		case r < unicode.MaxASCII && unicode.IsPrint(r):
+			symbol = true
+		default:
+			other = true
+		}
+	}
+
+	pool := 0
+	for _, c := range []struct {
+		used bool
+		size int
+	}{{lower, 26}, {upper, 26}, {digit, 10}, {symbol, 33}, {other, 100}} {
+		if c.used {
+			pool += c.size
+		}
+	}
+	if pool == 0 {
+		return Estimate{Rating: VeryWeak}
+	}
+
+	perChar := math.Log2(float64(pool))
+	var bits float64
+	for i, r := range runes {
+		if i > 0 && isPattern(runes[i-1], r) {
+			bits++
+			continue
+		}
+		bits += perChar
+	}
+
+	return Estimate{Bits: bits, Rating: rate(bits)}
+}
+
+// isPattern reports whether cur repeats prev or steps one from it.
+func isPattern(prev, cur rune) bool {
+	d := cur - prev
+	return d >= -1 && d <= 1
+}
+
+func rate(bits float64) Rating {
+	switch {
+	case bits < 28:
+		return VeryWeak
+	case bits < 40:
+		return Weak
+	case bits < 60:
+		return Fair
+	default:
+		return Strong
+	}
+}
+
+// MinSequence is the shortest run FindSequence reports.
+const MinSequence = 3
+
+// Sequence is a run of characters found by FindSequence.
+type Sequence struct {
+	Start  int // index of the first character, in runes
+	Length int // characters in the run
+	Step   int // 0 for a repeated character, 1 ascending, -1 descending
+}
+
+func (s Sequence) String() string {
+	switch s.Step {
+	case 0:
+		return fmt.Sprintf("%d repeated characters", s.Length)
+	case 1:
+		return fmt.Sprintf("ascending run of %d", s.Length)
+	default:
+		return fmt.Sprintf("descending run of %d", s.Length)
+	}
+}
+
+// FindSequence returns the first run of at least MinSequence characters
+// in password that repeat one character ("aaa") or step through letters
+// or digits one at a time ("abc", "XYZ", "321"). Letters are compared
+// without case, so "aBc" is a run too.
+func FindSequence(password string) (Sequence, bool) {
+	runes := []rune(password)
+	start, step := 0, 0
+	for i := 1; i < len(runes); i++ {
+		d, ok := sequenceStep(runes[i-1], runes[i])
+		switch {
+		case !ok:
+			start = i
+			continue
+		case i-start == 1 || d != step:
+			start, step = i-1, d
+		}
+		if n := i - start + 1; n >= MinSequence {
+			for i+1 < len(runes) {
+				if next, ok := sequenceStep(runes[i], runes[i+1]); !ok || next != step {
+					break
+				}
+				i++
+			}
+			return Sequence{Start: start, Length: i - start + 1, Step: step}, true
+		}
+	}
+	return Sequence{}, false
+}
+
+// sequenceStep returns how cur follows prev in a run: 0 when it repeats
+// it, 1 or -1 when both are letters or both digits one apart.
+func sequenceStep(prev, cur rune) (int, bool) {
+	if prev == cur {
+		return 0, true
+	}
+	prev, cur = unicode.ToLower(prev), unicode.ToLower(cur)
+	sameClass := (prev >= 'a' && prev <= 'z' && cur >= 'a' && cur <= 'z') ||
+		(prev >= '0' && prev <= '9' && cur >= '0' && cur <= '9')
+	if !sameClass {
+		return 0, false
+	}
+	switch cur - prev {
+	case 0:
+		return 0, true
+	case 1:
+		return 1, true
+	case -1:
+		return -1, true
+	}
+	return 0, false
+}
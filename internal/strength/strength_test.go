@@ -0,0 +1,68 @@
+package strength
+
+import "testing"
+
+func TestOf_Ratings(t *testing.T) {
+	tests := []struct {
+		password string
+		want     Rating
+	}{
+		{"", VeryWeak},
+		{"abc", VeryWeak},
+		{"aaaaaaaaaaaaaaaaaaaa", VeryWeak},
+		{"12345678901234", VeryWeak},
+		{"sunflow", Weak},
+		{"sunflower", Fair},
+		{"Tr0ub4dor&3", Strong},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			if got := Of(tt.password); got.Rating != tt.want {
+				t.Errorf("Of(%q) = %s (%.1f bits), want %s", tt.password, got.Rating, got.Bits, tt.want)
+			}
+		})
+	}
+}
+
+func TestOf_PatternsCountLess(t *testing.T) {
+	random := Of("qzkxmvbw")
+	run := Of("abcdefgh")
+	if run.Bits >= random.Bits {
+		t.Errorf("A run should estimate lower than random letters: %.1f vs %.1f", run.Bits, random.Bits)
+	}
+}
+
+func TestWeakness(t *testing.T) {
+	if w := (Estimate{Bits: 20}).Weakness(); w != 80 {
+		t.Errorf("Expected weakness 80, got %.1f", w)
+	}
+	if w := (Estimate{Bits: 150}).Weakness(); w != 1 {
+		t.Errorf("Strong passwords should have weakness 1, got %.1f", w)
+	}
+}
+
+func TestFindSequence(t *testing.T) {
+	tests := []struct {
+		password string
+		want     Sequence
+		found    bool
+	}{
+		{"x7#abcd!", Sequence{Start: 3, Length: 4, Step: 1}, true},
+		{"pass321", Sequence{Start: 4, Length: 3, Step: -1}, true},
+		{"aBc", Sequence{Start: 0, Length: 3, Step: 1}, true},
+		{"zz!aaA", Sequence{Start: 3, Length: 3, Step: 0}, true},
+		{"ab12ba", Sequence{}, false},
+		{"aab", Sequence{}, false},
+		{"9:;", Sequence{}, false}, // only letters and digits step
+		{"z{|", Sequence{}, false},
+		{"", Sequence{}, false},
+	}
+
+	for _, tt := range tests {
+		got, found := FindSequence(tt.password)
+		if found != tt.found || got != tt.want {
+			t.Errorf("FindSequence(%q) = %+v, %v; want %+v, %v", tt.password, got, found, tt.want, tt.found)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package timeutil
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dateLayouts are the absolute date formats accepted by Parse, tried in order.
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// extendedUnits are the units ParseDuration accepts on top of time.ParseDuration.
+var extendedUnits = map[byte]time.Duration{
+	'd': 24 * time.Hour,
+	'w': 7 * 24 * time.Hour,
+	'y': 365 * 24 * time.Hour,
+}
+
+// ParseDuration parses a duration like time.ParseDuration, additionally
+// accepting whole-number day (d), week (w) and year (y) units such as "90d".
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("empty duration")
+	}
+
+	if unit, ok := extendedUnits[s[len(s)-1]]; ok {
+		n, err := strconv.Atoi(s[:len(s)-1])
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return time.Duration(n) * unit, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
+
+// Parse interprets s as either an absolute date or a duration relative to now.
+// Durations are added to now when future is true and subtracted otherwise, so
+// "90d" means 90 days from now for an expiry and 90 days ago for a filter.
+func Parse(s string, now time.Time, future bool) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	d, err := ParseDuration(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date or duration %q (use YYYY-MM-DD or e.g. 90d, 12h)", s)
+	}
+
+	if future {
+		return now.Add(d), nil
+	}
+	return now.Add(-d), nil
+}
+
+// humanizeUnits are the units Humanize counts in, largest first. Months
+// and years are approximate: 30 and 365 days.
+var humanizeUnits = []struct {
+	name string
+	size time.Duration
+}{
+	{"year", 365 * 24 * time.Hour},
+	{"month", 30 * 24 * time.Hour},
+	{"day", 24 * time.Hour},
+	{"hour", time.Hour},
+	{"minute", time.Minute},
+}
+
+// Humanize describes d in its largest whole unit, e.g. "3 days ago" or
+// "1 year ago". A negative d lies in the future ("in 2 months"), and
+// anything under a minute either way is "just now".
+func Humanize(d time.Duration) string {
+	future := d < 0
+	if future {
+		d = -d
+	}
+
+	for _, unit := range humanizeUnits {
+		n := int64(d / unit.size)
+		if n < 1 {
+			continue
+		}
+		amount := fmt.Sprintf("%d %s", n, unit.name)
+		if n > 1 {
+			amount += "s"
+		}
+		if future {
+			return "in " + amount
+		}
+		return amount + " ago"
+	}
+	return "just now"
+}
@@ -0,0 +1,113 @@
+package timeutil
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected time.Duration
+		wantErr  bool
+	}{
+		{"90d", 90 * 24 * time.Hour, false},
+		{"2w", 14 * 24 * time.Hour, false},
+		{"1y", 365 * 24 * time.Hour, false},
+		{"12h", 12 * time.Hour, false},
+		{"1h30m", 90 * time.Minute, false},
+		{"", 0, true},
+		{"d", 0, true},
+		{"-5d", 0, true},
+		{"abc", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseDuration(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseDuration(%q) should fail", tt.input)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDuration(%q) failed: %v", tt.input, err)
+			}
+			if got != tt.expected {
+				t.Errorf("ParseDuration(%q) = %v, expected %v", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParse_AbsoluteDate(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.Local)
+
+	got, err := Parse("2025-12-31", now, true)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	expected := time.Date(2025, 12, 31, 0, 0, 0, 0, time.Local)
+	if !got.Equal(expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+
+	got, err = Parse("2025-12-31T10:00:00Z", now, false)
+	if err != nil {
+		t.Fatalf("Parse RFC3339 failed: %v", err)
+	}
+	if !got.Equal(time.Date(2025, 12, 31, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected RFC3339 result: %v", got)
+	}
+}
+
+func TestParse_RelativeDirection(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	future, err := Parse("10d", now, true)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if !future.Equal(now.Add(10 * 24 * time.Hour)) {
+		t.Errorf("Expected 10 days from now, got %v", future)
+	}
+
+	past, err := Parse("10d", now, false)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if !past.Equal(now.Add(-10 * 24 * time.Hour)) {
+		t.Errorf("Expected 10 days ago, got %v", past)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	if _, err := Parse("next tuesday", time.Now(), true); err == nil {
+		t.Error("Parse should fail for unrecognised input")
+	}
+}
+
+func TestHumanize(t *testing.T) {
+	day := 24 * time.Hour
+	tests := map[time.Duration]string{
+		0:                      "just now",
+		30 * time.Second:       "just now",
+		-30 * time.Second:      "just now",
+		time.Minute:            "1 minute ago",
+		90 * time.Minute:       "1 hour ago",
+		3*day + time.Hour:      "3 days ago",
+		45 * day:               "1 month ago",
+		312 * day:              "10 months ago",
+		2*365*day + 40*day:     "2 years ago",
+		-2 * day:               "in 2 days",
+		-(365*day + time.Hour): "in 1 year",
+	}
+
+	for d, want := range tests {
+		if got := Humanize(d); got != want {
+			t.Errorf("Humanize(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package undo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ompatil-15/coconut/internal/db"
+	"github.com/ompatil-15/coconut/internal/db/model"
+)
+
+const lastEntryKey = "undo:last"
+
+// Operation names recorded in the journal.
+const (
+	OpUpdate = "update"
+	OpDelete = "delete"
+)
+
+var ErrNothingToUndo = errors.New("nothing to undo")
+
+// Entry captures the state of a secret before a mutating operation.
+// The whole entry is encrypted at rest because it contains the secret.
+type Entry struct {
+	Op         string       `json:"op"`
+	RecordedAt time.Time    `json:"recordedAt"`
+	Previous   model.Secret `json:"previous"`
+}
+
+// Store keeps a single-entry undo journal in the system bucket.
+// Recording a new operation replaces whatever was there before.
+type Store struct {
+	repo  db.Repository
+	vault db.Vault
+}
+
+func NewStore(repo db.Repository, v db.Vault) *Store {
+	return &Store{
+		repo:  repo,
+		vault: v,
+	}
+}
+
+// Record saves the pre-operation state of a secret as the latest undo entry.
+func (s *Store) Record(op string, previous model.Secret) error {
+	if !s.vault.IsUnlocked() {
+		return fmt.Errorf("vault is locked")
+	}
+
+	entry := Entry{
+		Op:         op,
+		RecordedAt: time.Now(),
+		Previous:   previous,
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("marshal undo entry: %w", err)
+	}
+
+	enc, err := s.vault.Encrypt(string(data))
+	if err != nil {
+		return fmt.Errorf("encrypt undo entry: %w", err)
+	}
+
+	return s.repo.Put(lastEntryKey, []byte(enc))
+}
+
+// Last returns the most recent undo entry, or ErrNothingToUndo if the
+// journal is empty. A journal that cannot be read is an error, not an
+// empty one.
+func (s *Store) Last() (*Entry, error) {
+	if !s.vault.IsUnlocked() {
+		return nil, fmt.Errorf("vault is locked")
+	}
+
+	found, err := s.repo.Has(lastEntryKey)
+	if err != nil {
+		return nil, fmt.Errorf("read undo entry: %w", err)
+	}
+	if !found {
+		return nil, ErrNothingToUndo
+	}
+	data, err := s.repo.Get(lastEntryKey)
+	if err != nil {
+		return nil, fmt.Errorf("read undo entry: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, ErrNothingToUndo
+	}
+
+	dec, err := s.vault.Decrypt(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("decrypt undo entry: %w", err)
+	}
+
+	var entry Entry
+	if err := json.Unmarshal([]byte(dec), &entry); err != nil {
+		return nil, fmt.Errorf("unmarshal undo entry: %w", err)
+	}
+
+	return &entry, nil
+}
+
+// Clear removes the undo entry.
+func (s *Store) Clear() error {
+	if err := s.repo.Delete(lastEntryKey); err != nil {
+		return fmt.Errorf("remove undo entry: %w", err)
+	}
+	return nil
+}
+
+// Rekey re-encrypts the undo entry, if there is one, from one vault key to
+// another. repo is the system bucket, usually bound to the transaction
+// that re-encrypts the rest of the vault.
+func Rekey(repo db.Repository, from, to db.Vault) error {
+	found, err := repo.Has(lastEntryKey)
+	if err != nil {
+		return fmt.Errorf("read undo entry: %w", err)
+	}
+	if !found {
+		return nil
+	}
+	data, err := repo.Get(lastEntryKey)
+	if err != nil {
+		return fmt.Errorf("read undo entry: %w", err)
+	}
+	if len(data) == 0 {
+		return nil
+	}
+
+	dec, err := from.Decrypt(string(data))
+	if err != nil {
+		return fmt.Errorf("decrypt undo entry: %w", err)
+	}
+	enc, err := to.Encrypt(dec)
+	if err != nil {
+		return fmt.Errorf("encrypt undo entry: %w", err)
+	}
+
+	return repo.Put(lastEntryKey, []byte(enc))
+}
@@ -0,0 +1,169 @@
+package undo
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ompatil-15/coconut/internal/db/model"
+)
+
+// Mock repository for testing
+type mockRepository struct {
+	data map[string][]byte
+	err  error // returned by every call when set
+}
+
+func (m *mockRepository) Put(key string, value []byte) error {
+	if m.data == nil {
+		m.data = make(map[string][]byte)
+	}
+	m.data[key] = value
+	return nil
+}
+
+func (m *mockRepository) Get(key string) ([]byte, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	if data, exists := m.data[key]; exists {
+		return data, nil
+	}
+	return nil, errors.New("key not found")
+}
+
+func (m *mockRepository) Has(key string) (bool, error) {
+	if m.err != nil {
+		return false, m.err
+	}
+	_, exists := m.data[key]
+	return exists, nil
+}
+
+func (m *mockRepository) Delete(key string) error {
+	if m.err != nil {
+		return m.err
+	}
+	delete(m.data, key)
+	return nil
+}
+
+func (m *mockRepository) ListKeys() ([]string, error) {
+	keys := make([]string, 0, len(m.data))
+	for k := range m.data {
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+// Mock vault for testing
+type mockVault struct {
+	unlocked bool
+}
+
+func (m *mockVault) IsUnlocked() bool {
+	return m.unlocked
+}
+
+func (m *mockVault) Encrypt(plaintext string) (string, error) {
+	return "encrypted:" + plaintext, nil
+}
+
+func (m *mockVault) Decrypt(ciphertext string) (string, error) {
+	if !strings.HasPrefix(ciphertext, "encrypted:") {
+		return "", errors.New("invalid ciphertext")
+	}
+	return strings.TrimPrefix(ciphertext, "encrypted:"), nil
+}
+
+func TestStore_RecordAndLast(t *testing.T) {
+	repo := &mockRepository{}
+	store := NewStore(repo, &mockVault{unlocked: true})
+
+	secret := model.Secret{ID: "id-1", Username: "alice", Password: "s3cret"}
+	if err := store.Record(OpUpdate, secret); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+
+	stored := string(repo.data[lastEntryKey])
+	if !strings.HasPrefix(stored, "encrypted:") {
+		t.Error("Undo entry should be stored encrypted")
+	}
+
+	entry, err := store.Last()
+	if err != nil {
+		t.Fatalf("Last failed: %v", err)
+	}
+
+	if entry.Op != OpUpdate {
+		t.Errorf("Expected op '%s', got '%s'", OpUpdate, entry.Op)
+	}
+	if entry.Previous.Password != "s3cret" {
+		t.Errorf("Expected previous password to round-trip, got '%s'", entry.Previous.Password)
+	}
+}
+
+func TestStore_KeepsOnlyMostRecent(t *testing.T) {
+	repo := &mockRepository{}
+	store := NewStore(repo, &mockVault{unlocked: true})
+
+	_ = store.Record(OpUpdate, model.Secret{ID: "first"})
+	_ = store.Record(OpDelete, model.Secret{ID: "second"})
+
+	if len(repo.data) != 1 {
+		t.Errorf("Expected a single journal entry, got %d", len(repo.data))
+	}
+
+	entry, err := store.Last()
+	if err != nil {
+		t.Fatalf("Last failed: %v", err)
+	}
+	if entry.Op != OpDelete || entry.Previous.ID != "second" {
+		t.Errorf("Expected most recent entry, got %s/%s", entry.Op, entry.Previous.ID)
+	}
+}
+
+func TestStore_Empty(t *testing.T) {
+	store := NewStore(&mockRepository{}, &mockVault{unlocked: true})
+
+	if _, err := store.Last(); !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("Expected ErrNothingToUndo, got %v", err)
+	}
+}
+
+func TestStore_Clear(t *testing.T) {
+	store := NewStore(&mockRepository{}, &mockVault{unlocked: true})
+
+	_ = store.Record(OpDelete, model.Secret{ID: "id-1"})
+	if err := store.Clear(); err != nil {
+		t.Fatalf("Clear failed: %v", err)
+	}
+
+	if _, err := store.Last(); !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("Expected ErrNothingToUndo after Clear, got %v", err)
+	}
+}
+
+func TestStore_StorageErrors(t *testing.T) {
+	broken := errors.New("disk on fire")
+	store := NewStore(&mockRepository{err: broken}, &mockVault{unlocked: true})
+
+	if _, err := store.Last(); !errors.Is(err, broken) {
+		t.Errorf("Expected Last to report the storage error, got %v", err)
+	}
+	if err := store.Clear(); !errors.Is(err, broken) {
+		t.Errorf("Expected Clear to report the storage error, got %v", err)
+	}
+}
+
+func TestStore_Locked(t *testing.T) {
+	vault := &mockVault{unlocked: false}
+	store := NewStore(&mockRepository{}, vault)
+
+	if err := store.Record(OpUpdate, model.Secret{ID: "id-1"}); err == nil {
+		t.Error("Record should fail when vault is locked")
+	}
+	if _, err := store.Last(); err == nil {
+		t.Error("Last should fail when vault is locked")
+	}
+}
@@ -1,7 +1,13 @@
 package vault
 
 import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"sync/atomic"
 
 	"github.com/ompatil-15/coconut/internal/crypto"
 )
@@ -9,8 +15,13 @@ import (
 const (
 	saltKey              = "salt"
 	verificationTokenKey = "vault_verification"
+	kdfParamsKey         = "kdf:params"
 )
 
+// macContext separates the MAC subkey from the encryption key, so the vault
+// key itself is never used directly for authentication.
+const macContext = "coconut-mac-v1"
+
 // Verification token is a constant that we encrypt to verify password correctness
 const verificationTokenValue = "coconut-vault-v1-verification"
 
@@ -19,6 +30,8 @@ type Vault struct {
 	key      []byte
 	salt     []byte
 	unlocked bool
+	cipher   crypto.Cipher // set by WithCachedCipher
+	failed   *atomic.Bool  // set by a failed Decrypt; shared with cached copies
 }
 
 type SystemReader interface {
@@ -35,6 +48,7 @@ func NewVault(strategy crypto.CryptoStrategy, salt []byte) *Vault {
 		strategy: strategy,
 		salt:     salt,
 		unlocked: false,
+		failed:   new(atomic.Bool),
 	}
 }
 
@@ -50,6 +64,7 @@ func (v *Vault) Unlock(derivedKey []byte) {
 
 func (v *Vault) Lock() {
 	v.unlocked = false
+	v.cipher = nil
 	if v.key != nil {
 		for i := range v.key {
 			v.key[i] = 0
@@ -66,6 +81,9 @@ func (v *Vault) Encrypt(plaintext string) (string, error) {
 	if !v.unlocked {
 		return "", errors.New("vault locked")
 	}
+	if v.cipher != nil {
+		return v.cipher.Encrypt(plaintext)
+	}
 	return v.strategy.Encrypt(v.key, plaintext)
 }
 
@@ -73,7 +91,67 @@ func (v *Vault) Decrypt(ciphertext string) (string, error) {
 	if !v.unlocked {
 		return "", errors.New("vault locked")
 	}
-	return v.strategy.Decrypt(v.key, ciphertext)
+	var plaintext string
+	var err error
+	if v.cipher != nil {
+		plaintext, err = v.cipher.Decrypt(ciphertext)
+	} else {
+		plaintext, err = v.strategy.Decrypt(v.key, ciphertext)
+	}
+	if err != nil {
+		v.failed.Store(true)
+		return "", fmt.Errorf("%w: %v", ErrDecrypt, err)
+	}
+	return plaintext, nil
+}
+
+// DecryptFailed reports whether a Decrypt of v, or of a copy from
+// WithCachedCipher, has failed since v was created. Locking v does not
+// reset it, so a command's owner can still act on the failure afterwards.
+func (v *Vault) DecryptFailed() bool {
+	return v != nil && v.failed.Load()
+}
+
+// WithCachedCipher returns a copy of v that sets up its cipher once
+// instead of on every Encrypt and Decrypt, for an operation going over
+// many records, and a release func that locks the copy, zeroing its key
+// and dropping the cipher. Call release when the operation is done;
+// locking v does not lock the copy. It returns v itself and a release
+// that does nothing when v is locked, already cached or its strategy
+// cannot be keyed ahead of time.
+func (v *Vault) WithCachedCipher() (*Vault, func()) {
+	if v == nil || !v.unlocked || v.cipher != nil {
+		return v, func() {}
+	}
+	keyer, ok := v.strategy.(crypto.Keyer)
+	if !ok {
+		return v, func() {}
+	}
+	c, err := keyer.WithKey(v.key)
+	if err != nil {
+		// Encrypt and Decrypt report the same error.
+		return v, func() {}
+	}
+
+	cached := *v
+	cached.key = bytes.Clone(v.key) // so locking the copy leaves v's key alone
+	cached.cipher = c
+	return &cached, cached.Lock
+}
+
+// MAC authenticates data with a subkey derived from the vault key. It lets
+// data stored outside the encrypted records be checked for tampering.
+func (v *Vault) MAC(data []byte) ([]byte, error) {
+	if !v.unlocked {
+		return nil, errors.New("vault locked")
+	}
+
+	sub := hmac.New(sha256.New, v.key)
+	sub.Write([]byte(macContext))
+
+	mac := hmac.New(sha256.New, sub.Sum(nil))
+	mac.Write(data)
+	return mac.Sum(nil), nil
 }
 
 // CreateVerificationToken creates and encrypts a verification token for password validation.
@@ -96,7 +174,7 @@ func (v *Vault) VerifyPassword(encryptedToken string) error {
 
 	decrypted, err := v.Decrypt(encryptedToken)
 	if err != nil {
-		return errors.New("incorrect master password")
+		return ErrIncorrectPassword
 	}
 
 	if decrypted != verificationTokenValue {
@@ -108,6 +186,41 @@ func (v *Vault) VerifyPassword(encryptedToken string) error {
 
 var ErrVaultNotFound = errors.New("vault not initialized")
 
+// ErrIncorrectPassword is returned when the key does not decrypt the
+// verification token, i.e. the master password is wrong.
+var ErrIncorrectPassword = errors.New("incorrect master password")
+
+// ErrDecrypt is returned when a ciphertext does not decrypt with the vault
+// key, e.g. because the key is wrong or the stored record is corrupt.
+var ErrDecrypt = errors.New("decryption failed")
+
+// LoadKDFParams returns the key derivation parameters recorded for the
+// vault. Vaults created before they were recorded get the defaults.
+func LoadKDFParams(systemRepo SystemReader) (crypto.KDFParams, error) {
+	data, err := systemRepo.Get(kdfParamsKey)
+	if err != nil || len(data) == 0 {
+		return crypto.DefaultKDFParams(), nil
+	}
+
+	var params crypto.KDFParams
+	if err := json.Unmarshal(data, &params); err != nil {
+		return crypto.KDFParams{}, fmt.Errorf("parse key derivation params: %w", err)
+	}
+	if err := params.Validate(); err != nil {
+		return crypto.KDFParams{}, fmt.Errorf("stored key derivation params: %w", err)
+	}
+	return params, nil
+}
+
+// SaveKDFParams records the key derivation parameters for the vault.
+func SaveKDFParams(systemRepo SaltStore, params crypto.KDFParams) error {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return systemRepo.Put(kdfParamsKey, data)
+}
+
 // CheckVaultExists checks if a vault has been initialized
 // Returns true if vault exists, false otherwise
 func CheckVaultExists(systemRepo SystemReader) bool {
@@ -134,3 +247,21 @@ func VerifyVaultPassword(systemRepo SystemReader, vault *Vault) error {
 
 	return vault.VerifyPassword(string(encryptedToken))
 }
+
+// SaveKey records what unlocks v: its salt, a verification token encrypted
+// with its key, and the parameters the key was derived with. Writing all
+// three in the same transaction as the re-encrypted data changes the key
+// a vault opens with.
+func SaveKey(systemRepo SaltStore, v *Vault, params crypto.KDFParams) error {
+	token, err := v.CreateVerificationToken()
+	if err != nil {
+		return fmt.Errorf("create verification token: %w", err)
+	}
+	if err := systemRepo.Put(saltKey, v.salt); err != nil {
+		return fmt.Errorf("save salt: %w", err)
+	}
+	if err := systemRepo.Put(verificationTokenKey, []byte(token)); err != nil {
+		return fmt.Errorf("save verification token: %w", err)
+	}
+	return SaveKDFParams(systemRepo, params)
+}
@@ -1,6 +1,7 @@
 package vault
 
 import (
+	"bytes"
 	"errors"
 	"testing"
 
@@ -42,6 +43,14 @@ func (m *mockSystemReader) Get(key string) ([]byte, error) {
 	return nil, errors.New("key not found")
 }
 
+func (m *mockSystemReader) Put(key string, value []byte) error {
+	if m.data == nil {
+		m.data = make(map[string][]byte)
+	}
+	m.data[key] = value
+	return nil
+}
+
 func TestNewVault(t *testing.T) {
 	strategy := &mockCrypto{}
 	salt := []byte("test-salt")
@@ -121,6 +130,84 @@ func TestVault_EncryptDecrypt(t *testing.T) {
 	}
 }
 
+func TestVault_WithCachedCipher(t *testing.T) {
+	key := make([]byte, 32)
+	copy(key, "test-key-32-bytes-long-enough!!")
+	vault := UnlockWithKey(crypto.NewAESGCM(), []byte("salt"), key)
+
+	cached, release := vault.WithCachedCipher()
+	if cached == vault {
+		t.Fatal("Expected a copy with a cached cipher")
+	}
+	ciphertext, err := cached.Encrypt("secret message")
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	if got, err := vault.Decrypt(ciphertext); err != nil || got != "secret message" {
+		t.Errorf("Decrypt of cached ciphertext = %q, %v", got, err)
+	}
+	if _, err := cached.Decrypt("bad"); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("Expected ErrDecrypt from the cached cipher, got %v", err)
+	}
+
+	cachedKey := cached.key
+	release()
+	if !vault.IsUnlocked() || vault.key[0] != 't' {
+		t.Error("Releasing the copy should leave the original unlocked")
+	}
+	if !bytes.Equal(cachedKey, make([]byte, len(cachedKey))) || cached.cipher != nil {
+		t.Error("Releasing the copy should zero its key and drop its cipher")
+	}
+	if _, err := cached.Encrypt("x"); err == nil {
+		t.Error("Encrypt should fail once the copy is released")
+	}
+
+	// Strategies that cannot be keyed, and locked vaults, are returned as is.
+	mock := UnlockWithKey(&mockCrypto{}, []byte("salt"), key)
+	if got, release := mock.WithCachedCipher(); got != mock {
+		t.Error("Expected the vault itself for a strategy without WithKey")
+	} else {
+		release()
+		if !mock.IsUnlocked() {
+			t.Error("Releasing the vault itself should leave it unlocked")
+		}
+	}
+	vault.Lock()
+	if got, _ := vault.WithCachedCipher(); got != vault {
+		t.Error("Expected the vault itself when locked")
+	}
+}
+
+func TestVault_DecryptFailed(t *testing.T) {
+	key := make([]byte, 32)
+	copy(key, "test-key-32-bytes-long-enough!!")
+	vault := UnlockWithKey(crypto.NewAESGCM(), []byte("salt"), key)
+
+	ciphertext, err := vault.Encrypt("secret message")
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	if _, err := vault.Decrypt(ciphertext); err != nil || vault.DecryptFailed() {
+		t.Fatalf("Expected a good Decrypt not to count as a failure: %v", err)
+	}
+
+	// A failure through a cached copy is the original's failure too.
+	cached, release := vault.WithCachedCipher()
+	if _, err := cached.Decrypt("bad"); !errors.Is(err, ErrDecrypt) {
+		t.Fatalf("Expected ErrDecrypt, got %v", err)
+	}
+	release()
+	vault.Lock()
+	if !vault.DecryptFailed() {
+		t.Error("Expected the failure recorded on the original, even once locked")
+	}
+
+	other := UnlockWithKey(crypto.NewAESGCM(), []byte("salt"), key)
+	if other.DecryptFailed() {
+		t.Error("Expected another vault not to share the failure")
+	}
+}
+
 func TestVault_CreateVerificationToken(t *testing.T) {
 	strategy := &mockCrypto{}
 	vault := NewVault(strategy, []byte("salt"))
@@ -343,4 +430,93 @@ func TestVault_KeyZeroing(t *testing.T) {
 	if vault.key != nil {
 		t.Error("Vault's internal key should be nil after lock")
 	}
-}
\ No newline at end of file
+}
+
+func TestLoadKDFParams_DefaultsWhenMissing(t *testing.T) {
+	repo := &mockSystemReader{data: map[string][]byte{}}
+
+	params, err := LoadKDFParams(repo)
+	if err != nil {
+		t.Fatalf("LoadKDFParams failed: %v", err)
+	}
+	if params != crypto.DefaultKDFParams() {
+		t.Errorf("Expected default params for legacy vault, got %+v", params)
+	}
+}
+
+func TestKDFParams_RoundTrip(t *testing.T) {
+	repo := &mockSystemReader{data: map[string][]byte{}}
+
+	params := crypto.DefaultKDFParams()
+	params.KeyLen = 16
+	if err := SaveKDFParams(repo, params); err != nil {
+		t.Fatalf("SaveKDFParams failed: %v", err)
+	}
+
+	loaded, err := LoadKDFParams(repo)
+	if err != nil {
+		t.Fatalf("LoadKDFParams failed: %v", err)
+	}
+	if loaded.KeyBits() != 128 {
+		t.Errorf("Expected 128-bit key, got %d", loaded.KeyBits())
+	}
+}
+
+func TestLoadKDFParams_InvalidKeyLen(t *testing.T) {
+	repo := &mockSystemReader{data: map[string][]byte{
+		kdfParamsKey: []byte(`{"algorithm":"argon2id","time":3,"memoryKiB":65536,"threads":4,"keyLen":24}`),
+	}}
+
+	if _, err := LoadKDFParams(repo); err == nil {
+		t.Error("LoadKDFParams should reject an unsupported key length")
+	}
+}
+
+func TestSaveKey(t *testing.T) {
+	repo := &mockSystemReader{data: map[string][]byte{}}
+	v := UnlockWithKey(&mockCrypto{}, []byte("new-salt"), []byte("new-key"))
+
+	params := crypto.DefaultKDFParams()
+	if err := SaveKey(repo, v, params); err != nil {
+		t.Fatalf("SaveKey failed: %v", err)
+	}
+
+	if string(repo.data[saltKey]) != "new-salt" {
+		t.Errorf("Expected salt to be saved, got %q", repo.data[saltKey])
+	}
+	if err := VerifyVaultPassword(repo, v); err != nil {
+		t.Errorf("Saved verification token should verify: %v", err)
+	}
+	loaded, err := LoadKDFParams(repo)
+	if err != nil {
+		t.Fatalf("LoadKDFParams failed: %v", err)
+	}
+	if loaded != params {
+		t.Errorf("Expected saved params %+v, got %+v", params, loaded)
+	}
+}
+
+func TestVault_MAC(t *testing.T) {
+	v := NewVault(&mockCrypto{}, []byte("salt"))
+
+	if _, err := v.MAC([]byte("data")); err == nil {
+		t.Error("MAC should fail when vault is locked")
+	}
+
+	v.Unlock([]byte("test-key-32-bytes-long-for-aes!!"))
+	mac1, err := v.MAC([]byte("data"))
+	if err != nil {
+		t.Fatalf("MAC failed: %v", err)
+	}
+	mac2, _ := v.MAC([]byte("data"))
+	if string(mac1) != string(mac2) {
+		t.Error("MAC should be deterministic for the same key and data")
+	}
+
+	other := NewVault(&mockCrypto{}, []byte("salt"))
+	other.Unlock([]byte("another-key-32-bytes-long-for-ae"))
+	mac3, _ := other.MAC([]byte("data"))
+	if string(mac1) == string(mac3) {
+		t.Error("Different keys should produce different MACs")
+	}
+}
@@ -0,0 +1,67 @@
+package wordlist
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+)
+
+// RecommendedMinWords is the list size below which passphrases become
+// noticeably weak per word (~10 bits); callers should warn under it.
+const RecommendedMinWords = 1000
+
+// effLarge is EFF's large diceware wordlist (7776 words, CC BY 3.0 US):
+// https://www.eff.org/deeplinks/2016/07/new-wordlists-random-passphrases
+//
+//go:embed eff_large_wordlist.txt
+var effLarge string
+
+// Default returns the embedded EFF large wordlist.
+func Default() []string {
+	return parse(effLarge)
+}
+
+// Load reads a wordlist with one word per line. Blank lines and lines
+// starting with '#' are skipped, and duplicate words are removed so they
+// cannot inflate the reported entropy.
+func Load(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read wordlist: %w", err)
+	}
+
+	words := parse(string(data))
+	if len(words) < 2 {
+		return nil, fmt.Errorf("wordlist %s must contain at least 2 distinct words", path)
+	}
+	return words, nil
+}
+
+// Entropy returns the bits of entropy of a passphrase of count words drawn
+// uniformly from a list of size words.
+func Entropy(size, count int) float64 {
+	if size < 2 || count < 1 {
+		return 0
+	}
+	return float64(count) * math.Log2(float64(size))
+}
+
+func parse(data string) []string {
+	var words []string
+	seen := make(map[string]bool)
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		word := strings.TrimSpace(scanner.Text())
+		if word == "" || strings.HasPrefix(word, "#") || seen[word] {
+			continue
+		}
+		seen[word] = true
+		words = append(words, word)
+	}
+
+	return words
+}
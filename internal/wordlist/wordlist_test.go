@@ -0,0 +1,73 @@
+package wordlist
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDefault(t *testing.T) {
+	words := Default()
+
+	if len(words) != 7776 {
+		t.Errorf("Expected 7776 words in embedded list, got %d", len(words))
+	}
+	if words[0] != "abacus" {
+		t.Errorf("Expected first word 'abacus', got '%s'", words[0])
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tempDir := t.TempDir()
+	path := filepath.Join(tempDir, "words.txt")
+
+	content := "# my list\nalpha\n\nbravo\n  charlie  \nalpha\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write wordlist: %v", err)
+	}
+
+	words, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	expected := []string{"alpha", "bravo", "charlie"}
+	if len(words) != len(expected) {
+		t.Fatalf("Expected %d words, got %d: %v", len(expected), len(words), words)
+	}
+	for i, word := range expected {
+		if words[i] != word {
+			t.Errorf("Expected word %d to be '%s', got '%s'", i, word, words[i])
+		}
+	}
+}
+
+func TestLoad_TooSmall(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "words.txt")
+	if err := os.WriteFile(path, []byte("only\nonly\n"), 0600); err != nil {
+		t.Fatalf("Failed to write wordlist: %v", err)
+	}
+
+	if _, err := Load(path); err == nil {
+		t.Error("Load should fail for a list with fewer than 2 distinct words")
+	}
+}
+
+func TestLoad_Missing(t *testing.T) {
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("Load should fail for a missing file")
+	}
+}
+
+func TestEntropy(t *testing.T) {
+	got := Entropy(7776, 6)
+	expected := 6 * math.Log2(7776)
+	if math.Abs(got-expected) > 1e-9 {
+		t.Errorf("Expected %.4f bits, got %.4f", expected, got)
+	}
+
+	if Entropy(1, 6) != 0 || Entropy(7776, 0) != 0 {
+		t.Error("Entropy should be 0 for degenerate inputs")
+	}
+}